@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// activityLogSize is how many requests the activity log keeps; older ones
+// are dropped
+const activityLogSize = 500
+
+// activityRefresh is how often an open activity dialog shows new requests
+const activityRefresh = time.Second
+
+// activityEntry is one request the server answered
+type activityEntry struct {
+	Time   time.Time
+	Method string
+	Path   string
+	Client string
+	Status int
+	Bytes  int64
+}
+
+// activityLog keeps the most recent requests in a ring buffer. It's safe for
+// concurrent use; a nil log records nothing.
+type activityLog struct {
+	mu      sync.Mutex
+	entries []activityEntry
+	next    int // where the next entry goes once the buffer is full
+}
+
+func newActivityLog(size int) *activityLog {
+	return &activityLog{entries: make([]activityEntry, 0, size)}
+}
+
+// add records e, replacing the oldest entry when the log is full
+func (l *activityLog) add(e activityEntry) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) < cap(l.entries) {
+		l.entries = append(l.entries, e)
+		return
+	}
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+}
+
+// recent returns the entries newest first
+func (l *activityLog) recent() []activityEntry {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]activityEntry, 0, len(l.entries))
+	for i := range l.entries {
+		out = append(out, l.entries[(l.next+len(l.entries)-1-i)%len(l.entries)])
+	}
+	return out
+}
+
+// clear empties the log
+func (l *activityLog) clear() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = l.entries[:0]
+	l.next = 0
+}
+
+// activityRecorder notes the status and body size of a response
+type activityRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// Unwrap lets http.ResponseController reach the real writer
+func (w *activityRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *activityRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *activityRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// logActivity records every request next answers in log, including those
+// turned away by password protection
+func logActivity(next http.Handler, log *activityLog) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &activityRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		client := clientAddress(r)
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		log.add(activityEntry{
+			Time:   time.Now(),
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Client: client,
+			Status: status,
+			Bytes:  rec.bytes,
+		})
+	})
+}
+
+// summary is the entry as shown in the activity dialog
+func (e activityEntry) summary() string {
+	return fmt.Sprintf("%s  %s  %s %s  %d  %s",
+		e.Time.Format("15:04:05"), e.Client, e.Method, e.Path, e.Status, formatBytes(e.Bytes))
+}
+
+// showActivity shows the requests the running server has answered, newest
+// first, updating while the dialog is open
+func (p *Podcasterator) showActivity() {
+	if p.window == nil {
+		return
+	}
+
+	entries := p.activity.recent()
+	empty := widget.NewLabel("No requests yet. Subscribe to the feed or open its address in a browser.")
+	empty.Importance = widget.LowImportance
+	list := widget.NewList(
+		func() int { return len(entries) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(entries[i].summary())
+		},
+	)
+	update := func() {
+		entries = p.activity.recent()
+		empty.Hidden = len(entries) > 0
+		list.Refresh()
+		empty.Refresh()
+	}
+	update()
+
+	d := dialog.NewCustom("Server Activity", "Close", container.NewStack(list, container.NewCenter(empty)), p.window)
+	done := make(chan struct{})
+	d.SetOnClosed(func() { close(done) })
+	go func() {
+		tick := time.NewTicker(activityRefresh)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				fyne.Do(update)
+			}
+		}
+	}()
+	d.Resize(fyne.NewSize(640, 420))
+	d.Show()
+}
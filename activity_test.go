@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Server Activity Tests
+// =============================================================================
+
+// waitForActivity waits for log to hold n requests. Each is logged just
+// after its response is sent, so the client can get there first.
+func waitForActivity(t *testing.T, log *activityLog, n int) []activityEntry {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries := log.recent()
+		if len(entries) >= n || time.Now().After(deadline) {
+			if len(entries) != n {
+				t.Fatalf("logged %d requests; want %d", len(entries), n)
+			}
+			return entries
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestActivityLogRing(t *testing.T) {
+	log := newActivityLog(3)
+	if got := log.recent(); len(got) != 0 {
+		t.Fatalf("new log has %d entries", len(got))
+	}
+
+	paths := func() string {
+		var out []string
+		for _, e := range log.recent() {
+			out = append(out, e.Path)
+		}
+		return strings.Join(out, " ")
+	}
+	for i := 1; i <= 2; i++ {
+		log.add(activityEntry{Path: fmt.Sprintf("/%d", i)})
+	}
+	if got := paths(); got != "/2 /1" {
+		t.Errorf("recent() = %q; want newest first", got)
+	}
+	for i := 3; i <= 5; i++ {
+		log.add(activityEntry{Path: fmt.Sprintf("/%d", i)})
+	}
+	if got := paths(); got != "/5 /4 /3" {
+		t.Errorf("recent() once full = %q; want the oldest dropped", got)
+	}
+
+	log.clear()
+	if got := paths(); got != "" {
+		t.Errorf("recent() after clear = %q", got)
+	}
+	log.add(activityEntry{Path: "/6"})
+	if got := paths(); got != "/6" {
+		t.Errorf("recent() after clear and add = %q", got)
+	}
+
+	// A nil log quietly records nothing
+	var none *activityLog
+	none.add(activityEntry{Path: "/x"})
+	none.clear()
+	if none.recent() != nil {
+		t.Error("nil log returned entries")
+	}
+}
+
+func TestLogActivity(t *testing.T) {
+	log := newActivityLog(10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<rss/>"))
+	})
+	srv := httptest.NewServer(logActivity(mux, log))
+	defer srv.Close()
+
+	getBody(t, srv.URL+"/feed.xml")
+	getBody(t, srv.URL+"/missing")
+
+	entries := waitForActivity(t, log, 2)
+	missing, feed := entries[0], entries[1]
+	if feed.Path != "/feed.xml" || feed.Method != http.MethodGet || feed.Status != http.StatusOK || feed.Bytes != int64(len("<rss/>")) {
+		t.Errorf("feed entry = %+v", feed)
+	}
+	if feed.Client != "127.0.0.1" || feed.Time.IsZero() {
+		t.Errorf("feed entry client %q, time %v; want the client IP and a time", feed.Client, feed.Time)
+	}
+	if missing.Path != "/missing" || missing.Status != http.StatusNotFound {
+		t.Errorf("missing entry = %+v", missing)
+	}
+	if s := feed.summary(); !strings.Contains(s, "127.0.0.1") || !strings.Contains(s, "GET /feed.xml") || !strings.Contains(s, "200") {
+		t.Errorf("summary() = %q", s)
+	}
+}
+
+func TestServerActivity(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+	p.auth = feedAuth{Enabled: true, User: "office"}
+	p.auth.setPassword("s3cret")
+
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	base := strings.TrimSuffix(p.serverURL, "/feed.xml")
+
+	getBody(t, p.serverURL)
+	req, _ := http.NewRequest(http.MethodGet, base+"/files/abc/episode.mp3", nil)
+	req.SetBasicAuth("office", "s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET episode: %v", err)
+	}
+	resp.Body.Close()
+
+	entries := waitForActivity(t, p.activity, 2)
+	if e := entries[1]; e.Path != "/feed.xml" || e.Status != http.StatusUnauthorized {
+		t.Errorf("turned-away request logged as %+v", e)
+	}
+	if e := entries[0]; e.Path != "/files/abc/episode.mp3" || e.Status != http.StatusOK || e.Bytes != int64(len("copy")) {
+		t.Errorf("episode download logged as %+v", e)
+	}
+
+	p.shutdownServer()
+	if got := p.activity.recent(); len(got) != 0 {
+		t.Errorf("activity after stopping = %v; want it cleared", got)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Theme choices, as stored in state. An empty choice follows the OS.
+const (
+	themeSystem = ""
+	themeLight  = "light"
+	themeDark   = "dark"
+)
+
+// themeLabels are the appearance picker's options in order
+var themeLabels = []struct {
+	mode  string
+	label string
+}{
+	{themeSystem, "System"},
+	{themeLight, "Light"},
+	{themeDark, "Dark"},
+}
+
+// variantTheme is the default theme pinned to one variant, whatever the OS
+// asks for
+type variantTheme struct {
+	fyne.Theme
+	variant fyne.ThemeVariant
+}
+
+func (t variantTheme) Color(name fyne.ThemeColorName, _ fyne.ThemeVariant) color.Color {
+	return t.Theme.Color(name, t.variant)
+}
+
+// themeFor is the theme for a theme choice
+func themeFor(mode string) fyne.Theme {
+	switch mode {
+	case themeLight:
+		return variantTheme{theme.DefaultTheme(), theme.VariantLight}
+	case themeDark:
+		return variantTheme{theme.DefaultTheme(), theme.VariantDark}
+	default:
+		return theme.DefaultTheme()
+	}
+}
+
+// applyTheme switches the app to the chosen theme
+func (p *Podcasterator) applyTheme() {
+	if p.app != nil {
+		p.app.Settings().SetTheme(themeFor(p.themeMode))
+	}
+}
+
+// appearanceSection is the settings section for the light or dark theme
+func (p *Podcasterator) appearanceSection() fyne.CanvasObject {
+	var options []string
+	selected := themeLabels[0].label
+	for _, t := range themeLabels {
+		options = append(options, t.label)
+		if t.mode == p.themeMode {
+			selected = t.label
+		}
+	}
+
+	picker := widget.NewSelect(options, func(label string) {
+		for _, t := range themeLabels {
+			if t.label == label && t.mode != p.themeMode {
+				p.themeMode = t.mode
+				p.applyTheme()
+				p.markDirty()
+			}
+		}
+	})
+	picker.SetSelected(selected)
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("Appearance", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewBorder(nil, nil, widget.NewLabel("Theme:"), nil, picker),
+	)
+}
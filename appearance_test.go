@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// =============================================================================
+// Appearance Tests
+// =============================================================================
+
+func TestThemeFor(t *testing.T) {
+	def := theme.DefaultTheme()
+	tests := []struct {
+		mode    string
+		variant fyne.ThemeVariant
+	}{
+		{themeLight, theme.VariantLight},
+		{themeDark, theme.VariantDark},
+	}
+
+	for _, tc := range tests {
+		// A pinned theme ignores the variant the OS asks for
+		got := themeFor(tc.mode)
+		for _, asked := range []fyne.ThemeVariant{theme.VariantLight, theme.VariantDark} {
+			if got.Color(theme.ColorNameBackground, asked) != def.Color(theme.ColorNameBackground, tc.variant) {
+				t.Errorf("themeFor(%q) background for variant %d isn't the pinned variant's", tc.mode, asked)
+			}
+		}
+	}
+	if themeFor(themeSystem) != def {
+		t.Error("themeFor(system) isn't the default theme")
+	}
+}
+
+func TestAppearanceSection(t *testing.T) {
+	a := test.NewTempApp(t)
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.app = a
+	p.themeMode = themeDark
+
+	picker := p.appearanceSection().(*fyne.Container).Objects[1].(*fyne.Container).Objects[0].(*widget.Select)
+	if picker.Selected != "Dark" {
+		t.Errorf("picker shows %q; want Dark", picker.Selected)
+	}
+	if p.dirty {
+		t.Error("opening the picker marked state dirty")
+	}
+
+	picker.SetSelected("Light")
+	if p.themeMode != themeLight || !p.dirty {
+		t.Errorf("after choosing Light themeMode = %q, dirty %v", p.themeMode, p.dirty)
+	}
+	bg := a.Settings().Theme().Color(theme.ColorNameBackground, theme.VariantDark)
+	if bg != theme.DefaultTheme().Color(theme.ColorNameBackground, theme.VariantLight) {
+		t.Error("choosing Light didn't switch the app to the light theme")
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const artworkJPEGQuality = 90
+
+// artworkEncoder writes converted artwork. It exists so alternative encoders
+// (e.g. a progressive JPEG writer, which image/jpeg can't produce) can be
+// registered without touching the conversion code.
+type artworkEncoder interface {
+	Encode(w io.Writer, img image.Image) error
+}
+
+// baselineJPEGEncoder uses the standard library's baseline JPEG encoder
+type baselineJPEGEncoder struct {
+	Quality int
+}
+
+func (e baselineJPEGEncoder) Encode(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: e.Quality})
+}
+
+const (
+	encoderBaseline    = "baseline"
+	encoderProgressive = "progressive"
+)
+
+// artworkEncoders holds the available encoders by name. Only baseline ships
+// by default; a progressive encoder can be added under encoderProgressive.
+var artworkEncoders = map[string]artworkEncoder{
+	encoderBaseline: baselineJPEGEncoder{Quality: artworkJPEGQuality},
+}
+
+// artworkEncoderFor picks the encoder for the progressive setting, falling
+// back to baseline when no progressive encoder is registered. It returns the
+// name of the encoder actually chosen.
+func artworkEncoderFor(progressive bool) (artworkEncoder, string) {
+	if progressive {
+		if enc, ok := artworkEncoders[encoderProgressive]; ok {
+			return enc, encoderProgressive
+		}
+		fmt.Println("Progressive JPEG encoder not available, using baseline")
+	}
+	return artworkEncoders[encoderBaseline], encoderBaseline
+}
+
+// pngArtworkEncoder writes lossless PNG artwork, for covers with text or
+// flat colors that JPEG smears
+type pngArtworkEncoder struct{}
+
+func (pngArtworkEncoder) Encode(w io.Writer, img image.Image) error {
+	return (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(w, img)
+}
+
+// Artwork output formats, as stored in state. An empty format means JPEG.
+const (
+	artworkFormatJPEG = "jpeg"
+	artworkFormatPNG  = "png"
+)
+
+// jpegQuality is the chosen JPEG quality for artwork, artworkJPEGQuality
+// unless set
+func (p *Podcasterator) jpegQuality() int {
+	if p.artworkQuality < 1 || p.artworkQuality > 100 {
+		return artworkJPEGQuality
+	}
+	return p.artworkQuality
+}
+
+// jpegArtworkEncoder is the JPEG encoder for the progressive setting, at the
+// chosen quality when it's the baseline encoder
+func (p *Podcasterator) jpegArtworkEncoder() artworkEncoder {
+	enc, name := artworkEncoderFor(p.progressiveJPEG)
+	if name == encoderBaseline {
+		return baselineJPEGEncoder{Quality: p.jpegQuality()}
+	}
+	return enc
+}
+
+// artworkEncoder is the encoder for new podcast artwork in the chosen format
+func (p *Podcasterator) artworkEncoder() artworkEncoder {
+	if p.artworkFormat == artworkFormatPNG {
+		return pngArtworkEncoder{}
+	}
+	return p.jpegArtworkEncoder()
+}
+
+// artworkFileExt is the extension new podcast artwork is written with
+func (p *Podcasterator) artworkFileExt() string {
+	if p.artworkFormat == artworkFormatPNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// isPNGArtwork reports whether the converted artwork at path is a PNG. It
+// goes by the file, not the setting, since artwork set before the format
+// was changed keeps its format.
+func isPNGArtwork(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".png")
+}
+
+// artworkName is the name the artwork at path is served and exported under
+func artworkName(path string) string {
+	if isPNGArtwork(path) {
+		return "artwork.png"
+	}
+	return "artwork.jpg"
+}
+
+// artworkMIME is the Content-Type of the artwork at path
+func artworkMIME(path string) string {
+	if isPNGArtwork(path) {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+// artworkURL is the URL of the artwork at path under baseURL
+func artworkURL(baseURL, path string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + artworkName(path)
+}
+
+const (
+	defaultArtworkWarnKB  = 512 // Larger covers slow down every client refresh
+	artworkReducedQuality = 75
+)
+
+// validateArtwork checks converted artwork and returns human-readable
+// warnings. An empty result means the artwork looks fine.
+func validateArtwork(path string, maxBytes int64) []string {
+	var warnings []string
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return []string{fmt.Sprintf("Artwork file can't be read: %v", err)}
+	}
+
+	if maxBytes > 0 && info.Size() > maxBytes {
+		warnings = append(warnings, fmt.Sprintf(
+			"Artwork is %s, over the %s limit. Clients download it repeatedly; try a lower quality or smaller image.",
+			formatBytes(info.Size()), formatBytes(maxBytes)))
+	}
+
+	return warnings
+}
+
+// artworkInfo describes the converted artwork as listeners receive it
+type artworkInfo struct {
+	Width  int
+	Height int
+	Bytes  int64
+}
+
+// readArtworkInfo reads the dimensions and size back from a converted file
+func readArtworkInfo(path string) (artworkInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return artworkInfo{}, err
+	}
+	defer file.Close()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return artworkInfo{}, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return artworkInfo{}, err
+	}
+	return artworkInfo{Width: cfg.Width, Height: cfg.Height, Bytes: info.Size()}, nil
+}
+
+// Problems lists the ways the artwork falls short of podcast directory
+// requirements: square and at least artworkSize pixels on a side.
+func (a artworkInfo) Problems() []string {
+	var problems []string
+	if a.Width != a.Height {
+		problems = append(problems, "not square")
+	}
+	if a.undersized() {
+		problems = append(problems, fmt.Sprintf("smaller than %dpx", artworkSize))
+	}
+	return problems
+}
+
+// undersized reports whether the artwork is below the artworkSize minimum
+// Apple Podcasts accepts
+func (a artworkInfo) undersized() bool {
+	return a.Width < artworkSize || a.Height < artworkSize
+}
+
+// undersizedArtworkMessage explains why undersized artwork is a problem
+func undersizedArtworkMessage(a artworkInfo) string {
+	return fmt.Sprintf("Artwork is only %d×%d. Apple Podcasts rejects artwork smaller than %d×%d.",
+		a.Width, a.Height, artworkSize, artworkSize)
+}
+
+func (a artworkInfo) String() string {
+	return fmt.Sprintf("%d×%d · %s", a.Width, a.Height, formatBytes(a.Bytes))
+}
+
+// reencodeArtwork writes the artwork at srcPath to dstPath again with enc,
+// e.g. a JPEG encoder at a lower quality
+func reencodeArtwork(srcPath, dstPath string, enc artworkEncoder) error {
+	return convertImageWith(srcPath, dstPath, enc, func(img image.Image) image.Image {
+		return img
+	})
+}
+
+// formatBytes renders a byte count using binary units, e.g. "1.5 MB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
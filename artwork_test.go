@@ -0,0 +1,544 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// =============================================================================
+// Artwork Encoder Tests
+// =============================================================================
+
+// recordingEncoder stands in for a progressive encoder and notes that it ran
+type recordingEncoder struct {
+	used *bool
+}
+
+func (e recordingEncoder) Encode(w io.Writer, img image.Image) error {
+	*e.used = true
+	return baselineJPEGEncoder{Quality: artworkJPEGQuality}.Encode(w, img)
+}
+
+func TestArtworkEncoderFor(t *testing.T) {
+	t.Run("baseline by default", func(t *testing.T) {
+		_, name := artworkEncoderFor(false)
+		if name != encoderBaseline {
+			t.Errorf("artworkEncoderFor(false) = %q; want %q", name, encoderBaseline)
+		}
+	})
+
+	t.Run("progressive falls back without an encoder", func(t *testing.T) {
+		_, name := artworkEncoderFor(true)
+		if name != encoderBaseline {
+			t.Errorf("artworkEncoderFor(true) without progressive encoder = %q; want %q", name, encoderBaseline)
+		}
+	})
+
+	t.Run("progressive selected when registered", func(t *testing.T) {
+		used := false
+		artworkEncoders[encoderProgressive] = recordingEncoder{used: &used}
+		defer delete(artworkEncoders, encoderProgressive)
+
+		enc, name := artworkEncoderFor(true)
+		if name != encoderProgressive {
+			t.Fatalf("artworkEncoderFor(true) = %q; want %q", name, encoderProgressive)
+		}
+
+		tmpDir := t.TempDir()
+		srcPath := filepath.Join(tmpDir, "src.png")
+		writeTestPNG(t, srcPath, 50, 50)
+
+		if err := convertAndCropImageWith(srcPath, filepath.Join(tmpDir, "out.jpg"), 40, cropCenter, enc); err != nil {
+			t.Fatalf("convertAndCropImageWith() error = %v", err)
+		}
+		if !used {
+			t.Error("convertAndCropImageWith() did not use the selected encoder")
+		}
+	})
+}
+
+func TestBaselineJPEGEncoder(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	var buf bytes.Buffer
+	if err := (baselineJPEGEncoder{Quality: artworkJPEGQuality}).Encode(&buf, img); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	// Baseline JPEGs carry an SOF0 marker (0xFFC0)
+	if !bytes.Contains(buf.Bytes(), []byte{0xFF, 0xC0}) {
+		t.Error("Encode() output is not a baseline JPEG")
+	}
+}
+
+func writeTestPNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create test image: %v", err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+}
+
+// =============================================================================
+// Artwork Validation Tests
+// =============================================================================
+
+func TestValidateArtwork(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "artwork.jpg")
+	if err := os.WriteFile(path, make([]byte, 2048), 0644); err != nil {
+		t.Fatalf("Failed to create artwork: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		maxBytes     int64
+		wantWarnings int
+	}{
+		{"under threshold", path, 4096, 0},
+		{"over threshold", path, 1024, 1},
+		{"threshold disabled", path, 0, 0},
+		{"missing file", filepath.Join(tmpDir, "missing.jpg"), 1024, 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			warnings := validateArtwork(tc.path, tc.maxBytes)
+			if len(warnings) != tc.wantWarnings {
+				t.Errorf("validateArtwork() = %v; want %d warning(s)", warnings, tc.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestReadArtworkInfo(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cover.png")
+	writeTestPNG(t, src, 300, 200)
+
+	info, err := readArtworkInfo(src)
+	if err != nil {
+		t.Fatalf("readArtworkInfo() error = %v", err)
+	}
+	if info.Width != 300 || info.Height != 200 {
+		t.Errorf("dimensions = %dx%d; want 300x200", info.Width, info.Height)
+	}
+	if stat, _ := os.Stat(src); info.Bytes != stat.Size() {
+		t.Errorf("Bytes = %d; want %d", info.Bytes, stat.Size())
+	}
+
+	// Conversion crops to square but never upscales, so a small source
+	// stays flagged for its size after conversion
+	dst := filepath.Join(dir, "artwork.jpg")
+	if err := convertAndCropImageWith(src, dst, artworkSize, cropCenter, artworkEncoders[encoderBaseline]); err != nil {
+		t.Fatalf("convertAndCropImageWith() error = %v", err)
+	}
+	info, err = readArtworkInfo(dst)
+	if err != nil {
+		t.Fatalf("readArtworkInfo() error = %v", err)
+	}
+	if info.Width != 200 || info.Height != 200 || len(info.Problems()) != 1 {
+		t.Errorf("converted = %v %q; want 200x200 with one problem", info, info.Problems())
+	}
+
+	big := filepath.Join(dir, "big.png")
+	writeTestPNG(t, big, 1600, 1600)
+	if err := convertAndCropImageWith(big, dst, artworkSize, cropCenter, artworkEncoders[encoderBaseline]); err != nil {
+		t.Fatalf("convertAndCropImageWith() error = %v", err)
+	}
+	info, err = readArtworkInfo(dst)
+	if err != nil {
+		t.Fatalf("readArtworkInfo() error = %v", err)
+	}
+	if info.Width != artworkSize || info.Height != artworkSize || len(info.Problems()) != 0 {
+		t.Errorf("converted = %v %q; want %dx%d with no problems", info, info.Problems(), artworkSize, artworkSize)
+	}
+
+	if _, err := readArtworkInfo(filepath.Join(dir, "missing.jpg")); err == nil {
+		t.Error("readArtworkInfo() expected error for missing file")
+	}
+}
+
+func TestArtworkInfoProblems(t *testing.T) {
+	tests := []struct {
+		name string
+		info artworkInfo
+		want []string
+	}{
+		{"full size square", artworkInfo{Width: 1400, Height: 1400}, nil},
+		{"large square", artworkInfo{Width: 3000, Height: 3000}, nil},
+		{"small square", artworkInfo{Width: 600, Height: 600}, []string{"smaller than 1400px"}},
+		{"not square", artworkInfo{Width: 1600, Height: 1400}, []string{"not square"}},
+		{"small and not square", artworkInfo{Width: 300, Height: 200}, []string{"not square", "smaller than 1400px"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.info.Problems(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Problems() = %q; want %q", got, tc.want)
+			}
+		})
+	}
+
+	if got := (artworkInfo{Width: 1400, Height: 1400, Bytes: 1536}).String(); got != "1400×1400 · 1.5 KB" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestUpscaleSmallArtwork(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	dir := t.TempDir()
+
+	src := filepath.Join(dir, "small.png")
+	writeTestPNG(t, src, 400, 300)
+	p.artworkPath = filepath.Join(dir, "artwork.jpg")
+	if err := convertAndCropImageWith(src, p.artworkPath, artworkSize, cropCenter, artworkEncoders[encoderBaseline]); err != nil {
+		t.Fatalf("convertAndCropImageWith() error = %v", err)
+	}
+	if !p.offerUpscale(src, cropCenter) {
+		t.Error("offerUpscale() didn't warn about 300x300 artwork")
+	}
+	info, _ := readArtworkInfo(p.artworkPath)
+	if !strings.Contains(undersizedArtworkMessage(info), "only 300×300") {
+		t.Errorf("message = %q; want the converted size", undersizedArtworkMessage(info))
+	}
+
+	if err := upscaleImageWith(src, p.artworkPath, artworkSize, cropCenter, artworkEncoders[encoderBaseline]); err != nil {
+		t.Fatalf("upscaleImageWith() error = %v", err)
+	}
+	info, err := readArtworkInfo(p.artworkPath)
+	if err != nil || info.Width != artworkSize || info.Height != artworkSize {
+		t.Errorf("upscaled = %v, %v; want %dx%d", info, err, artworkSize, artworkSize)
+	}
+	if p.offerUpscale(src, cropCenter) {
+		t.Error("offerUpscale() warned about full-size artwork")
+	}
+}
+
+func TestArtworkFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		name   string
+		mime   string
+		magic  []byte
+	}{
+		{"", "artwork.jpg", "image/jpeg", []byte{0xFF, 0xD8}},
+		{artworkFormatJPEG, "artwork.jpg", "image/jpeg", []byte{0xFF, 0xD8}},
+		{artworkFormatPNG, "artwork.png", "image/png", []byte("\x89PNG")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name+" "+tc.format, func(t *testing.T) {
+			p, cleanup := newTestPodcasterator(t)
+			defer cleanup()
+			p.artworkFormat = tc.format
+
+			src := filepath.Join(t.TempDir(), "src.png")
+			writeTestPNG(t, src, 64, 64)
+			dst := filepath.Join(t.TempDir(), "artwork"+p.artworkFileExt())
+			if err := convertAndCropImageWith(src, dst, 32, cropCenter, p.artworkEncoder()); err != nil {
+				t.Fatalf("convertAndCropImageWith() error = %v", err)
+			}
+
+			data, _ := os.ReadFile(dst)
+			if !bytes.HasPrefix(data, tc.magic) {
+				t.Errorf("output starts % x; want % x", data[:min(len(data), 4)], tc.magic)
+			}
+			if info, err := readArtworkInfo(dst); err != nil || info.Width != 32 {
+				t.Errorf("readArtworkInfo() = %v, %v; want 32px wide", info, err)
+			}
+			if got := artworkName(dst); got != tc.name {
+				t.Errorf("artworkName() = %q; want %q", got, tc.name)
+			}
+			if got := artworkMIME(dst); got != tc.mime {
+				t.Errorf("artworkMIME() = %q; want %q", got, tc.mime)
+			}
+			if got := artworkURL("http://host/", dst); got != "http://host/"+tc.name {
+				t.Errorf("artworkURL() = %q", got)
+			}
+		})
+	}
+}
+
+func TestJPEGQuality(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	for _, q := range []int{0, -5, 101} {
+		p.artworkQuality = q
+		if got := p.jpegQuality(); got != artworkJPEGQuality {
+			t.Errorf("jpegQuality() with %d = %d; want the default %d", q, got, artworkJPEGQuality)
+		}
+	}
+	p.artworkQuality = 60
+	if enc, ok := p.artworkEncoder().(baselineJPEGEncoder); !ok || enc.Quality != 60 {
+		t.Errorf("artworkEncoder() = %#v; want baseline JPEG at quality 60", p.artworkEncoder())
+	}
+}
+
+func TestServeArtworkFormat(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+
+	p.artworkPath = filepath.Join(p.projectTempDir(), "artwork.png")
+	os.MkdirAll(p.projectTempDir(), 0755)
+	writeTestPNG(t, p.artworkPath, 8, 8)
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+	base := strings.TrimSuffix(p.serverURL, "/feed.xml")
+
+	resp, err := http.Get(base + "/artwork.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("GET /artwork.png = %d %s; want 200 image/png", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if code, _ := getBody(t, base+"/artwork.jpg"); code != http.StatusNotFound {
+		t.Errorf("GET /artwork.jpg for PNG artwork = %d; want 404", code)
+	}
+	if _, feed := getBody(t, p.serverURL); !strings.Contains(feed, base+"/artwork.png") {
+		t.Errorf("feed doesn't link the PNG artwork:\n%s", feed)
+	}
+}
+
+func TestReencodeArtworkShrinksFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "artwork.jpg")
+
+	// Noisy image so quality has a visible effect on size
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * y), uint8(x ^ y), uint8(x + y), 255})
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create artwork: %v", err)
+	}
+	if err := (baselineJPEGEncoder{Quality: 100}).Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode artwork: %v", err)
+	}
+	file.Close()
+
+	reduced := filepath.Join(tmpDir, "reduced.jpg")
+	if err := reencodeArtwork(path, reduced, baselineJPEGEncoder{Quality: artworkReducedQuality}); err != nil {
+		t.Fatalf("reencodeArtwork() error = %v", err)
+	}
+	before, _ := os.Stat(path)
+	after, _ := os.Stat(reduced)
+
+	if after.Size() >= before.Size() {
+		t.Errorf("reencodeArtwork() size = %d; want smaller than %d", after.Size(), before.Size())
+	}
+}
+
+func TestArtworkWarnBytes(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	if got := p.artworkWarnBytes(); got != defaultArtworkWarnKB*1024 {
+		t.Errorf("artworkWarnBytes() default = %d; want %d", got, defaultArtworkWarnKB*1024)
+	}
+
+	p.artworkWarnKB = 300
+	if got := p.artworkWarnBytes(); got != 300*1024 {
+		t.Errorf("artworkWarnBytes() = %d; want %d", got, 300*1024)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{512, "512 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+	}
+
+	for _, tc := range tests {
+		if result := formatBytes(tc.input); result != tc.expected {
+			t.Errorf("formatBytes(%d) = %q; want %q", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestSetArtworkInBackground(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.artworkImage = canvas.NewImageFromFile("")
+	p.artworkBtn = widget.NewButton("No artwork set", nil)
+	old := filepath.Join(p.tempDir, "artwork.png")
+	writeTestPNG(t, old, artworkSize, artworkSize)
+	p.artworkPath = old
+
+	// The artwork arrives from another goroutine, so it's watched for
+	// through the published snapshot, as the server would see it
+	p.live = &FeedServer{tempDir: p.tempDir}
+	p.publishTo(p.live)
+	published := func() string {
+		p.live.mu.RLock()
+		defer p.live.mu.RUnlock()
+		return p.live.artworkPath
+	}
+
+	src := filepath.Join(t.TempDir(), "cover.png")
+	writeTestPNG(t, src, 2*artworkSize, artworkSize)
+	p.setArtwork(src, cropCenter)
+	deadline := time.Now().Add(5 * time.Second)
+	for published() == old && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	want := filepath.Join(p.tempDir, "artwork"+p.artworkFileExt())
+	if p.artworkPath != want || p.artworkImage.File != want || p.artworkBtn.Text != "Delete artwork" {
+		t.Fatalf("artwork = %q, showing %q, button %q; want %q shown", p.artworkPath, p.artworkImage.File, p.artworkBtn.Text, want)
+	}
+	if info, err := readArtworkInfo(want); err != nil || info.Width != artworkSize || info.Height != artworkSize {
+		t.Errorf("converted artwork = %+v, %v; want %d square", info, err, artworkSize)
+	}
+	if fileExists(old) {
+		t.Error("artwork in the old format was kept")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(p.tempDir, ".artwork-*")); len(matches) != 0 {
+		t.Errorf("conversion left %v behind", matches)
+	}
+
+	// A bad image leaves the artwork as it was
+	bad := filepath.Join(t.TempDir(), "bad.png")
+	os.WriteFile(bad, []byte("not an image"), 0644)
+	p.setArtwork(bad, cropCenter)
+	deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if matches, _ := filepath.Glob(filepath.Join(p.tempDir, ".artwork-*")); len(matches) == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if published() != want || !fileExists(want) {
+		t.Error("a failed conversion replaced the artwork")
+	}
+}
+
+// gatedEncoder holds each encode until release is closed, so a test can see
+// what happens while artwork is still being converted
+type gatedEncoder struct {
+	release chan struct{}
+}
+
+func (e gatedEncoder) Encode(w io.Writer, img image.Image) error {
+	<-e.release
+	return baselineJPEGEncoder{Quality: artworkJPEGQuality}.Encode(w, img)
+}
+
+// returnsBeforeEncoding checks that convert hands back to the UI thread
+// while the encoder is still held, returning the channel it gave back
+func returnsBeforeEncoding(t *testing.T, convert func() <-chan struct{}) <-chan struct{} {
+	t.Helper()
+	returned := make(chan (<-chan struct{}))
+	go func() {
+		returned <- convert()
+	}()
+	select {
+	case finished := <-returned:
+		return finished
+	case <-time.After(5 * time.Second):
+		t.Fatal("conversion blocked until the encoder finished")
+		return nil
+	}
+}
+
+// waitForConversion waits for a background artwork conversion to finish
+func waitForConversion(t *testing.T, finished <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("artwork conversion never finished")
+	}
+}
+
+func TestUpscaleAndShrinkArtworkInBackground(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.artworkImage = canvas.NewImageFromFile("")
+	p.artworkBtn = widget.NewButton("Delete artwork", nil)
+
+	src := filepath.Join(t.TempDir(), "small.png")
+	writeTestPNG(t, src, 300, 300)
+	path := filepath.Join(p.tempDir, "artwork.jpg")
+	if err := convertAndCropImageWith(src, path, artworkSize, cropCenter, p.artworkEncoder()); err != nil {
+		t.Fatalf("convertAndCropImageWith() error = %v", err)
+	}
+	p.artworkPath = path
+
+	// The progressive encoder is the one held, so upscaling picks it up
+	// through the chosen settings
+	enc := gatedEncoder{release: make(chan struct{})}
+	artworkEncoders[encoderProgressive] = enc
+	defer delete(artworkEncoders, encoderProgressive)
+	p.progressiveJPEG = true
+
+	finished := returnsBeforeEncoding(t, func() <-chan struct{} { return p.upscaleArtwork(src, cropCenter) })
+	if info, err := readArtworkInfo(path); err != nil || info.Width != 300 {
+		t.Errorf("artwork while upscaling = %+v, %v; want the 300 wide original", info, err)
+	}
+	close(enc.release)
+	waitForConversion(t, finished)
+	if info, err := readArtworkInfo(p.artworkPath); err != nil || info.Width != artworkSize {
+		t.Errorf("upscaled artwork = %+v, %v; want %d wide", info, err, artworkSize)
+	}
+
+	enc = gatedEncoder{release: make(chan struct{})}
+	before, _ := os.Stat(path)
+	finished = returnsBeforeEncoding(t, func() <-chan struct{} { return p.shrinkArtwork(enc) })
+	if after, err := os.Stat(path); err != nil || !os.SameFile(before, after) {
+		t.Error("artwork was replaced before the encoder finished")
+	}
+	close(enc.release)
+	waitForConversion(t, finished)
+	if after, err := os.Stat(p.artworkPath); err != nil || os.SameFile(before, after) {
+		t.Fatalf("artwork was never replaced by the re-encoded copy: %v", err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(p.tempDir, ".artwork-*")); len(matches) != 0 {
+		t.Errorf("conversion left %v behind", matches)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// authIterations is the PBKDF2 work factor for stored passwords
+const authIterations = 100_000
+
+// authRealm is shown by browsers and podcast apps when they ask to sign in
+const authRealm = "Podcasterator"
+
+// authChecksPerMinute is how many new credentials each client address may
+// have checked a minute. Every check runs the slow hash, so without a cap
+// anyone on the network could keep the CPU busy guessing.
+const authChecksPerMinute = 10
+
+// authRejectedMax is how many rejected credentials are remembered, so an app
+// retrying a stale password is turned away without hashing it again
+const authRejectedMax = 1024
+
+// feedAuth is the optional username and password protecting the server.
+// Only a salted hash of the password is stored.
+type feedAuth struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	User    string `json:"user,omitempty"`
+	Salt    string `json:"salt,omitempty"` // hex
+	Hash    string `json:"hash,omitempty"` // hex PBKDF2-SHA256
+}
+
+// ready reports whether there's a username and password to check against
+func (a feedAuth) ready() bool {
+	return a.User != "" && a.Salt != "" && a.Hash != ""
+}
+
+// setPassword replaces the stored hash with one for password and a new salt
+func (a *feedAuth) setPassword(password string) error {
+	if password == "" {
+		return fmt.Errorf("enter a password")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil {
+		return err
+	}
+	a.Salt = hex.EncodeToString(salt)
+	a.Hash = hex.EncodeToString(hash)
+	return nil
+}
+
+// check reports whether user and password match
+func (a feedAuth) check(user, password string) bool {
+	salt, err := hex.DecodeString(a.Salt)
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(a.Hash)
+	if err != nil {
+		return false
+	}
+	got, err := hashPassword(password, salt)
+	if err != nil {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(a.User)) == 1
+	return subtle.ConstantTimeCompare(got, want) == 1 && userOK
+}
+
+func hashPassword(password string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, password, salt, authIterations, 32)
+}
+
+// basicAuth wraps next in HTTP Basic Auth. Hashing is deliberately slow, so
+// credentials that passed or failed once are remembered for the life of the
+// server rather than rehashed on every range request a player makes, and
+// each address may only have authChecksPerMinute new ones hashed.
+func basicAuth(next http.Handler, auth feedAuth) http.Handler {
+	var mu sync.Mutex
+	verified := map[[sha256.Size]byte]bool{} // true if accepted
+	checks := newRequestLimiter(authChecksPerMinute)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if ok {
+			key := sha256.Sum256([]byte(user + "\x00" + password))
+			mu.Lock()
+			accepted, seen := verified[key]
+			mu.Unlock()
+			if !seen {
+				if allowed, wait := checks.allow(clientAddress(r), time.Now()); !allowed {
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+					http.Error(w, "Too many sign-in attempts", http.StatusTooManyRequests)
+					return
+				}
+				accepted = auth.check(user, password)
+				mu.Lock()
+				if !accepted && len(verified) >= authRejectedMax {
+					// Forget the rejected ones; accepted ones are few and kept
+					for k, v := range verified {
+						if !v {
+							delete(verified, k)
+						}
+					}
+				}
+				verified[key] = accepted
+				mu.Unlock()
+			}
+			if accepted {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, authRealm))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// authSection is the Settings section for password protection. Changes
+// apply the next time the server starts.
+func (p *Podcasterator) authSection() fyne.CanvasObject {
+	status := widget.NewLabel("")
+	status.Importance = widget.LowImportance
+	updateStatus := func() {
+		if p.auth.Hash == "" {
+			status.SetText("No password set.")
+		} else {
+			status.SetText("A password is set.")
+		}
+	}
+	updateStatus()
+
+	enableCheck := widget.NewCheck("Require a username and password", func(checked bool) {
+		p.auth.Enabled = checked
+		p.markDirty()
+	})
+	enableCheck.SetChecked(p.auth.Enabled)
+
+	userEntry := widget.NewEntry()
+	userEntry.SetPlaceHolder("listener")
+	userEntry.SetText(p.auth.User)
+	userEntry.OnChanged = func(s string) {
+		p.auth.User = strings.TrimSpace(s)
+		p.markDirty()
+	}
+
+	passwordBtn := widget.NewButton("Set Password…", func() {
+		p.openPasswordDialog(updateStatus)
+	})
+
+	note := widget.NewLabel("Podcast apps ask for these when subscribing. Applies the next\ntime the server starts. The password is stored only as a hash.")
+	note.Importance = widget.LowImportance
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("Access", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		enableCheck,
+		container.NewBorder(nil, nil, widget.NewLabel("Username:"), passwordBtn, userEntry),
+		status,
+		note,
+	)
+}
+
+// openPasswordDialog asks for a new password twice and stores its hash
+func (p *Podcasterator) openPasswordDialog(onSet func()) {
+	password := widget.NewPasswordEntry()
+	confirm := widget.NewPasswordEntry()
+	confirm.Validator = func(s string) error {
+		if s != password.Text {
+			return fmt.Errorf("passwords don't match")
+		}
+		return nil
+	}
+
+	dialog.ShowForm("Set Password", "Set", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Password", password),
+			widget.NewFormItem("Confirm", confirm),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if password.Text != confirm.Text {
+				dialog.ShowError(fmt.Errorf("passwords don't match"), p.window)
+				return
+			}
+			if err := p.auth.setPassword(password.Text); err != nil {
+				dialog.ShowError(err, p.window)
+				return
+			}
+			p.markDirty()
+			onSet()
+		}, p.window)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Basic Auth Tests
+// =============================================================================
+
+func TestFeedAuthPassword(t *testing.T) {
+	var auth feedAuth
+	if err := auth.setPassword(""); err == nil {
+		t.Error("setPassword(\"\") succeeded")
+	}
+	if auth.ready() {
+		t.Error("ready() with no username or password")
+	}
+
+	auth.User = "office"
+	if err := auth.setPassword("s3cret"); err != nil {
+		t.Fatalf("setPassword() error = %v", err)
+	}
+	if !auth.ready() {
+		t.Error("ready() = false after setting a username and password")
+	}
+	if strings.Contains(auth.Hash, "s3cret") || strings.Contains(auth.Salt, "s3cret") {
+		t.Error("password stored in plain text")
+	}
+
+	tests := []struct {
+		user, password string
+		want           bool
+	}{
+		{"office", "s3cret", true},
+		{"office", "S3cret", false},
+		{"Office", "s3cret", false},
+		{"", "", false},
+	}
+	for _, tc := range tests {
+		if got := auth.check(tc.user, tc.password); got != tc.want {
+			t.Errorf("check(%q, %q) = %v; want %v", tc.user, tc.password, got, tc.want)
+		}
+	}
+
+	// The same password gets a new salt each time it's set
+	before := auth.Hash
+	auth.setPassword("s3cret")
+	if auth.Hash == before {
+		t.Error("setting the same password again reused the salt")
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	auth := feedAuth{Enabled: true, User: "office"}
+	auth.setPassword("s3cret")
+	handler := basicAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("feed"))
+	}), auth)
+
+	tests := []struct {
+		name           string
+		user, password string
+		withAuth       bool
+		want           int
+	}{
+		{"no credentials", "", "", false, http.StatusUnauthorized},
+		{"wrong password", "office", "guess", true, http.StatusUnauthorized},
+		{"right credentials", "office", "s3cret", true, http.StatusOK},
+		{"remembered credentials", "office", "s3cret", true, http.StatusOK},
+		{"other user, same password", "guest", "s3cret", true, http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/feed.xml", nil)
+			if tc.withAuth {
+				req.SetBasicAuth(tc.user, tc.password)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("status = %d; want %d", rec.Code, tc.want)
+			}
+			challenge := rec.Header().Get("WWW-Authenticate")
+			if tc.want == http.StatusUnauthorized && !strings.HasPrefix(challenge, `Basic realm="Podcasterator"`) {
+				t.Errorf("WWW-Authenticate = %q; want a Basic challenge", challenge)
+			}
+			if tc.want == http.StatusOK && rec.Body.String() != "feed" {
+				t.Errorf("body = %q; want the wrapped handler's", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestBasicAuthThrottlesChecks(t *testing.T) {
+	auth := feedAuth{Enabled: true, User: "office"}
+	auth.setPassword("s3cret")
+	handler := basicAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), auth)
+	get := func(remote, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/feed.xml", nil)
+		req.RemoteAddr = remote
+		req.SetBasicAuth("office", password)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := range authChecksPerMinute {
+		if rec := get("192.168.1.66:4000", fmt.Sprint("guess", i)); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("guess %d = %d; want 401", i+1, rec.Code)
+		}
+	}
+	// Further guesses from that address aren't hashed at all
+	if rec := get("192.168.1.66:4001", "one more"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("guess over the limit = %d, Retry-After %q; want 429 with a wait", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	// A guess already rejected is turned away without another check
+	if rec := get("192.168.1.66:4002", "guess0"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("repeated guess = %d; want 401 from the remembered rejection", rec.Code)
+	}
+	// Other devices can still sign in
+	if rec := get("192.168.1.20:5000", "s3cret"); rec.Code != http.StatusOK {
+		t.Errorf("right password from another address = %d; want 200", rec.Code)
+	}
+}
+
+func TestLaunchServerWithAuth(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+
+	// Turned on without a password, the server won't start unprotected
+	p.auth = feedAuth{Enabled: true, User: "office"}
+	if p.launchServer() {
+		p.shutdownServer()
+		t.Fatal("launchServer() started with password protection but no password")
+	}
+
+	p.auth.setPassword("s3cret")
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+
+	if code, _ := getBody(t, p.serverURL); code != http.StatusUnauthorized {
+		t.Errorf("GET %s without credentials = %d; want 401", p.serverURL, code)
+	}
+	req, _ := http.NewRequest("GET", p.serverURL, nil)
+	req.SetBasicAuth("office", "s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET with credentials: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET with credentials = %d; want 200", resp.StatusCode)
+	}
+	if p.serverAuthUser != "office" {
+		t.Errorf("serverAuthUser = %q; want office", p.serverAuthUser)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// setChecked ticks or unticks the file with id for bulk actions
+func (p *Podcasterator) setChecked(id string, on bool) {
+	if on {
+		if p.checked == nil {
+			p.checked = map[string]bool{}
+		}
+		p.checked[id] = true
+	} else {
+		delete(p.checked, id)
+	}
+	p.updateBulkBar()
+}
+
+// checkedIndices returns the positions of the ticked files, top to bottom
+func (p *Podcasterator) checkedIndices() []int {
+	var indices []int
+	for i, file := range p.files {
+		if p.checked[file.ID] {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// clearChecked unticks every file
+func (p *Podcasterator) clearChecked() {
+	p.checked = nil
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.updateBulkBar()
+}
+
+// moveBlock moves the files ticked in checked delta places (-1 up, 1 down)
+// in place. Ticked files next to each other move together; a block already
+// at the end it's moving towards stays put while the rest catch up to it.
+// It reports whether anything moved.
+func moveBlock(files []AudioFile, checked map[string]bool, delta int) bool {
+	moved := false
+	if delta < 0 {
+		for i := 1; i < len(files); i++ {
+			if checked[files[i].ID] && !checked[files[i-1].ID] {
+				files[i], files[i-1] = files[i-1], files[i]
+				moved = true
+			}
+		}
+	} else if delta > 0 {
+		for i := len(files) - 2; i >= 0; i-- {
+			if checked[files[i].ID] && !checked[files[i+1].ID] {
+				files[i], files[i+1] = files[i+1], files[i]
+				moved = true
+			}
+		}
+	}
+	return moved
+}
+
+// moveChecked moves the ticked files up (delta -1) or down (1) as a block
+func (p *Podcasterator) moveChecked(delta int) {
+	if !moveBlock(p.files, p.checked, delta) {
+		return
+	}
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+}
+
+// deleteChecked removes every ticked file as one delete, so a single Undo
+// puts them all back
+func (p *Podcasterator) deleteChecked() {
+	indices := p.checkedIndices()
+	if len(indices) == 0 {
+		return
+	}
+
+	if p.detail != nil && p.checked[p.detail.fileID] {
+		if p.fileList != nil {
+			p.fileList.UnselectAll()
+		}
+		p.hideEpisodeDetail()
+	}
+	if p.checked[p.selectedID] {
+		p.selectedID = ""
+	}
+
+	p.checked = nil
+	p.removeFiles(indices)
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.updateFileCount()
+	p.saveNow()
+}
+
+// updateBulkBar shows the bulk actions while any file is ticked. Ticks for
+// files no longer in the list are dropped.
+func (p *Podcasterator) updateBulkBar() {
+	if len(p.checked) > 0 {
+		present := make(map[string]bool, len(p.files))
+		for _, file := range p.files {
+			present[file.ID] = true
+		}
+		for id := range p.checked {
+			if !present[id] {
+				delete(p.checked, id)
+			}
+		}
+	}
+
+	if p.bulkBar == nil {
+		return
+	}
+	if len(p.checked) == 0 {
+		p.bulkBar.Hide()
+		return
+	}
+	p.bulkLabel.SetText(fmt.Sprintf("%d selected", len(p.checked)))
+	p.bulkBar.Show()
+}
+
+// createBulkBar builds the actions for the ticked files, hidden until a
+// file is ticked
+func (p *Podcasterator) createBulkBar() *fyne.Container {
+	p.bulkLabel = widget.NewLabel("")
+	p.bulkBar = container.NewHBox(
+		p.bulkLabel,
+		p.newTipButton("", theme.MoveUpIcon(), "Move the ticked files up", func() { p.moveChecked(-1) }),
+		p.newTipButton("", theme.MoveDownIcon(), "Move the ticked files down", func() { p.moveChecked(1) }),
+		p.newTipButton("Delete", theme.DeleteIcon(), "Remove the ticked files", p.deleteChecked),
+		p.newTipButton("Clear Selection", nil, "Untick every file", p.clearChecked),
+	)
+	p.updateBulkBar()
+	return p.bulkBar
+}
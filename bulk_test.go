@@ -0,0 +1,164 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// =============================================================================
+// Bulk Action Tests
+// =============================================================================
+
+func TestMoveBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		checked string
+		delta   int
+		want    string
+		moved   bool
+	}{
+		{"one up", "c", -1, "a,c,b,d,e", true},
+		{"block up", "c,d", -1, "a,c,d,b,e", true},
+		{"block down", "b,c", 1, "a,d,b,c,e", true},
+		{"apart up", "b,d", -1, "b,a,d,c,e", true},
+		{"apart down", "b,d", 1, "a,c,b,e,d", true},
+		{"block at top (no change)", "a,b", -1, "a,b,c,d,e", false},
+		{"block at bottom (no change)", "d,e", 1, "a,b,c,d,e", false},
+		{"top held, rest catch up", "a,c", -1, "a,c,b,d,e", true},
+		{"bottom held, rest catch up", "b,e", 1, "a,c,b,d,e", true},
+		{"nothing ticked (no change)", "", 1, "a,b,c,d,e", false},
+		{"all ticked (no change)", "a,b,c,d,e", -1, "a,b,c,d,e", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var files []AudioFile
+			for _, id := range strings.Split("a,b,c,d,e", ",") {
+				files = append(files, AudioFile{ID: id, DisplayName: id})
+			}
+			checked := map[string]bool{}
+			for _, id := range strings.Split(tc.checked, ",") {
+				if id != "" {
+					checked[id] = true
+				}
+			}
+
+			moved := moveBlock(files, checked, tc.delta)
+			if got := strings.Join(displayNames(files), ","); got != tc.want || moved != tc.moved {
+				t.Errorf("moveBlock(%s, %d) = %s, moved %v; want %s, %v", tc.checked, tc.delta, got, moved, tc.want, tc.moved)
+			}
+		})
+	}
+}
+
+func TestMoveChecked(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3", "c.mp3")
+	p.setChecked(p.files[1].ID, true)
+	p.setChecked(p.files[2].ID, true)
+
+	p.dirty = false
+	p.moveChecked(-1)
+	if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,c.mp3,a.mp3" {
+		t.Errorf("after moveChecked(-1) = %s; want b.mp3,c.mp3,a.mp3", got)
+	}
+	if !p.dirty {
+		t.Error("moveChecked() didn't mark the state dirty")
+	}
+
+	p.dirty = false
+	p.moveChecked(-1)
+	if p.dirty {
+		t.Error("moveChecked() at the top marked the state dirty")
+	}
+}
+
+func TestDeleteChecked(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3", "c.mp3", "d.mp3")
+	deleted := []AudioFile{p.files[0], p.files[2]}
+	p.setChecked(deleted[0].ID, true)
+	p.setChecked(deleted[1].ID, true)
+	p.selectedID = deleted[1].ID
+
+	p.dirty = false
+	p.deleteChecked()
+	if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,d.mp3" {
+		t.Errorf("after deleteChecked() = %s; want b.mp3,d.mp3", got)
+	}
+	for _, f := range deleted {
+		if fileExists(f.TempPath) {
+			t.Errorf("%s's temp copy is still in place", f.DisplayName)
+		}
+	}
+	if len(p.checked) != 0 || p.selectedID != "" {
+		t.Errorf("after deleteChecked() checked = %v, selected %q; want both cleared", p.checked, p.selectedID)
+	}
+	if state, err := readState(filepath.Join(p.configDir, "state.json")); err != nil || len(state.Files) != len(p.files) {
+		t.Errorf("deleteChecked() didn't save the removal straight away (%v)", err)
+	}
+
+	// One undo puts them all back
+	if len(p.undoStack) != 1 {
+		t.Fatalf("undo stack has %d records; want 1", len(p.undoStack))
+	}
+	p.undo()
+	if got := strings.Join(displayNames(p.files), ","); got != "a.mp3,b.mp3,c.mp3,d.mp3" {
+		t.Errorf("after undo = %s; want a.mp3,b.mp3,c.mp3,d.mp3", got)
+	}
+
+	p.dirty = false
+	p.deleteChecked()
+	if len(p.files) != 4 || p.dirty {
+		t.Error("deleteChecked() with nothing ticked changed something")
+	}
+}
+
+func TestBulkBar(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.fileList = p.newFileList()
+	p.createBulkBar()
+	if p.bulkBar.Visible() {
+		t.Error("bulk actions shown with nothing ticked")
+	}
+
+	// Ticking a row's checkbox ticks its file
+	item := p.fileList.CreateItem()
+	p.fileList.UpdateItem(1, item)
+	row := item.(*fileRow)
+	test.Tap(row.check)
+	if !p.checked[p.files[1].ID] {
+		t.Fatal("ticking the row's checkbox didn't tick its file")
+	}
+	if !p.bulkBar.Visible() || p.bulkLabel.Text != "1 selected" {
+		t.Errorf("bulk bar visible %v, label %q; want it shown with 1 selected", p.bulkBar.Visible(), p.bulkLabel.Text)
+	}
+
+	// A reused row shows the tick of the file it now holds
+	p.fileList.UpdateItem(0, item)
+	if row.check.Checked {
+		t.Error("a reused row kept the previous file's tick")
+	}
+	if len(p.checked) != 1 {
+		t.Error("reusing a row changed the ticks")
+	}
+
+	// Ticks of files removed another way are dropped
+	p.deleteFile(1)
+	if p.bulkBar.Visible() || len(p.checked) != 0 {
+		t.Error("bulk bar still shown after the ticked file was deleted")
+	}
+
+	p.setChecked(p.files[0].ID, true)
+	p.clearChecked()
+	if p.bulkBar.Visible() || len(p.checked) != 0 {
+		t.Error("clearChecked() left ticks")
+	}
+}
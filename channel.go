@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/google/uuid"
+)
+
+// channelNamespaces are the prefixes custom channel elements may use. The
+// feed declares a namespace only when an element needs it.
+var channelNamespaces = map[string]string{
+	"atom":       atomNamespace,
+	"content":    "http://purl.org/rss/1.0/modules/content/",
+	"googleplay": "http://www.google.com/schemas/play-podcasts/1.0",
+	"itunes":     "http://www.itunes.com/dtds/podcast-1.0.dtd",
+	"podcast":    "https://podcastindex.org/namespace/1.0",
+}
+
+// alwaysDeclared are the namespaces rssDocument declares on every feed
+var alwaysDeclared = map[string]bool{"atom": true, "content": true}
+
+var channelElementName = regexp.MustCompile(`^(?:([A-Za-z][A-Za-z0-9]*):)?[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// reservedChannelElements are the <channel> children the feed writer always
+// emits, or that only belong in an <item>, lowercased. A custom one would
+// be a duplicate or make the feed invalid.
+var reservedChannelElements = map[string]bool{
+	"title":       true,
+	"link":        true,
+	"description": true,
+	"pubdate":     true,
+	"image":       true,
+	"item":        true,
+	"atom:link":   true,
+	"enclosure":   true,
+	"guid":        true,
+	"source":      true,
+	"comments":    true,
+}
+
+// channelElement is an extra element injected into <channel>, such as
+// <copyright> or <podcast:locked>. The value is written as escaped text.
+// Attrs and Children are only set by the app itself; the settings table
+// edits name/value. An element with children has no value of its own.
+type channelElement struct {
+	Name     string            `json:"name"`
+	Value    string            `json:"value"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Children []channelElement  `json:"children,omitempty"`
+}
+
+func (e channelElement) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Local: e.Name}}
+	keys := make([]string, 0, len(e.Attrs))
+	for k := range e.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: k}, Value: e.Attrs[k]})
+	}
+	if len(e.Children) == 0 {
+		return enc.EncodeElement(e.Value, start)
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, child := range e.Children {
+		if err := enc.Encode(child); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// podcastGUIDNamespace is the UUIDv5 namespace Podcasting 2.0 defines for
+// <podcast:guid>
+var podcastGUIDNamespace = uuid.MustParse("ead4c236-bf58-58c6-a2c6-a6b28d128cb6")
+
+// podcastGUIDFor derives a show GUID from a feed URL as the Podcasting 2.0
+// spec describes: scheme and trailing slashes removed, then UUIDv5.
+func podcastGUIDFor(feedURL string) string {
+	u := feedURL
+	if _, rest, ok := strings.Cut(u, "://"); ok {
+		u = rest
+	}
+	u = strings.TrimRight(u, "/")
+	return uuid.NewSHA1(podcastGUIDNamespace, []byte(u)).String()
+}
+
+// ensureChannelGUID returns the show's GUID, deriving it from feedURL the
+// first time and keeping it from then on. The local feed URL changes with
+// the network, but the GUID must not. It's called before the feed is first
+// served or exported, since building the feed doesn't change the state.
+func (p *Podcasterator) ensureChannelGUID(feedURL string) string {
+	if p.channelGUID == "" {
+		p.channelGUID = podcastGUIDFor(feedURL)
+		p.markDirty()
+	}
+	return p.channelGUID
+}
+
+// podcastChannelElements returns <podcast:guid> and <podcast:locked>
+func (p *Podcasterator) podcastChannelElements(feedURL string) []channelElement {
+	locked := channelElement{Name: "podcast:locked", Value: "no"}
+	if p.feedLocked {
+		locked.Value = "yes"
+	}
+	if email := p.validOwnerEmail(); email != "" {
+		locked.Attrs = map[string]string{"owner": email}
+	}
+	guid := p.channelGUID
+	if guid == "" {
+		guid = podcastGUIDFor(feedURL)
+	}
+	return []channelElement{
+		{Name: "podcast:guid", Value: guid},
+		locked,
+	}
+}
+
+// mergeChannelElements appends custom elements to the generated ones. A
+// custom element replaces a generated element of the same name.
+func mergeChannelElements(generated, custom []channelElement) []channelElement {
+	overridden := map[string]bool{}
+	for _, e := range custom {
+		overridden[e.Name] = true
+	}
+	var merged []channelElement
+	for _, e := range generated {
+		if !overridden[e.Name] {
+			merged = append(merged, e)
+		}
+	}
+	return append(merged, custom...)
+}
+
+// validateChannelElementName accepts a plain element name or one with a
+// prefix from channelNamespaces, other than those the feed writes itself
+// (see reservedChannelElements).
+func validateChannelElementName(name string) error {
+	m := channelElementName.FindStringSubmatch(name)
+	if m == nil || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return fmt.Errorf("%q is not a valid element name", name)
+	}
+	if reservedChannelElements[strings.ToLower(name)] {
+		return fmt.Errorf("<%s> is written by the feed itself and can't be added", name)
+	}
+	if prefix := m[1]; prefix != "" {
+		if _, ok := channelNamespaces[prefix]; !ok {
+			return fmt.Errorf("unknown namespace prefix %q in %q", prefix, name)
+		}
+	}
+	return nil
+}
+
+// channelNamespaceAttrs returns the xmlns declarations needed by elements
+// beyond the ones every feed declares, in a stable order.
+func channelNamespaceAttrs(elements []channelElement) []xml.Attr {
+	seen := map[string]bool{}
+	var prefixes []string
+	for _, e := range elements {
+		prefix, _, ok := strings.Cut(e.Name, ":")
+		if !ok || alwaysDeclared[prefix] || seen[prefix] {
+			continue
+		}
+		if _, known := channelNamespaces[prefix]; !known {
+			continue
+		}
+		seen[prefix] = true
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	attrs := make([]xml.Attr, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		attrs = append(attrs, xml.Attr{
+			Name:  xml.Name{Local: "xmlns:" + prefix},
+			Value: channelNamespaces[prefix],
+		})
+	}
+	return attrs
+}
+
+// openChannelElementsDialog edits the custom channel elements as a table of
+// name/value rows. Nothing is saved unless every name is valid.
+func (p *Podcasterator) openChannelElementsDialog() {
+	type row struct {
+		name, value *widget.Entry
+	}
+	var rows []*row
+	rowsBox := container.NewVBox()
+
+	var addRow func(e channelElement)
+	addRow = func(e channelElement) {
+		r := &row{name: widget.NewEntry(), value: widget.NewEntry()}
+		r.name.SetPlaceHolder("podcast:locked")
+		r.name.SetText(e.Name)
+		r.value.SetPlaceHolder("Value")
+		r.value.SetText(e.Value)
+		rows = append(rows, r)
+
+		var line *fyne.Container
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			for i := range rows {
+				if rows[i] == r {
+					rows = append(rows[:i], rows[i+1:]...)
+					break
+				}
+			}
+			rowsBox.Remove(line)
+		})
+		line = container.NewBorder(nil, nil, nil, removeBtn, container.NewGridWithColumns(2, r.name, r.value))
+		rowsBox.Add(line)
+	}
+	for _, e := range p.channelElements {
+		addRow(e)
+	}
+
+	addBtn := widget.NewButtonWithIcon("Add element", theme.ContentAddIcon(), func() {
+		addRow(channelElement{})
+	})
+
+	prefixes := make([]string, 0, len(channelNamespaces))
+	for prefix := range channelNamespaces {
+		prefixes = append(prefixes, prefix+":")
+	}
+	sort.Strings(prefixes)
+	note := widget.NewLabel("Added to the feed's <channel>.\nKnown prefixes: " + strings.Join(prefixes, " "))
+	note.Importance = widget.LowImportance
+
+	content := container.NewBorder(note, addBtn, nil, nil, container.NewVScroll(rowsBox))
+
+	d := dialog.NewCustomConfirm("Channel Elements", "Save", "Cancel", content, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		var elements []channelElement
+		for _, r := range rows {
+			name := strings.TrimSpace(r.name.Text)
+			if name == "" {
+				continue
+			}
+			if err := validateChannelElementName(name); err != nil {
+				dialog.ShowError(err, p.window)
+				return
+			}
+			elements = append(elements, channelElement{Name: name, Value: r.value.Text})
+		}
+		p.channelElements = elements
+		p.markDirty()
+	}, p.window)
+	d.Resize(fyne.NewSize(600, 400))
+	d.Show()
+}
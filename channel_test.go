@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Custom Channel Element Tests
+// =============================================================================
+
+func TestValidateChannelElementName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"copyright", false},
+		{"managingEditor", false},
+		{"podcast:locked", false},
+		{"itunes:author", false},
+		{"googleplay:block", false},
+		{"", true},
+		{"1copyright", true},
+		{"has space", true},
+		{"bad<name", true},
+		{"foo:bar", true},
+		{"podcast:", true},
+		{":locked", true},
+		{"xmlns:podcast", true},
+		{"xml-stylesheet", true},
+		{"title", true},
+		{"link", true},
+		{"description", true},
+		{"pubDate", true},
+		{"image", true},
+		{"item", true},
+		{"Item", true},
+		{"atom:link", true},
+		{"enclosure", true},
+		{"guid", true},
+		{"atom:author", false},
+		{"itunes:image", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateChannelElementName(tc.name)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validateChannelElementName(%q) error = %v; wantErr %v", tc.name, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderRSSChannelElements(t *testing.T) {
+	extra := []channelElement{
+		{Name: "copyright", Value: "© 2024 Me & <Friends>"},
+		{Name: "podcast:locked", Value: "yes"},
+		{Name: "itunes:author", Value: "Me"},
+		{Name: "podcast:medium", Value: "podcast"},
+	}
+
+	rss, err := renderRSS(newTestFeed("http://localhost:8080"), "http://localhost:8080/feed.xml", feedExtras{Channel: extra})
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"<copyright>© 2024 Me &amp; &lt;Friends&gt;</copyright>",
+		"<podcast:locked>yes</podcast:locked>",
+		"<itunes:author>Me</itunes:author>",
+		`xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`,
+		`xmlns:podcast="https://podcastindex.org/namespace/1.0"`,
+	} {
+		if !strings.Contains(rss, want) {
+			t.Errorf("feed missing %s:\n%s", want, rss)
+		}
+	}
+	if n := strings.Count(rss, "xmlns:podcast="); n != 1 {
+		t.Errorf("podcast namespace declared %d times; want 1", n)
+	}
+
+	// Must still be well-formed with the namespaces resolved
+	var doc struct {
+		Channel struct {
+			Copyright string `xml:"copyright"`
+			Locked    string `xml:"https://podcastindex.org/namespace/1.0 locked"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+		t.Fatalf("feed is not valid XML: %v", err)
+	}
+	if doc.Channel.Copyright != "© 2024 Me & <Friends>" || doc.Channel.Locked != "yes" {
+		t.Errorf("parsed channel = %+v", doc.Channel)
+	}
+}
+
+func TestChannelNamespaceAttrsSkipsDeclared(t *testing.T) {
+	attrs := channelNamespaceAttrs([]channelElement{
+		{Name: "atom:link"},
+		{Name: "content:encoded"},
+		{Name: "copyright"},
+	})
+	if len(attrs) != 0 {
+		t.Errorf("channelNamespaceAttrs() = %v; want none", attrs)
+	}
+}
+
+// =============================================================================
+// Podcasting 2.0 Tests
+// =============================================================================
+
+func TestPodcastGUIDFor(t *testing.T) {
+	// Example from the Podcasting 2.0 namespace spec
+	tests := []string{
+		"https://podnews.net/rss",
+		"http://podnews.net/rss/",
+		"podnews.net/rss",
+	}
+	for _, feedURL := range tests {
+		if got := podcastGUIDFor(feedURL); got != "9b024349-ccf0-5f69-a609-6b82873eab3c" {
+			t.Errorf("podcastGUIDFor(%q) = %q; want %q", feedURL, got, "9b024349-ccf0-5f69-a609-6b82873eab3c")
+		}
+	}
+}
+
+func TestChannelGUIDStableAcrossRestarts(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	guid := p.ensureChannelGUID("http://192.168.1.10:8080/feed.xml")
+	if guid != podcastGUIDFor("http://192.168.1.10:8080/feed.xml") {
+		t.Errorf("first GUID = %q; want it derived from the feed URL", guid)
+	}
+	if !p.dirty {
+		t.Error("generating the GUID did not mark state dirty")
+	}
+	p.saveState()
+
+	// A restart on a different network must keep the same GUID
+	p2 := &Podcasterator{tempDir: p.tempDir, configDir: p.configDir}
+	p2.loadState()
+	if got := p2.ensureChannelGUID("http://10.0.0.7:8080/feed.xml"); got != guid {
+		t.Errorf("GUID after restart = %q; want %q", got, guid)
+	}
+}
+
+func TestPodcastChannelElements(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.feedLocked = true
+	p.ownerEmail = "me@example.com"
+	feedURL := "http://localhost:8080/feed.xml"
+	p.ensureChannelGUID(feedURL)
+
+	extra := mergeChannelElements(p.podcastChannelElements(feedURL), []channelElement{{Name: "copyright", Value: "Me"}})
+	rss, err := renderRSS(newTestFeed("http://localhost:8080"), feedURL, feedExtras{Channel: extra})
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"<podcast:guid>" + p.channelGUID + "</podcast:guid>",
+		`<podcast:locked owner="me@example.com">yes</podcast:locked>`,
+		"<copyright>Me</copyright>",
+	} {
+		if !strings.Contains(rss, want) {
+			t.Errorf("feed missing %s:\n%s", want, rss)
+		}
+	}
+
+	// A custom element of the same name replaces the generated one
+	merged := mergeChannelElements(p.podcastChannelElements(feedURL), []channelElement{{Name: "podcast:locked", Value: "no"}})
+	count := 0
+	for _, e := range merged {
+		if e.Name == "podcast:locked" {
+			count++
+			if e.Value != "no" {
+				t.Errorf("podcast:locked = %q; want the custom value", e.Value)
+			}
+		}
+	}
+	if count != 1 {
+		t.Errorf("podcast:locked appears %d times; want 1", count)
+	}
+
+	p.feedLocked = false
+	p.ownerEmail = ""
+	for _, e := range p.podcastChannelElements(feedURL) {
+		if e.Name == "podcast:locked" && (e.Value != "no" || e.Attrs != nil) {
+			t.Errorf("unlocked element = %+v; want value no without owner", e)
+		}
+	}
+}
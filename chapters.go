@@ -0,0 +1,433 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// chaptersName is the file name an episode's chapters are served under,
+// next to its audio at /files/<id>/
+const chaptersName = "chapters.json"
+
+// chapter is one chapter marker embedded in an audio file
+type chapter struct {
+	Start time.Duration
+	Title string
+}
+
+// readChapters returns the chapters embedded in an M4A/M4B file, in order.
+// Nero chapters (moov/udta/chpl) are used when present, otherwise the
+// QuickTime chapter track Apple's tools write. Other formats and files
+// without chapters return none.
+func readChapters(path string) ([]chapter, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m4a", ".mp4", ".m4b":
+	default:
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	moovStart, moovEnd, err := findMP4Atom(f, 0, info.Size(), "moov")
+	if err != nil {
+		return nil, nil
+	}
+	if chapters := neroChapters(f, moovStart, moovEnd); len(chapters) > 0 {
+		return chapters, nil
+	}
+	return quickTimeChapters(f, moovStart, moovEnd), nil
+}
+
+// readMP4Body reads the body of an atom, refusing anything larger than max
+func readMP4Body(r io.ReadSeeker, start, end, max int64) ([]byte, bool) {
+	if end-start < 0 || end-start > max {
+		return nil, false
+	}
+	body := make([]byte, end-start)
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, false
+	}
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// findMP4Path follows a path of nested atoms such as "mdia/minf/stbl" down
+// from the atom body between start and end
+func findMP4Path(r io.ReadSeeker, start, end int64, path string) (int64, int64, bool) {
+	for _, typ := range strings.Split(path, "/") {
+		var err error
+		if start, end, err = findMP4Atom(r, start, end, typ); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, end, true
+}
+
+// neroChapters reads the chpl atom: a count, then for each chapter its
+// start in 100ns units and a length-prefixed title
+func neroChapters(r io.ReadSeeker, moovStart, moovEnd int64) []chapter {
+	start, end, ok := findMP4Path(r, moovStart, moovEnd, "udta/chpl")
+	if !ok {
+		return nil
+	}
+	body, ok := readMP4Body(r, start, end, 1<<20)
+	if !ok || len(body) < 5 {
+		return nil
+	}
+	pos := 4
+	if body[0] == 1 {
+		pos += 4 // reserved
+	}
+	if pos >= len(body) {
+		return nil
+	}
+	count := int(body[pos])
+	pos++
+
+	var chapters []chapter
+	for i := 0; i < count && pos+9 <= len(body); i++ {
+		ticks := binary.BigEndian.Uint64(body[pos:])
+		titleLen := int(body[pos+8])
+		pos += 9
+		if pos+titleLen > len(body) {
+			break
+		}
+		chapters = append(chapters, chapter{
+			Start: scaledDuration(ticks, 10000000),
+			Title: chapterTitle(body[pos : pos+titleLen]),
+		})
+		pos += titleLen
+	}
+	return chapters
+}
+
+// quickTimeChapters reads the text track another track points to with a
+// tref/chap reference. Each of its samples is a chapter title, starting
+// where the sample does.
+func quickTimeChapters(r io.ReadSeeker, moovStart, moovEnd int64) []chapter {
+	type track struct{ start, end int64 }
+	tracks := map[uint32]track{}
+	var chapterIDs []uint32
+	for pos := moovStart; ; {
+		start, end, err := findMP4Atom(r, pos, moovEnd, "trak")
+		if err != nil {
+			break
+		}
+		pos = end
+		if id, ok := mp4TrackID(r, start, end); ok {
+			tracks[id] = track{start, end}
+		}
+		if refStart, refEnd, ok := findMP4Path(r, start, end, "tref/chap"); ok {
+			if body, ok := readMP4Body(r, refStart, refEnd, 1024); ok {
+				for i := 0; i+4 <= len(body); i += 4 {
+					chapterIDs = append(chapterIDs, binary.BigEndian.Uint32(body[i:]))
+				}
+			}
+		}
+	}
+
+	for _, id := range chapterIDs {
+		if t, ok := tracks[id]; ok {
+			if chapters := textTrackSamples(r, t.start, t.end); len(chapters) > 0 {
+				return chapters
+			}
+		}
+	}
+	return nil
+}
+
+// mp4TrackID reads the track ID from a trak's tkhd
+func mp4TrackID(r io.ReadSeeker, trakStart, trakEnd int64) (uint32, bool) {
+	start, end, ok := findMP4Path(r, trakStart, trakEnd, "tkhd")
+	if !ok {
+		return 0, false
+	}
+	body, ok := readMP4Body(r, start, end, 256)
+	if !ok || len(body) < 24 {
+		return 0, false
+	}
+	if body[0] == 1 {
+		return binary.BigEndian.Uint32(body[20:]), true
+	}
+	return binary.BigEndian.Uint32(body[12:]), true
+}
+
+// textTrackSamples reads each sample of a text track as a chapter, using
+// the sample table to find where each one starts and is stored
+func textTrackSamples(r io.ReadSeeker, trakStart, trakEnd int64) []chapter {
+	mdhdStart, mdhdEnd, ok := findMP4Path(r, trakStart, trakEnd, "mdia/mdhd")
+	if !ok {
+		return nil
+	}
+	mdhd, ok := readMP4Body(r, mdhdStart, mdhdEnd, 256)
+	if !ok || len(mdhd) < 24 {
+		return nil
+	}
+	timescale := binary.BigEndian.Uint32(mdhd[12:])
+	if mdhd[0] == 1 {
+		timescale = binary.BigEndian.Uint32(mdhd[20:])
+	}
+	if timescale == 0 {
+		return nil
+	}
+
+	stblStart, stblEnd, ok := findMP4Path(r, trakStart, trakEnd, "mdia/minf/stbl")
+	if !ok {
+		return nil
+	}
+	table := func(typ string) []byte {
+		start, end, err := findMP4Atom(r, stblStart, stblEnd, typ)
+		if err != nil {
+			return nil
+		}
+		body, _ := readMP4Body(r, start, end, 1<<20)
+		return body
+	}
+
+	// Start of each sample, from the runs of equal durations in stts
+	var starts []uint64
+	var elapsed uint64
+	stts := table("stts")
+	for i := 8; i+8 <= len(stts); i += 8 {
+		count, delta := binary.BigEndian.Uint32(stts[i:]), binary.BigEndian.Uint32(stts[i+4:])
+		for n := uint32(0); n < count && len(starts) < 10000; n++ {
+			starts = append(starts, elapsed)
+			elapsed += uint64(delta)
+		}
+	}
+
+	// Size of each sample, or one size for all of them
+	stsz := table("stsz")
+	if len(stsz) < 12 {
+		return nil
+	}
+	sizeOf := func(i int) uint32 {
+		if fixed := binary.BigEndian.Uint32(stsz[4:]); fixed != 0 {
+			return fixed
+		}
+		if at := 12 + 4*i; at+4 <= len(stsz) {
+			return binary.BigEndian.Uint32(stsz[at:])
+		}
+		return 0
+	}
+
+	// Chunk offsets, 32- or 64-bit
+	var chunks []int64
+	if stco := table("stco"); len(stco) >= 8 {
+		for i := 8; i+4 <= len(stco); i += 4 {
+			chunks = append(chunks, int64(binary.BigEndian.Uint32(stco[i:])))
+		}
+	} else if co64 := table("co64"); len(co64) >= 8 {
+		for i := 8; i+8 <= len(co64); i += 8 {
+			chunks = append(chunks, int64(binary.BigEndian.Uint64(co64[i:])))
+		}
+	}
+
+	// stsc maps runs of chunks to how many samples each holds; the samples
+	// of a chunk are stored back to back from its offset
+	stsc := table("stsc")
+	var offsets []int64
+	for i := 8; i+12 <= len(stsc); i += 12 {
+		first := int(binary.BigEndian.Uint32(stsc[i:])) - 1
+		perChunk := int(binary.BigEndian.Uint32(stsc[i+4:]))
+		last := len(chunks)
+		if i+24 <= len(stsc) {
+			last = int(binary.BigEndian.Uint32(stsc[i+12:])) - 1
+		}
+		for c := first; c >= 0 && c < last && c < len(chunks); c++ {
+			offset := chunks[c]
+			for n := 0; n < perChunk && len(offsets) < len(starts); n++ {
+				offsets = append(offsets, offset)
+				offset += int64(sizeOf(len(offsets) - 1))
+			}
+		}
+	}
+
+	var chapters []chapter
+	for i, offset := range offsets {
+		size := sizeOf(i)
+		if size < 2 || size > 4096 {
+			continue
+		}
+		sample, ok := readMP4Body(r, offset, offset+int64(size), 4096)
+		if !ok {
+			continue
+		}
+		// A sample is a 16-bit length followed by the text
+		textLen := int(binary.BigEndian.Uint16(sample))
+		if 2+textLen > len(sample) {
+			continue
+		}
+		chapters = append(chapters, chapter{
+			Start: scaledDuration(starts[i], uint64(timescale)),
+			Title: chapterTitle(sample[2 : 2+textLen]),
+		})
+	}
+	return chapters
+}
+
+// chapterTitle decodes a title stored as UTF-8, or as UTF-16 when it starts
+// with a byte order mark
+func chapterTitle(b []byte) string {
+	if len(b) >= 2 && (b[0] == 0xfe && b[1] == 0xff || b[0] == 0xff && b[1] == 0xfe) {
+		order := binary.ByteOrder(binary.BigEndian)
+		if b[0] == 0xff {
+			order = binary.LittleEndian
+		}
+		units := make([]uint16, 0, len(b)/2)
+		for i := 2; i+2 <= len(b); i += 2 {
+			units = append(units, order.Uint16(b[i:]))
+		}
+		return strings.TrimSpace(string(utf16.Decode(units)))
+	}
+	if !utf8.Valid(b) {
+		return strings.TrimSpace(strings.ToValidUTF8(string(b), "?"))
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// chaptersDocument is the Podcast Namespace JSON chapters format
+type chaptersDocument struct {
+	Version  string             `json:"version"`
+	Chapters []chaptersDocEntry `json:"chapters"`
+}
+
+type chaptersDocEntry struct {
+	StartTime float64 `json:"startTime"`
+	Title     string  `json:"title,omitempty"`
+}
+
+// chaptersJSON renders chapters as a Podcast Namespace chapters file, or
+// nil when there are none
+func chaptersJSON(chapters []chapter) ([]byte, error) {
+	if len(chapters) == 0 {
+		return nil, nil
+	}
+	doc := chaptersDocument{Version: "1.2.0"}
+	for _, c := range chapters {
+		doc.Chapters = append(doc.Chapters, chaptersDocEntry{
+			StartTime: c.Start.Round(time.Millisecond).Seconds(),
+			Title:     c.Title,
+		})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// chaptersPath is where the chapters file of file is kept, beside its temp
+// copy so it never lands next to the original
+func chaptersPath(file AudioFile) string {
+	return filepath.Join(filepath.Dir(file.TempPath), chaptersName)
+}
+
+// applyChapters writes data as the chapters file of f, or removes it when
+// data is nil, and points ChaptersPath at it
+func applyChapters(f *AudioFile, data []byte) error {
+	if f.TempPath == "" {
+		return nil
+	}
+	path := chaptersPath(*f)
+	if data == nil {
+		if f.ChaptersPath != "" {
+			os.Remove(f.ChaptersPath)
+		}
+		f.ChaptersPath = ""
+		return nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		f.ChaptersPath = ""
+		return err
+	}
+	f.ChaptersPath = path
+	return nil
+}
+
+// refreshChapters reads the chapters of what's served for the file at index
+// again, e.g. once its start has been cut
+func (p *Podcasterator) refreshChapters(index int) {
+	file := &p.files[index]
+	if file.IsExternal() {
+		return
+	}
+	chapters, err := readChapters(file.ServedPath())
+	if err != nil {
+		return
+	}
+	data, err := chaptersJSON(chapters)
+	if err == nil {
+		err = applyChapters(file, data)
+	}
+	if err != nil {
+		fmt.Printf("Couldn't save chapters for %s: %v\n", file.DisplayName, err)
+	}
+}
+
+// chaptersExtractor writes the chapters embedded in a file beside its temp
+// copy. Files without chapters have none.
+type chaptersExtractor struct{}
+
+func (chaptersExtractor) Name() string { return "chapters" }
+
+func (chaptersExtractor) Extract(path string) (func(f *AudioFile), error) {
+	chapters, err := readChapters(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := chaptersJSON(chapters)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *AudioFile) {
+		if err := applyChapters(f, data); err != nil {
+			fmt.Printf("Couldn't save chapters for %s: %v\n", f.DisplayName, err)
+		}
+	}, nil
+}
+
+// chaptersURL is the URL of an episode's chapters under baseURL
+func chaptersURL(baseURL, id string) string {
+	return fmt.Sprintf("%s/files/%s/%s", strings.TrimRight(baseURL, "/"), id, chaptersName)
+}
+
+// itemChaptersElements is the <podcast:chapters> for an item, or nothing
+// when the file has no chapters
+func itemChaptersElements(file AudioFile, baseURL string) []channelElement {
+	if file.ChaptersPath == "" || !fileExists(file.ChaptersPath) {
+		return nil
+	}
+	return []channelElement{{Name: "podcast:chapters", Attrs: map[string]string{
+		"url":  chaptersURL(baseURL, file.ID),
+		"type": "application/json+chapters",
+	}}}
+}
+
+// serveChapters serves the chapters file of an episode
+func (s *FeedServer) serveChapters(w http.ResponseWriter, r *http.Request, file AudioFile) {
+	if file.ChaptersPath == "" || !fileExists(file.ChaptersPath) {
+		http.Error(w, "Chapters not found", http.StatusNotFound)
+		return
+	}
+	if !s.servableCopy(file.ChaptersPath) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json+chapters")
+	http.ServeFile(w, r, file.ChaptersPath)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Chapter Tests
+// =============================================================================
+
+// chplAtom builds Nero chapters as ffmpeg writes them
+func chplAtom(chapters ...chapter) []byte {
+	body := []byte{1, 0, 0, 0, 0, 0, 0, 0, byte(len(chapters))}
+	for _, c := range chapters {
+		body = binary.BigEndian.AppendUint64(body, uint64(c.Start/100))
+		body = append(body, byte(len(c.Title)))
+		body = append(body, c.Title...)
+	}
+	return mp4Atom("udta", mp4Atom("chpl", body))
+}
+
+// fullAtomBody is a version/flags header followed by 32-bit values
+func fullAtomBody(values ...uint32) []byte {
+	body := []byte{0, 0, 0, 0}
+	for _, v := range values {
+		body = binary.BigEndian.AppendUint32(body, v)
+	}
+	return body
+}
+
+// tkhdBody is a version 0 track header for id
+func tkhdBody(id uint32) []byte {
+	return append(fullAtomBody(0, 0, id), make([]byte, 68)...)
+}
+
+// quickTimeChapterFile builds an m4b whose audio track 1 points at text
+// track 2 holding titles, each lasting one minute. The samples sit in one
+// chunk at the start of the file, followed by the moov.
+func quickTimeChapterFile(titles ...string) []byte {
+	var samples, sizes []byte
+	for _, title := range titles {
+		sample := binary.BigEndian.AppendUint16(nil, uint16(len(title)))
+		sample = append(sample, title...)
+		samples = append(samples, sample...)
+		sizes = binary.BigEndian.AppendUint32(sizes, uint32(len(sample)))
+	}
+	mdat := mp4Atom("mdat", samples)
+
+	audio := mp4Atom("trak",
+		mp4Atom("tkhd", tkhdBody(1)),
+		mp4Atom("tref", mp4Atom("chap", binary.BigEndian.AppendUint32(nil, 2))))
+	stbl := mp4Atom("stbl",
+		mp4Atom("stts", fullAtomBody(1, uint32(len(titles)), 60000)),
+		mp4Atom("stsc", fullAtomBody(1, 1, uint32(len(titles)), 1)),
+		mp4Atom("stsz", append(fullAtomBody(0, uint32(len(titles))), sizes...)),
+		mp4Atom("stco", fullAtomBody(1, 8)))
+	text := mp4Atom("trak",
+		mp4Atom("tkhd", tkhdBody(2)),
+		mp4Atom("mdia",
+			mp4Atom("mdhd", append(fullAtomBody(0, 0, 1000, uint32(60000*len(titles))), 0, 0, 0, 0)),
+			mp4Atom("minf", stbl)))
+	moov := mp4Atom("moov", mp4Atom("mvhd", mvhdBody(0, 1000, uint64(60000*len(titles)))), audio, text)
+	return append(mdat, moov...)
+}
+
+func TestReadChapters(t *testing.T) {
+	nero := []chapter{
+		{Start: 0, Title: "Opening Credits"},
+		{Start: 95*time.Second + 500*time.Millisecond, Title: "Chapter 1"},
+		{Start: 30 * time.Minute, Title: "Chapter 2"},
+	}
+	moov := mp4Atom("moov", mp4Atom("mvhd", mvhdBody(0, 1000, 3600000)), chplAtom(nero...))
+
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want []chapter
+	}{
+		{"nero", "book.m4b", moov, nero},
+		{"quicktime track", "book.m4b", quickTimeChapterFile("Intro", "Ünïcode"), []chapter{
+			{Start: 0, Title: "Intro"},
+			{Start: time.Minute, Title: "Ünïcode"},
+		}},
+		{"no chapters", "song.m4a", mp4Atom("moov", mp4Atom("mvhd", mvhdBody(0, 1000, 1000))), nil},
+		{"not an mp4", "book.m4b", []byte("garbage"), nil},
+		{"mp3", "show.mp3", mp3Frames(10), nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readChapters(writeAudio(t, tc.file, tc.data))
+			if err != nil {
+				t.Fatalf("readChapters() error = %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("readChapters() = %+v; want %+v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("chapter %d = %+v; want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChapterTitle(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"utf-8", []byte("Prologue "), "Prologue"},
+		{"utf-16 big endian", []byte{0xfe, 0xff, 0, 'H', 0, 'i'}, "Hi"},
+		{"utf-16 little endian", []byte{0xff, 0xfe, 'H', 0, 'i', 0}, "Hi"},
+		{"invalid", []byte{'A', 0xff, 'B'}, "A?B"},
+	}
+	for _, tc := range tests {
+		if got := chapterTitle(tc.in); got != tc.want {
+			t.Errorf("%s: chapterTitle() = %q; want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestChaptersJSON(t *testing.T) {
+	if data, err := chaptersJSON(nil); data != nil || err != nil {
+		t.Errorf("chaptersJSON(nil) = %q, %v; want nothing", data, err)
+	}
+
+	data, err := chaptersJSON([]chapter{{0, "One"}, {90500 * time.Millisecond, "Two"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc chaptersDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("chapters file isn't JSON: %v", err)
+	}
+	if doc.Version != "1.2.0" || len(doc.Chapters) != 2 ||
+		doc.Chapters[1].StartTime != 90.5 || doc.Chapters[1].Title != "Two" {
+		t.Errorf("chapters file = %s", data)
+	}
+}
+
+func TestChaptersExtractor(t *testing.T) {
+	moov := mp4Atom("moov", mp4Atom("mvhd", mvhdBody(0, 1000, 3600000)), chplAtom(chapter{0, "Start"}))
+	file := AudioFile{TempPath: writeAudio(t, "book.m4b", moov)}
+
+	apply, err := chaptersExtractor{}.Extract(file.TempPath)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	apply(&file)
+	if file.ChaptersPath != filepath.Join(filepath.Dir(file.TempPath), chaptersName) {
+		t.Fatalf("ChaptersPath = %q; want it beside the temp copy", file.ChaptersPath)
+	}
+	if data, _ := os.ReadFile(file.ChaptersPath); !strings.Contains(string(data), `"title": "Start"`) {
+		t.Errorf("chapters file = %s", data)
+	}
+
+	// Audio replaced by one without chapters removes the file
+	os.WriteFile(file.TempPath, mp4Atom("moov", mp4Atom("mvhd", mvhdBody(0, 1000, 1000))), 0644)
+	apply, err = chaptersExtractor{}.Extract(file.TempPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := file.ChaptersPath
+	apply(&file)
+	if file.ChaptersPath != "" || fileExists(old) {
+		t.Errorf("ChaptersPath = %q; want the chapters removed", file.ChaptersPath)
+	}
+}
+
+func TestFeedChapters(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3")
+	data, _ := chaptersJSON([]chapter{{0, "One"}})
+	if err := applyChapters(&p.files[1], data); err != nil {
+		t.Fatal(err)
+	}
+	id := p.files[1].ID
+
+	rss, files, err := p.siteFeed("http://host")
+	if err != nil {
+		t.Fatalf("siteFeed() error = %v", err)
+	}
+	want := `<podcast:chapters type="application/json+chapters" url="http://host/files/` + id + `/chapters.json">`
+	if !strings.Contains(rss, want) {
+		t.Errorf("feed doesn't link the chapters:\n%s", rss)
+	}
+	if strings.Count(rss, "<podcast:chapters") != 1 {
+		t.Errorf("want chapters only on the episode that has them:\n%s", rss)
+	}
+	if !strings.Contains(rss, `xmlns:podcast=`) {
+		t.Errorf("feed doesn't declare the podcast namespace:\n%s", rss)
+	}
+	if last := files[len(files)-1]; last.ID != id || last.Name != chaptersName {
+		t.Errorf("static export files end with %+v; want the chapters", last)
+	}
+}
+
+func TestServeChapters(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	if code, _ := getBody(t, srv.URL+"/files/abc/chapters.json"); code != http.StatusNotFound {
+		t.Errorf("GET chapters of an episode without any = %d; want 404", code)
+	}
+
+	data, _ := chaptersJSON([]chapter{{0, "One"}})
+	applyChapters(&p.files[0], data)
+	p.markDirty()
+	resp, err := http.Get(srv.URL + "/files/abc/chapters.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json+chapters" {
+		t.Errorf("GET chapters = %d %s; want 200 application/json+chapters", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// A chapters path outside the temp dir is never served
+	outside := filepath.Join(t.TempDir(), "chapters.json")
+	os.WriteFile(outside, data, 0644)
+	p.files[0].ChaptersPath = outside
+	p.markDirty()
+	if code, _ := getBody(t, srv.URL+"/files/abc/chapters.json"); code != http.StatusForbidden {
+		t.Errorf("chapters outside the temp dir = %d; want 403", code)
+	}
+}
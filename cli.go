@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// serveOptions are the flags of the serve command
+type serveOptions struct {
+	dir       string
+	name      string
+	artwork   string
+	publicURL string
+	port      int
+	localOnly bool
+	limits    serverLimits
+}
+
+// parseServeArgs reads the flags of `podcasterator serve`
+func parseServeArgs(args []string) (serveOptions, error) {
+	var opts serveOptions
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: podcasterator serve [flags]\n\nServes the audio files in a folder as a podcast, without the window, until interrupted.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.dir, "dir", ".", "folder of audio files to serve, searched recursively")
+	fs.StringVar(&opts.name, "name", defaultPodcastName, "podcast name")
+	fs.StringVar(&opts.artwork, "artwork", "", "image to use as the podcast artwork")
+	fs.StringVar(&opts.publicURL, "url", "", "address the feed is reached at, e.g. behind a reverse proxy")
+	port := fs.String("port", strconv.Itoa(defaultServerPort), "port to serve on; the next free one is used if it's taken")
+	fs.BoolVar(&opts.localOnly, "local", false, "only serve to this computer")
+	fs.IntVar(&opts.limits.MaxDownloads, "max-downloads", 0, "most files sent at once; 0 for no limit")
+	fs.IntVar(&opts.limits.RequestsPerMinute, "rate-limit", 0, "most requests a minute from each address; 0 for no limit")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	var err error
+	if opts.port, err = validatePort(*port); err != nil {
+		return opts, fmt.Errorf("-port: %w", err)
+	}
+	if opts.limits.MaxDownloads < 0 || opts.limits.RequestsPerMinute < 0 {
+		return opts, fmt.Errorf("limits can't be negative")
+	}
+	if !dirExists(opts.dir) {
+		return opts, fmt.Errorf("%s isn't a folder", opts.dir)
+	}
+	return opts, nil
+}
+
+// folderEpisode is the episode for the audio file at path, served where it
+// is. Its ID comes from the path, so podcast apps see the same episode
+// across restarts.
+func folderEpisode(path string) AudioFile {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	file := AudioFile{
+		ID:            hex.EncodeToString(sum[:8]),
+		OriginalPath:  abs,
+		DisplayName:   filepath.Base(abs),
+		ServeOriginal: true,
+	}
+	tags, _ := readTags(abs)
+	if name := tagFileName(tags.Title, filepath.Ext(abs)); name != "" {
+		file.DisplayName = name
+	}
+	tags.apply(&file)
+	file.refreshStat()
+	if d, err := audioDuration(abs); err == nil {
+		file.Duration = d
+	}
+	// Dates in the feed stay put while the files do
+	file.AddedAt = file.ModTime
+	return file
+}
+
+// folderPodcast is a podcast of the audio in opts.dir, in natural name
+// order, for serving without the window. Artwork is converted into
+// tempDir. Formats that would need converting to play are skipped.
+func folderPodcast(opts serveOptions, tempDir string) (*Podcasterator, error) {
+	p := &Podcasterator{
+		podcastName: opts.name,
+		tempDir:     tempDir,
+		publicURL:   opts.publicURL,
+		localOnly:   opts.localOnly,
+		port:        opts.port,
+	}
+
+	for _, path := range folderFiles(opts.dir, false) {
+		if needsTranscode(path) {
+			fmt.Printf("Skipping %s: convert it to M4A or MP3 to serve it\n", path)
+			continue
+		}
+		p.files = append(p.files, folderEpisode(path))
+	}
+	if len(p.files) == 0 {
+		return nil, fmt.Errorf("no audio files in %s", opts.dir)
+	}
+
+	if opts.artwork != "" {
+		if !isImageFile(opts.artwork) {
+			return nil, fmt.Errorf("%s isn't a supported image", opts.artwork)
+		}
+		artworkPath := filepath.Join(tempDir, "artwork"+p.artworkFileExt())
+		if err := convertAndCropImageWith(opts.artwork, artworkPath, artworkSize, cropCenter, p.artworkEncoder()); err != nil {
+			return nil, fmt.Errorf("couldn't convert %s: %w", opts.artwork, err)
+		}
+		p.artworkPath = artworkPath
+	}
+	return p, nil
+}
+
+// runServe is `podcasterator serve`: it serves a folder of audio as a
+// podcast, without the window, until interrupted
+func runServe(args []string) error {
+	opts, err := parseServeArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "podcasterator-serve-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	p, err := folderPodcast(opts, tempDir)
+	if err != nil {
+		return err
+	}
+
+	ln, err := listenFrom(opts.localOnly, opts.port)
+	if err != nil {
+		return fmt.Errorf("couldn't start the server on port %d: %w", opts.port, err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	if port != opts.port {
+		fmt.Printf("Port %d is in use, so the server is running on port %d\n", opts.port, port)
+	}
+
+	feed := newFeedServer(p.resolveBaseURL(fmt.Sprintf("http://%s:%d", p.advertisedHost(), port)), tempDir)
+	p.publishTo(feed)
+	server := &http.Server{Handler: opts.limits.wrap(newServeMux(feed, time.Now()))}
+	fmt.Printf("Serving %d episodes of %q\nFeed: %s\n", len(p.files), p.podcastName, feed.feedURL)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	failed := make(chan error, 1)
+	go func() { failed <- server.Serve(ln) }()
+
+	select {
+	case err := <-failed:
+		return fmt.Errorf("the server stopped unexpectedly: %w", err)
+	case sig := <-sigs:
+		fmt.Printf("Received %v, shutting down...\n", sig)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Serve Command Tests
+// =============================================================================
+
+func TestParseServeArgs(t *testing.T) {
+	dir := t.TempDir()
+	opts, err := parseServeArgs([]string{"--dir", dir, "--name", "My Feed", "--port", "9000", "--local", "--max-downloads", "3", "--rate-limit", "120"})
+	if err != nil {
+		t.Fatalf("parseServeArgs() error = %v", err)
+	}
+	want := serveOptions{dir: dir, name: "My Feed", port: 9000, localOnly: true, limits: serverLimits{MaxDownloads: 3, RequestsPerMinute: 120}}
+	if opts != want {
+		t.Errorf("parseServeArgs() = %+v; want %+v", opts, want)
+	}
+
+	if opts, _ := parseServeArgs([]string{"--dir", dir}); opts.port != defaultServerPort || opts.name != defaultPodcastName {
+		t.Errorf("defaults = %+v", opts)
+	}
+	for _, args := range [][]string{
+		{"--dir", dir, "--port", "80"},
+		{"--dir", filepath.Join(dir, "missing")},
+		{"--dir", dir, "extra"},
+		{"--dir", dir, "--max-downloads", "-1"},
+	} {
+		if _, err := parseServeArgs(args); err == nil {
+			t.Errorf("parseServeArgs(%q) accepted", args)
+		}
+	}
+}
+
+func TestFolderPodcast(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "Disc 1"), 0755)
+	for _, name := range []string{"Disc 1/Track 10.mp3", "Disc 1/Track 2.mp3", "notes.txt", "raw.wav"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+	}
+	cover := filepath.Join(t.TempDir(), "cover.png")
+	writeTestPNG(t, cover, artworkSize, artworkSize)
+
+	tempDir := t.TempDir()
+	p, err := folderPodcast(serveOptions{dir: dir, name: "Book", artwork: cover, port: defaultServerPort}, tempDir)
+	if err != nil {
+		t.Fatalf("folderPodcast() error = %v", err)
+	}
+	if got := displayNames(p.files); !slices.Equal(got, []string{"Track 2.mp3", "Track 10.mp3"}) {
+		t.Errorf("episodes = %v; want the audio in natural order", got)
+	}
+	for _, f := range p.files {
+		if !f.ServeOriginal || !filepath.IsAbs(f.OriginalPath) || f.AddedAt.IsZero() {
+			t.Errorf("%+v isn't served in place", f)
+		}
+	}
+	if !fileExists(p.artworkPath) || filepath.Dir(p.artworkPath) != tempDir {
+		t.Errorf("artwork at %q; want it converted into the temp dir", p.artworkPath)
+	}
+
+	// The same folder is the same episodes after a restart
+	again, _ := folderPodcast(serveOptions{dir: dir, name: "Book"}, t.TempDir())
+	if again.files[0].ID != p.files[0].ID || p.files[0].ID == p.files[1].ID {
+		t.Errorf("IDs %s, %s then %s; want them stable and distinct", p.files[0].ID, p.files[1].ID, again.files[0].ID)
+	}
+
+	if _, err := folderPodcast(serveOptions{dir: t.TempDir()}, t.TempDir()); err == nil {
+		t.Error("folderPodcast() of a folder without audio succeeded")
+	}
+}
+
+func TestServeFolder(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "episode.mp3"), []byte("audio"), 0644)
+	p, err := folderPodcast(serveOptions{dir: dir, name: "Headless"}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Served the way runServe serves it
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	feed := newFeedServer(srv.URL, p.tempDir)
+	p.publishTo(feed)
+	mux.Handle("/", newServeMux(feed, time.Now()))
+
+	code, rss := getBody(t, srv.URL+"/feed.xml")
+	enclosure := srv.URL + "/files/" + p.files[0].ID + "/episode.mp3"
+	if code != http.StatusOK || !strings.Contains(rss, "<title>Headless</title>") || !strings.Contains(rss, enclosure) {
+		t.Fatalf("GET /feed.xml = %d:\n%s", code, rss)
+	}
+	resp, err := http.Get(enclosure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusOK || string(body) != "audio" {
+		t.Errorf("GET enclosure = %d %q; want the original served", resp.StatusCode, body)
+	}
+}
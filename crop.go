@@ -0,0 +1,175 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// cropCenter is the crop position that takes the middle of an image
+const cropCenter = 0.5
+
+// cropToSquare cuts the largest centered square out of img, so artwork is
+// cropped rather than letterboxed or left non-square
+func cropToSquare(img image.Image) image.Image {
+	return cropSquareAt(img, cropCenter)
+}
+
+// cropSquareAt cuts the largest square out of img at pos along its long side,
+// from 0 (left or top) to 1 (right or bottom)
+func cropSquareAt(img image.Image, pos float64) image.Image {
+	rect := squareCropRect(img.Bounds(), pos)
+	if rect == img.Bounds() {
+		return img
+	}
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(rect)
+	}
+	out := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(out, out.Bounds(), img, rect.Min, draw.Src)
+	return out
+}
+
+// squareCropRect is the largest square within bounds at pos along the long
+// side
+func squareCropRect(bounds image.Rectangle, pos float64) image.Rectangle {
+	side := min(bounds.Dx(), bounds.Dy())
+	offset := int(math.Round(math.Max(0, math.Min(1, pos)) * float64(max(bounds.Dx(), bounds.Dy())-side)))
+	if bounds.Dx() > bounds.Dy() {
+		return image.Rect(bounds.Min.X+offset, bounds.Min.Y, bounds.Min.X+offset+side, bounds.Max.Y)
+	}
+	return image.Rect(bounds.Min.X, bounds.Min.Y+offset, bounds.Max.X, bounds.Min.Y+offset+side)
+}
+
+// pickArtworkCrop asks where to crop the image at path when it isn't square,
+// then calls apply with the chosen position. Square images, and images whose
+// size can't be read, are applied as-is with a centered crop.
+func (p *Podcasterator) pickArtworkCrop(path string, apply func(crop float64)) {
+	info, err := readArtworkInfo(path)
+	if err != nil || info.Width == info.Height || p.window == nil {
+		apply(cropCenter)
+		return
+	}
+
+	selector := newCropSelector(path, info.Width, info.Height)
+	note := widget.NewLabel("Drag the square to choose the part of the image to use.")
+	note.Importance = widget.LowImportance
+
+	dialog.ShowCustomConfirm("Crop Artwork", "Use", "Cancel",
+		container.NewBorder(nil, note, nil, nil, selector), func(ok bool) {
+			if ok {
+				apply(selector.pos)
+			}
+		}, p.window)
+}
+
+// cropSelector shows an image with a square selection that can be dragged
+// along its long side
+type cropSelector struct {
+	widget.BaseWidget
+
+	width, height int
+	pos           float64
+
+	image *canvas.Image
+	frame *canvas.Rectangle
+	shade [2]*canvas.Rectangle
+}
+
+func newCropSelector(path string, width, height int) *cropSelector {
+	s := &cropSelector{width: width, height: height, pos: cropCenter}
+	s.image = canvas.NewImageFromFile(path)
+	s.image.FillMode = canvas.ImageFillContain
+	s.frame = canvas.NewRectangle(color.Transparent)
+	s.frame.StrokeColor = theme.Color(theme.ColorNamePrimary)
+	s.frame.StrokeWidth = 2
+	for i := range s.shade {
+		s.shade[i] = canvas.NewRectangle(color.NRGBA{A: 0x99})
+	}
+	s.ExtendBaseWidget(s)
+	return s
+}
+
+// imageRect is where the image is drawn within size, scaled to fit
+func (s *cropSelector) imageRect(size fyne.Size) (fyne.Position, fyne.Size) {
+	scale := min(size.Width/float32(s.width), size.Height/float32(s.height))
+	drawn := fyne.NewSize(float32(s.width)*scale, float32(s.height)*scale)
+	return fyne.NewPos((size.Width-drawn.Width)/2, (size.Height-drawn.Height)/2), drawn
+}
+
+func (s *cropSelector) Dragged(ev *fyne.DragEvent) {
+	_, drawn := s.imageRect(s.Size())
+	slack, delta := drawn.Width-drawn.Height, ev.Dragged.DX
+	if s.height > s.width {
+		slack, delta = drawn.Height-drawn.Width, ev.Dragged.DY
+	}
+	if slack <= 0 {
+		return
+	}
+	s.pos = math.Max(0, math.Min(1, s.pos+float64(delta/slack)))
+	s.Refresh()
+}
+
+func (s *cropSelector) DragEnd() {}
+
+func (s *cropSelector) MinSize() fyne.Size {
+	return fyne.NewSize(360, 360)
+}
+
+func (s *cropSelector) CreateRenderer() fyne.WidgetRenderer {
+	return &cropSelectorRenderer{s: s}
+}
+
+type cropSelectorRenderer struct {
+	s *cropSelector
+}
+
+func (r *cropSelectorRenderer) Layout(size fyne.Size) {
+	s := r.s
+	origin, drawn := s.imageRect(size)
+	s.image.Move(fyne.NewPos(0, 0))
+	s.image.Resize(size)
+
+	side := min(drawn.Width, drawn.Height)
+	framePos := origin
+	if s.width > s.height {
+		framePos.X += float32(s.pos) * (drawn.Width - side)
+		s.shade[0].Move(origin)
+		s.shade[0].Resize(fyne.NewSize(framePos.X-origin.X, drawn.Height))
+		s.shade[1].Move(fyne.NewPos(framePos.X+side, origin.Y))
+		s.shade[1].Resize(fyne.NewSize(origin.X+drawn.Width-framePos.X-side, drawn.Height))
+	} else {
+		framePos.Y += float32(s.pos) * (drawn.Height - side)
+		s.shade[0].Move(origin)
+		s.shade[0].Resize(fyne.NewSize(drawn.Width, framePos.Y-origin.Y))
+		s.shade[1].Move(fyne.NewPos(origin.X, framePos.Y+side))
+		s.shade[1].Resize(fyne.NewSize(drawn.Width, origin.Y+drawn.Height-framePos.Y-side))
+	}
+	s.frame.Move(framePos)
+	s.frame.Resize(fyne.NewSize(side, side))
+}
+
+func (r *cropSelectorRenderer) MinSize() fyne.Size {
+	return r.s.MinSize()
+}
+
+func (r *cropSelectorRenderer) Refresh() {
+	r.Layout(r.s.Size())
+	canvas.Refresh(r.s)
+}
+
+func (r *cropSelectorRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.s.image, r.s.shade[0], r.s.shade[1], r.s.frame}
+}
+
+func (r *cropSelectorRenderer) Destroy() {}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+// =============================================================================
+// Artwork Crop Tests
+// =============================================================================
+
+// halvesImage is w×h, red in its left or top half and blue in the rest
+func halvesImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{0, 0, 255, 255}
+			if (w > h && x < w/2) || (h > w && y < h/2) {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCropToSquare(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		want image.Rectangle
+	}{
+		{"wide", image.NewRGBA(image.Rect(0, 0, 300, 200)), image.Rect(50, 0, 250, 200)},
+		{"tall", image.NewRGBA(image.Rect(0, 0, 200, 301)), image.Rect(0, 51, 200, 251)},
+		{"square", image.NewRGBA(image.Rect(0, 0, 200, 200)), image.Rect(0, 0, 200, 200)},
+		{"offset bounds", image.NewRGBA(image.Rect(10, 10, 110, 60)), image.Rect(35, 10, 85, 60)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cropToSquare(tc.img).Bounds(); got != tc.want {
+				t.Errorf("cropToSquare() bounds = %v; want %v", got, tc.want)
+			}
+		})
+	}
+
+	// An image type without SubImage is copied into a new image
+	got := cropSquareAt(struct{ image.Image }{halvesImage(40, 20)}, 0)
+	if b := got.Bounds(); b.Dx() != 20 || b.Dy() != 20 {
+		t.Fatalf("copied crop bounds = %v; want 20x20", b)
+	}
+	if r, _, _, _ := got.At(0, 0).RGBA(); r == 0 {
+		t.Error("copied crop isn't the red half")
+	}
+}
+
+func TestCropSquareAt(t *testing.T) {
+	bounds := image.Rect(0, 0, 400, 100)
+	tests := []struct {
+		pos  float64
+		want image.Rectangle
+	}{
+		{0, image.Rect(0, 0, 100, 100)},
+		{1, image.Rect(300, 0, 400, 100)},
+		{0.5, image.Rect(150, 0, 250, 100)},
+		{-1, image.Rect(0, 0, 100, 100)},
+		{2, image.Rect(300, 0, 400, 100)},
+	}
+
+	for _, tc := range tests {
+		if got := squareCropRect(bounds, tc.pos); got != tc.want {
+			t.Errorf("squareCropRect(%v) = %v; want %v", tc.pos, got, tc.want)
+		}
+	}
+
+	img := halvesImage(100, 200)
+	if r, _, b, _ := cropSquareAt(img, 1).At(50, 150).RGBA(); r != 0 || b == 0 {
+		t.Error("cropping at the bottom didn't keep the blue half")
+	}
+}
+
+func TestCropSelectorDrag(t *testing.T) {
+	test.NewTempApp(t)
+	src := filepath.Join(t.TempDir(), "wide.png")
+	writeTestPNG(t, src, 400, 200)
+
+	s := newCropSelector(src, 400, 200)
+	s.Resize(fyne.NewSize(400, 400))
+	test.WidgetRenderer(s).Layout(s.Size())
+
+	// The image is drawn 400×200, so there are 200 points of travel
+	s.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(100, 30)})
+	if s.pos != 1 {
+		t.Errorf("pos after dragging right = %v; want 1", s.pos)
+	}
+	if s.frame.Position().X != 200 || s.frame.Size().Width != 200 {
+		t.Errorf("frame at %v size %v; want x 200, 200 wide", s.frame.Position(), s.frame.Size())
+	}
+
+	s.Dragged(&fyne.DragEvent{Dragged: fyne.NewDelta(-500, 0)})
+	if s.pos != 0 {
+		t.Errorf("pos after dragging past the left = %v; want 0", s.pos)
+	}
+}
+
+func TestConvertCropsToSquare(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "wide.png")
+	writeTestPNG(t, src, 600, 300)
+	dst := filepath.Join(dir, "artwork.jpg")
+
+	if err := convertAndCropImageWith(src, dst, 200, 0, artworkEncoders[encoderBaseline]); err != nil {
+		t.Fatalf("convertAndCropImageWith() error = %v", err)
+	}
+	info, err := readArtworkInfo(dst)
+	if err != nil || info.Width != 200 || info.Height != 200 {
+		t.Errorf("converted = %v, %v; want 200x200", info, err)
+	}
+}
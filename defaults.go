@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Episode types as defined by itunes:episodeType. Blank means full.
+var episodeTypes = []string{"full", "trailer", "bonus"}
+
+// episodeDefaults are applied to each newly added file. Files keep whatever
+// they were given at add time, so changing a default never rewrites
+// existing episodes.
+type episodeDefaults struct {
+	Explicit    bool   `json:"explicit,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Author      string `json:"author,omitempty"`
+	EpisodeType string `json:"episode_type,omitempty"`
+}
+
+// apply fills in any field the file doesn't already have
+func (d episodeDefaults) apply(file *AudioFile) {
+	if d.Explicit {
+		file.Explicit = true
+	}
+	if file.Language == "" {
+		file.Language = d.Language
+	}
+	if file.Author == "" {
+		file.Author = d.Author
+	}
+	if file.EpisodeType == "" {
+		file.EpisodeType = d.EpisodeType
+	}
+}
+
+// validateEpisodeType accepts a blank or one of episodeTypes
+func validateEpisodeType(t string) error {
+	if t == "" {
+		return nil
+	}
+	for _, known := range episodeTypes {
+		if t == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("episode type must be one of %v", episodeTypes)
+}
+
+// episodeDefaultsSection is the Settings section for episodeDefaults
+func (p *Podcasterator) episodeDefaultsSection() fyne.CanvasObject {
+	explicitCheck := widget.NewCheck("Explicit", func(checked bool) {
+		p.episodeDefaults.Explicit = checked
+		p.markDirty()
+	})
+	explicitCheck.SetChecked(p.episodeDefaults.Explicit)
+
+	authorEntry := widget.NewEntry()
+	authorEntry.SetText(p.episodeDefaults.Author)
+	authorEntry.OnChanged = func(s string) {
+		p.episodeDefaults.Author = s
+		p.markDirty()
+	}
+
+	languageEntry := widget.NewEntry()
+	languageEntry.SetPlaceHolder("e.g. en-us")
+	languageEntry.SetText(p.episodeDefaults.Language)
+	languageEntry.OnChanged = func(s string) {
+		p.episodeDefaults.Language = s
+		p.markDirty()
+	}
+
+	typeSelect := widget.NewSelect(episodeTypes, func(s string) {
+		p.episodeDefaults.EpisodeType = s
+		p.markDirty()
+	})
+	typeSelect.PlaceHolder = "full"
+	typeSelect.SetSelected(p.episodeDefaults.EpisodeType)
+
+	note := widget.NewLabel("Applied to newly added files; each episode can still be changed.")
+	note.Importance = widget.LowImportance
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("Episode Defaults", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewForm(
+			widget.NewFormItem("Author", authorEntry),
+			widget.NewFormItem("Language", languageEntry),
+			widget.NewFormItem("Episode type", typeSelect),
+			widget.NewFormItem("", explicitCheck),
+		),
+		note,
+	)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// =============================================================================
+// Episode Defaults Tests
+// =============================================================================
+
+func TestNewFilesInheritEpisodeDefaults(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.episodeDefaults = episodeDefaults{
+		Explicit:    true,
+		Language:    "en-us",
+		Author:      "Jane Host",
+		EpisodeType: "bonus",
+	}
+
+	srcDir := t.TempDir()
+	for _, name := range []string{"one.mp3", "two.mp3"} {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte("audio"), 0644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		p.addFile(path)
+	}
+
+	for _, f := range p.files {
+		if !f.Explicit || f.Language != "en-us" || f.Author != "Jane Host" || f.EpisodeType != "bonus" {
+			t.Errorf("%s = explicit %v, %q, %q, %q; want the defaults", f.DisplayName, f.Explicit, f.Language, f.Author, f.EpisodeType)
+		}
+	}
+
+	// Override one episode, then change the defaults
+	err := p.applyEpisodeEdits(p.files[1].ID, episodeEdits{
+		Title:       p.files[1].DisplayName,
+		Author:      "Guest Host",
+		Language:    "de",
+		EpisodeType: "full",
+		Explicit:    false,
+	})
+	if err != nil {
+		t.Fatalf("applyEpisodeEdits() error = %v", err)
+	}
+	p.episodeDefaults = episodeDefaults{Author: "Someone Else"}
+
+	overridden := p.files[1]
+	if overridden.Explicit || overridden.Author != "Guest Host" || overridden.Language != "de" || overridden.EpisodeType != "full" {
+		t.Errorf("overridden file = %+v; want its own values kept", overridden)
+	}
+	if p.files[0].Author != "Jane Host" {
+		t.Errorf("existing file author = %q; changing defaults must not rewrite it", p.files[0].Author)
+	}
+}
+
+func TestEpisodeDefaultsKeepFileValues(t *testing.T) {
+	d := episodeDefaults{Language: "en", Author: "Default", EpisodeType: "full"}
+	file := AudioFile{Author: "From Tags", EpisodeType: "trailer"}
+	d.apply(&file)
+
+	if file.Author != "From Tags" || file.EpisodeType != "trailer" || file.Language != "en" {
+		t.Errorf("apply() = %q, %q, %q; want existing values kept and blanks filled", file.Author, file.EpisodeType, file.Language)
+	}
+}
+
+func TestValidateEpisodeType(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", false},
+		{"full", false},
+		{"trailer", false},
+		{"bonus", false},
+		{"Full", true},
+		{"preview", true},
+	}
+	for _, tc := range tests {
+		if err := validateEpisodeType(tc.value); (err != nil) != tc.wantErr {
+			t.Errorf("validateEpisodeType(%q) error = %v; wantErr %v", tc.value, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+const pubDateLayout = "2006-01-02 15:04"
+
+// episodeEdits holds the detail panel's form values before validation
+type episodeEdits struct {
+	Title       string
+	Description string
+	PubDate     string
+	Season      string
+	Episode     string
+	Explicit    bool
+	Author      string
+	Language    string
+	EpisodeType string
+}
+
+// episodeDetail is the side panel for viewing and editing one episode
+type episodeDetail struct {
+	box           *fyne.Container
+	fileID        string
+	titleEntry    *widget.Entry
+	descEntry     *widget.Entry
+	pubDateEntry  *widget.Entry
+	seasonEntry   *widget.Entry
+	episodeEntry  *widget.Entry
+	explicitCheck *widget.Check
+	authorEntry   *widget.Entry
+	languageEntry *widget.Entry
+	typeSelect    *widget.Select
+	originalCheck *widget.Check
+	infoLabel     *widget.Label
+	revealBtn     *widget.Button
+}
+
+// parseEpisodeNumber accepts a blank (meaning none) or non-negative integer
+func parseEpisodeNumber(field, s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a whole number", field)
+	}
+	return n, nil
+}
+
+// parsePubDate accepts a blank (automatic, from list order) or a local
+// date in pubDateLayout
+func parsePubDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(pubDateLayout, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("publish date must look like %s", pubDateLayout)
+	}
+	return t, nil
+}
+
+// applyEpisodeEdits validates edits and applies them to the file with id.
+// Nothing is changed if any field is invalid.
+func (p *Podcasterator) applyEpisodeEdits(id string, edits episodeEdits) error {
+	index := -1
+	for i := range p.files {
+		if p.files[i].ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("episode no longer exists")
+	}
+
+	season, err := parseEpisodeNumber("Season", edits.Season)
+	if err != nil {
+		return err
+	}
+	episode, err := parseEpisodeNumber("Episode", edits.Episode)
+	if err != nil {
+		return err
+	}
+	pubDate, err := parsePubDate(edits.PubDate)
+	if err != nil {
+		return err
+	}
+	if err := validateEpisodeType(edits.EpisodeType); err != nil {
+		return err
+	}
+
+	file := &p.files[index]
+	if err := p.applyRename(file, strings.TrimSpace(edits.Title)); err != nil {
+		return err
+	}
+
+	file.Description = strings.TrimSpace(edits.Description)
+	file.PubDate = pubDate
+	file.Season = season
+	file.Episode = episode
+	file.Explicit = edits.Explicit
+	file.Author = strings.TrimSpace(edits.Author)
+	file.Language = strings.TrimSpace(edits.Language)
+	file.EpisodeType = edits.EpisodeType
+
+	p.applySeasonSorts()
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+	return nil
+}
+
+// setEpisodeNotes replaces the show notes of the file with id
+func (p *Podcasterator) setEpisodeNotes(id, notes string) error {
+	for i := range p.files {
+		if p.files[i].ID != id {
+			continue
+		}
+		p.files[i].Description = strings.TrimSpace(notes)
+		if p.fileList != nil {
+			p.fileList.RefreshItem(i)
+		}
+		if p.detail != nil && p.detail.fileID == id {
+			p.showEpisodeDetail(id)
+		}
+		p.markDirty()
+		return nil
+	}
+	return fmt.Errorf("episode no longer exists")
+}
+
+// editNotes opens a dialog for the show notes of the file at index, the
+// multi-line counterpart of the rename dialog
+func (p *Podcasterator) editNotes(index int) {
+	if index < 0 || index >= len(p.files) || p.window == nil {
+		return
+	}
+	file := p.files[index]
+
+	entry := widget.NewMultiLineEntry()
+	entry.Wrapping = fyne.TextWrapWord
+	entry.SetMinRowsVisible(8)
+	entry.SetPlaceHolder("Shown as the episode description in podcast apps")
+	entry.SetText(file.Description)
+
+	d := dialog.NewCustomConfirm("Show Notes: "+truncateFilename(file.DisplayName), "Save", "Cancel",
+		entry,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := p.setEpisodeNotes(file.ID, entry.Text); err != nil {
+				dialog.ShowError(err, p.window)
+			}
+		},
+		p.window,
+	)
+	d.Resize(fyne.NewSize(500, 300))
+	d.Show()
+	p.window.Canvas().Focus(entry)
+}
+
+func (p *Podcasterator) createDetailPanel() *fyne.Container {
+	d := &episodeDetail{
+		titleEntry:    widget.NewEntry(),
+		descEntry:     widget.NewMultiLineEntry(),
+		pubDateEntry:  widget.NewEntry(),
+		seasonEntry:   widget.NewEntry(),
+		episodeEntry:  widget.NewEntry(),
+		infoLabel:     widget.NewLabel(""),
+		authorEntry:   widget.NewEntry(),
+		languageEntry: widget.NewEntry(),
+		typeSelect:    widget.NewSelect(episodeTypes, nil),
+	}
+	d.typeSelect.PlaceHolder = "full"
+	d.explicitCheck = widget.NewCheck("Explicit", nil)
+	// Takes effect immediately, including for a running server
+	d.originalCheck = widget.NewCheck("Serve original file instead of copy", func(on bool) {
+		if err := p.setServeOriginal(d.fileID, on); err != nil {
+			dialog.ShowError(err, p.window)
+			d.originalCheck.SetChecked(!on)
+		}
+	})
+	// Greyed when the original is found missing, like the row's button
+	d.revealBtn = widget.NewButton("Show Original", func() {
+		for i := range p.files {
+			if p.files[i].ID == d.fileID {
+				p.revealOriginal(i)
+				if !hasOriginal(p.files[i]) {
+					d.revealBtn.Disable()
+				}
+				return
+			}
+		}
+	})
+	d.descEntry.SetMinRowsVisible(5)
+	d.descEntry.Wrapping = fyne.TextWrapWord
+	d.pubDateEntry.SetPlaceHolder("Automatic (list order)")
+	d.seasonEntry.SetPlaceHolder("None")
+	d.episodeEntry.SetPlaceHolder("None")
+
+	saveBtn := widget.NewButton("Save", func() {
+		err := p.applyEpisodeEdits(d.fileID, episodeEdits{
+			Title:       d.titleEntry.Text,
+			Description: d.descEntry.Text,
+			PubDate:     d.pubDateEntry.Text,
+			Season:      d.seasonEntry.Text,
+			Episode:     d.episodeEntry.Text,
+			Explicit:    d.explicitCheck.Checked,
+			Author:      d.authorEntry.Text,
+			Language:    d.languageEntry.Text,
+			EpisodeType: d.typeSelect.Selected,
+		})
+		if err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		p.showEpisodeDetail(d.fileID)
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	closeBtn := widget.NewButton("Close", func() {
+		p.fileList.UnselectAll()
+		p.hideEpisodeDetail()
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem("Title", d.titleEntry),
+		widget.NewFormItem("Description", d.descEntry),
+		widget.NewFormItem("Publish date", d.pubDateEntry),
+		widget.NewFormItem("Season", d.seasonEntry),
+		widget.NewFormItem("Episode", d.episodeEntry),
+		widget.NewFormItem("Episode type", d.typeSelect),
+		widget.NewFormItem("Author", d.authorEntry),
+		widget.NewFormItem("Language", d.languageEntry),
+		widget.NewFormItem("", d.explicitCheck),
+		widget.NewFormItem("", d.originalCheck),
+	)
+
+	d.box = container.NewBorder(
+		widget.NewLabelWithStyle("Episode Details", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewVBox(d.infoLabel, container.NewHBox(saveBtn, closeBtn, layout.NewSpacer(), d.revealBtn)),
+		nil, nil,
+		container.NewVScroll(form),
+	)
+	d.box.Hide()
+
+	p.detail = d
+	return d.box
+}
+
+// showEpisodeDetail fills the side panel from the file with id
+func (p *Podcasterator) showEpisodeDetail(id string) {
+	if p.detail == nil {
+		return
+	}
+
+	for _, file := range p.files {
+		if file.ID != id {
+			continue
+		}
+
+		d := p.detail
+		d.fileID = id
+		d.titleEntry.SetText(file.DisplayName)
+		d.descEntry.SetText(file.Description)
+		d.pubDateEntry.SetText("")
+		if !file.PubDate.IsZero() {
+			d.pubDateEntry.SetText(file.PubDate.Local().Format(pubDateLayout))
+		}
+		d.seasonEntry.SetText("")
+		if file.Season > 0 {
+			d.seasonEntry.SetText(strconv.Itoa(file.Season))
+		}
+		d.episodeEntry.SetText("")
+		if file.Episode > 0 {
+			d.episodeEntry.SetText(strconv.Itoa(file.Episode))
+		}
+		d.explicitCheck.SetChecked(file.Explicit)
+		d.authorEntry.SetText(file.Author)
+		d.languageEntry.SetText(file.Language)
+		d.typeSelect.ClearSelected()
+		if file.EpisodeType != "" {
+			d.typeSelect.SetSelected(file.EpisodeType)
+		}
+		d.originalCheck.SetChecked(file.ServeOriginal)
+		if file.IsExternal() || needsTranscode(file.OriginalPath) || file.StartOffset > 0 {
+			d.originalCheck.Disable()
+		} else {
+			d.originalCheck.Enable()
+		}
+		d.infoLabel.SetText(episodeInfo(file))
+		if hasOriginal(file) {
+			d.revealBtn.Enable()
+		} else {
+			d.revealBtn.Disable()
+		}
+		d.box.Show()
+		return
+	}
+
+	p.hideEpisodeDetail()
+}
+
+func (p *Podcasterator) hideEpisodeDetail() {
+	if p.detail != nil {
+		p.detail.fileID = ""
+		p.detail.box.Hide()
+	}
+}
+
+// episodeInfo summarizes the read-only facts shown under the form
+func episodeInfo(file AudioFile) string {
+	lines := []string{}
+	switch {
+	case file.IsExternal():
+		lines = append(lines, "Hosted at "+file.ExternalURL)
+		if file.ExternalLength > 0 {
+			lines = append(lines, "Size: "+formatBytes(file.ExternalLength))
+		}
+	case file.Size > 0:
+		lines = append(lines, "Size: "+formatBytes(file.Size))
+	}
+	if file.Duration > 0 {
+		lines = append(lines, "Length: "+formatDuration(file.Duration))
+	}
+	if file.Artist != "" {
+		lines = append(lines, "Artist: "+file.Artist)
+	}
+	if file.Album != "" {
+		album := "Album: " + file.Album
+		if file.Track > 0 {
+			album += fmt.Sprintf(" (track %d)", file.Track)
+		}
+		lines = append(lines, album)
+	}
+	if file.OriginalPath != "" {
+		lines = append(lines, "Source: "+file.OriginalPath)
+	} else if file.SourceURL != "" {
+		lines = append(lines, "Source: "+file.SourceURL)
+	}
+	return strings.Join(lines, "\n")
+}
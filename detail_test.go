@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// =============================================================================
+// Episode Detail Tests
+// =============================================================================
+
+func TestParseEpisodeNumber(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"  ", 0, false},
+		{"3", 3, false},
+		{" 12 ", 12, false},
+		{"-1", 0, true},
+		{"two", 0, true},
+	}
+
+	for _, tc := range tests {
+		n, err := parseEpisodeNumber("Episode", tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseEpisodeNumber(%q) error = %v; wantErr %v", tc.input, err, tc.wantErr)
+		}
+		if n != tc.expected {
+			t.Errorf("parseEpisodeNumber(%q) = %d; want %d", tc.input, n, tc.expected)
+		}
+	}
+}
+
+func TestParsePubDate(t *testing.T) {
+	got, err := parsePubDate("2024-03-15 09:30")
+	if err != nil {
+		t.Fatalf("parsePubDate() error = %v", err)
+	}
+	want := time.Date(2024, 3, 15, 9, 30, 0, 0, time.Local)
+	if !got.Equal(want) {
+		t.Errorf("parsePubDate() = %v; want %v", got, want)
+	}
+
+	if got, err := parsePubDate(""); err != nil || !got.IsZero() {
+		t.Errorf("parsePubDate(\"\") = %v, %v; want zero time", got, err)
+	}
+	if _, err := parsePubDate("March 15"); err == nil {
+		t.Error("parsePubDate() expected error for bad format")
+	}
+}
+
+func TestApplyEpisodeEdits(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	tempPath := filepath.Join(p.tempDir, "id1", "old.mp3")
+	os.MkdirAll(filepath.Dir(tempPath), 0755)
+	os.WriteFile(tempPath, []byte("audio"), 0644)
+
+	p.files = []AudioFile{
+		{ID: "id0", DisplayName: "other.mp3"},
+		{ID: "id1", DisplayName: "old.mp3", TempPath: tempPath},
+	}
+
+	err := p.applyEpisodeEdits("id1", episodeEdits{
+		Title:       "New Title",
+		Description: "  Show notes  ",
+		PubDate:     "2024-03-15 09:30",
+		Season:      "2",
+		Episode:     "5",
+		Explicit:    true,
+	})
+	if err != nil {
+		t.Fatalf("applyEpisodeEdits() error = %v", err)
+	}
+
+	file := p.files[1]
+	if file.DisplayName != "New Title.mp3" {
+		t.Errorf("DisplayName = %q; want %q", file.DisplayName, "New Title.mp3")
+	}
+	if !fileExists(file.TempPath) || filepath.Base(file.TempPath) != "New Title.mp3" {
+		t.Errorf("Temp file not renamed: %q", file.TempPath)
+	}
+	if file.Description != "Show notes" {
+		t.Errorf("Description = %q; want %q", file.Description, "Show notes")
+	}
+	if file.Season != 2 || file.Episode != 5 || !file.Explicit {
+		t.Errorf("Season/Episode/Explicit = %d/%d/%v; want 2/5/true", file.Season, file.Episode, file.Explicit)
+	}
+	if file.PubDate.IsZero() {
+		t.Error("PubDate was not set")
+	}
+
+	t.Run("invalid edit changes nothing", func(t *testing.T) {
+		before := p.files[1]
+		err := p.applyEpisodeEdits("id1", episodeEdits{Title: "Another", Season: "x"})
+		if err == nil {
+			t.Fatal("applyEpisodeEdits() expected error for invalid season")
+		}
+		if p.files[1] != before {
+			t.Errorf("Invalid edit modified file: %+v", p.files[1])
+		}
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		if err := p.applyEpisodeEdits("missing", episodeEdits{}); err == nil {
+			t.Error("applyEpisodeEdits() expected error for unknown id")
+		}
+	})
+}
+
+func TestFeedItemUsesEpisodeDetails(t *testing.T) {
+	pubDate := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+	file := AudioFile{
+		ID:             "ext",
+		DisplayName:    "Episode",
+		Description:    "Notes",
+		PubDate:        pubDate,
+		ExternalURL:    "https://cdn.example.com/ep.mp3",
+		ExternalLength: 10,
+		ExternalType:   "audio/mpeg",
+	}
+
+	item, ok := feedItemNamed(file, file.DisplayName, "http://localhost:8080", time.Now())
+	if !ok {
+		t.Fatal("feedItemNamed() skipped the file")
+	}
+	if item.Description != "Notes" {
+		t.Errorf("Description = %q; want %q", item.Description, "Notes")
+	}
+	if !item.Created.Equal(pubDate) {
+		t.Errorf("Created = %v; want explicit publish date %v", item.Created, pubDate)
+	}
+}
+
+func TestEpisodeInfo(t *testing.T) {
+	info := episodeInfo(AudioFile{Size: 2048, OriginalPath: "/music/ep.mp3"})
+	if info != "Size: 2.0 KB\nSource: /music/ep.mp3" {
+		t.Errorf("episodeInfo() = %q", info)
+	}
+}
+
+func TestSetEpisodeNotes(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.files = []AudioFile{{ID: "id1", DisplayName: "ep.mp3"}}
+
+	if err := p.setEpisodeNotes("id1", "  Notes for the lecture\n"); err != nil {
+		t.Fatalf("setEpisodeNotes() error = %v", err)
+	}
+	if p.files[0].Description != "Notes for the lecture" {
+		t.Errorf("Description = %q", p.files[0].Description)
+	}
+	if !p.dirty {
+		t.Error("setEpisodeNotes() didn't mark the state dirty")
+	}
+
+	if err := p.setEpisodeNotes("gone", "x"); err == nil {
+		t.Error("setEpisodeNotes() on a missing episode succeeded")
+	}
+}
+
+func TestDetailShowOriginal(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.createDetailPanel()
+	p.files = append(p.files, AudioFile{ID: "x", DisplayName: "x.mp3", ExternalURL: "https://example.com/x.mp3"})
+
+	p.showEpisodeDetail(p.files[0].ID)
+	if p.detail.revealBtn.Disabled() {
+		t.Error("Show Original is disabled for a file whose original exists")
+	}
+	p.showEpisodeDetail("x")
+	if !p.detail.revealBtn.Disabled() {
+		t.Error("Show Original is enabled for a hosted episode")
+	}
+
+	// The original goes missing while the panel is open
+	p.showEpisodeDetail(p.files[1].ID)
+	os.Remove(p.files[1].OriginalPath)
+	p.detail.revealBtn.OnTapped()
+	if !p.files[1].OriginalMissing || !p.detail.revealBtn.Disabled() {
+		t.Error("Show Original stays enabled after finding the original gone")
+	}
+	p.showEpisodeDetail(p.files[1].ID)
+	if !p.detail.revealBtn.Disabled() {
+		t.Error("Show Original is enabled for a file whose original is missing")
+	}
+}
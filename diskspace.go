@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// freeSpaceMargin is left free on the drive by copies into the temp dir, so
+// adding files never fills it completely
+const freeSpaceMargin = 100 << 20
+
+// checkFreeSpace reports an error when the drive holding dir can't take need
+// more bytes plus freeSpaceMargin. dir may not exist yet. If the free space
+// can't be read the copy goes ahead, and fails on its own if the drive fills.
+func checkFreeSpace(dir string, need int64) error {
+	for !dirExists(dir) && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+	}
+	free, err := freeSpace(dir)
+	if err != nil {
+		return nil
+	}
+	if uint64(need)+freeSpaceMargin > free {
+		return fmt.Errorf("not enough free space in %s: %s is needed and %s is free. "+
+			"Free up some space, or move the temp folder to a larger drive in Settings",
+			dir, formatBytes(need), formatBytes(int64(free)))
+	}
+	return nil
+}
+
+// sourcesSize totals the sizes of the files at paths. Files that can't be
+// read count as nothing; they fail when they're copied.
+func sourcesSize(paths []string) int64 {
+	var total int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// requireFreeSpace returns false, telling the user why, when the temp dir's
+// drive can't take copies of the files at paths. Anything that copies files
+// in checks it first, so a full drive never leaves a truncated copy.
+func (p *Podcasterator) requireFreeSpace(paths []string) bool {
+	err := checkFreeSpace(p.tempDir, sourcesSize(paths))
+	if err == nil {
+		return true
+	}
+	if p.window != nil {
+		dialog.ShowError(err, p.window)
+	}
+	return false
+}
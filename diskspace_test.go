@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Disk Space Tests
+// =============================================================================
+
+func TestFreeSpace(t *testing.T) {
+	free, err := freeSpace(t.TempDir())
+	if err != nil {
+		t.Fatalf("freeSpace() error = %v", err)
+	}
+	if free == 0 {
+		t.Error("freeSpace() = 0 for a writable temp dir")
+	}
+	if _, err := freeSpace(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("freeSpace() of a missing path succeeded")
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkFreeSpace(dir, 0); err != nil {
+		t.Errorf("checkFreeSpace(0) error = %v", err)
+	}
+	// A folder that doesn't exist yet is checked on the drive it would be on
+	if err := checkFreeSpace(filepath.Join(dir, "projects", "abc"), 1024); err != nil {
+		t.Errorf("checkFreeSpace() of a missing subfolder error = %v", err)
+	}
+	err := checkFreeSpace(dir, 1<<62)
+	if err == nil || !strings.Contains(err.Error(), "not enough free space") {
+		t.Errorf("checkFreeSpace() needing more than the drive holds error = %v", err)
+	}
+}
+
+// sparseFileLargerThanFree makes a file in dir bigger than the free space on
+// its drive without using that space
+func sparseFileLargerThanFree(t *testing.T, dir string) string {
+	t.Helper()
+	free, err := freeSpace(dir)
+	if err != nil {
+		t.Skipf("can't read free space: %v", err)
+	}
+	path := filepath.Join(dir, "huge.mp3")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Truncate(int64(free) + 1<<30); err != nil {
+		t.Skipf("can't make a sparse file that large: %v", err)
+	}
+	return path
+}
+
+func TestAddFilesNeedsFreeSpace(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	huge := sparseFileLargerThanFree(t, p.tempDir)
+	small := filepath.Join(t.TempDir(), "small.mp3")
+	os.WriteFile(small, []byte("audio"), 0644)
+
+	err := p.addFiles([]string{small, huge})
+	if err == nil || !strings.Contains(err.Error(), "not enough free space") {
+		t.Fatalf("addFiles() error = %v; want a free space error", err)
+	}
+	if len(p.files) != 0 {
+		t.Errorf("addFiles() added %d files; want the batch refused", len(p.files))
+	}
+	if entries, _ := os.ReadDir(p.tempDir); len(entries) != 1 {
+		t.Errorf("temp dir holds %d entries; want only the source file", len(entries))
+	}
+}
+
+func TestReplaceAudioNeedsFreeSpace(t *testing.T) {
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	huge := sparseFileLargerThanFree(t, t.TempDir())
+	before := p.files[0]
+
+	if _, err := p.prepareReplace(0, huge); err == nil {
+		t.Fatal("prepareReplace() with too little space succeeded")
+	}
+	if p.files[0].TempPath != before.TempPath || !fileExists(before.TempPath) {
+		t.Error("a refused replace touched the existing copy")
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// freeSpace is how many bytes can still be written to the drive holding
+// path, as available to this user
+func freeSpace(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import "golang.org/x/sys/windows"
+
+// freeSpace is how many bytes can still be written to the drive holding
+// path, as available to this user
+func freeSpace(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var avail uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &avail, nil, nil); err != nil {
+		return 0, err
+	}
+	return avail, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// extensionsForType maps audio Content-Types to a supported extension, used
+// when the URL and Content-Disposition don't give a usable file name.
+var extensionsForType = map[string]string{
+	"audio/mpeg":  ".mp3",
+	"audio/mp3":   ".mp3",
+	"audio/mp4":   ".m4a",
+	"audio/m4a":   ".m4a",
+	"audio/x-m4a": ".m4a",
+	"audio/aac":   ".m4a",
+	"video/mp4":   ".mp4",
+}
+
+// progressWriter counts bytes written and reports them to onProgress
+type progressWriter struct {
+	written    int64
+	total      int64
+	onProgress func(written, total int64)
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	w.written += int64(len(b))
+	if w.onProgress != nil {
+		w.onProgress(w.written, w.total)
+	}
+	return len(b), nil
+}
+
+// downloadFileName works out a file name for a download, preferring
+// Content-Disposition, then the URL path, then the Content-Type. Text and
+// JSON responses are refused even at an audio URL.
+func downloadFileName(resp *http.Response) (string, error) {
+	name := ""
+	if cd := resp.Header.Get("Content-Disposition"); cd != "" {
+		if _, params, err := mime.ParseMediaType(cd); err == nil {
+			name = params["filename"]
+		}
+	}
+	if name == "" && resp.Request != nil {
+		// resp.Request is the final request, so this is the post-redirect URL
+		if unescaped, err := url.PathUnescape(path.Base(resp.Request.URL.Path)); err == nil {
+			name = unescaped
+		}
+	}
+
+	// Never trust a server-provided name with directory parts
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = ""
+	}
+
+	// An error page or login wall is never audio, whatever the URL says
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" {
+		return "", fmt.Errorf("not an audio file: the server sent %s", mediaType)
+	}
+
+	// Downloads aren't transcoded, so only formats served as-is are kept
+	if isSupportedFile(name) && !needsTranscode(name) {
+		return name, nil
+	}
+
+	ext, ok := extensionsForType[mediaType]
+	if !ok {
+		return "", fmt.Errorf("not a supported audio file (type %q)", mediaType)
+	}
+	if name == "" {
+		name = "download"
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ext, nil
+}
+
+// downloadAudio streams rawURL into dir and returns the path of the saved
+// file. Redirects are followed; cancelling ctx aborts the transfer and
+// removes the partial file.
+func downloadAudio(ctx context.Context, client *http.Client, rawURL, dir string, keepExtension bool, onProgress func(written, total int64)) (string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("URL must be an absolute http:// or https:// address")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	name, err := downloadFileName(resp)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if resp.ContentLength > 0 {
+		if err := checkFreeSpace(dir, resp.ContentLength); err != nil {
+			return "", err
+		}
+	}
+	dstPath := filepath.Join(dir, podcastFileName(name, keepExtension))
+
+	out, err := os.Create(dstPath)
+	if err != nil {
+		return "", err
+	}
+
+	counter := &progressWriter{total: resp.ContentLength, onProgress: onProgress}
+	_, err = io.Copy(io.MultiWriter(out, counter), resp.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dstPath)
+		return "", err
+	}
+
+	return dstPath, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// =============================================================================
+// Download Tests
+// =============================================================================
+
+func newDownloadTestServer(t *testing.T, content []byte) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/audio/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write(content)
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="../../Chapter 1.m4b"`)
+		w.Write(content)
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mp4")
+		w.Write(content)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/audio/final.mp3", http.StatusFound)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	// A login wall or error page at an audio URL
+	mux.HandleFunc("/login/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html>Sign in</html>"))
+	})
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"error":"expired"}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadAudio(t *testing.T) {
+	content := bytes.Repeat([]byte("audio"), 10000)
+	server := newDownloadTestServer(t, content)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedName string
+		wantErr      bool
+	}{
+		{"name from URL", "/audio/episode%201.mp3", "episode 1.mp3", false},
+		{"name from Content-Disposition is sanitized and renamed", "/download", "Chapter 1.m4a", false},
+		{"extension from Content-Type", "/stream", "stream.m4a", false},
+		{"redirect uses final URL", "/moved", "final.mp3", false},
+		{"html rejected", "/page", "", true},
+		{"html at an audio URL rejected", "/login/episode.mp3", "", true},
+		{"json at an audio URL rejected", "/api/episode.m4a", "", true},
+		{"not found rejected", "/missing", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var lastWritten int64
+
+			path, err := downloadAudio(context.Background(), server.Client(), server.URL+tc.path, dir, false,
+				func(written, total int64) { lastWritten = written })
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("downloadAudio(%q) expected error, got nil", tc.path)
+				}
+				entries, _ := os.ReadDir(dir)
+				if len(entries) != 0 {
+					t.Errorf("downloadAudio(%q) left %d file(s) behind", tc.path, len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("downloadAudio(%q) error = %v", tc.path, err)
+			}
+
+			if filepath.Base(path) != tc.expectedName {
+				t.Errorf("Downloaded name = %q; want %q", filepath.Base(path), tc.expectedName)
+			}
+			if filepath.Dir(path) != dir {
+				t.Errorf("Downloaded to %q; want inside %q", path, dir)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read download: %v", err)
+			}
+			if !bytes.Equal(data, content) {
+				t.Error("Downloaded content does not match")
+			}
+			if lastWritten != int64(len(content)) {
+				t.Errorf("Progress reported %d bytes; want %d", lastWritten, len(content))
+			}
+		})
+	}
+}
+
+func TestDownloadAudioCancel(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	dir := t.TempDir()
+	if _, err := downloadAudio(ctx, server.Client(), server.URL+"/slow.mp3", dir, false, nil); err == nil {
+		t.Fatal("downloadAudio() expected error after cancel")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("downloadAudio() left %d partial file(s) after cancel", len(entries))
+	}
+}
+
+func TestDownloadAudioRejectsBadURL(t *testing.T) {
+	for _, rawURL := range []string{"", "not a url", "file:///etc/passwd", "/relative.mp3"} {
+		if _, err := downloadAudio(context.Background(), nil, rawURL, t.TempDir(), false, nil); err == nil {
+			t.Errorf("downloadAudio(%q) expected error", rawURL)
+		}
+	}
+}
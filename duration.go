@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var errUnknownDuration = errors.New("duration could not be determined")
+
+// audioDuration reads the playback length of an MP3 or MP4 audio file
+func audioDuration(path string) (time.Duration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return mp3Duration(f, info.Size())
+	case ".m4a", ".mp4", ".m4b":
+		return mp4Duration(f, info.Size())
+	}
+	return 0, errUnknownDuration
+}
+
+// ensureDuration reads the duration of a local file that has none cached,
+// such as one just cut, leaving the caller to mark the change. It's never
+// called while building the feed, which only publishes what's known.
+func (p *Podcasterator) ensureDuration(index int) {
+	file := &p.files[index]
+	if file.Duration > 0 || file.IsExternal() {
+		return
+	}
+	if d, err := audioDuration(file.ServedPath()); err == nil {
+		file.Duration = d
+	}
+}
+
+// scaledDuration converts a count of units, perSecond of which make a
+// second, to a Duration. Whole seconds and the remainder are converted
+// separately so long files can't overflow.
+func scaledDuration(n, perSecond uint64) time.Duration {
+	secs, rem := n/perSecond, n%perSecond
+	return time.Duration(secs)*time.Second + time.Duration(rem)*time.Second/time.Duration(perSecond)
+}
+
+// formatDuration formats d as HH:MM:SS for <itunes:duration>
+func formatDuration(d time.Duration) string {
+	secs := int64(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
+
+// =============================================================================
+// MP3
+// =============================================================================
+
+// mp3Bitrates holds the bitrate tables in kbps, indexed by
+// [MPEG-1 ? 0 : 1][layer-1][bitrate index]
+var mp3Bitrates = [2][3][15]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+var mp3SampleRates = [3]int{44100, 48000, 32000}
+
+// mp3Frame is a decoded MPEG audio frame header
+type mp3Frame struct {
+	mpeg1      bool
+	layer      int // 1, 2 or 3
+	bitrate    int // bits per second
+	sampleRate int
+	samples    int // samples per frame
+	size       int // frame length in bytes, header included
+	mono       bool
+}
+
+// parseMP3Frame decodes a 4-byte frame header, reporting false for anything
+// that isn't a valid one
+func parseMP3Frame(h []byte) (mp3Frame, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	version := (h[1] >> 3) & 3 // 0 = MPEG-2.5, 2 = MPEG-2, 3 = MPEG-1
+	layerBits := (h[1] >> 1) & 3
+	bitrateIndex := int(h[2] >> 4)
+	rateIndex := int(h[2]>>2) & 3
+	if version == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	fr := mp3Frame{
+		mpeg1: version == 3,
+		layer: 4 - int(layerBits),
+		mono:  h[3]>>6 == 3,
+	}
+	table := 1
+	if fr.mpeg1 {
+		table = 0
+	}
+	fr.bitrate = mp3Bitrates[table][fr.layer-1][bitrateIndex] * 1000
+	fr.sampleRate = mp3SampleRates[rateIndex]
+	switch version {
+	case 2:
+		fr.sampleRate /= 2
+	case 0:
+		fr.sampleRate /= 4
+	}
+
+	padding := int(h[2]>>1) & 1
+	switch {
+	case fr.layer == 1:
+		fr.samples = 384
+		fr.size = (12*fr.bitrate/fr.sampleRate + padding) * 4
+	case fr.layer == 3 && !fr.mpeg1:
+		fr.samples = 576
+		fr.size = 72*fr.bitrate/fr.sampleRate + padding
+	default:
+		fr.samples = 1152
+		fr.size = 144*fr.bitrate/fr.sampleRate + padding
+	}
+	return fr, true
+}
+
+// sideInfoSize is the length of the Layer III side information that
+// precedes a Xing header
+func (fr mp3Frame) sideInfoSize() int {
+	switch {
+	case fr.mpeg1 && fr.mono:
+		return 17
+	case fr.mpeg1:
+		return 32
+	case fr.mono:
+		return 9
+	}
+	return 17
+}
+
+// mp3Duration uses the frame count from a Xing/Info or VBRI header when the
+// file has one, and otherwise estimates from the first frame's bitrate
+// assuming the file is constant bitrate.
+func mp3Duration(r io.ReadSeeker, size int64) (time.Duration, error) {
+	start, err := skipID3v2(r)
+	if err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 64*1024)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+	buf = buf[:n]
+
+	offset, fr, ok := findMP3Frame(buf)
+	if !ok {
+		return 0, errUnknownDuration
+	}
+	frame := buf[offset:]
+
+	if frames, ok := vbrFrameCount(frame, fr); ok && frames > 0 {
+		return scaledDuration(uint64(frames)*uint64(fr.samples), uint64(fr.sampleRate)), nil
+	}
+
+	audioBytes := size - start - int64(offset)
+	if hasID3v1(r, size) {
+		audioBytes -= 128
+	}
+	if audioBytes <= 0 {
+		return 0, errUnknownDuration
+	}
+	return scaledDuration(uint64(audioBytes)*8, uint64(fr.bitrate)), nil
+}
+
+// skipID3v2 positions r after a leading ID3v2 tag and returns that offset
+func skipID3v2(r io.ReadSeeker) (int64, error) {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, errUnknownDuration
+	}
+	if string(header[:3]) != "ID3" {
+		_, err := r.Seek(0, io.SeekStart)
+		return 0, err
+	}
+
+	// The size is syncsafe: 7 bits per byte
+	tagSize := int64(header[6]&0x7F)<<21 | int64(header[7]&0x7F)<<14 |
+		int64(header[8]&0x7F)<<7 | int64(header[9]&0x7F)
+	start := 10 + tagSize
+	if header[5]&0x10 != 0 {
+		start += 10 // footer
+	}
+	_, err := r.Seek(start, io.SeekStart)
+	return start, err
+}
+
+// findMP3Frame returns the offset of the first frame header in buf. When the
+// following frame also fits in buf it must be valid too, so stray 0xFF bytes
+// aren't mistaken for a frame.
+func findMP3Frame(buf []byte) (int, mp3Frame, bool) {
+	for i := 0; i+4 <= len(buf); i++ {
+		fr, ok := parseMP3Frame(buf[i:])
+		if !ok {
+			continue
+		}
+		next := i + fr.size
+		if next+4 <= len(buf) {
+			if _, ok := parseMP3Frame(buf[next:]); !ok {
+				continue
+			}
+		}
+		return i, fr, true
+	}
+	return 0, mp3Frame{}, false
+}
+
+// vbrFrameCount reads the total frame count from a Xing/Info or VBRI header
+// in the first frame
+func vbrFrameCount(frame []byte, fr mp3Frame) (uint32, bool) {
+	xing := 4 + fr.sideInfoSize()
+	if len(frame) >= xing+12 {
+		tag := string(frame[xing : xing+4])
+		flags := binary.BigEndian.Uint32(frame[xing+4:])
+		if (tag == "Xing" || tag == "Info") && flags&1 != 0 {
+			return binary.BigEndian.Uint32(frame[xing+8:]), true
+		}
+	}
+
+	const vbri = 4 + 32
+	if len(frame) >= vbri+18 && string(frame[vbri:vbri+4]) == "VBRI" {
+		return binary.BigEndian.Uint32(frame[vbri+14:]), true
+	}
+	return 0, false
+}
+
+// hasID3v1 reports whether the file ends with a 128-byte ID3v1 tag
+func hasID3v1(r io.ReadSeeker, size int64) bool {
+	if size < 128 {
+		return false
+	}
+	tag := make([]byte, 3)
+	if _, err := r.Seek(size-128, io.SeekStart); err != nil {
+		return false
+	}
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return false
+	}
+	return string(tag) == "TAG"
+}
+
+// =============================================================================
+// MP4
+// =============================================================================
+
+// mp4Duration reads the duration and timescale from moov/mvhd
+func mp4Duration(r io.ReadSeeker, size int64) (time.Duration, error) {
+	moovStart, moovEnd, err := findMP4Atom(r, 0, size, "moov")
+	if err != nil {
+		return 0, err
+	}
+	mvhdStart, mvhdEnd, err := findMP4Atom(r, moovStart, moovEnd, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+
+	body := make([]byte, mvhdEnd-mvhdStart)
+	if len(body) < 20 || len(body) > 256 {
+		return 0, errUnknownDuration
+	}
+	if _, err := r.Seek(mvhdStart, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, errUnknownDuration
+	}
+
+	var timescale, duration uint64
+	if body[0] == 1 {
+		// Version 1: 64-bit creation/modification times and duration
+		if len(body) < 32 {
+			return 0, errUnknownDuration
+		}
+		timescale = uint64(binary.BigEndian.Uint32(body[20:]))
+		duration = binary.BigEndian.Uint64(body[24:])
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(body[12:]))
+		duration = uint64(binary.BigEndian.Uint32(body[16:]))
+	}
+	if timescale == 0 || duration == 0 {
+		return 0, errUnknownDuration
+	}
+	return scaledDuration(duration, timescale), nil
+}
+
+// findMP4Atom finds the first atom named typ between start and end and
+// returns the bounds of its body
+func findMP4Atom(r io.ReadSeeker, start, end int64, typ string) (int64, int64, error) {
+	header := make([]byte, 16)
+	for pos := start; pos+8 <= end; {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return 0, 0, err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return 0, 0, errUnknownDuration
+		}
+
+		atomSize := int64(binary.BigEndian.Uint32(header))
+		headerSize := int64(8)
+		switch atomSize {
+		case 0:
+			// Extends to the end of the enclosing container
+			atomSize = end - pos
+		case 1:
+			// 64-bit size follows the type
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return 0, 0, errUnknownDuration
+			}
+			atomSize = int64(binary.BigEndian.Uint64(header[8:]))
+			headerSize = 16
+		}
+		if atomSize < headerSize || pos+atomSize > end {
+			return 0, 0, errUnknownDuration
+		}
+
+		if bytes.Equal(header[4:8], []byte(typ)) {
+			return pos + headerSize, pos + atomSize, nil
+		}
+		pos += atomSize
+	}
+	return 0, 0, errUnknownDuration
+}
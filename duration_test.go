@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Audio Duration Tests
+// =============================================================================
+
+// mp3Header is an MPEG-1 Layer III frame header: 128 kbps, 44.1 kHz, stereo,
+// no padding, so every frame is 417 bytes
+var mp3Header = []byte{0xFF, 0xFB, 0x90, 0x00}
+
+const mp3FrameSize = 417
+
+// mp3Frames returns count silent frames
+func mp3Frames(count int) []byte {
+	frame := make([]byte, mp3FrameSize)
+	copy(frame, mp3Header)
+	return bytes.Repeat(frame, count)
+}
+
+// mp4Atom builds an atom with a 32-bit size
+func mp4Atom(typ string, body ...[]byte) []byte {
+	content := bytes.Join(body, nil)
+	atom := binary.BigEndian.AppendUint32(nil, uint32(8+len(content)))
+	atom = append(atom, typ...)
+	return append(atom, content...)
+}
+
+// mvhdBody builds a movie header of the given version
+func mvhdBody(version byte, timescale uint32, duration uint64) []byte {
+	body := []byte{version, 0, 0, 0}
+	if version == 1 {
+		body = append(body, make([]byte, 16)...) // creation and modification times
+		body = binary.BigEndian.AppendUint32(body, timescale)
+		body = binary.BigEndian.AppendUint64(body, duration)
+	} else {
+		body = append(body, make([]byte, 8)...)
+		body = binary.BigEndian.AppendUint32(body, timescale)
+		body = binary.BigEndian.AppendUint32(body, uint32(duration))
+	}
+	return append(body, make([]byte, 80)...) // rate, volume, matrix, etc.
+}
+
+func writeAudio(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAudioDuration(t *testing.T) {
+	// 100 CBR frames of 417 bytes at 128 kbps
+	cbr := 100 * mp3FrameSize * 8 * time.Second / 128000
+
+	id3v2 := append([]byte("ID3\x04\x00\x00\x00\x00\x02\x00"), make([]byte, 256)...)
+	id3v1 := append([]byte("TAG"), make([]byte, 125)...)
+
+	xing := mp3Frames(3)
+	copy(xing[4+32:], "Xing\x00\x00\x00\x01")
+	binary.BigEndian.PutUint32(xing[4+32+8:], 1000)
+
+	vbri := mp3Frames(3)
+	copy(vbri[4+32:], "VBRI")
+	binary.BigEndian.PutUint32(vbri[4+32+14:], 500)
+
+	// mdat with a 64-bit size, which must be skipped correctly
+	largeMdat := append([]byte{0, 0, 0, 1}, "mdat"...)
+	largeMdat = binary.BigEndian.AppendUint64(largeMdat, 16+64)
+	largeMdat = append(largeMdat, make([]byte, 64)...)
+
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want time.Duration
+	}{
+		{"mp3 cbr", "a.mp3", mp3Frames(100), cbr},
+		{"mp3 cbr with id3 tags", "a.mp3", bytes.Join([][]byte{id3v2, mp3Frames(100), id3v1}, nil), cbr},
+		{"mp3 xing", "a.mp3", xing, 1000 * 1152 * time.Second / 44100},
+		{"mp3 vbri", "a.mp3", vbri, 500 * 1152 * time.Second / 44100},
+		{
+			"mp4 mvhd v0", "a.m4a",
+			bytes.Join([][]byte{
+				mp4Atom("ftyp", []byte("M4A \x00\x00\x00\x00")),
+				mp4Atom("moov", mp4Atom("mvhd", mvhdBody(0, 1000, 90500))),
+			}, nil),
+			90500 * time.Millisecond,
+		},
+		{
+			"mp4 mvhd v1 after large mdat", "a.m4b",
+			bytes.Join([][]byte{
+				mp4Atom("ftyp", []byte("M4B \x00\x00\x00\x00")),
+				largeMdat,
+				mp4Atom("moov", mp4Atom("trak"), mp4Atom("mvhd", mvhdBody(1, 44100, 44100*3600))),
+			}, nil),
+			time.Hour,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := audioDuration(writeAudio(t, tc.file, tc.data))
+			if err != nil {
+				t.Fatalf("audioDuration() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("audioDuration() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAudioDurationUnknown(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{"not an mp3", "a.mp3", []byte(strings.Repeat("not audio ", 100))},
+		{"empty mp3", "a.mp3", nil},
+		{"mp4 without moov", "a.m4a", mp4Atom("ftyp", []byte("M4A \x00\x00\x00\x00"))},
+		{"truncated mp4 atom", "a.m4a", []byte{0, 0, 1, 0, 'm', 'o', 'o', 'v'}},
+		{"unsupported extension", "a.wav", mp3Frames(10)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := audioDuration(writeAudio(t, tc.file, tc.data)); err == nil {
+				t.Errorf("audioDuration() = %v; want an error", got)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "00:00:00"},
+		{59*time.Second + 600*time.Millisecond, "00:01:00"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "01:02:03"},
+		{100 * time.Hour, "100:00:00"},
+	}
+
+	for _, tc := range tests {
+		if got := formatDuration(tc.in); got != tc.want {
+			t.Errorf("formatDuration(%v) = %q; want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRenderRSSItemDuration(t *testing.T) {
+	feedURL := "http://localhost:8080/feed.xml"
+
+	extras := feedExtras{Items: map[string][]channelElement{
+		"1": itemElementsFor(AudioFile{Duration: time.Hour + 2*time.Minute + 3*time.Second}),
+	}}
+	rss, err := renderRSS(newTestFeed("http://localhost:8080"), feedURL, extras)
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+	if !strings.Contains(rss, "<itunes:duration>01:02:03</itunes:duration>") {
+		t.Errorf("feed is missing itunes:duration:\n%s", rss)
+	}
+	if !strings.Contains(rss, `xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`) {
+		t.Errorf("feed does not declare the itunes namespace:\n%s", rss)
+	}
+
+	// Unknown durations are left out entirely
+	extras = feedExtras{Items: map[string][]channelElement{"1": itemElementsFor(AudioFile{})}}
+	rss, err = renderRSS(newTestFeed("http://localhost:8080"), feedURL, extras)
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+	if strings.Contains(rss, "itunes:duration") {
+		t.Errorf("feed has itunes:duration for an unknown duration:\n%s", rss)
+	}
+}
+
+func TestFeedForLeavesDurationsAlone(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	path := writeAudio(t, "a.mp3", mp3Frames(100))
+	p.files = []AudioFile{{ID: "1", DisplayName: "a.mp3", TempPath: path}}
+	p.dirty = false
+	p.feedFor("http://host")
+	if p.files[0].Duration != 0 || p.dirty {
+		t.Errorf("building the feed read the duration (%v) or marked a change", p.files[0].Duration)
+	}
+}
+
+func TestExtractMissingDurations(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	path := writeAudio(t, "a.mp3", mp3Frames(100))
+	p.files = []AudioFile{
+		{ID: "1", TempPath: path},
+		{ID: "2", TempPath: path, Duration: time.Minute},
+		{ID: "3", ExternalURL: "https://example.com/3.mp3"},
+	}
+	var updates []metadataUpdate
+	var mu sync.Mutex
+	p.processing = map[string]bool{}
+	p.metadata = newMetadataPipeline(1, defaultExtractors(), func(u metadataUpdate) {
+		mu.Lock()
+		defer mu.Unlock()
+		updates = append(updates, u)
+	})
+	p.extractMissingDurations()
+	p.metadata.Close()
+
+	// Only the missing duration is read, not everything a new file gets
+	for _, u := range updates {
+		if u.FileID != "1" || (!u.Done && u.Extractor != "duration") {
+			t.Errorf("unexpected update %+v", u)
+		}
+		p.applyMetadataUpdate(u)
+	}
+	if want := 100 * mp3FrameSize * 8 * time.Second / 128000; p.files[0].Duration != want {
+		t.Errorf("Duration = %v; want %v", p.files[0].Duration, want)
+	}
+	if p.files[1].Duration != time.Minute || p.processing["1"] {
+		t.Errorf("files after extraction = %+v", p.files)
+	}
+}
+
+func TestEnsureDurationCachesResult(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	path := writeAudio(t, "a.mp3", mp3Frames(100))
+	p.files = []AudioFile{{ID: "1", TempPath: path}}
+
+	p.ensureDuration(0)
+	want := 100 * mp3FrameSize * 8 * time.Second / 128000
+	if p.files[0].Duration != want {
+		t.Fatalf("Duration = %v; want %v", p.files[0].Duration, want)
+	}
+
+	// A cached duration is used without reading the file again
+	os.Remove(path)
+	p.ensureDuration(0)
+	if p.files[0].Duration != want {
+		t.Errorf("Duration = %v after file removed; want cached %v", p.files[0].Duration, want)
+	}
+}
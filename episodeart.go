@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// episodeCoverName is the file name an episode's artwork is served under,
+// next to its audio at /files/<id>/
+const episodeCoverName = "cover.jpg"
+
+// episodeCoverPath is where the converted artwork for the episode with id
+// is kept
+func (p *Podcasterator) episodeCoverPath(id string) string {
+	return filepath.Join(p.projectTempDir(), "covers", id+".jpg")
+}
+
+// episodeCoverURL is the URL of an episode's artwork under baseURL
+func episodeCoverURL(baseURL, id string) string {
+	return fmt.Sprintf("%s/files/%s/%s", strings.TrimRight(baseURL, "/"), id, episodeCoverName)
+}
+
+// itemImageElements is the <itunes:image> for an item: the episode's own
+// artwork, or channelArt, the channel's artwork URL, when it has none
+func itemImageElements(file AudioFile, baseURL, channelArt string) []channelElement {
+	href := ""
+	switch {
+	case file.ArtworkPath != "" && fileExists(file.ArtworkPath):
+		href = episodeCoverURL(baseURL, file.ID)
+	case channelArt != "":
+		href = channelArt
+	default:
+		return nil
+	}
+	return []channelElement{{Name: "itunes:image", Attrs: map[string]string{"href": href}}}
+}
+
+// setEpisodeArtwork converts the image at path, cropped to a square at crop,
+// into the artwork of the episode with id. It's converted like the podcast's
+// artwork, but always as JPEG since covers are served as cover.jpg.
+func (p *Podcasterator) setEpisodeArtwork(id, path string, crop float64) error {
+	if err := p.tempDirErr(); err != nil {
+		return err
+	}
+	for i := range p.files {
+		if p.files[i].ID != id {
+			continue
+		}
+		dst := p.episodeCoverPath(id)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := convertAndCropImageWith(path, dst, artworkSize, crop, p.jpegArtworkEncoder()); err != nil {
+			return fmt.Errorf("couldn't convert %s: %w", filepath.Base(path), err)
+		}
+		p.files[i].ArtworkPath = dst
+		p.refreshEpisodeRow(i)
+		p.markDirty()
+		return nil
+	}
+	return fmt.Errorf("episode no longer exists")
+}
+
+// removeEpisodeArtwork deletes the artwork of the episode with id, so it
+// falls back to the podcast's
+func (p *Podcasterator) removeEpisodeArtwork(id string) error {
+	for i := range p.files {
+		if p.files[i].ID != id {
+			continue
+		}
+		if p.files[i].ArtworkPath != "" {
+			os.Remove(p.files[i].ArtworkPath)
+		}
+		p.files[i].ArtworkPath = ""
+		p.refreshEpisodeRow(i)
+		p.markDirty()
+		return nil
+	}
+	return fmt.Errorf("episode no longer exists")
+}
+
+func (p *Podcasterator) refreshEpisodeRow(i int) {
+	if p.fileList != nil {
+		p.fileList.RefreshItem(i)
+	}
+}
+
+// editEpisodeArtwork lets the user pick artwork for the episode at index,
+// or, when it has some, shows it with options to change or remove it
+func (p *Podcasterator) editEpisodeArtwork(index int) {
+	if index < 0 || index >= len(p.files) || p.window == nil {
+		return
+	}
+	file := p.files[index]
+	if file.ArtworkPath == "" {
+		p.chooseEpisodeArtwork(file.ID)
+		return
+	}
+
+	preview := canvas.NewImageFromFile(file.ArtworkPath)
+	preview.FillMode = canvas.ImageFillContain
+	preview.SetMinSize(fyne.NewSize(240, 240))
+
+	var d dialog.Dialog
+	changeBtn := widget.NewButton("Change…", func() {
+		d.Hide()
+		p.chooseEpisodeArtwork(file.ID)
+	})
+	removeBtn := widget.NewButton("Remove", func() {
+		d.Hide()
+		if err := p.removeEpisodeArtwork(file.ID); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	})
+	note := widget.NewLabel("Without its own artwork the episode uses the podcast's.")
+	note.Importance = widget.LowImportance
+
+	d = dialog.NewCustom("Cover: "+truncateFilename(file.DisplayName), "Close",
+		container.NewVBox(preview, note, container.NewHBox(changeBtn, removeBtn)), p.window)
+	d.Show()
+}
+
+// chooseEpisodeArtwork opens a file picker for the artwork of the episode
+// with id
+func (p *Podcasterator) chooseEpisodeArtwork(id string) {
+	if !p.requireTempDir() {
+		return
+	}
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		path := reader.URI().Path()
+		if !isImageFile(path) {
+			dialog.ShowError(fmt.Errorf("unsupported image type: %s", filepath.Ext(path)), p.window)
+			return
+		}
+		p.pickArtworkCrop(path, func(crop float64) {
+			if err := p.setEpisodeArtwork(id, path, crop); err != nil {
+				dialog.ShowError(err, p.window)
+			}
+		})
+	}, p.window)
+}
+
+// serveEpisodeCover serves the artwork of file, which must be inside the
+// temp dir like any copy the server hands out
+func (s *FeedServer) serveEpisodeCover(w http.ResponseWriter, r *http.Request, file AudioFile) {
+	if file.ArtworkPath == "" || !fileExists(file.ArtworkPath) {
+		http.Error(w, "Artwork not found", http.StatusNotFound)
+		return
+	}
+	if !s.servableCopy(file.ArtworkPath) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeFile(w, r, file.ArtworkPath)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Episode Artwork Tests
+// =============================================================================
+
+func TestItemImageElements(t *testing.T) {
+	cover := filepath.Join(t.TempDir(), "cover.jpg")
+	os.WriteFile(cover, []byte("jpeg"), 0644)
+
+	const channelArt = "http://host:8080/artwork.jpg"
+	tests := []struct {
+		name       string
+		file       AudioFile
+		channelArt string
+		want       string
+	}{
+		{"own artwork", AudioFile{ID: "1", ArtworkPath: cover}, channelArt, "http://host:8080/files/1/cover.jpg"},
+		{"falls back to the podcast's", AudioFile{ID: "2"}, channelArt, channelArt},
+		{"falls back to PNG artwork", AudioFile{ID: "2"}, "http://host:8080/artwork.png", "http://host:8080/artwork.png"},
+		{"missing cover falls back", AudioFile{ID: "3", ArtworkPath: "/gone.jpg"}, channelArt, channelArt},
+		{"no artwork anywhere", AudioFile{ID: "4"}, "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			elements := itemImageElements(tc.file, "http://host:8080/", tc.channelArt)
+			got := ""
+			if len(elements) == 1 && elements[0].Name == "itunes:image" {
+				got = elements[0].Attrs["href"]
+			}
+			if got != tc.want || (tc.want == "" && len(elements) != 0) {
+				t.Errorf("itunes:image href = %q (%d elements); want %q", got, len(elements), tc.want)
+			}
+		})
+	}
+}
+
+func TestSetEpisodeArtwork(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3")
+	id := p.files[1].ID
+
+	src := filepath.Join(t.TempDir(), "art.png")
+	writeTestPNG(t, src, 200, 200)
+
+	if err := p.setEpisodeArtwork("missing", src, cropCenter); err == nil {
+		t.Error("setEpisodeArtwork() for an unknown episode succeeded")
+	}
+	if err := p.setEpisodeArtwork(id, src, cropCenter); err != nil {
+		t.Fatalf("setEpisodeArtwork() error = %v", err)
+	}
+	cover := p.files[1].ArtworkPath
+	if info, err := readArtworkInfo(cover); err != nil || info.Width != 200 {
+		t.Errorf("converted cover = %+v, %v; want a 200px image", info, err)
+	}
+	if !strings.HasPrefix(cover, p.projectTempDir()) {
+		t.Errorf("cover at %s; want it in the project's temp dir", cover)
+	}
+	if !strings.Contains(fileRowDetails(p.files[1]), "🖼 artwork") {
+		t.Error("row details don't mark the episode's artwork")
+	}
+
+	// The feed links it on that item and the podcast artwork on the others
+	p.artworkPath = filepath.Join(t.TempDir(), "artwork.jpg")
+	os.WriteFile(p.artworkPath, []byte("jpeg"), 0644)
+	rss, files, err := p.siteFeed("http://host")
+	if err != nil {
+		t.Fatalf("siteFeed() error = %v", err)
+	}
+	if !strings.Contains(rss, `<itunes:image href="http://host/files/`+id+`/cover.jpg">`) {
+		t.Errorf("feed doesn't link the episode's cover:\n%s", rss)
+	}
+	if strings.Count(rss, `<itunes:image href="http://host/artwork.jpg">`) != 2 {
+		t.Errorf("want the podcast artwork on the channel and the other item:\n%s", rss)
+	}
+	if last := files[len(files)-1]; last.ID != id || last.Name != episodeCoverName || last.Path != cover {
+		t.Errorf("static export files end with %+v; want the cover", last)
+	}
+
+	if err := p.removeEpisodeArtwork(id); err != nil {
+		t.Fatalf("removeEpisodeArtwork() error = %v", err)
+	}
+	if p.files[1].ArtworkPath != "" || fileExists(cover) {
+		t.Error("removeEpisodeArtwork() left the cover behind")
+	}
+}
+
+func TestServeEpisodeCover(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	code, _ := getBody(t, srv.URL+"/files/abc/cover.jpg")
+	if code != http.StatusNotFound {
+		t.Errorf("GET cover of an episode without one = %d; want 404", code)
+	}
+
+	cover := p.episodeCoverPath("abc")
+	os.MkdirAll(filepath.Dir(cover), 0755)
+	os.WriteFile(cover, []byte("jpeg"), 0644)
+	p.files[0].ArtworkPath = cover
+	p.markDirty()
+
+	resp, err := http.Get(srv.URL + "/files/abc/cover.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/jpeg" {
+		t.Errorf("GET cover = %d %s; want 200 image/jpeg", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// A cover path outside the temp dir is never served
+	outside := filepath.Join(t.TempDir(), "elsewhere.jpg")
+	os.WriteFile(outside, []byte("jpeg"), 0644)
+	p.files[0].ArtworkPath = outside
+	p.markDirty()
+	rec := httptest.NewRecorder()
+	p.live.serveFile(rec, httptest.NewRequest("GET", "/files/abc/cover.jpg", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("cover outside the temp dir = %d; want 403", rec.Code)
+	}
+
+	// The audio is still served under its own name
+	if code, body := getBody(t, srv.URL+"/files/abc/episode.mp3"); code != http.StatusOK || body != "copy" {
+		t.Errorf("GET audio = %d %q", code, body)
+	}
+}
+
+func TestEpisodeArtworkPurgedWithFile(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3")
+
+	src := filepath.Join(t.TempDir(), "art.png")
+	writeTestPNG(t, src, 50, 50)
+	p.setEpisodeArtwork(p.files[0].ID, src, cropCenter)
+	cover := p.files[0].ArtworkPath
+
+	p.deleteFile(0)
+	if !fileExists(cover) {
+		t.Fatal("cover removed before the delete can no longer be undone")
+	}
+	p.undoRemove()
+	if len(p.files) != 1 || p.files[0].ArtworkPath != cover {
+		t.Fatalf("undo didn't restore the episode with its cover: %+v", p.files)
+	}
+
+	p.deleteFile(0)
+	p.purgeTrash()
+	if fileExists(cover) {
+		t.Error("cover left behind after the trash was emptied")
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	manifestName    = "manifest.json"
+	manifestVersion = 1
+)
+
+// manifestEntry describes one exported file so a sync script can verify
+// what arrived on the server
+type manifestEntry struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	MIMEType string `json:"mime_type"`
+	SHA256   string `json:"sha256"`
+	Duration int64  `json:"duration_seconds,omitempty"`
+
+	// StartOffset is how much of the start was cut from the exported audio
+	StartOffset float64 `json:"start_offset_seconds,omitempty"`
+}
+
+type exportManifest struct {
+	Version int             `json:"version"`
+	Files   []manifestEntry `json:"files"`
+}
+
+// copyWithHash copies src to dst and returns the size and SHA-256 of what
+// was written, hashing as the data streams through instead of re-reading it.
+func copyWithHash(src, dst string) (int64, string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, "", err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, "", err
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, h), in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// uniqueExportName returns name, or name with a " (n)" suffix if it's taken
+func uniqueExportName(name string, used map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// exportFiles copies every local file into dir in list order and writes a
+// manifest.json describing them. External files are already hosted and are
+// skipped.
+func exportFiles(files []AudioFile, dir string) (exportManifest, error) {
+	manifest := exportManifest{Version: manifestVersion, Files: []manifestEntry{}}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return manifest, err
+	}
+
+	used := map[string]bool{strings.ToLower(manifestName): true}
+	for _, file := range files {
+		if file.IsExternal() {
+			continue
+		}
+
+		name := uniqueExportName(file.DisplayName, used)
+		size, sum, err := copyWithHash(file.ServedPath(), filepath.Join(dir, name))
+		if err != nil {
+			return manifest, fmt.Errorf("export %s: %w", file.DisplayName, err)
+		}
+
+		manifest.Files = append(manifest.Files, manifestEntry{
+			Name:     name,
+			Size:     size,
+			MIMEType: mimeTypeFor(filepath.Ext(name)),
+			SHA256:   sum,
+			Duration: int64(file.Duration.Round(time.Second) / time.Second),
+
+			StartOffset: file.StartOffset.Seconds(),
+		})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	return manifest, writeFileAtomic(filepath.Join(dir, manifestName), data, 0644)
+}
+
+// openExportDialog asks for a destination folder and exports into it
+func (p *Podcasterator) openExportDialog() {
+	if len(p.files) == 0 {
+		return
+	}
+
+	dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+		if err != nil || folder == nil {
+			return
+		}
+
+		files := append([]AudioFile(nil), p.files...)
+		dir := folder.Path()
+		go func() {
+			manifest, err := exportFiles(files, dir)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, p.window)
+					return
+				}
+				dialog.ShowInformation("Export Complete",
+					fmt.Sprintf("Exported %d files with %s to\n%s", len(manifest.Files), manifestName, dir), p.window)
+			})
+		}()
+	}, p.window)
+}
+
+// siteFile is a local file placed at files/<ID>/<Name> in a static export,
+// the same path the server uses for it
+type siteFile struct {
+	ID   string
+	Name string
+	Path string
+}
+
+// validateSiteURL checks the base URL a static export is hosted at and
+// returns it without a trailing slash
+func validateSiteURL(s string) (string, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "/")
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("enter the http:// or https:// address the folder will be hosted at")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("the address can't have a query or fragment")
+	}
+	return s, nil
+}
+
+// siteFeed renders the feed for hosting at baseURL and lists the files it
+// links to, including episode artwork. External audio is linked where it's
+// hosted and isn't listed.
+func (p *Podcasterator) siteFeed(baseURL string) (string, []siteFile, error) {
+	p.ensureChannelGUID(feedURLFor(baseURL))
+	p.ensureFeedEpoch()
+	feed, extras := p.feedFor(baseURL)
+	rss, err := renderRSS(feed, feedURLFor(baseURL), extras)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var files []siteFile
+	for i, file := range p.files {
+		if file.IsExternal() {
+			continue
+		}
+		files = append(files, siteFile{
+			ID:   file.ID,
+			Name: p.servedName(i),
+			Path: file.ServedPath(),
+		})
+	}
+	// Episode artwork, chapters and transcripts go beside the audio, as the
+	// server has them
+	for _, file := range p.files {
+		if file.ArtworkPath != "" && fileExists(file.ArtworkPath) {
+			files = append(files, siteFile{ID: file.ID, Name: episodeCoverName, Path: file.ArtworkPath})
+		}
+		if file.ChaptersPath != "" && fileExists(file.ChaptersPath) {
+			files = append(files, siteFile{ID: file.ID, Name: chaptersName, Path: file.ChaptersPath})
+		}
+		if file.TranscriptPath != "" && fileExists(file.TranscriptPath) {
+			files = append(files, siteFile{ID: file.ID, Name: transcriptName(file.TranscriptPath), Path: file.TranscriptPath})
+		}
+	}
+	return rss, files, nil
+}
+
+// exportSite writes a self-contained copy of the podcast into dir: feed.xml,
+// the artwork as artwork.jpg or artwork.png when artworkPath is set, and each file under files/<id>/, so
+// the folder can be uploaded as-is to any static web host.
+func exportSite(dir, rss, artworkPath string, files []siteFile) error {
+	for _, f := range files {
+		fileDir := filepath.Join(dir, "files", f.ID)
+		if err := os.MkdirAll(fileDir, 0755); err != nil {
+			return err
+		}
+		if _, err := copyWithProgress(context.Background(), f.Path, filepath.Join(fileDir, f.Name), nil); err != nil {
+			return fmt.Errorf("export %s: %w", f.Name, err)
+		}
+	}
+
+	if artworkPath != "" && fileExists(artworkPath) {
+		if _, err := copyWithProgress(context.Background(), artworkPath, filepath.Join(dir, artworkName(artworkPath)), nil); err != nil {
+			return fmt.Errorf("export artwork: %w", err)
+		}
+	}
+
+	// The feed goes last so a failed export never leaves one pointing at missing files
+	return writeFileAtomic(filepath.Join(dir, "feed.xml"), []byte(rss), 0644)
+}
+
+// openSiteExportDialog asks for the URL the podcast will be hosted at and a
+// destination folder, then writes a static copy of the feed and files there
+func (p *Podcasterator) openSiteExportDialog() {
+	if len(p.files) == 0 {
+		return
+	}
+
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://example.com/podcast")
+	urlEntry.SetText(p.publicURL)
+	urlEntry.Validator = func(s string) error {
+		_, err := validateSiteURL(s)
+		return err
+	}
+
+	note := widget.NewLabel("Writes feed.xml, the artwork and a files/ folder with every\nlink under this address. Upload the folder's contents there.")
+	note.Importance = widget.LowImportance
+
+	content := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Hosted at:"), nil, urlEntry),
+		note,
+	)
+	d := dialog.NewCustomConfirm("Export Feed and Files", "Choose Folder…", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		baseURL, err := validateSiteURL(urlEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		p.chooseSiteExportFolder(baseURL)
+	}, p.window)
+	d.Resize(fyne.NewSize(500, 180))
+	d.Show()
+}
+
+// chooseSiteExportFolder asks for the folder and exports the site for
+// baseURL into it
+func (p *Podcasterator) chooseSiteExportFolder(baseURL string) {
+	dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+		if err != nil || folder == nil {
+			return
+		}
+
+		rss, files, err := p.siteFeed(baseURL)
+		if err != nil {
+			dialog.ShowError(err, p.window)
+			return
+		}
+		artworkPath := p.artworkPath
+		dir := folder.Path()
+		go func() {
+			err := exportSite(dir, rss, artworkPath, files)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, p.window)
+					return
+				}
+				dialog.ShowInformation("Export Complete",
+					fmt.Sprintf("Exported the feed and %d files to\n%s\n\nOnce uploaded, subscribe at %s", len(files), dir, feedURLFor(baseURL)), p.window)
+			})
+		}()
+	}, p.window)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Export Manifest Tests
+// =============================================================================
+
+func TestExportFilesManifest(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	srcDir := t.TempDir()
+	contents := map[string]string{
+		"a.mp3": "first episode",
+		"b.m4a": "second episode, a bit longer",
+	}
+	for _, name := range []string{"a.mp3", "b.m4a"} {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte(contents[name]), 0644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		p.addFile(path)
+	}
+	p.files = append(p.files, AudioFile{ID: "ext", DisplayName: "Hosted", ExternalURL: "https://example.com/x.mp3"})
+
+	outDir := filepath.Join(t.TempDir(), "export")
+	manifest, err := exportFiles(p.files, outDir)
+	if err != nil {
+		t.Fatalf("exportFiles() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, manifestName))
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var onDisk exportManifest
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+	if onDisk.Version != manifestVersion || len(onDisk.Files) != 2 || len(manifest.Files) != 2 {
+		t.Fatalf("manifest = %+v; want version %d with 2 files", onDisk, manifestVersion)
+	}
+
+	wantTypes := map[string]string{"a.mp3": "audio/mpeg", "b.m4a": "audio/mp4"}
+	for _, entry := range onDisk.Files {
+		exported, err := os.ReadFile(filepath.Join(outDir, entry.Name))
+		if err != nil {
+			t.Errorf("manifest lists %s but it wasn't exported: %v", entry.Name, err)
+			continue
+		}
+		sum := sha256.Sum256(exported)
+		if entry.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s sha256 = %s; want %s", entry.Name, entry.SHA256, hex.EncodeToString(sum[:]))
+		}
+		if entry.Size != int64(len(exported)) {
+			t.Errorf("%s size = %d; want %d", entry.Name, entry.Size, len(exported))
+		}
+		if string(exported) != contents[entry.Name] {
+			t.Errorf("%s content = %q; want %q", entry.Name, exported, contents[entry.Name])
+		}
+		if entry.MIMEType != wantTypes[entry.Name] {
+			t.Errorf("%s mime type = %q; want %q", entry.Name, entry.MIMEType, wantTypes[entry.Name])
+		}
+	}
+}
+
+func TestUniqueExportName(t *testing.T) {
+	used := map[string]bool{}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ep.mp3", "ep.mp3"},
+		{"ep.mp3", "ep (2).mp3"},
+		{"EP.mp3", "EP (3).mp3"},
+		{"other.mp3", "other.mp3"},
+	}
+	for _, tc := range tests {
+		if got := uniqueExportName(tc.name, used); got != tc.want {
+			t.Errorf("uniqueExportName(%q) = %q; want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// =============================================================================
+// Static Site Export Tests
+// =============================================================================
+
+func TestValidateSiteURL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"https://example.com/podcast/", "https://example.com/podcast", false},
+		{"  http://host:8000 ", "http://host:8000", false},
+		{"example.com/podcast", "", true},
+		{"ftp://example.com", "", true},
+		{"https://", "", true},
+		{"https://example.com/?a=b", "", true},
+		{"", "", true},
+	}
+
+	for _, tc := range tests {
+		got, err := validateSiteURL(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("validateSiteURL(%q) = %q, %v; want %q, error %v", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestExportSite(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.numberEnclosures = true
+
+	srcDir := t.TempDir()
+	for _, name := range []string{"one.mp3", "two & more.m4a"} {
+		path := filepath.Join(srcDir, name)
+		os.WriteFile(path, []byte(name), 0644)
+		p.addFile(path)
+	}
+	p.files = append(p.files, AudioFile{ID: "ext", DisplayName: "Hosted", ExternalURL: "https://cdn.example.com/x.mp3"})
+	p.artworkPath = filepath.Join(p.tempDir, "artwork.jpg")
+	os.WriteFile(p.artworkPath, []byte("jpeg"), 0644)
+
+	baseURL := "https://example.com/pod"
+	rss, files, err := p.siteFeed(baseURL)
+	if err != nil {
+		t.Fatalf("siteFeed() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("siteFeed() listed %d files; want the 2 local ones", len(files))
+	}
+
+	outDir := t.TempDir()
+	if err := exportSite(outDir, rss, p.artworkPath, files); err != nil {
+		t.Fatalf("exportSite() error = %v", err)
+	}
+
+	feed, err := os.ReadFile(filepath.Join(outDir, "feed.xml"))
+	if err != nil {
+		t.Fatalf("feed.xml not written: %v", err)
+	}
+	for _, want := range []string{
+		`<atom:link href="https://example.com/pod/feed.xml"`,
+		"https://example.com/pod/artwork.jpg",
+		"https://example.com/pod/files/" + p.files[0].ID + "/01-one.mp3",
+		"https://example.com/pod/files/" + p.files[1].ID + "/02-two%20&amp;%20more.m4a",
+		"https://cdn.example.com/x.mp3",
+	} {
+		if !strings.Contains(string(feed), want) {
+			t.Errorf("feed.xml is missing %s", want)
+		}
+	}
+	if strings.Contains(string(feed), "localhost") {
+		t.Error("feed.xml links to localhost")
+	}
+
+	for i, name := range []string{"one.mp3", "two & more.m4a"} {
+		served := fmt.Sprintf("%02d-%s", i+1, name)
+		got, err := os.ReadFile(filepath.Join(outDir, "files", p.files[i].ID, served))
+		if err != nil || string(got) != name {
+			t.Errorf("files/%s/%s = %q, %v; want the contents of %s", p.files[i].ID, served, got, err, name)
+		}
+	}
+	if !fileExists(filepath.Join(outDir, "artwork.jpg")) {
+		t.Error("artwork.jpg not exported")
+	}
+}
+
+func TestExportSiteMissingFile(t *testing.T) {
+	outDir := t.TempDir()
+	files := []siteFile{{ID: "1", Name: "gone.mp3", Path: filepath.Join(t.TempDir(), "gone.mp3")}}
+	if err := exportSite(outDir, "<rss/>", "", files); err == nil {
+		t.Fatal("exportSite() with a missing file succeeded")
+	}
+	if fileExists(filepath.Join(outDir, "feed.xml")) {
+		t.Error("feed.xml written by a failed export")
+	}
+}
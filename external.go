@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const externalFetchTimeout = 10 * time.Second
+
+// newExternalFile validates user input for an externally-hosted episode.
+// Title defaults to the URL's file name and type is inferred from its
+// extension when left blank.
+func newExternalFile(rawURL, title, length, mimeType string) (AudioFile, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return AudioFile{}, errors.New("URL must be an absolute http:// or https:// address")
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = path.Base(u.Path)
+		if title == "." || title == "/" {
+			title = u.Host
+		}
+	}
+
+	var size int64
+	if length = strings.TrimSpace(length); length != "" {
+		size, err = strconv.ParseInt(length, 10, 64)
+		if err != nil || size < 0 {
+			return AudioFile{}, fmt.Errorf("length must be a whole number of bytes, got %q", length)
+		}
+	}
+
+	mimeType = strings.TrimSpace(mimeType)
+	if mimeType == "" {
+		detected, ok := audioMIMETypes[strings.ToLower(path.Ext(u.Path))]
+		if !ok {
+			return AudioFile{}, errors.New("couldn't detect the media type from the URL; please enter it")
+		}
+		mimeType = detected
+	}
+	if !strings.HasPrefix(mimeType, "audio/") && !strings.HasPrefix(mimeType, "video/") {
+		return AudioFile{}, fmt.Errorf("type must be an audio or video MIME type, got %q", mimeType)
+	}
+
+	return AudioFile{
+		ID:             uuid.New().String(),
+		DisplayName:    title,
+		ExternalURL:    rawURL,
+		ExternalLength: size,
+		ExternalType:   mimeType,
+	}, nil
+}
+
+// fetchEnclosureInfo issues a HEAD request for an external enclosure and
+// returns its Content-Length and Content-Type. Redirects are followed.
+// Either value is zero/empty when the server doesn't report it.
+func fetchEnclosureInfo(client *http.Client, rawURL string) (int64, string, error) {
+	if client == nil {
+		client = &http.Client{Timeout: externalFetchTimeout}
+	}
+
+	resp, err := client.Head(rawURL)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, "", fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	var length int64
+	if resp.ContentLength > 0 {
+		length = resp.ContentLength
+	}
+
+	mimeType := resp.Header.Get("Content-Type")
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return length, strings.TrimSpace(mimeType), nil
+}
+
+// fillExternalDetails fills in whichever of length and mimeType the user left
+// blank using a HEAD request. Values the user typed always win.
+func fillExternalDetails(client *http.Client, rawURL, length, mimeType string) (string, string, error) {
+	if strings.TrimSpace(length) != "" && strings.TrimSpace(mimeType) != "" {
+		return length, mimeType, nil
+	}
+
+	size, fetchedType, err := fetchEnclosureInfo(client, strings.TrimSpace(rawURL))
+	if err != nil {
+		return length, mimeType, err
+	}
+
+	if strings.TrimSpace(length) == "" && size > 0 {
+		length = strconv.FormatInt(size, 10)
+	}
+	if strings.TrimSpace(mimeType) == "" && (strings.HasPrefix(fetchedType, "audio/") || strings.HasPrefix(fetchedType, "video/")) {
+		mimeType = fetchedType
+	}
+	return length, mimeType, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// External Enclosure Tests
+// =============================================================================
+
+func TestNewExternalFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		title        string
+		length       string
+		mimeType     string
+		wantErr      bool
+		expectedName string
+		expectedType string
+		expectedLen  int64
+	}{
+		{"mp3 with inferred title and type", "https://cdn.example.com/shows/ep1.mp3", "", "1234", "", false, "ep1.mp3", "audio/mpeg", 1234},
+		{"m4a with explicit title", "https://cdn.example.com/ep2.m4a", "Episode 2", "", "", false, "Episode 2", "audio/mp4", 0},
+		{"explicit type for unknown extension", "http://cdn.example.com/stream?id=3", "Live", "10", "audio/ogg", false, "Live", "audio/ogg", 10},
+		{"unknown extension without type", "https://cdn.example.com/stream", "", "", "", true, "", "", 0},
+		{"relative URL rejected", "/files/ep.mp3", "", "", "", true, "", "", 0},
+		{"ftp scheme rejected", "ftp://example.com/ep.mp3", "", "", "", true, "", "", 0},
+		{"negative length rejected", "https://cdn.example.com/ep.mp3", "", "-5", "", true, "", "", 0},
+		{"non-numeric length rejected", "https://cdn.example.com/ep.mp3", "", "big", "", true, "", "", 0},
+		{"non-media type rejected", "https://cdn.example.com/ep.mp3", "", "", "text/html", true, "", "", 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := newExternalFile(tc.url, tc.title, tc.length, tc.mimeType)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("newExternalFile(%q) expected error, got nil", tc.url)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newExternalFile(%q) error = %v", tc.url, err)
+			}
+
+			if !file.IsExternal() {
+				t.Error("newExternalFile() result is not marked external")
+			}
+			if file.ID == "" {
+				t.Error("newExternalFile() did not assign an ID")
+			}
+			if file.DisplayName != tc.expectedName {
+				t.Errorf("DisplayName = %q; want %q", file.DisplayName, tc.expectedName)
+			}
+			if file.ExternalType != tc.expectedType {
+				t.Errorf("ExternalType = %q; want %q", file.ExternalType, tc.expectedType)
+			}
+			if file.ExternalLength != tc.expectedLen {
+				t.Errorf("ExternalLength = %d; want %d", file.ExternalLength, tc.expectedLen)
+			}
+		})
+	}
+}
+
+func TestFetchEnclosureInfo(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ep.mp3", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("Request method = %s; want HEAD", r.Method)
+		}
+		w.Header().Set("Content-Type", "audio/mpeg; charset=binary")
+		w.Header().Set("Content-Length", "98765")
+	})
+	mux.HandleFunc("/old.mp3", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ep.mp3", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/bare", func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Content-Type"] = nil
+	})
+	mux.HandleFunc("/slow.mp3", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := server.Client()
+
+	t.Run("reads length and type", func(t *testing.T) {
+		length, mimeType, err := fetchEnclosureInfo(client, server.URL+"/ep.mp3")
+		if err != nil {
+			t.Fatalf("fetchEnclosureInfo() error = %v", err)
+		}
+		if length != 98765 {
+			t.Errorf("length = %d; want 98765", length)
+		}
+		if mimeType != "audio/mpeg" {
+			t.Errorf("type = %q; want %q", mimeType, "audio/mpeg")
+		}
+	})
+
+	t.Run("follows redirects", func(t *testing.T) {
+		length, _, err := fetchEnclosureInfo(client, server.URL+"/old.mp3")
+		if err != nil {
+			t.Fatalf("fetchEnclosureInfo() error = %v", err)
+		}
+		if length != 98765 {
+			t.Errorf("length after redirect = %d; want 98765", length)
+		}
+	})
+
+	t.Run("missing headers are left empty", func(t *testing.T) {
+		length, mimeType, err := fetchEnclosureInfo(client, server.URL+"/bare")
+		if err != nil {
+			t.Fatalf("fetchEnclosureInfo() error = %v", err)
+		}
+		if length != 0 || mimeType != "" {
+			t.Errorf("fetchEnclosureInfo() = (%d, %q); want (0, \"\")", length, mimeType)
+		}
+	})
+
+	t.Run("not found is an error", func(t *testing.T) {
+		if _, _, err := fetchEnclosureInfo(client, server.URL+"/missing.mp3"); err == nil {
+			t.Error("fetchEnclosureInfo() expected error for 404")
+		}
+	})
+
+	t.Run("timeout is an error", func(t *testing.T) {
+		short := &http.Client{Timeout: 50 * time.Millisecond}
+		if _, _, err := fetchEnclosureInfo(short, server.URL+"/slow.mp3"); err == nil {
+			t.Error("fetchEnclosureInfo() expected timeout error")
+		}
+	})
+
+	t.Run("manual values override fetched ones", func(t *testing.T) {
+		length, mimeType, err := fillExternalDetails(client, server.URL+"/ep.mp3", "", "audio/x-custom")
+		if err != nil {
+			t.Fatalf("fillExternalDetails() error = %v", err)
+		}
+		if length != "98765" {
+			t.Errorf("length = %q; want %q", length, "98765")
+		}
+		if mimeType != "audio/x-custom" {
+			t.Errorf("type = %q; want manual override %q", mimeType, "audio/x-custom")
+		}
+	})
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/feeds"
+	"golang.org/x/text/unicode/norm"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomLink is the <atom:link rel="self"> element podcast validators expect
+// to point back at the feed's own URL.
+type atomLink struct {
+	XMLName xml.Name `xml:"atom:link"`
+	Href    string   `xml:"href,attr"`
+	Rel     string   `xml:"rel,attr"`
+	Type    string   `xml:"type,attr"`
+}
+
+// rssItem mirrors feeds.RssItem, with description made optional so items
+// without show notes leave it out, plus elements it doesn't support
+type rssItem struct {
+	XMLName     xml.Name `xml:"item"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	Content     *feeds.RssContent
+	Author      string `xml:"author,omitempty"`
+	Category    string `xml:"category,omitempty"`
+	Comments    string `xml:"comments,omitempty"`
+	Enclosure   *feeds.RssEnclosure
+	Guid        *feeds.RssGuid
+	PubDate     string `xml:"pubDate,omitempty"`
+	Source      string `xml:"source,omitempty"`
+	Extra       []channelElement
+}
+
+func newRSSItem(item *feeds.RssItem, extra []channelElement) *rssItem {
+	return &rssItem{
+		Title:       item.Title,
+		Link:        item.Link,
+		Description: item.Description,
+		Content:     item.Content,
+		Author:      item.Author,
+		Category:    item.Category,
+		Comments:    item.Comments,
+		Enclosure:   item.Enclosure,
+		Guid:        item.Guid,
+		PubDate:     item.PubDate,
+		Source:      item.Source,
+		Extra:       extra,
+	}
+}
+
+// rssChannel extends the gorilla/feeds channel with elements it doesn't
+// support. Items shadows the embedded feed's items.
+type rssChannel struct {
+	*feeds.RssFeed
+	Items    []*rssItem `xml:"item"`
+	AtomLink *atomLink
+	Extra    []channelElement
+}
+
+// feedExtras are the elements gorilla/feeds has no fields for: extra
+// <channel> elements, and extra elements for each item keyed by item Id
+type feedExtras struct {
+	Channel []channelElement
+	Items   map[string][]channelElement
+}
+
+// namespaceAttrs returns the xmlns declarations every extra element needs
+func (e feedExtras) namespaceAttrs() []xml.Attr {
+	elements := append([]channelElement{}, e.Channel...)
+	for _, item := range e.Items {
+		elements = append(elements, item...)
+	}
+	return channelNamespaceAttrs(elements)
+}
+
+// itemElementsFor returns the per-item iTunes elements for a file
+func itemElementsFor(file AudioFile) []channelElement {
+	elements := []channelElement{
+		{Name: "itunes:title", Value: file.DisplayName},
+		{Name: "itunes:explicit", Value: itunesBool(file.Explicit)},
+	}
+	if file.Episode > 0 {
+		elements = append(elements, channelElement{Name: "itunes:episode", Value: strconv.Itoa(file.Episode)})
+	}
+	if file.Season > 0 {
+		elements = append(elements, channelElement{Name: "itunes:season", Value: strconv.Itoa(file.Season)})
+	}
+	if file.EpisodeType != "" && validateEpisodeType(file.EpisodeType) == nil {
+		elements = append(elements, channelElement{Name: "itunes:episodeType", Value: file.EpisodeType})
+	}
+	if file.Duration > 0 {
+		elements = append(elements, channelElement{Name: "itunes:duration", Value: formatDuration(file.Duration)})
+	}
+	return elements
+}
+
+// rssDocument mirrors feeds.RssFeedXml with the extra namespaces we need
+type rssDocument struct {
+	XMLName          xml.Name   `xml:"rss"`
+	Version          string     `xml:"version,attr"`
+	ContentNamespace string     `xml:"xmlns:content,attr"`
+	AtomNamespace    string     `xml:"xmlns:atom,attr"`
+	Namespaces       []xml.Attr `xml:",any,attr"`
+	Channel          *rssChannel
+}
+
+// writeRSS streams feed to w as RSS 2.0 with a self link pointing at
+// feedURL and any extra elements, without building the whole document in
+// memory first.
+func writeRSS(w io.Writer, feed *feeds.Feed, feedURL string, extras feedExtras) error {
+	channel := &rssChannel{
+		RssFeed: (&feeds.Rss{Feed: feed}).RssFeed(),
+		AtomLink: &atomLink{
+			Href: feedURL,
+			Rel:  "self",
+			Type: "application/rss+xml",
+		},
+		Extra: extras.Channel,
+	}
+	// RssFeed converts items in order, so they line up with feed.Items
+	for i, item := range channel.RssFeed.Items {
+		channel.Items = append(channel.Items, newRSSItem(item, extras.Items[feed.Items[i].Id]))
+	}
+
+	doc := &rssDocument{
+		Version:          "2.0",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+		AtomNamespace:    atomNamespace,
+		Namespaces:       extras.namespaceAttrs(),
+		Channel:          channel,
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// renderRSS returns the output of writeRSS as a string.
+func renderRSS(feed *feeds.Feed, feedURL string, extras feedExtras) (string, error) {
+	var sb strings.Builder
+	if err := writeRSS(&sb, feed, feedURL, extras); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// notesHTML renders plain-text show notes for <content:encoded>: escaped,
+// with blank lines separating paragraphs and single newlines kept as breaks.
+func notesHTML(notes string) string {
+	notes = strings.TrimSpace(strings.ReplaceAll(notes, "\r\n", "\n"))
+	if notes == "" {
+		return ""
+	}
+	var paragraphs []string
+	for _, para := range strings.Split(notes, "\n\n") {
+		if para = strings.TrimSpace(para); para != "" {
+			lines := strings.Split(html.EscapeString(para), "\n")
+			paragraphs = append(paragraphs, "<p>"+strings.Join(lines, "<br>")+"</p>")
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// feedURLFor returns the feed URL for a base URL, ignoring any trailing slash.
+func feedURLFor(baseURL string) string {
+	return strings.TrimRight(baseURL, "/") + "/feed.xml"
+}
+
+// slugLetters spells letters that don't decompose into a base letter and
+// accents
+var slugLetters = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "Æ", "AE", "œ", "oe", "Œ", "OE", "ø", "o", "Ø", "O",
+	"ł", "l", "Ł", "L", "đ", "d", "Đ", "D", "þ", "th", "Þ", "TH", "ı", "i",
+)
+
+// asciiSlug turns a file name into one with only ASCII letters, digits,
+// dots, dashes and underscores, e.g. "Café Ünïcode 🎧.mp3" into
+// "Cafe-Unicode.mp3". Accents are dropped and anything else becomes a dash.
+// A name with nothing left is "episode".
+func asciiSlug(name string) string {
+	ext := filepath.Ext(name)
+	stem := asciiSlugStem(strings.TrimSuffix(name, ext))
+	if stem == "" {
+		stem = "episode"
+	}
+	if ext = asciiSlugStem(ext); ext != "" {
+		stem += "." + strings.ToLower(ext)
+	}
+	return stem
+}
+
+// asciiSlugStem is asciiSlug for a name without its extension. Leading and
+// trailing dots are dropped.
+func asciiSlugStem(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(slugLetters.Replace(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Accents split off by NFD
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-'):
+			if r == '-' {
+				dash = b.Len() > 0
+				continue
+			}
+			if dash {
+				b.WriteByte('-')
+				dash = false
+			}
+			b.WriteRune(r)
+		default:
+			dash = b.Len() > 0
+		}
+	}
+	return strings.Trim(b.String(), ".")
+}
+
+// enclosureName is the file name used in a file's enclosure URL. When
+// numbered, it's prefixed with the zero-padded list position so clients that
+// sort downloads by file name keep the list order. The title is unchanged.
+func enclosureName(displayName string, index, count int, numbered bool) string {
+	if !numbered {
+		return displayName
+	}
+	return fmt.Sprintf("%0*d-%s", numberWidth(count), index+1, displayName)
+}
+
+// servedName is the enclosure file name of the file at index, with the
+// project's naming options applied. The file handler finds files by ID, so
+// a slug still serves the right file, and the title keeps the real name.
+func (p *Podcasterator) servedName(index int) string {
+	name := p.files[index].DisplayName
+	if p.asciiFileNames {
+		name = asciiSlug(name)
+	}
+	return enclosureName(name, index, len(p.files), p.numberEnclosures)
+}
+
+// buildFeed is the feed of files under baseURL, titled title, with the
+// artwork at artworkURL unless it's empty. Each local file's enclosure is
+// named by names, in the same order, or by its DisplayName when names is
+// nil. Publish dates follow list order back from epoch, the first file's,
+// and files that can't be served are left out.
+func buildFeed(files []AudioFile, names []string, title, baseURL, artworkURL string, epoch time.Time) *feeds.Feed {
+	feed := &feeds.Feed{
+		Title:   title,
+		Link:    &feeds.Link{Href: baseURL},
+		Created: time.Now(),
+		Items:   []*feeds.Item{},
+	}
+	if artworkURL != "" {
+		feed.Image = &feeds.Image{Url: artworkURL, Title: title, Link: baseURL}
+	}
+
+	for i, file := range files {
+		name := file.DisplayName
+		if names != nil {
+			name = names[i]
+		}
+		if item, ok := feedItemNamed(file, name, baseURL, episodeTime(epoch, i)); ok {
+			feed.Items = append(feed.Items, item)
+		}
+	}
+	return feed
+}
+
+// feedItemNamed builds the feed item for a file, with name used as the file
+// name in local enclosure URLs; the file handler resolves files by ID, so any
+// name works. Local files are linked under baseURL; external files link
+// straight to their hosted URL. Local files use the cached size, and are
+// only stat'ed when the cache is stale. created is the publish date unless
+// the file has its own. It returns false when a stale file is missing.
+func feedItemNamed(file AudioFile, name, baseURL string, created time.Time) (*feeds.Item, bool) {
+	// An explicit publish date overrides the one derived from list order
+	if !file.PubDate.IsZero() {
+		created = file.PubDate
+	}
+
+	var author *feeds.Author
+	if file.Author != "" {
+		author = &feeds.Author{Name: file.Author}
+	}
+
+	if file.IsExternal() {
+		return &feeds.Item{
+			Title:       file.DisplayName,
+			Description: file.Description,
+			Content:     notesHTML(file.Description),
+			Link:        &feeds.Link{Href: file.ExternalURL},
+			Created:     created,
+			Enclosure: &feeds.Enclosure{
+				Url:    file.ExternalURL,
+				Length: strconv.FormatInt(file.ExternalLength, 10),
+				Type:   file.ExternalType,
+			},
+			Id:     file.ID,
+			Author: author,
+		}, true
+	}
+
+	if file.ModTime.IsZero() {
+		if err := file.refreshStat(); err != nil {
+			return nil, false
+		}
+	}
+
+	encodedName := url.PathEscape(name)
+	fileURL := fmt.Sprintf("%s/files/%s/%s", baseURL, file.ID, encodedName)
+
+	return &feeds.Item{
+		Title:       file.DisplayName,
+		Description: file.Description,
+		Content:     notesHTML(file.Description),
+		Link:        &feeds.Link{Href: fileURL},
+		Created:     created,
+		Enclosure: &feeds.Enclosure{
+			Url:    fileURL,
+			Length: fmt.Sprintf("%d", file.Size),
+			Type:   mimeTypeFor(filepath.Ext(file.ServedPath())),
+		},
+		Id:     file.ID,
+		Author: author,
+	}, true
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/feeds"
+)
+
+// =============================================================================
+// Feed Rendering Tests
+// =============================================================================
+
+func newTestFeed(baseURL string) *feeds.Feed {
+	return &feeds.Feed{
+		Title:       "Test Podcast",
+		Link:        &feeds.Link{Href: baseURL},
+		Description: "Local podcast feed",
+		Created:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Items: []*feeds.Item{
+			{
+				Title:   "episode.mp3",
+				Link:    &feeds.Link{Href: baseURL + "/files/1/episode.mp3"},
+				Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Enclosure: &feeds.Enclosure{
+					Url:    baseURL + "/files/1/episode.mp3",
+					Length: "1234",
+					Type:   "audio/mpeg",
+				},
+				Id: "1",
+			},
+		},
+	}
+}
+
+func TestRenderRSSSelfLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+	}{
+		{"local server", "http://192.168.1.10:8080"},
+		{"public override", "https://example.com/podcast"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			feedURL := feedURLFor(tc.baseURL)
+			rss, err := renderRSS(newTestFeed(tc.baseURL), feedURL, feedExtras{})
+			if err != nil {
+				t.Fatalf("renderRSS() error = %v", err)
+			}
+
+			var doc struct {
+				Channel struct {
+					Links []struct {
+						Href string `xml:"href,attr"`
+						Rel  string `xml:"rel,attr"`
+					} `xml:"http://www.w3.org/2005/Atom link"`
+					Items []struct {
+						Title string `xml:"title"`
+					} `xml:"item"`
+				} `xml:"channel"`
+			}
+			if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+				t.Fatalf("renderRSS() produced invalid XML: %v", err)
+			}
+
+			if len(doc.Channel.Links) != 1 {
+				t.Fatalf("Found %d atom:link elements; want 1", len(doc.Channel.Links))
+			}
+			link := doc.Channel.Links[0]
+			if link.Rel != "self" {
+				t.Errorf("atom:link rel = %q; want %q", link.Rel, "self")
+			}
+			if link.Href != feedURL {
+				t.Errorf("atom:link href = %q; want %q", link.Href, feedURL)
+			}
+			if len(doc.Channel.Items) != 1 {
+				t.Errorf("Found %d items; want 1", len(doc.Channel.Items))
+			}
+		})
+	}
+}
+
+func TestRenderRSSDeclaresAtomNamespace(t *testing.T) {
+	rss, err := renderRSS(newTestFeed("http://localhost:8080"), "http://localhost:8080/feed.xml", feedExtras{})
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+
+	if !strings.HasPrefix(rss, xml.Header) {
+		t.Error("renderRSS() output is missing the XML header")
+	}
+	if !strings.Contains(rss, `xmlns:atom="`+atomNamespace+`"`) {
+		t.Error("renderRSS() output does not declare the atom namespace")
+	}
+}
+
+func TestFeedURLFor(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{"http://192.168.1.10:8080", "http://192.168.1.10:8080/feed.xml"},
+		{"https://example.com/podcast/", "https://example.com/podcast/feed.xml"},
+	}
+
+	for _, tc := range tests {
+		if result := feedURLFor(tc.baseURL); result != tc.expected {
+			t.Errorf("feedURLFor(%q) = %q; want %q", tc.baseURL, result, tc.expected)
+		}
+	}
+}
+
+func TestResolveBaseURL(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	local := "http://192.168.1.10:8080"
+	if result := p.resolveBaseURL(local); result != local {
+		t.Errorf("resolveBaseURL() without override = %q; want %q", result, local)
+	}
+
+	p.publicURL = "https://example.com/podcast/"
+	if result := p.resolveBaseURL(local); result != "https://example.com/podcast" {
+		t.Errorf("resolveBaseURL() with override = %q; want %q", result, "https://example.com/podcast")
+	}
+	p.localOnly = true
+	if result := p.resolveBaseURL(local); result != local {
+		t.Errorf("resolveBaseURL() in local-only mode = %q; want %q", result, local)
+	}
+}
+
+// =============================================================================
+// Feed Item Tests
+// =============================================================================
+
+func TestFeedItemForExternalFile(t *testing.T) {
+	file := AudioFile{
+		ID:             "ext-1",
+		DisplayName:    "Hosted Episode",
+		ExternalURL:    "https://cdn.example.com/ep.mp3",
+		ExternalLength: 5000,
+		ExternalType:   "audio/mpeg",
+	}
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	item, ok := feedItemNamed(file, file.DisplayName, "http://192.168.1.10:8080", created)
+	if !ok {
+		t.Fatal("feedItemNamed() skipped an external file")
+	}
+
+	if item.Enclosure.Url != file.ExternalURL {
+		t.Errorf("Enclosure URL = %q; want %q", item.Enclosure.Url, file.ExternalURL)
+	}
+	if item.Enclosure.Length != "5000" {
+		t.Errorf("Enclosure length = %q; want %q", item.Enclosure.Length, "5000")
+	}
+	if item.Enclosure.Type != "audio/mpeg" {
+		t.Errorf("Enclosure type = %q; want %q", item.Enclosure.Type, "audio/mpeg")
+	}
+	if !item.Created.Equal(created) {
+		t.Errorf("Created = %v; want %v", item.Created, created)
+	}
+}
+
+func TestFeedItemForMissingLocalFile(t *testing.T) {
+	file := AudioFile{ID: "1", DisplayName: "gone.mp3", TempPath: "/nonexistent/gone.mp3"}
+
+	if _, ok := feedItemNamed(file, file.DisplayName, "http://localhost:8080", time.Now()); ok {
+		t.Error("feedItemNamed() should skip local files whose temp copy is missing")
+	}
+}
+
+func TestBuildFeed(t *testing.T) {
+	dir := t.TempDir()
+	mp3 := filepath.Join(dir, "one.mp3")
+	m4a := filepath.Join(dir, "two.m4a")
+	os.WriteFile(mp3, []byte("mp3 audio"), 0644)
+	os.WriteFile(m4a, []byte("m4a"), 0644)
+	files := []AudioFile{
+		{ID: "1", DisplayName: "One", TempPath: mp3},
+		{ID: "2", DisplayName: "Two", TempPath: m4a},
+		{ID: "3", DisplayName: "Gone", TempPath: filepath.Join(dir, "gone.mp3")},
+		{ID: "4", DisplayName: "Hosted", ExternalURL: "https://cdn.example.com/4.mp3", ExternalLength: 42, ExternalType: "audio/mpeg"},
+	}
+	names := []string{"01 One.mp3", "02 Two.m4a", "gone.mp3", "Hosted"}
+	const base = "http://host:8080"
+
+	feed := buildFeed(files, names, "My Show", base, base+"/artwork.jpg", time.Now())
+	if feed.Title != "My Show" || feed.Link.Href != base {
+		t.Errorf("channel = %q at %q", feed.Title, feed.Link.Href)
+	}
+	if feed.Image == nil || feed.Image.Url != base+"/artwork.jpg" || feed.Image.Title != "My Show" || feed.Image.Link != base {
+		t.Errorf("channel image = %+v; want the artwork", feed.Image)
+	}
+
+	// The missing file is left out
+	want := []struct{ url, length, mime string }{
+		{base + "/files/1/01%20One.mp3", "9", "audio/mpeg"},
+		{base + "/files/2/02%20Two.m4a", "3", "audio/mp4"},
+		{"https://cdn.example.com/4.mp3", "42", "audio/mpeg"},
+	}
+	if len(feed.Items) != len(want) {
+		t.Fatalf("feed has %d items; want %d", len(feed.Items), len(want))
+	}
+	for i, w := range want {
+		enc := feed.Items[i].Enclosure
+		if enc.Url != w.url || enc.Length != w.length || enc.Type != w.mime {
+			t.Errorf("item %d enclosure = %s %s %s; want %s %s %s", i, enc.Url, enc.Length, enc.Type, w.url, w.length, w.mime)
+		}
+	}
+	if !feed.Items[0].Created.After(feed.Items[1].Created) {
+		t.Error("first episode isn't the newest")
+	}
+
+	// Without served names or artwork
+	feed = buildFeed(files[:1], nil, "My Show", base, "", time.Now())
+	if feed.Image != nil {
+		t.Errorf("channel image = %+v; want none without artwork", feed.Image)
+	}
+	if got := feed.Items[0].Enclosure.Url; got != base+"/files/1/One" {
+		t.Errorf("enclosure URL = %q; want the display name", got)
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata golden files")
+
+// goldenFeed has enough variety (escaping, external enclosure, description)
+// to catch formatting drift in the streamed output
+func goldenFeed() *feeds.Feed {
+	feed := newTestFeed("http://192.168.1.10:8080")
+	feed.Items = append(feed.Items,
+		&feeds.Item{
+			Title:       "Q&A <live> \"special\"",
+			Description: "Notes & links",
+			Content:     notesHTML("Notes & links"),
+			Link:        &feeds.Link{Href: "https://cdn.example.com/ep%202.mp3"},
+			Created:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Enclosure: &feeds.Enclosure{
+				Url:    "https://cdn.example.com/ep%202.mp3",
+				Length: "99",
+				Type:   "audio/mpeg",
+			},
+			Id: "2",
+		},
+	)
+	return feed
+}
+
+func TestWriteRSSGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeRSS(&buf, goldenFeed(), "http://192.168.1.10:8080/feed.xml", feedExtras{}); err != nil {
+		t.Fatalf("writeRSS: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "feed.golden")
+	if *updateGolden {
+		os.MkdirAll("testdata", 0755)
+		if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("writeRSS output differs from %s\ngot:\n%s\nwant:\n%s", golden, buf.Bytes(), want)
+	}
+}
+
+// TestWriteRSSMatchesBaseline checks the streamed writer against
+// testdata/feed.baseline.golden, which is goldenFeed as rendered by the
+// xml.MarshalIndent writer it replaced, before streaming. It's never
+// rewritten by -update. The one difference is intended: since show notes
+// were added, an item without them leaves out <description> rather than
+// writing an empty one.
+func TestWriteRSSMatchesBaseline(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeRSS(&buf, goldenFeed(), "http://192.168.1.10:8080/feed.xml", feedExtras{}); err != nil {
+		t.Fatalf("writeRSS: %v", err)
+	}
+
+	baseline, err := os.ReadFile(filepath.Join("testdata", "feed.baseline.golden"))
+	if err != nil {
+		t.Fatalf("read baseline: %v", err)
+	}
+	want := bytes.Replace(baseline, []byte("      <description></description>\n"), nil, 1)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("writeRSS output differs from the baseline writer's\ngot:\n%s\nwant:\n%s", buf.Bytes(), want)
+	}
+}
+
+func TestRenderRSSMatchesWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	writeRSS(&buf, goldenFeed(), "http://example.com/feed.xml", feedExtras{})
+	rss, err := renderRSS(goldenFeed(), "http://example.com/feed.xml", feedExtras{})
+	if err != nil {
+		t.Fatalf("renderRSS: %v", err)
+	}
+	if rss != buf.String() {
+		t.Error("renderRSS and writeRSS output differ")
+	}
+}
+
+func TestFeedItemUsesStatCache(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	src := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(src, make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p.addFile(src)
+	file := &p.files[0]
+
+	if file.Size != 100 || file.ModTime.IsZero() {
+		t.Fatalf("cache after add = %d, %v; want 100 and a mod time", file.Size, file.ModTime)
+	}
+
+	// A fresh cache is trusted without touching the disk
+	os.WriteFile(file.TempPath, make([]byte, 250), 0644)
+	item, _ := feedItemNamed(*file, file.DisplayName, "http://host", time.Now())
+	if item.Enclosure.Length != "100" {
+		t.Errorf("Length with fresh cache = %s; want 100", item.Enclosure.Length)
+	}
+
+	// Renaming invalidates the cache so the next build re-stats
+	if err := p.applyRename(file, "renamed"); err != nil {
+		t.Fatalf("applyRename: %v", err)
+	}
+	if !file.ModTime.IsZero() {
+		t.Error("rename did not invalidate the cache")
+	}
+	item, _ = feedItemNamed(*file, file.DisplayName, "http://host", time.Now())
+	if item.Enclosure.Length != "250" {
+		t.Errorf("Length after rename = %s; want 250", item.Enclosure.Length)
+	}
+
+	if err := file.refreshStat(); err != nil {
+		t.Fatalf("refreshStat: %v", err)
+	}
+	info, _ := os.Stat(file.TempPath)
+	if file.Size != info.Size() || !file.ModTime.Equal(info.ModTime()) {
+		t.Errorf("cache = %d, %v; want %d, %v", file.Size, file.ModTime, info.Size(), info.ModTime())
+	}
+}
+
+func TestNotesHTML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"  \n ", ""},
+		{"Hello", "<p>Hello</p>"},
+		{"Q&A <live>", "<p>Q&amp;A &lt;live&gt;</p>"},
+		{"line one\nline two", "<p>line one<br>line two</p>"},
+		{"first\r\n\r\nsecond\n\n\n", "<p>first</p>\n<p>second</p>"},
+	}
+
+	for _, tc := range tests {
+		if got := notesHTML(tc.in); got != tc.want {
+			t.Errorf("notesHTML(%q) = %q; want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestFeedItemShowNotes(t *testing.T) {
+	file := AudioFile{ID: "1", DisplayName: "ep.mp3", TempPath: "/tmp/ep.mp3", Size: 10, ModTime: time.Now()}
+
+	item, _ := feedItemNamed(file, file.DisplayName, "http://localhost:8080", time.Now())
+	rss, err := renderRSS(&feeds.Feed{Title: "T", Description: "D", Link: &feeds.Link{}, Items: []*feeds.Item{item}}, "http://localhost:8080/feed.xml", feedExtras{})
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+	if strings.Contains(rss, "<description></description>") || strings.Contains(rss, "content:encoded>") {
+		t.Errorf("item without notes has empty notes elements:\n%s", rss)
+	}
+
+	file.Description = "Chapter 1\nThe beginning"
+	item, _ = feedItemNamed(file, file.DisplayName, "http://localhost:8080", time.Now())
+	if item.Description != file.Description {
+		t.Errorf("Description = %q; want %q", item.Description, file.Description)
+	}
+	if item.Content != "<p>Chapter 1<br>The beginning</p>" {
+		t.Errorf("Content = %q", item.Content)
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// List view modes. Detailed is the default and stored as "".
+const (
+	listViewDetailed = ""
+	listViewCompact  = "compact"
+)
+
+// fileRow is a file list row: a checkbox that ticks the file for bulk
+// actions, then the view mode's content. It shows the row's menu when
+// right-clicked (or two-finger tapped), so every action is at hand in
+// either view mode.
+type fileRow struct {
+	widget.BaseWidget
+	check   *widget.Check
+	content *fyne.Container
+	onMenu  func(pos fyne.Position)
+}
+
+func newFileRow(content *fyne.Container) *fileRow {
+	r := &fileRow{check: widget.NewCheck("", nil), content: content}
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+func (r *fileRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewBorder(nil, nil, r.check, nil, r.content))
+}
+
+// TappedSecondary implements fyne.SecondaryTappable
+func (r *fileRow) TappedSecondary(e *fyne.PointEvent) {
+	if r.onMenu != nil {
+		r.onMenu(e.AbsolutePosition)
+	}
+}
+
+// newFileList builds the file list for the current view mode. The detailed
+// row has a button per action plus a second line of file details; the
+// compact row is just the name and a menu with the same actions. Both show
+// that menu on right-click.
+func (p *Podcasterator) newFileList() *widget.List {
+	var list *widget.List
+	if p.listView == listViewCompact {
+		list = widget.NewList(
+			func() int { return len(p.files) },
+			func() fyne.CanvasObject {
+				return newFileRow(container.NewBorder(nil, nil,
+					p.newTipButton("", theme.MoreVerticalIcon(), "Episode actions", nil), nil,
+					widget.NewLabel(""),
+				))
+			},
+			p.updateCompactRow,
+		)
+	} else {
+		list = widget.NewList(
+			func() int { return len(p.files) },
+			func() fyne.CanvasObject {
+				details := widget.NewLabel("")
+				details.Importance = widget.LowImportance
+				details.SizeName = theme.SizeNameCaptionText
+				return newFileRow(container.NewHBox(
+					p.newTipButton("", theme.MoveUpIcon(), "Move up", nil),
+					p.newTipButton("", theme.MoveDownIcon(), "Move down", nil),
+					p.newTipButton("", theme.DocumentCreateIcon(), "Rename", nil),
+					p.newTipButton("", theme.DocumentIcon(), "Show notes", nil),
+					p.newTipButton("", theme.MediaPhotoIcon(), "Episode artwork", nil),
+					p.newTipButton("", theme.MediaReplayIcon(), "Replace audio with another file", nil),
+					p.newTipButton("", theme.ViewRefreshIcon(), "Reload from the original file", nil),
+					p.newTipButton("", theme.FolderOpenIcon(), "Show the original file", nil),
+					p.newTipButton("", theme.DeleteIcon(), "Remove from the podcast", nil),
+					container.NewVBox(widget.NewLabel(""), details),
+				))
+			},
+			p.updateDetailedRow,
+		)
+	}
+
+	// Selecting a row opens its details in a side panel
+	list.OnSelected = func(id widget.ListItemID) {
+		if id < len(p.files) {
+			p.selectedID = p.files[id].ID
+			p.showEpisodeDetail(p.files[id].ID)
+		}
+	}
+	list.OnUnselected = func(widget.ListItemID) {
+		p.selectedID = ""
+	}
+	return list
+}
+
+func (p *Podcasterator) updateDetailedRow(i widget.ListItemID, o fyne.CanvasObject) {
+	row := o.(*fileRow)
+	c := row.content
+	upBtn := c.Objects[0].(*tipButton)
+	downBtn := c.Objects[1].(*tipButton)
+	renameBtn := c.Objects[2].(*tipButton)
+	notesBtn := c.Objects[3].(*tipButton)
+	coverBtn := c.Objects[4].(*tipButton)
+	replaceBtn := c.Objects[5].(*tipButton)
+	reloadBtn := c.Objects[6].(*tipButton)
+	revealBtn := c.Objects[7].(*tipButton)
+	delBtn := c.Objects[8].(*tipButton)
+	text := c.Objects[9].(*fyne.Container)
+	label := text.Objects[0].(*widget.Label)
+	details := text.Objects[1].(*widget.Label)
+
+	if i >= len(p.files) {
+		return
+	}
+	file := p.files[i]
+	setRowTitle(label, p.fileRowTitle(file), file.OriginalMissing)
+	details.SetText(fileRowDetails(file))
+	p.bindRowCheck(row, file.ID)
+	row.onMenu = func(pos fyne.Position) { p.showFileRowMenu(i, pos) }
+
+	upBtn.OnTapped = func() { p.moveUp(i) }
+	downBtn.OnTapped = func() { p.moveDown(i) }
+	renameBtn.OnTapped = func() { p.renameFile(i) }
+	notesBtn.OnTapped = func() { p.editNotes(i) }
+	coverBtn.OnTapped = func() { p.editEpisodeArtwork(i) }
+	replaceBtn.OnTapped = func() { p.replaceFile(i) }
+	reloadBtn.OnTapped = func() { p.reloadFile(i) }
+	revealBtn.OnTapped = func() { p.revealOriginal(i) }
+	delBtn.OnTapped = func() { p.deleteFile(i) }
+
+	if file.IsExternal() {
+		replaceBtn.Disable()
+	} else {
+		replaceBtn.Enable()
+	}
+
+	// Original may have been moved or deleted since it was added
+	if hasOriginal(file) {
+		reloadBtn.Enable()
+		revealBtn.Enable()
+	} else {
+		reloadBtn.Disable()
+		revealBtn.Disable()
+	}
+}
+
+// bindRowCheck points a reused row's checkbox at the file with id
+func (p *Podcasterator) bindRowCheck(row *fileRow, id string) {
+	row.check.OnChanged = nil
+	row.check.SetChecked(p.checked[id])
+	row.check.OnChanged = func(on bool) { p.setChecked(id, on) }
+}
+
+// setRowTitle shows a row's title, greyed out when its original is missing
+func setRowTitle(label *widget.Label, text string, originalMissing bool) {
+	importance := widget.MediumImportance
+	if originalMissing {
+		importance = widget.LowImportance
+	}
+	if label.Text == text && label.Importance == importance {
+		return
+	}
+	label.Importance = importance
+	label.Text = text
+	label.Refresh()
+}
+
+func (p *Podcasterator) updateCompactRow(i widget.ListItemID, o fyne.CanvasObject) {
+	row := o.(*fileRow)
+	c := row.content
+	label := c.Objects[0].(*widget.Label)
+	menuBtn := c.Objects[1].(*tipButton)
+
+	if i >= len(p.files) {
+		return
+	}
+	setRowTitle(label, p.fileRowTitle(p.files[i]), p.files[i].OriginalMissing)
+	p.bindRowCheck(row, p.files[i].ID)
+	row.onMenu = func(pos fyne.Position) { p.showFileRowMenu(i, pos) }
+	menuBtn.OnTapped = func() {
+		if p.window == nil {
+			return
+		}
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
+		pos.Y += menuBtn.Size().Height
+		p.showFileRowMenu(i, pos)
+	}
+}
+
+// showFileRowMenu pops up the actions for the file at index at pos
+func (p *Podcasterator) showFileRowMenu(index int, pos fyne.Position) {
+	if p.window == nil || index < 0 || index >= len(p.files) {
+		return
+	}
+	widget.ShowPopUpMenuAtPosition(p.fileRowMenu(index), p.window.Canvas(), pos)
+}
+
+// fileRowTitle is the row's main line: name with season and status prefixes
+func (p *Podcasterator) fileRowTitle(file AudioFile) string {
+	text := truncateFilename(file.DisplayName)
+	if file.Season > 0 {
+		text = fmt.Sprintf("S%d · %s", file.Season, text)
+	}
+	if p.processing[file.ID] {
+		text = "⏳ " + text
+	}
+	return text
+}
+
+// fileRowDetails is the detailed row's second line: size, where the file
+// came from and any status markers
+func fileRowDetails(file AudioFile) string {
+	var parts []string
+
+	size := file.Size
+	if file.IsExternal() {
+		size = file.ExternalLength
+	}
+	if size > 0 {
+		parts = append(parts, formatBytes(size))
+	}
+	if file.Duration > 0 {
+		parts = append(parts, formatDuration(file.Duration))
+	}
+
+	switch {
+	case file.IsExternal():
+		parts = append(parts, "🌐 "+urlHost(file.ExternalURL))
+	case file.SourceURL != "":
+		parts = append(parts, "⬇ "+urlHost(file.SourceURL))
+	case file.OriginalPath != "":
+		parts = append(parts, filepath.Dir(file.OriginalPath))
+		if file.OriginalMissing {
+			parts = append(parts, "⚠ original missing")
+		}
+	}
+
+	if file.ServeOriginal {
+		parts = append(parts, "🔗 serving original")
+	}
+	if file.StartOffset > 0 {
+		parts = append(parts, "✂ starts at "+formatStartOffset(file.StartOffset))
+	}
+	if file.Description != "" {
+		parts = append(parts, "📝 notes")
+	}
+	if file.ArtworkPath != "" {
+		parts = append(parts, "🖼 artwork")
+	}
+	if file.TranscriptPath != "" {
+		parts = append(parts, "💬 transcript")
+	}
+	return strings.Join(parts, " · ")
+}
+
+func urlHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// fileRowMenu holds the row actions: the compact row's menu and the
+// right-click menu of both views, matching the detailed row's buttons
+func (p *Podcasterator) fileRowMenu(i int) *fyne.Menu {
+	file := p.files[i]
+
+	replace := fyne.NewMenuItem("Replace Audio…", func() { p.replaceFile(i) })
+	replace.Disabled = file.IsExternal()
+	reload := fyne.NewMenuItem("Reload from Source", func() { p.reloadFile(i) })
+	reload.Disabled = !hasOriginal(file)
+	reveal := fyne.NewMenuItem("Show Original", func() { p.revealOriginal(i) })
+	reveal.Disabled = !hasOriginal(file)
+	startAt := fyne.NewMenuItem("Start At…", func() { p.editStartOffset(i) })
+	startAt.Disabled = file.IsExternal()
+	transcript := fyne.NewMenuItem("Transcript…", func() { p.editTranscript(i) })
+	transcript.Disabled = file.IsExternal()
+
+	return fyne.NewMenu("",
+		fyne.NewMenuItem("Move Up", func() { p.moveUp(i) }),
+		fyne.NewMenuItem("Move Down", func() { p.moveDown(i) }),
+		fyne.NewMenuItem("Move to Top", func() { p.moveToTop(i) }),
+		fyne.NewMenuItem("Move to Bottom", func() { p.moveToBottom(i) }),
+		fyne.NewMenuItem("Rename…", func() { p.renameFile(i) }),
+		fyne.NewMenuItem("Show Notes…", func() { p.editNotes(i) }),
+		fyne.NewMenuItem("Episode Artwork…", func() { p.editEpisodeArtwork(i) }),
+		transcript,
+		startAt,
+		replace,
+		reload,
+		reveal,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Delete", func() { p.deleteFile(i) }),
+	)
+}
+
+// setListView switches the list layout, rebuilding the list in place
+func (p *Podcasterator) setListView(mode string) {
+	if mode == p.listView {
+		return
+	}
+	p.listView = mode
+	p.markDirty()
+
+	if p.fileListHolder == nil {
+		return
+	}
+	p.hideEpisodeDetail()
+	p.selectedID = ""
+	p.fileList = p.newFileList()
+	p.fileListHolder.Objects = []fyne.CanvasObject{p.fileList}
+	p.fileListHolder.Refresh()
+}
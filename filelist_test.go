@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// =============================================================================
+// File List View Tests
+// =============================================================================
+
+func TestFileRowDetails(t *testing.T) {
+	original := filepath.Join(t.TempDir(), "ep.mp3")
+	os.WriteFile(original, []byte("audio"), 0644)
+
+	tests := []struct {
+		name string
+		file AudioFile
+		want string
+	}{
+		{"local file", AudioFile{OriginalPath: original, Size: 2048}, "2.0 KB · " + filepath.Dir(original)},
+		{"missing original", AudioFile{OriginalPath: "/gone/ep.mp3", OriginalMissing: true}, "/gone · ⚠ original missing"},
+		{"downloaded", AudioFile{SourceURL: "https://cdn.example.com/a.mp3", Size: 10}, "10 B · ⬇ cdn.example.com"},
+		{"external", AudioFile{ExternalURL: "https://host.example/b.mp3", ExternalLength: 1024}, "1.0 KB · 🌐 host.example"},
+		{"serving original", AudioFile{OriginalPath: original, ServeOriginal: true}, filepath.Dir(original) + " · 🔗 serving original"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fileRowDetails(tc.file); got != tc.want {
+				t.Errorf("fileRowDetails() = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// newListTestPodcasterator has three files added from disk
+func newListTestPodcasterator(t *testing.T) (*Podcasterator, func()) {
+	p, cleanup := newTestPodcasterator(t)
+	srcDir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.mp3", "c.mp3"} {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte("audio"), 0644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		p.addFile(path)
+	}
+	return p, cleanup
+}
+
+func TestFileListViewsKeepActionsWorking(t *testing.T) {
+	test.NewTempApp(t)
+
+	for _, mode := range []string{listViewDetailed, listViewCompact} {
+		t.Run("mode "+mode, func(t *testing.T) {
+			p, cleanup := newListTestPodcasterator(t)
+			defer cleanup()
+			p.listView = mode
+			p.fileList = p.newFileList()
+
+			row := p.fileList.CreateItem()
+			p.fileList.UpdateItem(0, row)
+
+			var label *widget.Label
+			if mode == listViewCompact {
+				label = row.(*fileRow).content.Objects[0].(*widget.Label)
+			} else {
+				label = row.(*fileRow).content.Objects[9].(*fyne.Container).Objects[0].(*widget.Label)
+			}
+			if label.Text != "a.mp3" {
+				t.Errorf("row label = %q; want %q", label.Text, "a.mp3")
+			}
+
+			// Detailed rows act through their buttons, compact rows through the menu
+			act := func(label string, button int) {
+				if mode == listViewDetailed {
+					row := p.fileList.CreateItem()
+					p.fileList.UpdateItem(0, row)
+					row.(*fileRow).content.Objects[button].(*tipButton).OnTapped()
+					return
+				}
+				for _, item := range p.fileRowMenu(0).Items {
+					if item.Label == label {
+						item.Action()
+						return
+					}
+				}
+				t.Fatalf("menu has no %q item", label)
+			}
+
+			act("Move Down", 1)
+			if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,a.mp3,c.mp3" {
+				t.Errorf("after Move Down order = %s", got)
+			}
+			act("Delete", 8)
+			if got := strings.Join(displayNames(p.files), ","); got != "a.mp3,c.mp3" {
+				t.Errorf("after Delete order = %s", got)
+			}
+		})
+	}
+}
+
+// findPopUpMenu finds the pop-up menu inside a canvas overlay
+func findPopUpMenu(o fyne.CanvasObject) *widget.PopUpMenu {
+	switch o := o.(type) {
+	case nil:
+		return nil
+	case *widget.PopUpMenu:
+		return o
+	case *fyne.Container:
+		for _, child := range o.Objects {
+			if menu := findPopUpMenu(child); menu != nil {
+				return menu
+			}
+		}
+	case fyne.Widget:
+		for _, child := range test.WidgetRenderer(o).Objects() {
+			if menu := findPopUpMenu(child); menu != nil {
+				return menu
+			}
+		}
+	}
+	return nil
+}
+
+func TestFileRowContextMenu(t *testing.T) {
+	test.NewTempApp(t)
+	for _, mode := range []string{listViewDetailed, listViewCompact} {
+		t.Run("mode "+mode, func(t *testing.T) {
+			p, cleanup := newListTestPodcasterator(t)
+			defer cleanup()
+			p.listView = mode
+			p.fileList = p.newFileList()
+			p.window = test.NewTempWindow(t, p.fileList)
+
+			row := p.fileList.CreateItem()
+			p.fileList.UpdateItem(1, row)
+			test.TapSecondary(row.(fyne.SecondaryTappable))
+
+			menu := findPopUpMenu(p.window.Canvas().Overlays().Top())
+			if menu == nil {
+				t.Fatal("right-clicking a row didn't show a menu")
+			}
+			test.Tap(menu.Items[0].(fyne.Tappable)) // Move Up
+			if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,a.mp3,c.mp3" {
+				t.Errorf("after the menu's Move Up order = %s; want the right-clicked row moved", got)
+			}
+		})
+	}
+}
+
+func TestSetListViewPersists(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.setListView(listViewCompact)
+	if !p.dirty {
+		t.Error("setListView() did not mark state dirty")
+	}
+	p.saveState()
+
+	p2 := &Podcasterator{tempDir: p.tempDir, configDir: p.configDir}
+	p2.loadState()
+	if p2.listView != listViewCompact {
+		t.Errorf("listView after reload = %q; want %q", p2.listView, listViewCompact)
+	}
+}
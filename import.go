@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/google/uuid"
+)
+
+// copyProgressThreshold is the size above which copying a file shows a
+// progress dialog. Smaller files copy in the background without one.
+const copyProgressThreshold = 32 << 20
+
+// importWorkers bounds how many files a batch import copies at once
+const importWorkers = 4
+
+// contextReader fails reads once ctx is cancelled, so a long io.Copy stops
+// between chunks
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(b)
+}
+
+// copyWithProgress copies src to dst, reporting bytes written against the
+// size of src, and returns the SHA-256 of the data, hashed as it streams
+// through. Cancelling ctx stops the copy; dst is removed on any error.
+func copyWithProgress(ctx context.Context, src, dst string, onProgress func(written, total int64)) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	counter := &progressWriter{total: info.Size(), onProgress: onProgress}
+	_, err = io.Copy(io.MultiWriter(out, h, counter), contextReader{ctx: ctx, r: in})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// importFile copies the audio file at path into its own folder under
+// tempDir and returns the new playlist entry. The file is named after its
+// embedded title when it has one. A transcript beside the original with the
+// same base name is copied along with it. With verify the copy is checked
+// against the original. On error nothing is left behind, but for a copy cut
+// short, which is kept to resume (see copyResuming).
+func importFile(ctx context.Context, path, tempDir string, keepExtension, verify bool, onProgress func(written, total int64)) (AudioFile, error) {
+	id := uuid.New().String()
+	fileName := podcastFileName(filepath.Base(path), keepExtension)
+
+	// Prefer the embedded title; untagged files keep their file name
+	tags, _ := readTags(path)
+	if name := tagFileName(tags.Title, filepath.Ext(fileName)); name != "" {
+		fileName = name
+	}
+
+	dir := filepath.Join(tempDir, id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return AudioFile{}, err
+	}
+	tempPath := filepath.Join(dir, fileName)
+	sum, err := importAudio(ctx, path, tempPath, filepath.Join(tempDir, partialDirName), verify, onProgress)
+	if err != nil {
+		os.RemoveAll(dir)
+		return AudioFile{}, err
+	}
+
+	file := AudioFile{
+		ID:           id,
+		OriginalPath: path,
+		TempPath:     tempPath,
+		DisplayName:  fileName,
+		SHA256:       sum,
+	}
+	tags.apply(&file)
+
+	// A transcript that fails to copy isn't worth losing the audio over
+	if transcript := sidecarTranscript(path); transcript != "" {
+		if dst, err := copyTranscript(transcript, tempPath); err == nil {
+			file.TranscriptPath = dst
+		} else {
+			fmt.Printf("Couldn't copy transcript %s: %v\n", transcript, err)
+		}
+	}
+	return file, nil
+}
+
+// isAdded reports whether the file at path is already in the list
+func (p *Podcasterator) isAdded(path string) bool {
+	for _, f := range p.files {
+		if f.OriginalPath == path {
+			return true
+		}
+	}
+	return false
+}
+
+// contentSums is the hashes of the audio in the list, and of a batch's
+// files as they're added, for content dedup
+type contentSums map[string]bool
+
+// claim records sum for a file about to be added, reporting false when a
+// file with the same audio is already there
+func (c contentSums) claim(sum string) bool {
+	if c[sum] {
+		return false
+	}
+	c[sum] = true
+	return true
+}
+
+// contentSums is the hashes of the audio already in the list, or nil with
+// content dedup off
+func (p *Podcasterator) contentSums() contentSums {
+	if !p.dedupByContent {
+		return nil
+	}
+	known := contentSums{}
+	for _, f := range p.files {
+		if f.SHA256 != "" {
+			known[f.SHA256] = true
+		}
+	}
+	return known
+}
+
+// showCopyProgress shows a progress dialog titled title for copying path
+// when it's large enough to need one. Closing it calls cancel. It returns
+// the dialog and its bar, or nils for a small file.
+func (p *Podcasterator) showCopyProgress(title, path string, cancel func()) (*widget.ProgressBar, dialog.Dialog) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < copyProgressThreshold || p.window == nil {
+		return nil, nil
+	}
+	bar := widget.NewProgressBar()
+	d := dialog.NewCustom(title, "Cancel",
+		container.NewVBox(widget.NewLabel(filepath.Base(path)), bar), p.window)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(450, 150))
+	d.Show()
+	return bar, d
+}
+
+// copyProgress is a copy's onProgress that shows it on bar from any
+// goroutine, or nil when bar is nil
+func copyProgress(bar *widget.ProgressBar) func(written, total int64) {
+	if bar == nil {
+		return nil
+	}
+	lastPercent := -1
+	return func(written, total int64) {
+		if total <= 0 {
+			return
+		}
+		// Only hop to the UI thread when the visible value changes
+		percent := int(written * 100 / total)
+		if percent != lastPercent {
+			lastPercent = percent
+			fyne.Do(func() { bar.SetValue(float64(percent) / 100) })
+		}
+	}
+}
+
+// addFileInBackground copies a file off the UI thread, with a cancellable
+// progress dialog for large files. The file joins the list once the copy
+// is complete.
+func (p *Podcasterator) addFileInBackground(path string) {
+	if p.tempDirErr() != nil || p.isAdded(path) {
+		return
+	}
+	if len(p.transcodablePaths([]string{path})) == 0 || !p.requireFreeSpace([]string{path}) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bar, d := p.showCopyProgress("Copying", path, cancel)
+	tempDir, keepExtension, verify, projectID := p.projectTempDir(), p.keepExtension, p.verifyCopies, p.projectID
+	go func() {
+		file, err := importFile(ctx, path, tempDir, keepExtension, verify, copyProgress(bar))
+		cancelled := ctx.Err() != nil
+		cancel()
+
+		fyne.Do(func() {
+			if d != nil {
+				d.Hide()
+			}
+			if err != nil {
+				if !cancelled && p.window != nil {
+					dialog.ShowError(fmt.Errorf("couldn't copy %s: %w", filepath.Base(path), err), p.window)
+				}
+				return
+			}
+			results := []importResult{{Path: path, File: file}}
+			if p.projectID != projectID {
+				discardImport(results)
+				return
+			}
+			before := len(p.files)
+			if duplicates, _ := p.finishImport(results); duplicates > 0 {
+				p.showAlreadyAdded(path)
+				return
+			}
+			// Small copies finish too quickly to need telling about
+			if d != nil {
+				p.notifyImported(len(p.files)-before, 0)
+			}
+		})
+	}()
+}
+
+// importResult is the outcome of copying one file of a batch
+type importResult struct {
+	Path string
+	File AudioFile
+	Err  error
+}
+
+// importFiles copies paths into tempDir with at most workers copies running
+// at once. Results are in the order of paths. onDone, if set, is called from
+// the workers as each file finishes.
+func importFiles(ctx context.Context, paths []string, tempDir string, keepExtension, verify bool, workers int, onDone func(done, total int)) []importResult {
+	results := make([]importResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				file, err := importFile(ctx, paths[i], tempDir, keepExtension, verify, nil)
+				results[i] = importResult{Path: paths[i], File: file, Err: err}
+				if onDone != nil {
+					mu.Lock()
+					done++
+					onDone(done, len(paths))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// folderFiles lists the supported audio files under dir in natural order
+// (see naturalPathLess), or with walkOrder in the walk's plain name order
+func folderFiles(dir string, walkOrder bool) []string {
+	var paths []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if isSupportedFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if !walkOrder {
+		sort.SliceStable(paths, func(i, j int) bool {
+			return naturalPathLess(paths[i], paths[j])
+		})
+	}
+	return paths
+}
+
+// pendingImports drops paths that are already in the list or repeated
+// within paths
+func (p *Podcasterator) pendingImports(paths []string) []string {
+	seen := make(map[string]bool, len(p.files)+len(paths))
+	for _, f := range p.files {
+		seen[f.OriginalPath] = true
+	}
+	var pending []string
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			pending = append(pending, path)
+		}
+	}
+	return pending
+}
+
+// finishImport adds the copied files of a batch in their original order and
+// returns how many were duplicates, along with the errors of those that
+// failed. Files added to the list while the batch was copying, and with
+// content dedup on, files whose audio is already in the list or earlier in
+// the batch, are duplicates: they're skipped and their copies removed. The
+// audio is compared by the hash taken while it was copied, so no source is
+// read twice.
+func (p *Podcasterator) finishImport(results []importResult) (int, error) {
+	var files []AudioFile
+	duplicates := 0
+	var errs []error
+	known := p.contentSums()
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(r.Path), r.Err))
+		case p.isAdded(r.Path), known != nil && r.File.SHA256 != "" && !known.claim(r.File.SHA256):
+			os.RemoveAll(filepath.Dir(r.File.TempPath))
+			duplicates++
+		default:
+			files = append(files, r.File)
+		}
+	}
+	p.appendFiles(files)
+	return duplicates, errors.Join(errs...)
+}
+
+// notifyImported sends a system notification saying how many files an
+// import added and how many it skipped as duplicates, so a long copy
+// finishing is noticed with the window in the background. Nothing is sent
+// when nothing was added or skipped.
+func (p *Podcasterator) notifyImported(added, duplicates int) {
+	if p.app == nil || (added <= 0 && duplicates <= 0) {
+		return
+	}
+	message := fmt.Sprintf("Added %s to %s", plural(added, "file", "files"), p.podcastName)
+	if duplicates > 0 {
+		message += fmt.Sprintf(", skipped %s", plural(duplicates, "duplicate", "duplicates"))
+	}
+	p.app.SendNotification(fyne.NewNotification("Import Finished", message))
+}
+
+// plural is n followed by one or many to suit it
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// showAlreadyAdded explains that the file dropped from path wasn't added
+// because it, or the same audio from elsewhere, is already in the list
+func (p *Podcasterator) showAlreadyAdded(path string) {
+	if p.window == nil {
+		return
+	}
+	dialog.ShowInformation("Already Added",
+		fmt.Sprintf("%s is already in the list, so it wasn't added again.", filepath.Base(path)), p.window)
+}
+
+// discardImport removes the copies of a batch that won't be added
+func discardImport(results []importResult) {
+	for _, r := range results {
+		if r.Err == nil {
+			os.RemoveAll(filepath.Dir(r.File.TempPath))
+		}
+	}
+}
+
+// addFiles copies several files and adds them, blocking until every copy is
+// done. The UI uses addFilesInBackground instead.
+func (p *Podcasterator) addFiles(paths []string) error {
+	if p.tempDirErr() != nil {
+		return nil
+	}
+	pending := p.pendingImports(paths)
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := checkFreeSpace(p.tempDir, sourcesSize(pending)); err != nil {
+		return err
+	}
+	results := importFiles(context.Background(), pending, p.projectTempDir(), p.keepExtension, p.verifyCopies, importWorkers, nil)
+	_, err := p.finishImport(results)
+	return err
+}
+
+// addFilesInBackground copies a batch of files off the UI thread, showing
+// overall progress with a Cancel button. Cancelling stops the remaining
+// copies; files already copied are still added. The list is refreshed once
+// when the batch is done.
+func (p *Podcasterator) addFilesInBackground(paths []string) {
+	if p.tempDirErr() != nil {
+		return
+	}
+	pending := p.transcodablePaths(p.pendingImports(paths))
+	if len(pending) == 0 || !p.requireFreeSpace(pending) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var bar *widget.ProgressBar
+	var status *widget.Label
+	var d dialog.Dialog
+	if p.window != nil {
+		bar = widget.NewProgressBar()
+		status = widget.NewLabel(fmt.Sprintf("Importing 0/%d", len(pending)))
+		d = dialog.NewCustom("Importing", "Cancel", container.NewVBox(status, bar), p.window)
+		d.SetOnClosed(cancel)
+		d.Resize(fyne.NewSize(450, 150))
+		d.Show()
+	}
+
+	tempDir, keepExtension, verify, projectID := p.projectTempDir(), p.keepExtension, p.verifyCopies, p.projectID
+	go func() {
+		results := importFiles(ctx, pending, tempDir, keepExtension, verify, importWorkers, func(done, total int) {
+			if bar == nil {
+				return
+			}
+			fyne.Do(func() {
+				status.SetText(fmt.Sprintf("Importing %d/%d", done, total))
+				bar.SetValue(float64(done) / float64(total))
+			})
+		})
+		cancelled := ctx.Err() != nil
+		cancel()
+
+		fyne.Do(func() {
+			if d != nil {
+				d.Hide()
+			}
+			// The files belong to the project that was active when they were dropped
+			if p.projectID != projectID {
+				discardImport(results)
+				return
+			}
+			before := len(p.files)
+			duplicates, err := p.finishImport(results)
+			p.notifyImported(len(p.files)-before, duplicates)
+			if err != nil && !cancelled && p.window != nil {
+				dialog.ShowError(fmt.Errorf("couldn't copy some files:\n%w", err), p.window)
+			}
+		})
+	}()
+}
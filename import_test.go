@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+// =============================================================================
+// File Import Tests
+// =============================================================================
+
+func TestCopyWithProgress(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.mp3")
+	data := bytes.Repeat([]byte("audio"), 100000)
+	os.WriteFile(src, data, 0644)
+	dst := filepath.Join(dir, "dst.mp3")
+
+	var last, total int64
+	calls := 0
+	sum, err := copyWithProgress(context.Background(), src, dst, func(w, tot int64) {
+		if w < last {
+			t.Errorf("progress went backwards: %d after %d", w, last)
+		}
+		last, total = w, tot
+		calls++
+	})
+	if err != nil {
+		t.Fatalf("copyWithProgress() error = %v", err)
+	}
+	if calls == 0 || last != int64(len(data)) || total != int64(len(data)) {
+		t.Errorf("progress ended at %d/%d after %d calls; want %d/%d", last, total, calls, len(data), len(data))
+	}
+	if got, _ := os.ReadFile(dst); !bytes.Equal(got, data) {
+		t.Error("copied data differs from source")
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256(data)); sum != want {
+		t.Errorf("copyWithProgress() hash = %s; want %s", sum, want)
+	}
+}
+
+func TestCopyWithProgressCancelled(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.mp3")
+	os.WriteFile(src, []byte("audio"), 0644)
+	dst := filepath.Join(dir, "dst.mp3")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := copyWithProgress(ctx, src, dst, nil); err == nil {
+		t.Fatal("copyWithProgress() with a cancelled context succeeded")
+	}
+	if fileExists(dst) {
+		t.Error("partial copy left behind")
+	}
+}
+
+func TestImportAudioErrors(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.mp3")
+	os.WriteFile(src, []byte("audio"), 0644)
+
+	tests := []struct {
+		name     string
+		src, dst string
+	}{
+		{"source not found", filepath.Join(dir, "missing.mp3"), filepath.Join(dir, "dst.mp3")},
+		{"destination folder missing", src, filepath.Join(dir, "missing", "dst.mp3")},
+	}
+	for _, tc := range tests {
+		for _, partialDir := range []string{"", filepath.Join(dir, partialDirName)} {
+			t.Run(fmt.Sprintf("%s partial dir %q", tc.name, partialDir), func(t *testing.T) {
+				if _, err := importAudio(context.Background(), tc.src, tc.dst, partialDir, false, nil); err == nil {
+					t.Error("importAudio() succeeded")
+				}
+				if fileExists(tc.dst) {
+					t.Error("importAudio() left a copy behind")
+				}
+			})
+		}
+	}
+}
+
+func TestImportFileCleansUpOnError(t *testing.T) {
+	tempDir := t.TempDir()
+	if _, err := importFile(context.Background(), filepath.Join(t.TempDir(), "missing.mp3"), tempDir, false, false, nil); err == nil {
+		t.Fatal("importFile() of a missing file succeeded")
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("temp dir has %d entries after a failed import; want 0", len(entries))
+	}
+}
+
+func TestAddFileInBackground(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	os.WriteFile(src, []byte("audio"), 0644)
+
+	// The import lands on p.files from another goroutine, so it's watched
+	// for through the published snapshot, as the server would see it
+	p.live = &FeedServer{tempDir: p.tempDir}
+	p.publishTo(p.live)
+	imported := func() []AudioFile {
+		p.live.mu.RLock()
+		defer p.live.mu.RUnlock()
+		var files []AudioFile
+		for _, file := range p.live.files {
+			files = append(files, file)
+		}
+		return files
+	}
+
+	p.addFileInBackground(src)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(imported()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	files := imported()
+	if len(files) != 1 || files[0].OriginalPath != src || !fileExists(files[0].TempPath) {
+		t.Fatalf("files after background add = %+v", files)
+	}
+
+	// Adding the same file again is ignored
+	p.addFileInBackground(src)
+	time.Sleep(50 * time.Millisecond)
+	if n := len(imported()); n != 1 {
+		t.Errorf("got %d files after adding the same file twice; want 1", n)
+	}
+}
+
+func TestImportFiles(t *testing.T) {
+	src := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.mp3", "b.mp3", "missing.mp3", "c.mp3", "d.mp3", "e.mp3"} {
+		path := filepath.Join(src, name)
+		if name != "missing.mp3" {
+			os.WriteFile(path, []byte(name), 0644)
+		}
+		paths = append(paths, path)
+	}
+
+	var calls, last int
+	results := importFiles(context.Background(), paths, t.TempDir(), false, false, 3, func(done, total int) {
+		calls++
+		last = done
+		if total != len(paths) {
+			t.Errorf("total = %d; want %d", total, len(paths))
+		}
+	})
+
+	if calls != len(paths) || last != len(paths) {
+		t.Errorf("onDone called %d times ending at %d; want %d", calls, last, len(paths))
+	}
+	for i, r := range results {
+		if r.Path != paths[i] {
+			t.Errorf("results[%d].Path = %s; want %s", i, r.Path, paths[i])
+		}
+		if failed := r.Err != nil; failed != (filepath.Base(r.Path) == "missing.mp3") {
+			t.Errorf("results[%d].Err = %v", i, r.Err)
+		}
+		if r.Err == nil && r.File.DisplayName != filepath.Base(r.Path) {
+			t.Errorf("results[%d].File.DisplayName = %q", i, r.File.DisplayName)
+		}
+	}
+}
+
+func TestFolderFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"1.mp3", "10.mp3", "2.mp3", "notes.txt", "Disc 10/1.mp3", "Disc 2/10.mp3", "Disc 2/9.mp3"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("audio"), 0644)
+	}
+	relative := func(paths []string) string {
+		for i, path := range paths {
+			rel, _ := filepath.Rel(dir, path)
+			paths[i] = filepath.ToSlash(rel)
+		}
+		return strings.Join(paths, ",")
+	}
+
+	if got := relative(folderFiles(dir, false)); got != "1.mp3,2.mp3,10.mp3,Disc 2/9.mp3,Disc 2/10.mp3,Disc 10/1.mp3" {
+		t.Errorf("natural order = %s", got)
+	}
+	if got := relative(folderFiles(dir, true)); got != "1.mp3,10.mp3,2.mp3,Disc 10/1.mp3,Disc 2/10.mp3,Disc 2/9.mp3" {
+		t.Errorf("walk order = %s", got)
+	}
+}
+
+func TestPendingImports(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.files = []AudioFile{{ID: "1", OriginalPath: "/a.mp3"}}
+
+	got := p.pendingImports([]string{"/a.mp3", "/b.mp3", "/c.mp3", "/b.mp3"})
+	if strings.Join(got, ",") != "/b.mp3,/c.mp3" {
+		t.Errorf("pendingImports() = %v; want [/b.mp3 /c.mp3]", got)
+	}
+}
+
+func TestAddFiles(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"03.mp3", "01.mp3", "02.mp3"} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(name), 0644)
+		paths = append(paths, path)
+	}
+	p.addFile(paths[1])
+
+	missing := filepath.Join(dir, "missing.mp3")
+	err := p.addFiles(append(paths, paths[0], missing))
+	if err == nil || !strings.Contains(err.Error(), "missing.mp3") {
+		t.Errorf("addFiles() error = %v; want it to name missing.mp3", err)
+	}
+	if got := strings.Join(displayNames(p.files), ","); got != "01.mp3,03.mp3,02.mp3" {
+		t.Errorf("files = %s; want 01.mp3,03.mp3,02.mp3", got)
+	}
+	for _, f := range p.files {
+		if !fileExists(f.TempPath) {
+			t.Errorf("%s has no temp copy", f.DisplayName)
+		}
+	}
+	if !p.dirty {
+		t.Error("addFiles() didn't mark the state dirty")
+	}
+}
+
+func TestNotifyImported(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.app = test.NewTempApp(t)
+
+	test.AssertNotificationSent(t, fyne.NewNotification("Import Finished", "Added 3 files to Test Podcast"), func() {
+		p.notifyImported(3, 0)
+	})
+	test.AssertNotificationSent(t, fyne.NewNotification("Import Finished", "Added 1 file to Test Podcast"), func() {
+		p.notifyImported(1, 0)
+	})
+	test.AssertNotificationSent(t, fyne.NewNotification("Import Finished", "Added 1 file to Test Podcast, skipped 2 duplicates"), func() {
+		p.notifyImported(1, 2)
+	})
+	test.AssertNotificationSent(t, fyne.NewNotification("Import Finished", "Added 0 files to Test Podcast, skipped 1 duplicate"), func() {
+		p.notifyImported(0, 1)
+	})
+	// Nothing is said about a batch that added and skipped nothing
+	test.AssertNotificationSent(t, nil, func() {
+		p.notifyImported(0, 0)
+	})
+}
+
+func TestAddFilesDedupByContent(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(data), 0644)
+		return path
+	}
+	first := writeFile("a/ep.mp3", "same audio")
+	copied := writeFile("b/ep copy.mp3", "same audio")
+	again := writeFile("c/ep again.mp3", "same audio")
+	other := writeFile("c/other.mp3", "other audio")
+
+	tests := []struct {
+		name  string
+		dedup bool
+		want  string
+	}{
+		{"off", false, "ep.mp3,ep copy.mp3,ep again.mp3,other.mp3"},
+		{"on", true, "ep.mp3,other.mp3"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, cleanup := newTestPodcasterator(t)
+			defer cleanup()
+			p.dedupByContent = tc.dedup
+
+			p.addFile(first)
+			// The batch repeats the first file's audio from two other folders
+			p.addFiles([]string{copied, again, other})
+
+			if got := strings.Join(displayNames(p.files), ","); got != tc.want {
+				t.Errorf("files = %s; want %s", got, tc.want)
+			}
+			if p.files[0].SHA256 == "" {
+				t.Error("added file has no content hash")
+			}
+			if entries, _ := os.ReadDir(p.tempDir); countDirs(entries) != len(p.files) {
+				t.Errorf("temp dir has %d file folders; want %d", countDirs(entries), len(p.files))
+			}
+		})
+	}
+}
+
+func countDirs(entries []os.DirEntry) int {
+	n := 0
+	for _, e := range entries {
+		if e.IsDir() {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gorilla/feeds"
+)
+
+// indexPage is the browsable page served at the server root, for checking
+// the feed from any browser
+type indexPage struct {
+	PodcastName string
+	FeedURL     string
+	ArtworkURL  string
+	Episodes    []indexEpisode
+}
+
+type indexEpisode struct {
+	Title string
+	URL   string
+	Type  string
+}
+
+// newIndexPage lists feed's episodes as published, so the page plays
+// exactly what podcast apps get
+func newIndexPage(feed *feeds.Feed, feedURL string) indexPage {
+	page := indexPage{PodcastName: feed.Title, FeedURL: feedURL}
+	if feed.Image != nil {
+		page.ArtworkURL = feed.Image.Url
+	}
+	for _, item := range feed.Items {
+		if item.Enclosure == nil {
+			continue
+		}
+		page.Episodes = append(page.Episodes, indexEpisode{
+			Title: item.Title,
+			URL:   item.Enclosure.Url,
+			Type:  item.Enclosure.Type,
+		})
+	}
+	return page
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.PodcastName}}</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; padding: 0 1em; }
+img { width: 12em; height: 12em; object-fit: cover; }
+li { margin-bottom: 1em; }
+audio { display: block; width: 100%; margin-top: 0.3em; }
+</style>
+</head>
+<body>
+<h1>{{.PodcastName}}</h1>
+{{if .ArtworkURL}}<img src="{{.ArtworkURL}}" alt="Artwork">
+{{end}}<p>Feed: <a href="{{.FeedURL}}">{{.FeedURL}}</a></p>
+{{if .Episodes}}<ol>
+{{range .Episodes}}<li>{{.Title}}
+<audio controls preload="none"><source src="{{.URL}}"{{if .Type}} type="{{.Type}}"{{end}}></audio></li>
+{{end}}</ol>
+{{else}}<p>No episodes.</p>
+{{end}}</body>
+</html>
+`))
+
+// indexHandler serves page at the root. "/" matches every path the mux
+// doesn't otherwise know, so anything else is a 404.
+func indexHandler(page indexPage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		indexTemplate.Execute(w, page)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/feeds"
+)
+
+// =============================================================================
+// Index Page Tests
+// =============================================================================
+
+func TestNewIndexPage(t *testing.T) {
+	feed := &feeds.Feed{
+		Title: "My Show",
+		Image: &feeds.Image{Url: "http://host:8080/artwork.png"},
+		Items: []*feeds.Item{
+			{Title: "One", Enclosure: &feeds.Enclosure{Url: "http://host:8080/files/a/one.mp3", Type: "audio/mpeg"}},
+			{Title: "No audio"},
+			{Title: "Hosted", Enclosure: &feeds.Enclosure{Url: "https://example.com/b.m4a", Type: "audio/mp4"}},
+		},
+	}
+
+	page := newIndexPage(feed, "http://host:8080/feed.xml")
+	if page.PodcastName != "My Show" || page.FeedURL != "http://host:8080/feed.xml" || page.ArtworkURL != "http://host:8080/artwork.png" {
+		t.Errorf("page = %+v", page)
+	}
+	want := []indexEpisode{
+		{Title: "One", URL: "http://host:8080/files/a/one.mp3", Type: "audio/mpeg"},
+		{Title: "Hosted", URL: "https://example.com/b.m4a", Type: "audio/mp4"},
+	}
+	if len(page.Episodes) != len(want) {
+		t.Fatalf("episodes = %+v; want %+v", page.Episodes, want)
+	}
+	for i := range want {
+		if page.Episodes[i] != want[i] {
+			t.Errorf("episode %d = %+v; want %+v", i, page.Episodes[i], want[i])
+		}
+	}
+
+	if page := newIndexPage(&feeds.Feed{Title: "Bare"}, ""); page.ArtworkURL != "" || len(page.Episodes) != 0 {
+		t.Errorf("page without artwork or items = %+v", page)
+	}
+}
+
+func TestIndexHandler(t *testing.T) {
+	page := indexPage{
+		PodcastName: "Tom & Jerry's <Show>",
+		FeedURL:     "http://host:8080/feed.xml",
+		Episodes: []indexEpisode{
+			{Title: "Episode <1>", URL: "http://host:8080/files/a/one.mp3", Type: "audio/mpeg"},
+		},
+	}
+	srv := httptest.NewServer(indexHandler(page))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatalf("GET /: %v", err)
+	}
+	resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q; want text/html", ct)
+	}
+
+	code, body := getBody(t, srv.URL+"/")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d; want %d", code, http.StatusOK)
+	}
+	for _, want := range []string{
+		"Tom &amp; Jerry&#39;s &lt;Show&gt;",
+		`<a href="http://host:8080/feed.xml">`,
+		"Episode &lt;1&gt;",
+		`<source src="http://host:8080/files/a/one.mp3" type="audio/mpeg">`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "<img") {
+		t.Error("page shows artwork when there is none")
+	}
+
+	if code, _ := getBody(t, srv.URL+"/missing"); code != http.StatusNotFound {
+		t.Errorf("GET /missing status = %d; want %d", code, http.StatusNotFound)
+	}
+	post, err := http.Post(srv.URL+"/", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("POST /: %v", err)
+	}
+	post.Body.Close()
+	if post.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d; want %d", post.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServerIndexPage(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+	p.files[0].DisplayName = "Pilot.mp3"
+
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+	base := strings.TrimSuffix(p.serverURL, "/feed.xml")
+
+	code, body := getBody(t, base+"/")
+	if code != http.StatusOK {
+		t.Fatalf("GET / status = %d; want %d", code, http.StatusOK)
+	}
+	for _, want := range []string{p.serverURL, "Pilot", base + "/files/abc/"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("index page missing %q:\n%s", want, body)
+		}
+	}
+
+	// The catch-all root mustn't shadow or stand in for other paths
+	if code, _ := getBody(t, base+"/nothing-here"); code != http.StatusNotFound {
+		t.Errorf("GET /nothing-here status = %d; want %d", code, http.StatusNotFound)
+	}
+	if code, _ := getBody(t, p.serverURL); code != http.StatusOK {
+		t.Errorf("GET /feed.xml status = %d; want %d", code, http.StatusOK)
+	}
+}
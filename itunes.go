@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// appleCategories are Apple Podcasts' top-level categories
+var appleCategories = []string{
+	"Arts", "Business", "Comedy", "Education", "Fiction", "Government",
+	"Health & Fitness", "History", "Kids & Family", "Leisure", "Music", "News",
+	"Religion & Spirituality", "Science", "Society & Culture", "Sports",
+	"Technology", "True Crime", "TV & Film",
+}
+
+// feedLanguages are common BCP-47 codes offered for the feed's <language>
+var feedLanguages = []string{
+	"ar", "cs", "da", "de", "el", "en", "en-AU", "en-CA", "en-GB", "en-US",
+	"es", "es-MX", "fi", "fr", "fr-CA", "he", "hi", "hu", "id", "it", "ja",
+	"ko", "nl", "no", "pl", "pt", "pt-BR", "ro", "ru", "sv", "th", "tr",
+	"uk", "vi", "zh-CN", "zh-TW",
+}
+
+// systemLanguage returns the OS locale as a BCP-47 code, e.g. "en-US". It's
+// a variable so tests don't depend on the machine's locale.
+var systemLanguage = func() string {
+	return lang.SystemLocale().LanguageString()
+}
+
+const (
+	defaultCategory    = "Leisure"
+	defaultFeedSummary = "Local podcast feed"
+
+	// maxSummaryLength is Apple's limit for show descriptions
+	maxSummaryLength = 4000
+)
+
+// validCategory returns category if Apple recognizes it, otherwise the default
+func validCategory(category string) string {
+	category = strings.TrimSpace(category)
+	for _, c := range appleCategories {
+		if strings.EqualFold(c, category) {
+			return c
+		}
+	}
+	return defaultCategory
+}
+
+// cleanSummary trims a show summary and cuts it to Apple's length limit
+func cleanSummary(summary string) string {
+	summary = strings.TrimSpace(summary)
+	if utf8.RuneCountInString(summary) > maxSummaryLength {
+		summary = strings.TrimSpace(string([]rune(summary)[:maxSummaryLength]))
+	}
+	return summary
+}
+
+func itunesBool(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+// feedSummary is the show description, used for both <description> and
+// <itunes:summary>
+func (p *Podcasterator) feedSummary() string {
+	if summary := cleanSummary(p.podcastSummary); summary != "" {
+		return summary
+	}
+	return defaultFeedSummary
+}
+
+// feedAuthor is the show author, falling back to the podcast name
+func (p *Podcasterator) feedAuthor() string {
+	if author := strings.TrimSpace(p.podcastAuthor); author != "" {
+		return author
+	}
+	if name := strings.TrimSpace(p.podcastName); name != "" {
+		return name
+	}
+	return "Podcasterator"
+}
+
+// feedLanguage is the feed's language, the OS locale unless one is chosen
+func (p *Podcasterator) feedLanguage() string {
+	if language := strings.TrimSpace(p.podcastLanguage); language != "" {
+		return language
+	}
+	if language := systemLanguage(); language != "" {
+		return language
+	}
+	return "en"
+}
+
+// validateOwnerEmail accepts a blank email or a bare address such as
+// you@example.com, without a display name or angle brackets
+func validateOwnerEmail(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return fmt.Errorf("%q is not an email address like you@example.com", email)
+	}
+	return nil
+}
+
+// validOwnerEmail is the owner email if it's a valid address, otherwise ""
+func (p *Podcasterator) validOwnerEmail() string {
+	email := strings.TrimSpace(p.ownerEmail)
+	if validateOwnerEmail(email) != nil {
+		return ""
+	}
+	return email
+}
+
+// itunesOwnerElements returns <itunes:owner> with whichever of the owner's
+// name and email are set, or nothing when neither is
+func (p *Podcasterator) itunesOwnerElements() []channelElement {
+	var children []channelElement
+	if name := strings.TrimSpace(p.ownerName); name != "" {
+		children = append(children, channelElement{Name: "itunes:name", Value: name})
+	}
+	if email := p.validOwnerEmail(); email != "" {
+		children = append(children, channelElement{Name: "itunes:email", Value: email})
+	}
+	if len(children) == 0 {
+		return nil
+	}
+	return []channelElement{{Name: "itunes:owner", Children: children}}
+}
+
+// itunesChannelElements returns the show-level tags Apple Podcasts
+// requires: <language> and the iTunes details. Artwork is linked under
+// baseURL when it's set.
+func (p *Podcasterator) itunesChannelElements(baseURL string) []channelElement {
+	elements := []channelElement{
+		{Name: "language", Value: p.feedLanguage()},
+		{Name: "itunes:author", Value: p.feedAuthor()},
+		{Name: "itunes:summary", Value: p.feedSummary()},
+		{Name: "itunes:explicit", Value: itunesBool(p.podcastExplicit)},
+		{Name: "itunes:category", Attrs: map[string]string{"text": validCategory(p.podcastCategory)}},
+	}
+	if p.artworkPath != "" && fileExists(p.artworkPath) {
+		elements = append(elements, channelElement{
+			Name:  "itunes:image",
+			Attrs: map[string]string{"href": artworkURL(baseURL, p.artworkPath)},
+		})
+	}
+	return append(elements, p.itunesOwnerElements()...)
+}
+
+// podcastDetailsSection is the settings section for the show-level details
+// podcast directories display
+func (p *Podcasterator) podcastDetailsSection() fyne.CanvasObject {
+	authorEntry := widget.NewEntry()
+	authorEntry.SetPlaceHolder("Defaults to the podcast name")
+	authorEntry.SetText(p.podcastAuthor)
+	authorEntry.OnChanged = func(s string) {
+		p.podcastAuthor = s
+		p.markDirty()
+	}
+
+	summaryEntry := widget.NewMultiLineEntry()
+	summaryEntry.Wrapping = fyne.TextWrapWord
+	summaryEntry.SetMinRowsVisible(3)
+	summaryEntry.SetPlaceHolder(defaultFeedSummary)
+	summaryEntry.SetText(p.podcastSummary)
+	summaryEntry.OnChanged = func(s string) {
+		p.podcastSummary = s
+		p.markDirty()
+	}
+
+	categorySelect := widget.NewSelect(appleCategories, func(s string) {
+		p.podcastCategory = s
+		p.markDirty()
+	})
+	categorySelect.PlaceHolder = defaultCategory
+	categorySelect.SetSelected(p.podcastCategory)
+
+	explicitCheck := widget.NewCheck("Explicit", func(checked bool) {
+		p.podcastExplicit = checked
+		p.markDirty()
+	})
+	explicitCheck.SetChecked(p.podcastExplicit)
+
+	languageSelect := widget.NewSelectEntry(feedLanguages)
+	languageSelect.SetPlaceHolder(systemLanguage() + " (system)")
+	languageSelect.SetText(p.podcastLanguage)
+	languageSelect.OnChanged = func(s string) {
+		p.podcastLanguage = strings.TrimSpace(s)
+		p.markDirty()
+	}
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("Podcast", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewForm(
+			widget.NewFormItem("Author", authorEntry),
+			widget.NewFormItem("Summary", summaryEntry),
+			widget.NewFormItem("Category", categorySelect),
+			widget.NewFormItem("Language", languageSelect),
+			widget.NewFormItem("", explicitCheck),
+		),
+	)
+}
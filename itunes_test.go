@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// iTunes Tag Tests
+// =============================================================================
+
+func TestValidCategory(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Technology", "Technology"},
+		{"  true crime ", "True Crime"},
+		{"", defaultCategory},
+		{"Not A Category", defaultCategory},
+		{"<Arts>", defaultCategory},
+	}
+
+	for _, tc := range tests {
+		if got := validCategory(tc.in); got != tc.want {
+			t.Errorf("validCategory(%q) = %q; want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCleanSummary(t *testing.T) {
+	if got := cleanSummary("  A show.\n"); got != "A show." {
+		t.Errorf("cleanSummary() = %q; want %q", got, "A show.")
+	}
+
+	long := strings.Repeat("é", maxSummaryLength+10)
+	if got := cleanSummary(long); len([]rune(got)) != maxSummaryLength {
+		t.Errorf("cleanSummary() kept %d characters; want %d", len([]rune(got)), maxSummaryLength)
+	}
+}
+
+func elementsByName(elements []channelElement) map[string]channelElement {
+	byName := map[string]channelElement{}
+	for _, e := range elements {
+		byName[e.Name] = e
+	}
+	return byName
+}
+
+func TestITunesChannelElementsDefaults(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.podcastAuthor = "   "
+	p.podcastCategory = "bogus"
+
+	got := elementsByName(p.itunesChannelElements("http://localhost:8080"))
+	if got["itunes:author"].Value != "Test Podcast" {
+		t.Errorf("itunes:author = %q; want the podcast name", got["itunes:author"].Value)
+	}
+	if got["itunes:summary"].Value != defaultFeedSummary {
+		t.Errorf("itunes:summary = %q; want %q", got["itunes:summary"].Value, defaultFeedSummary)
+	}
+	if got["itunes:explicit"].Value != "false" {
+		t.Errorf("itunes:explicit = %q; want false", got["itunes:explicit"].Value)
+	}
+	if got["itunes:category"].Attrs["text"] != defaultCategory {
+		t.Errorf("itunes:category text = %q; want %q", got["itunes:category"].Attrs["text"], defaultCategory)
+	}
+	if _, ok := got["itunes:image"]; ok {
+		t.Error("itunes:image present without artwork")
+	}
+}
+
+func TestITunesChannelElementsSet(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.podcastAuthor = "Jane Host"
+	p.podcastSummary = "Talk about <things> & stuff"
+	p.podcastExplicit = true
+	p.podcastCategory = "Comedy"
+	p.artworkPath = filepath.Join(p.tempDir, "artwork.jpg")
+	if err := os.WriteFile(p.artworkPath, []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := elementsByName(p.itunesChannelElements("http://localhost:8080/"))
+	if got["itunes:author"].Value != "Jane Host" {
+		t.Errorf("itunes:author = %q", got["itunes:author"].Value)
+	}
+	if got["itunes:summary"].Value != "Talk about <things> & stuff" {
+		t.Errorf("itunes:summary = %q", got["itunes:summary"].Value)
+	}
+	if got["itunes:explicit"].Value != "true" {
+		t.Errorf("itunes:explicit = %q; want true", got["itunes:explicit"].Value)
+	}
+	if got["itunes:category"].Attrs["text"] != "Comedy" {
+		t.Errorf("itunes:category text = %q", got["itunes:category"].Attrs["text"])
+	}
+	if href := got["itunes:image"].Attrs["href"]; href != "http://localhost:8080/artwork.jpg" {
+		t.Errorf("itunes:image href = %q", href)
+	}
+}
+
+func TestItemElementsFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    AudioFile
+		want    map[string]string
+		missing []string
+	}{
+		{
+			name:    "bare file",
+			file:    AudioFile{DisplayName: "ep.mp3"},
+			want:    map[string]string{"itunes:title": "ep.mp3", "itunes:explicit": "false"},
+			missing: []string{"itunes:episode", "itunes:season", "itunes:episodeType", "itunes:duration"},
+		},
+		{
+			name: "full details",
+			file: AudioFile{DisplayName: "ep.mp3", Episode: 3, Season: 2, EpisodeType: "bonus", Explicit: true},
+			want: map[string]string{
+				"itunes:title":       "ep.mp3",
+				"itunes:episode":     "3",
+				"itunes:season":      "2",
+				"itunes:episodeType": "bonus",
+				"itunes:explicit":    "true",
+			},
+		},
+		{
+			name:    "invalid episode type",
+			file:    AudioFile{DisplayName: "ep.mp3", EpisodeType: "special"},
+			missing: []string{"itunes:episodeType"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := elementsByName(itemElementsFor(tc.file))
+			for name, value := range tc.want {
+				if got[name].Value != value {
+					t.Errorf("%s = %q; want %q", name, got[name].Value, value)
+				}
+			}
+			for _, name := range tc.missing {
+				if _, ok := got[name]; ok {
+					t.Errorf("%s present; want it omitted", name)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderRSSITunesTags(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.podcastSummary = "Notes & <asides>"
+
+	extras := feedExtras{
+		Channel: p.itunesChannelElements("http://localhost:8080"),
+		Items: map[string][]channelElement{
+			"1": itemElementsFor(AudioFile{DisplayName: "episode.mp3", Episode: 7}),
+		},
+	}
+	rss, err := renderRSS(newTestFeed("http://localhost:8080"), "http://localhost:8080/feed.xml", extras)
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+	if !strings.Contains(rss, `xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`) {
+		t.Errorf("feed does not declare the itunes namespace:\n%s", rss)
+	}
+
+	var doc struct {
+		Channel struct {
+			Author   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+			Summary  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+			Category struct {
+				Text string `xml:"text,attr"`
+			} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+			Items []struct {
+				Title   string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+				Episode string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+		t.Fatalf("renderRSS() produced invalid XML: %v", err)
+	}
+	if doc.Channel.Author != "Test Podcast" {
+		t.Errorf("itunes:author = %q", doc.Channel.Author)
+	}
+	if doc.Channel.Summary != "Notes & <asides>" {
+		t.Errorf("itunes:summary = %q", doc.Channel.Summary)
+	}
+	if doc.Channel.Category.Text != defaultCategory {
+		t.Errorf("itunes:category = %q", doc.Channel.Category.Text)
+	}
+	if len(doc.Channel.Items) != 1 || doc.Channel.Items[0].Title != "episode.mp3" || doc.Channel.Items[0].Episode != "7" {
+		t.Errorf("items = %+v", doc.Channel.Items)
+	}
+}
+
+func TestFeedLanguage(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	defer func(orig func() string) { systemLanguage = orig }(systemLanguage)
+	systemLanguage = func() string { return "fr-CA" }
+
+	if got := elementsByName(p.itunesChannelElements("http://host"))["language"].Value; got != "fr-CA" {
+		t.Errorf("language = %q; want the system locale", got)
+	}
+	p.podcastLanguage = "pt-BR"
+	if got := elementsByName(p.itunesChannelElements("http://host"))["language"].Value; got != "pt-BR" {
+		t.Errorf("language = %q; want the chosen one", got)
+	}
+	p.podcastLanguage = ""
+	systemLanguage = func() string { return "" }
+	if got := p.feedLanguage(); got != "en" {
+		t.Errorf("feedLanguage() = %q with no locale; want en", got)
+	}
+}
+
+func TestValidateOwnerEmail(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{"", false},
+		{"me@example.com", false},
+		{" me@example.com ", false},
+		{"me", true},
+		{"me@", true},
+		{"Me <me@example.com>", true},
+		{"me@example.com, you@example.com", true},
+	}
+	for _, tc := range tests {
+		if err := validateOwnerEmail(tc.in); (err != nil) != tc.wantErr {
+			t.Errorf("validateOwnerEmail(%q) error = %v; wantErr %v", tc.in, err, tc.wantErr)
+		}
+	}
+}
+
+func TestITunesOwner(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	if _, ok := elementsByName(p.itunesChannelElements("http://host"))["itunes:owner"]; ok {
+		t.Error("itunes:owner present with no owner set")
+	}
+
+	// An invalid email is left out of both the owner and podcast:locked
+	p.ownerEmail = "not an email"
+	if p.itunesOwnerElements() != nil || p.podcastChannelElements("http://host/feed.xml")[1].Attrs != nil {
+		t.Error("invalid owner email was written to the feed")
+	}
+
+	p.ownerName = "Jane Host"
+	p.ownerEmail = "jane@example.com"
+	extras := feedExtras{Channel: p.itunesChannelElements("http://host")}
+	rss, err := renderRSS(newTestFeed("http://host"), "http://host/feed.xml", extras)
+	if err != nil {
+		t.Fatalf("renderRSS() error = %v", err)
+	}
+	want := "<itunes:owner><itunes:name>Jane Host</itunes:name><itunes:email>jane@example.com</itunes:email></itunes:owner>"
+	if !strings.Contains(strings.Join(strings.Fields(rss), ""), strings.Join(strings.Fields(want), "")) {
+		t.Errorf("feed is missing the owner:\n%s", rss)
+	}
+
+	var doc struct {
+		Channel struct {
+			Language string `xml:"language"`
+			Owner    struct {
+				Name  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd name"`
+				Email string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd email"`
+			} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd owner"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+		t.Fatalf("renderRSS() produced invalid XML: %v", err)
+	}
+	if doc.Channel.Owner.Name != "Jane Host" || doc.Channel.Owner.Email != "jane@example.com" || doc.Channel.Language == "" {
+		t.Errorf("channel = %+v", doc.Channel)
+	}
+
+	// Only the name is set: the owner has no empty email
+	p.ownerEmail = ""
+	owner := p.itunesOwnerElements()
+	if len(owner) != 1 || len(owner[0].Children) != 1 || owner[0].Children[0].Name != "itunes:name" {
+		t.Errorf("itunesOwnerElements() = %+v; want just the name", owner)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// downloadRetryAfter is how long a client turned away by the download limit
+// is asked to wait before trying again
+const downloadRetryAfter = 30 * time.Second
+
+// serverLimits keeps a misbehaving client on the network from saturating
+// the upload. Each limit is off while it's 0, as it is by default.
+type serverLimits struct {
+	// MaxDownloads is how many /files/ requests are answered at once;
+	// any more get 503 until one finishes
+	MaxDownloads int `json:"max_downloads,omitempty"`
+	// RequestsPerMinute is how many requests each client address may make
+	// a minute, in bursts of up to that many; any more get 429
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+}
+
+// wrap applies whichever limits are set to next
+func (l serverLimits) wrap(next http.Handler) http.Handler {
+	if l.MaxDownloads > 0 {
+		next = limitDownloads(next, l.MaxDownloads)
+	}
+	if l.RequestsPerMinute > 0 {
+		next = limitRequests(next, l.RequestsPerMinute)
+	}
+	return next
+}
+
+// limitDownloads lets at most max requests for /files/ through to next at
+// once. The rest are refused straight away rather than queued, so a client
+// opening many connections can't tie them all up waiting.
+func limitDownloads(next http.Handler, max int) http.Handler {
+	slots := make(chan struct{}, max)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/files/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set("Retry-After", strconv.Itoa(int(downloadRetryAfter.Seconds())))
+			http.Error(w, "Too many downloads at once, try again shortly", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// requestLimiter is a token bucket for each client address: a client may
+// make perMinute requests at once and earns them back evenly over a minute
+type requestLimiter struct {
+	perMinute int
+
+	mu      sync.Mutex
+	clients map[string]*clientBucket
+	pruned  time.Time
+}
+
+type clientBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRequestLimiter(perMinute int) *requestLimiter {
+	return &requestLimiter{perMinute: perMinute, clients: map[string]*clientBucket{}}
+}
+
+// allow reports whether client may make a request at now and, if not, how
+// long until it may
+func (l *requestLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// A client idle for a minute has a full bucket again, the same as one
+	// never seen, so it can be forgotten
+	if now.Sub(l.pruned) > time.Minute {
+		for c, b := range l.clients {
+			if now.Sub(b.last) > time.Minute {
+				delete(l.clients, c)
+			}
+		}
+		l.pruned = now
+	}
+
+	burst := float64(l.perMinute)
+	perSecond := burst / 60
+	b, ok := l.clients[client]
+	if !ok {
+		b = &clientBucket{tokens: burst, last: now}
+		l.clients[client] = b
+	}
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// clientAddress is the address r came from, without the port, since a
+// client's connections each come from a different one
+func clientAddress(r *http.Request) string {
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return client
+}
+
+// limitRequests turns away clients making more than perMinute requests a
+// minute, by address, with 429 and how long to wait
+func limitRequests(next http.Handler, perMinute int) http.Handler {
+	limiter := newRequestLimiter(perMinute)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := limiter.allow(clientAddress(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitEntry edits one limit, where blank or 0 means no limit
+func (p *Podcasterator) limitEntry(limit *int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("No limit")
+	if *limit > 0 {
+		entry.SetText(strconv.Itoa(*limit))
+	}
+	parse := func(s string) (int, error) {
+		if strings.TrimSpace(s) == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("enter a number, or leave blank for no limit")
+		}
+		return n, nil
+	}
+	entry.Validator = func(s string) error {
+		_, err := parse(s)
+		return err
+	}
+	entry.OnChanged = func(s string) {
+		if n, err := parse(s); err == nil && n != *limit {
+			*limit = n
+			p.markDirty()
+		}
+	}
+	return entry
+}
+
+// limitsSection is the Settings section for limiting what clients can ask
+// of the server. Changes apply the next time the server starts.
+func (p *Podcasterator) limitsSection() fyne.CanvasObject {
+	note := widget.NewLabel("Keeps one device on the network from using all of your upload.\nBlank means no limit. Applies the next time the server starts.")
+	note.Importance = widget.LowImportance
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("Limits", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewBorder(nil, nil, widget.NewLabel("Downloads at once:"), nil, p.limitEntry(&p.limits.MaxDownloads)),
+		container.NewBorder(nil, nil, widget.NewLabel("Requests per minute per device:"), nil, p.limitEntry(&p.limits.RequestsPerMinute)),
+		note,
+	)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Server Limit Tests
+// =============================================================================
+
+func TestLimitDownloads(t *testing.T) {
+	const max = 2
+	entered := make(chan struct{}, max)
+	release := make(chan struct{})
+	srv := httptest.NewServer(serverLimits{MaxDownloads: max}.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/files/abc/episode.mp3" {
+			entered <- struct{}{}
+			<-release
+		}
+		w.Write([]byte("ok"))
+	})))
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	for range max {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(srv.URL + "/files/abc/episode.mp3")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("download within the limit = %d; want 200", resp.StatusCode)
+			}
+		}()
+	}
+	for range max {
+		<-entered
+	}
+
+	// Every slot is taken, so the next download is turned away at once
+	resp, err := http.Get(srv.URL + "/files/def/episode.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("download over the limit = %d, Retry-After %q; want 503 with a wait", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	// Anything but files still gets through
+	if code, _ := getBody(t, srv.URL+"/feed.xml"); code != http.StatusOK {
+		t.Errorf("feed while downloads are full = %d; want 200", code)
+	}
+
+	close(release)
+	wg.Wait()
+	if code, _ := getBody(t, srv.URL+"/files/def/episode.mp3"); code != http.StatusOK {
+		t.Errorf("download after the others finished = %d; want 200", code)
+	}
+}
+
+func TestRequestLimiter(t *testing.T) {
+	l := newRequestLimiter(60)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range 60 {
+		if ok, _ := l.allow("10.0.0.2", start); !ok {
+			t.Fatalf("request %d of a burst of 60 refused", i+1)
+		}
+	}
+	ok, wait := l.allow("10.0.0.2", start)
+	if ok || wait != time.Second {
+		t.Errorf("61st request = %v, wait %v; want refused for a second", ok, wait)
+	}
+	if ok, _ := l.allow("10.0.0.3", start); !ok {
+		t.Error("another address was limited too")
+	}
+	if ok, _ := l.allow("10.0.0.2", start.Add(time.Second)); !ok {
+		t.Error("request refused after waiting as told")
+	}
+
+	// Clients idle for over a minute are forgotten
+	l.allow("10.0.0.4", start.Add(2*time.Minute))
+	if len(l.clients) != 1 {
+		t.Errorf("%d clients remembered; want only the one just seen", len(l.clients))
+	}
+}
+
+func TestLimitRequests(t *testing.T) {
+	handler := serverLimits{RequestsPerMinute: 2}.wrap(http.NotFoundHandler())
+	get := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+		req.RemoteAddr = remote
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	get("192.168.1.20:5000")
+	get("192.168.1.20:5001")
+	// Counted by address, whichever port the connection came from
+	if rec := get("192.168.1.20:5002"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("third request = %d, Retry-After %q; want 429 after 30s", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := get("192.168.1.21:5000"); rec.Code == http.StatusTooManyRequests {
+		t.Error("another device was limited too")
+	}
+}
+
+func TestServerLimitsOffByDefault(t *testing.T) {
+	mux := http.NewServeMux()
+	if got := (serverLimits{}).wrap(mux); got != http.Handler(mux) {
+		t.Error("zero limits wrapped the handler")
+	}
+}
@@ -1,20 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
-	"image/jpeg"
 	_ "image/gif"
 	_ "image/png"
-	"io"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -30,12 +34,28 @@ import (
 )
 
 const (
-	maxFilenameLength = 50
-	serverPort        = 8080
-	artworkSize       = 1400 // Standard podcast artwork size
+	maxFilenameLength  = 50
+	defaultPodcastName = "My Podcast"
+	defaultServerPort  = 8080
+	artworkSize        = 1400 // Standard podcast artwork size
+	shutdownTimeout    = 5 * time.Second
+	autosaveDelay      = 500 * time.Millisecond
+	publishDelay       = 300 * time.Millisecond
 )
 
 var supportedExtensions = []string{".mp3", ".m4a", ".mp4", ".m4b"}
+
+// audioMIMETypes maps every audio extension the app accepts, including the
+// transcoded ones, to its MIME type. MP4 audio is always audio/mp4.
+var audioMIMETypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".mp4":  "audio/mp4",
+	".m4b":  "audio/mp4",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".ogg":  "audio/ogg",
+}
 var supportedImageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".bmp", ".tiff", ".tif"}
 
 // AudioFile represents an audio file in the playlist
@@ -44,53 +64,325 @@ type AudioFile struct {
 	OriginalPath string `json:"original_path"`
 	TempPath     string `json:"temp_path"`
 	DisplayName  string `json:"display_name"`
+
+	// Externally-hosted media has no temp copy; the feed links straight to it
+	ExternalURL    string `json:"external_url,omitempty"`
+	ExternalLength int64  `json:"external_length,omitempty"`
+	ExternalType   string `json:"external_type,omitempty"`
+
+	// Season groups episodes; 0 means no season
+	Season int `json:"season,omitempty"`
+
+	// SourceURL is set for files downloaded from the web instead of copied from disk
+	SourceURL string `json:"source_url,omitempty"`
+
+	// Cached stat of the served file so feed builds don't touch the disk.
+	// A zero ModTime marks the cache stale.
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"mod_time,omitzero"`
+
+	// Duration is the playback length read from the audio; 0 means unknown
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// OriginalMissing is set when OriginalPath was last found to be gone,
+	// so list rows can grey out without touching the disk
+	OriginalMissing bool `json:"original_missing,omitempty"`
+
+	// ServeOriginal serves OriginalPath directly instead of the temp copy.
+	// The copy is kept so the file can be switched back at any time.
+	ServeOriginal bool `json:"serve_original,omitempty"`
+
+	// StartOffset cuts the start of the episode, e.g. a long intro. The
+	// temp copy without it is made with ffmpeg and served from TrimmedPath.
+	StartOffset time.Duration `json:"start_offset,omitempty"`
+	TrimmedPath string        `json:"trimmed_path,omitempty"`
+
+	// Episode details edited in the side panel
+	Description string    `json:"description,omitempty"`
+	PubDate     time.Time `json:"pub_date,omitzero"` // zero means derived from list order
+	Episode     int       `json:"episode,omitempty"`
+	Explicit    bool      `json:"explicit,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	EpisodeType string    `json:"episode_type,omitempty"`
+
+	// AddedAt is when the file joined the list. The earliest one anchors the
+	// feed's publish dates so they stay the same between launches.
+	AddedAt time.Time `json:"added_at,omitzero"`
+
+	// Read from the file's embedded ID3 or MP4 tags when it was added
+	Artist string `json:"artist,omitempty"`
+	Album  string `json:"album,omitempty"`
+	Track  int    `json:"track,omitempty"`
+
+	// SHA256 is the hash of the source audio, taken while it was copied.
+	// Empty for external and downloaded files.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// ArtworkPath is the episode's own converted cover; empty uses the
+	// podcast's artwork
+	ArtworkPath string `json:"artwork_path,omitempty"`
+
+	// ChaptersPath is the chapters file read from the audio's embedded
+	// chapter markers; empty when it has none
+	ChaptersPath string `json:"chapters_path,omitempty"`
+
+	// TranscriptPath is the episode's .vtt or .srt transcript, copied from
+	// beside the source audio or attached by hand
+	TranscriptPath string `json:"transcript_path,omitempty"`
+}
+
+// IsExternal reports whether the file is hosted elsewhere rather than copied locally
+func (f AudioFile) IsExternal() bool {
+	return f.ExternalURL != ""
+}
+
+// ServedPath is the local file the server sends for this entry
+func (f AudioFile) ServedPath() string {
+	if f.ServeOriginal {
+		return f.OriginalPath
+	}
+	if f.TrimmedPath != "" {
+		return f.TrimmedPath
+	}
+	return f.TempPath
+}
+
+// refreshStat re-reads the size and modification time of the served file
+func (f *AudioFile) refreshStat() error {
+	info, err := os.Stat(f.ServedPath())
+	if err != nil {
+		f.ModTime = time.Time{}
+		return err
+	}
+	f.Size = info.Size()
+	f.ModTime = info.ModTime()
+	return nil
+}
+
+// invalidateStat marks the cached size and modification time stale
+func (f *AudioFile) invalidateStat() {
+	f.ModTime = time.Time{}
 }
 
 // AppState represents the persisted application state
 type AppState struct {
-	Files        []AudioFile `json:"files"`
-	PodcastName  string      `json:"podcast_name"`
-	ArtworkPath  string      `json:"artwork_path"`
+	// The active project is stored inline, where a single podcast's
+	// settings were kept before there were projects
+	Project
+
+	// Projects are the other projects, as they were when last switched away from
+	Projects []Project `json:"projects,omitempty"`
+
+	ProgressiveJPEG bool   `json:"progressive_jpeg,omitempty"`
+	ArtworkWarnKB   int    `json:"artwork_warn_kb,omitempty"`
+	ArtworkFormat   string `json:"artwork_format,omitempty"`
+	ArtworkQuality  int    `json:"artwork_quality,omitempty"`
+
+	KeepExtension  bool `json:"keep_extension,omitempty"`
+	DedupByContent bool `json:"dedup_by_content,omitempty"`
+
+	// WalkFolderOrder adds a folder's files in plain name order instead of
+	// natural order
+	WalkFolderOrder bool `json:"walk_folder_order,omitempty"`
+
+	// VerifyCopies reads each copy back and checks it against the original
+	VerifyCopies bool `json:"verify_copies,omitempty"`
+
+	LocalOnly bool `json:"local_only,omitempty"`
+	Port      int  `json:"port,omitempty"` // 0 means defaultServerPort
+
+	// ResumeServer starts the server on launch when ServerWasRunning, on
+	// LastServerPort, the port it last ran on
+	ResumeServer     bool `json:"resume_server,omitempty"`
+	ServerWasRunning bool `json:"server_was_running,omitempty"`
+	LastServerPort   int  `json:"last_server_port,omitempty"`
+
+	// ServerAddress is the IP put in feed URLs; empty picks one automatically
+	ServerAddress string `json:"server_address,omitempty"`
+
+	Auth     feedAuth `json:"auth,omitzero"`
+	UseHTTPS bool     `json:"use_https,omitempty"`
+
+	// AllowCORS lets browser-based players fetch the feed and episodes
+	AllowCORS bool `json:"allow_cors,omitempty"`
+
+	// Limits caps downloads and requests; the zero value has no limits
+	Limits serverLimits `json:"limits,omitzero"`
+
+	// TempDir is where copies are kept; empty uses the platform default
+	TempDir string `json:"temp_dir,omitempty"`
+
+	ListView string `json:"list_view,omitempty"`
+	Theme    string `json:"theme,omitempty"` // "" follows the OS
 }
 
-// Podcasterator is the main application
+// Podcasterator is the main application. Its fields belong to the UI
+// goroutine; see FeedServer for what the server's goroutines may read.
 type Podcasterator struct {
 	app            fyne.App
 	window         fyne.Window
 	files          []AudioFile
 	fileList       *widget.List
+	selectedID     string // file selected in the list, for keyboard shortcuts
+	fileListHolder *fyne.Container
+	listView       string
+	checked        map[string]bool // files ticked for bulk actions, by ID
+	bulkBar        *fyne.Container
+	bulkLabel      *widget.Label
 	serverRunning  bool
+	launching      bool // a launch is between the guard and the server starting
 	serverURL      string
 	server         *http.Server
-	serverMux      sync.Mutex
+	live           *FeedServer  // what the running server publishes
+	mdns           *mdnsAdvert  // mDNS advertisement of the running server
+	mdnsURL        string       // feed URL under the mDNS host name
+	serverAuthUser string       // username the running server requires, if any
+	serverMux      sync.Mutex   // guards the server fields above
+	activity       *activityLog // requests answered since the server started
 	podcastName    string
 	podcastEntry   *widget.Entry
+	projectID      string
+	projects       []Project // every project but the active one
+	projectSelect  *widget.Select
+	projectNewBtn  *widget.Button
+	projectDelBtn  *widget.Button
+	undoStack      []undoRecord
+	undoBtn        *tipButton
 	tempDir        string
+	customTempDir  string // chosen in Settings; empty uses defaultTempDir
+	defaultTempDir string
 	configDir      string
-	launchBtn      *widget.Button
-	stopBtn        *widget.Button
+	launchBtn      *tipButton
+	launchHint     *widget.Label // why launchBtn is disabled
+	stopBtn        *tipButton
 	urlLabel       *widget.Label
-	copyBtn        *widget.Button
+	liveLabel      *widget.Label // says the running feed follows edits
+	copyBtn        *tipButton
+	activityBtn    *tipButton
 	fileCountLabel *widget.Label
 	artworkPath    string
 	artworkImage   *canvas.Image
 	artworkBtn     *widget.Button
+	artworkInfo    *widget.Label
+	tooltips       *tooltipLayer // button tips over the window content
+	publicURL      string
+	publicURLEntry *widget.Entry
+
+	progressiveJPEG bool
+	artworkWarnKB   int
+	artworkFormat   string
+	artworkQuality  int
+	seasonSorts     map[int]string
+	keepExtension   bool
+	dedupByContent  bool
+	walkFolderOrder bool
+	verifyCopies    bool
+	localOnly       bool
+	serverAddress   string // IP advertised in feed URLs, "" for automatic
+	auth            feedAuth
+	useHTTPS        bool
+	allowCORS       bool
+	limits          serverLimits
+	themeMode       string
+	localOnlyCheck  *widget.Check
+	port            int
+	portEntry       *widget.Entry
+
+	resumeServer     bool
+	serverWasRunning bool // the server was left running, as saved for the next launch
+	lastServerPort   int
+
+	numberEnclosures bool
+	asciiFileNames   bool
+	channelElements  []channelElement
+	channelGUID      string
+	feedEpoch        time.Time
+	feedLocked       bool
+	ownerEmail       string
+	ownerName        string
+	episodeDefaults  episodeDefaults
+
+	watchFolder   string
+	watchRemovals bool
+	watchRemoved  []string // originals in watchFolder removed from the list
+	watcher       *folderWatcher
+
+	podcastAuthor   string
+	podcastSummary  string
+	podcastExplicit bool
+	podcastCategory string
+	podcastLanguage string
+
+	metadata   *metadataPipeline
+	processing map[string]bool // file IDs still being processed by the pipeline
+
+	detail *episodeDetail
+
+	dirty         bool          // state changed since the last save
+	autosaveDelay time.Duration // see startAutosave
+	saveTimer     *time.Timer   // pending autosave
+	publishDelay  time.Duration // see schedulePublish
+	publishTimer  *time.Timer   // pending republish of a running feed
+
+	tempDirUnavailable bool         // saved files live in a temp dir that's missing
+	movingTempDir      *tempDirMove // the move of the temp dir under way, if any
 }
 
 func main() {
+	// Serving a folder from the command line needs no window
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := runServe(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "podcasterator serve:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Configure Wayland support for Linux
 	setupWaylandSupport()
 
 	a := app.NewWithID("com.podcasterator.app")
 	p := &Podcasterator{
 		app:         a,
-		podcastName: "My Podcast",
+		podcastName: defaultPodcastName,
 	}
 
 	p.setupDirectories()
 	p.loadState()
+	p.ensureProjectID()
+	p.purgeTrash() // left over if the app didn't exit cleanly
+	p.ensureTempDir()
+	p.applyTheme()
 	p.createUI()
+	if p.tempDirUnavailable {
+		p.showTempDirMissing()
+	}
+	p.startWatching()
+	p.startMetadataPipeline()
+	p.startAutosave(autosaveDelay)
+	p.publishDelay = publishDelay
+	p.resumeServerOnLaunch()
+
+	// Ctrl+C or a service manager stop shouldn't cut off downloads or lose
+	// the arrangement. Quitting returns from ShowAndRun, so the cleanup
+	// below runs on this goroutine like any other quit.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go handleSignals(sigs, func() { fyne.Do(a.Quit) })
+
 	p.window.ShowAndRun()
+	p.shutdown()
+}
+
+// handleSignals waits for the first signal on sigs and then runs shutdown.
+func handleSignals(sigs <-chan os.Signal, shutdown func()) {
+	sig, ok := <-sigs
+	if !ok {
+		return
+	}
+	fmt.Printf("Received %v, shutting down...\n", sig)
+	shutdown()
 }
 
 func (p *Podcasterator) setupDirectories() {
@@ -123,13 +415,16 @@ func (p *Podcasterator) setupDirectories() {
 		p.configDir = filepath.Join(os.TempDir(), "podcasterator-config")
 	}
 
-	os.MkdirAll(p.tempDir, 0755)
+	// A temp dir chosen in Settings replaces this once state is loaded, and
+	// it's created by ensureTempDir then
+	p.defaultTempDir = p.tempDir
 	os.MkdirAll(p.configDir, 0755)
 }
 
 func (p *Podcasterator) createUI() {
 	p.window = p.app.NewWindow("Podcasterator")
 	p.window.Resize(fyne.NewSize(900, 600))
+	p.tooltips = newTooltipLayer()
 
 	// Title
 	title := widget.NewLabelWithStyle("Podcasterator", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
@@ -175,62 +470,73 @@ func (p *Podcasterator) createUI() {
 	// Store reference for later updates
 	p.artworkBtn = deleteArtworkBtn
 
+	// Dimensions and size of what listeners actually receive
+	p.artworkInfo = widget.NewLabel("")
+	p.updateArtworkInfo()
+
 	artworkContainer := container.NewVBox(
 		artworkBox,
+		container.NewCenter(p.artworkInfo),
 		container.NewCenter(deleteArtworkBtn),
 	)
 
-	// File list with arrow buttons for reordering
-	p.fileList = widget.NewList(
-		func() int { return len(p.files) },
-		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewButtonWithIcon("", theme.MoveUpIcon(), nil),
-				widget.NewButtonWithIcon("", theme.MoveDownIcon(), nil),
-				widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), nil),
-				widget.NewButtonWithIcon("", theme.DeleteIcon(), nil),
-				widget.NewLabel(""),
-			)
-		},
-		func(i widget.ListItemID, o fyne.CanvasObject) {
-			c := o.(*fyne.Container)
-			upBtn := c.Objects[0].(*widget.Button)
-			downBtn := c.Objects[1].(*widget.Button)
-			renameBtn := c.Objects[2].(*widget.Button)
-			delBtn := c.Objects[3].(*widget.Button)
-			label := c.Objects[4].(*widget.Label)
-
-			if i < len(p.files) {
-				file := p.files[i]
-				label.SetText(truncateFilename(file.DisplayName))
-
-				upBtn.OnTapped = func() { p.moveUp(i) }
-				downBtn.OnTapped = func() { p.moveDown(i) }
-				renameBtn.OnTapped = func() { p.renameFile(i) }
-				delBtn.OnTapped = func() { p.deleteFile(i) }
-			}
-		},
-	)
+	// File list, in the detailed or compact layout
+	p.fileList = p.newFileList()
+	p.fileListHolder = container.NewStack(p.fileList)
 
 	p.fileCountLabel = widget.NewLabel(fmt.Sprintf("%d files", len(p.files)))
 
 	// File list action buttons
-	clearAllBtn := widget.NewButton("Clear All", func() {
+	clearAllBtn := p.newTipButton("Clear All", nil, "Remove every file from the podcast", func() {
 		p.clearAll()
 	})
 
-	alphabetizeBtn := widget.NewButton("Alphabetize", func() {
-		p.alphabetize()
+	p.undoBtn = p.newTipButton("Undo", theme.ContentUndoIcon(), "Bring back the last removed files", func() {
+		p.undoRemove()
 	})
+	p.updateUndoButton()
 
-	reverseBtn := widget.NewButton("Reverse", func() {
+	var sortBtn *tipButton
+	sortBtn = p.newTipButton("Sort", theme.MenuDropDownIcon(), "Sort the files, or number their titles in list order", func() {
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(sortBtn)
+		pos.Y += sortBtn.Size().Height
+		widget.ShowPopUpMenuAtPosition(p.sortMenu(), p.window.Canvas(), pos)
+	})
+
+	reverseBtn := p.newTipButton("Reverse", nil, "Reverse the order of the files", func() {
 		p.reverse()
 	})
 
+	renameAllBtn := p.newTipButton("Rename All", nil, "Rename each file in turn, from the top", func() {
+		p.renameAllSequentially()
+	})
+
+	exportBtn := p.newTipButton("Export", nil, "Copy the files to a folder for hosting elsewhere", func() {
+		p.openExportDialog()
+	})
+
+	siteExportBtn := p.newTipButton("Export Feed", nil, "Write the feed and files as a static site to upload", func() {
+		p.openSiteExportDialog()
+	})
+
+	playlistExportBtn := p.newTipButton("Export Playlist", nil, "Save the files as an .m3u playlist", func() {
+		p.openExportPlaylistDialog()
+	})
+
+	seasonsBtn := p.newTipButton("Seasons", nil, "Group episodes into seasons", func() {
+		p.openSeasonsDialog()
+	})
+
 	fileListActions := container.NewHBox(
 		clearAllBtn,
-		alphabetizeBtn,
+		p.undoBtn,
+		sortBtn,
 		reverseBtn,
+		renameAllBtn,
+		seasonsBtn,
+		exportBtn,
+		siteExportBtn,
+		playlistExportBtn,
 	)
 
 	// Podcast name input
@@ -238,19 +544,60 @@ func (p *Podcasterator) createUI() {
 	p.podcastEntry.SetText(p.podcastName)
 	p.podcastEntry.OnChanged = func(s string) {
 		p.podcastName = s
-		p.saveState()
+		p.markDirty()
 	}
 	podcastNameRow := container.NewBorder(nil, nil,
 		widget.NewLabel("Podcast Name:"), nil,
 		p.podcastEntry,
 	)
 
+	// Optional public URL, used when the feed is reached through a proxy or hosted elsewhere
+	p.publicURLEntry = widget.NewEntry()
+	p.publicURLEntry.SetPlaceHolder(fmt.Sprintf("%s://%s:%d", p.serverScheme(), serverHost(false, p.serverAddress), p.serverPort()))
+	p.publicURLEntry.SetText(p.publicURL)
+	p.publicURLEntry.OnChanged = func(s string) {
+		p.publicURL = strings.TrimSpace(s)
+		p.markDirty()
+	}
+	publicURLRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Public URL:"), nil,
+		p.publicURLEntry,
+	)
+
+	// Port the server listens on; the next few are tried if it's taken
+	p.portEntry = widget.NewEntry()
+	p.portEntry.SetText(strconv.Itoa(p.serverPort()))
+	p.portEntry.Validator = func(s string) error {
+		_, err := validatePort(s)
+		return err
+	}
+	p.portEntry.OnChanged = func(s string) {
+		if port, err := validatePort(s); err == nil {
+			p.port = port
+			p.markDirty()
+		}
+	}
+	portRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Port:"), nil,
+		p.portEntry,
+	)
+
 	// Server controls
-	p.launchBtn = widget.NewButton("Launch Local Podcast Server", func() {
+	p.launchBtn = p.newTipButton("Launch Local Podcast Server", nil, launchTip, func() {
 		p.launchServer()
 	})
+	p.launchHint = widget.NewLabel("Add audio files to launch the server.")
+	p.launchHint.Importance = widget.LowImportance
+	p.updateLaunchButton()
+
+	// Local-only mode previews the feed on this computer without exposing it to the LAN
+	p.localOnlyCheck = widget.NewCheck("Local only (this computer)", func(checked bool) {
+		p.localOnly = checked
+		p.markDirty()
+	})
+	p.localOnlyCheck.SetChecked(p.localOnly)
 
-	p.stopBtn = widget.NewButton("Stop server", func() {
+	p.stopBtn = p.newTipButton("Stop server", nil, "Stop serving the feed", func() {
 		p.stopServer()
 	})
 	p.stopBtn.Hide()
@@ -258,37 +605,71 @@ func (p *Podcasterator) createUI() {
 	p.urlLabel = widget.NewLabel("")
 	p.urlLabel.Hide()
 
-	p.copyBtn = widget.NewButton("Copy URL", func() {
+	p.liveLabel = widget.NewLabel("")
+	p.liveLabel.Importance = widget.SuccessImportance
+	p.liveLabel.Hide()
+
+	p.copyBtn = p.newTipButton("Copy URL", nil, "Copy the feed address to paste into a podcast app", func() {
 		p.window.Clipboard().SetContent(p.serverURL)
 	})
 	p.copyBtn.Hide()
 
+	p.activityBtn = p.newTipButton("Activity…", nil, "See the requests the server has answered", func() {
+		p.showActivity()
+	})
+	p.activityBtn.Hide()
+
+	settingsBtn := p.newTipButton("Settings", theme.SettingsIcon(), "Feed, network, import and storage options", func() {
+		p.openSettingsDialog()
+	})
+
 	serverControls := container.NewVBox(
 		p.launchBtn,
+		p.launchHint,
+		p.localOnlyCheck,
 		p.stopBtn,
-		container.NewHBox(p.copyBtn, p.urlLabel),
+		container.NewHBox(p.copyBtn, p.activityBtn, p.urlLabel),
+		p.liveLabel,
+		settingsBtn,
 	)
 
 	// Left panel
 	leftPanel := container.NewBorder(
 		container.NewVBox(title, container.NewPadded(dropZoneContainer)),
-		container.NewVBox(podcastNameRow, serverControls),
+		container.NewVBox(p.createProjectRow(), podcastNameRow, publicURLRow, portRow, serverControls),
 		nil, nil,
 		artworkContainer,
 	)
 
 	// Right panel
+	viewToggle := widget.NewRadioGroup([]string{"Detailed", "Compact"}, func(s string) {
+		if s == "Compact" {
+			p.setListView(listViewCompact)
+		} else if s == "Detailed" {
+			p.setListView(listViewDetailed)
+		}
+	})
+	viewToggle.Horizontal = true
+	viewToggle.Required = true
+	if p.listView == listViewCompact {
+		viewToggle.SetSelected("Compact")
+	} else {
+		viewToggle.SetSelected("Detailed")
+	}
+
 	rightPanel := container.NewBorder(
-		container.NewVBox(p.fileCountLabel, fileListActions),
-		nil, nil, nil,
-		container.NewScroll(p.fileList),
+		container.NewVBox(container.NewBorder(nil, nil, p.fileCountLabel, viewToggle), fileListActions, p.createBulkBar()),
+		nil, nil, p.createDetailPanel(),
+		p.fileListHolder,
 	)
 
 	// Main content
 	content := container.NewHSplit(leftPanel, rightPanel)
 	content.SetOffset(0.4)
 
-	p.window.SetContent(content)
+	p.window.SetContent(p.tooltips.wrap(content))
+
+	p.registerShortcuts()
 
 	// Set up drag and drop
 	p.window.SetOnDropped(func(pos fyne.Position, uris []fyne.URI) {
@@ -307,9 +688,196 @@ func (p *Podcasterator) createUI() {
 	})
 }
 
+func (p *Podcasterator) openSettingsDialog() {
+	progressiveCheck := widget.NewCheck("Progressive JPEG artwork", func(checked bool) {
+		p.progressiveJPEG = checked
+		p.markDirty()
+	})
+	progressiveCheck.SetChecked(p.progressiveJPEG)
+
+	artworkNote := widget.NewLabel("Applies to newly set artwork. Baseline JPEG is used\nwhen no progressive encoder is available.")
+	artworkNote.Importance = widget.LowImportance
+
+	qualityEntry := widget.NewEntry()
+	qualityEntry.SetText(strconv.Itoa(p.jpegQuality()))
+	qualityEntry.Validator = func(s string) error {
+		if q, err := strconv.Atoi(s); err != nil || q < 1 || q > 100 {
+			return fmt.Errorf("enter a quality from 1 to 100")
+		}
+		return nil
+	}
+	qualityEntry.OnChanged = func(s string) {
+		if q, err := strconv.Atoi(s); err == nil && q >= 1 && q <= 100 {
+			p.artworkQuality = q
+			p.markDirty()
+		}
+	}
+	qualityRow := container.NewBorder(nil, nil, widget.NewLabel("JPEG quality (1-100):"), nil, qualityEntry)
+
+	formatSelect := widget.NewSelect([]string{"JPEG", "PNG"}, func(s string) {
+		format := artworkFormatJPEG
+		if s == "PNG" {
+			format = artworkFormatPNG
+		}
+		if format == p.artworkFormat || (p.artworkFormat == "" && format == artworkFormatJPEG) {
+			return
+		}
+		p.artworkFormat = format
+		p.markDirty()
+	})
+	if p.artworkFormat == artworkFormatPNG {
+		formatSelect.SetSelected("PNG")
+	} else {
+		formatSelect.SetSelected("JPEG")
+	}
+	formatRow := container.NewBorder(nil, nil, widget.NewLabel("Artwork format:"), nil, formatSelect)
+
+	warnEntry := widget.NewEntry()
+	warnEntry.SetText(strconv.Itoa(int(p.artworkWarnBytes() / 1024)))
+	warnEntry.Validator = func(s string) error {
+		if kb, err := strconv.Atoi(s); err != nil || kb <= 0 {
+			return fmt.Errorf("enter a size in KB")
+		}
+		return nil
+	}
+	warnEntry.OnChanged = func(s string) {
+		if kb, err := strconv.Atoi(s); err == nil && kb > 0 {
+			p.artworkWarnKB = kb
+			p.markDirty()
+		}
+	}
+	warnRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Warn when artwork exceeds (KB):"), nil,
+		warnEntry,
+	)
+
+	keepExtCheck := widget.NewCheck("Keep original .mp4/.m4b extension", func(checked bool) {
+		p.keepExtension = checked
+		p.markDirty()
+	})
+	keepExtCheck.SetChecked(p.keepExtension)
+
+	filesNote := widget.NewLabel("By default MP4 and M4B files are served as .m4a.\nApplies to newly added files.")
+	filesNote.Importance = widget.LowImportance
+
+	dedupCheck := widget.NewCheck("Skip files with the same audio as one already added", func(checked bool) {
+		p.dedupByContent = checked
+		p.markDirty()
+	})
+	dedupCheck.SetChecked(p.dedupByContent)
+
+	dedupNote := widget.NewLabel("Compares file contents, so the same episode added from\nanother folder isn't copied twice.")
+	dedupNote.Importance = widget.LowImportance
+
+	verifyCheck := widget.NewCheck("Verify copies against the originals", func(checked bool) {
+		p.verifyCopies = checked
+		p.markDirty()
+	})
+	verifyCheck.SetChecked(p.verifyCopies)
+
+	verifyNote := widget.NewLabel("Reads each copy back to catch corruption, e.g. from a flaky\nUSB drive. A bad copy is made again, then reported. Slower.")
+	verifyNote.Importance = widget.LowImportance
+
+	folderOrderCheck := widget.NewCheck("Add folders in plain name order (10.mp3 before 2.mp3)", func(checked bool) {
+		p.walkFolderOrder = checked
+		p.markDirty()
+	})
+	folderOrderCheck.SetChecked(p.walkFolderOrder)
+
+	folderOrderNote := widget.NewLabel("By default a folder's files are added in natural order,\n2.mp3 before 10.mp3, like the Alphabetically sort.")
+	folderOrderNote.Importance = widget.LowImportance
+
+	numberCheck := widget.NewCheck("Number served file names in list order", func(checked bool) {
+		p.numberEnclosures = checked
+		p.markDirty()
+	})
+	numberCheck.SetChecked(p.numberEnclosures)
+
+	numberNote := widget.NewLabel("Prefixes download names with 01-, 02-, ... for apps that\nsort by file name. Titles and files are not renamed.")
+	numberNote.Importance = widget.LowImportance
+
+	asciiCheck := widget.NewCheck("Use plain ASCII served file names", func(checked bool) {
+		p.asciiFileNames = checked
+		p.markDirty()
+	})
+	asciiCheck.SetChecked(p.asciiFileNames)
+
+	asciiNote := widget.NewLabel("Turns Café Ünïcode 🎧.mp3 into Cafe-Unicode.mp3 in download links,\nfor apps that fail on accents or emoji. Titles keep the real name.")
+	asciiNote.Importance = widget.LowImportance
+
+	// Podcasting 2.0 <podcast:locked> asks directories not to let others import the feed
+	lockedCheck := widget.NewCheck("Lock feed against imports (podcast:locked)", func(checked bool) {
+		p.feedLocked = checked
+		p.markDirty()
+	})
+	lockedCheck.SetChecked(p.feedLocked)
+
+	ownerEntry := widget.NewEntry()
+	ownerEntry.SetPlaceHolder("you@example.com")
+	ownerEntry.SetText(p.ownerEmail)
+	ownerEntry.Validator = validateOwnerEmail
+	ownerEntry.OnChanged = func(s string) {
+		p.ownerEmail = strings.TrimSpace(s)
+		p.markDirty()
+	}
+	ownerRow := container.NewBorder(nil, nil, widget.NewLabel("Owner email:"), nil, ownerEntry)
+
+	ownerNameEntry := widget.NewEntry()
+	ownerNameEntry.SetPlaceHolder("Your name")
+	ownerNameEntry.SetText(p.ownerName)
+	ownerNameEntry.OnChanged = func(s string) {
+		p.ownerName = strings.TrimSpace(s)
+		p.markDirty()
+	}
+	ownerNameRow := container.NewBorder(nil, nil, widget.NewLabel("Owner name:"), nil, ownerNameEntry)
+
+	ownerNote := widget.NewLabel("Directories show the owner as <itunes:owner> and email it to\nconfirm the feed is yours. Left out when both are blank.")
+	ownerNote.Importance = widget.LowImportance
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Files", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		keepExtCheck,
+		filesNote,
+		dedupCheck,
+		dedupNote,
+		verifyCheck,
+		verifyNote,
+		folderOrderCheck,
+		folderOrderNote,
+		numberCheck,
+		numberNote,
+		asciiCheck,
+		asciiNote,
+		p.podcastDetailsSection(),
+		widget.NewLabelWithStyle("Feed", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		lockedCheck,
+		ownerNameRow,
+		ownerRow,
+		ownerNote,
+		widget.NewButton("Custom Channel Elements…", func() {
+			p.openChannelElementsDialog()
+		}),
+		widget.NewLabelWithStyle("Artwork", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		formatRow,
+		qualityRow,
+		progressiveCheck,
+		artworkNote,
+		warnRow,
+		p.episodeDefaultsSection(),
+		p.watchSection(),
+		p.storageSection(),
+		p.networkSection(),
+		p.authSection(),
+		p.limitsSection(),
+		p.appearanceSection(),
+	)
+
+	dialog.ShowCustom("Settings", "Close", content, p.window)
+}
+
 func (p *Podcasterator) handleDroppedPath(path string) {
 	info, err := os.Stat(path)
-	if err != nil {
+	if err != nil || !p.requireTempDir() {
 		return
 	}
 
@@ -317,9 +885,11 @@ func (p *Podcasterator) handleDroppedPath(path string) {
 		p.addFolder(path)
 	} else {
 		if isImageFile(path) {
-			p.setArtwork(path)
+			p.chooseArtwork(path)
+		} else if isPlaylistFile(path) {
+			p.importPlaylist(path)
 		} else if isSupportedFile(path) {
-			p.addFile(path)
+			p.addFileInBackground(path)
 		}
 	}
 }
@@ -335,9 +905,11 @@ func (p *Podcasterator) openFileDialog() {
 
 			path := reader.URI().Path()
 			if isSupportedFile(path) {
-				p.addFile(path)
+				p.addFileInBackground(path)
 			} else if isImageFile(path) {
-				p.setArtwork(path)
+				p.chooseArtwork(path)
+			} else if isPlaylistFile(path) {
+				p.importPlaylist(path)
 			}
 		}, p.window)
 	})
@@ -360,73 +932,254 @@ func (p *Podcasterator) openFileDialog() {
 
 			path := reader.URI().Path()
 			if isImageFile(path) {
-				p.setArtwork(path)
+				p.chooseArtwork(path)
 			}
 		}, p.window)
 	})
 
+	var d dialog.Dialog
+	playlistBtn := widget.NewButton("Import Playlist", func() {
+		d.Hide()
+		p.openPlaylistDialog()
+	})
+
+	downloadBtn := widget.NewButton("Download from URL", func() {
+		d.Hide()
+		p.openDownloadDialog()
+	})
+
+	externalBtn := widget.NewButton("Add External URL", func() {
+		d.Hide()
+		p.openExternalURLDialog()
+	})
+
 	content := container.NewVBox(
 		widget.NewLabel("Choose what to add:"),
 		fileBtn,
 		folderBtn,
 		imageBtn,
+		playlistBtn,
+		downloadBtn,
+		externalBtn,
 	)
 
-	d := dialog.NewCustom("Add Files", "Cancel", content, p.window)
+	d = dialog.NewCustom("Add Files", "Cancel", content, p.window)
 	d.Show()
 }
 
-func (p *Podcasterator) addFile(path string) {
+func (p *Podcasterator) openDownloadDialog() {
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://example.com/episode.mp3")
+
+	d := dialog.NewForm("Download from URL", "Download", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("URL", urlEntry)},
+		func(confirmed bool) {
+			if confirmed && strings.TrimSpace(urlEntry.Text) != "" {
+				p.downloadFromURL(strings.TrimSpace(urlEntry.Text))
+			}
+		}, p.window)
+	d.Resize(fyne.NewSize(500, 150))
+	d.Show()
+}
+
+// downloadFromURL fetches audio into temp storage with a cancellable
+// progress dialog, then adds it like a local file.
+func (p *Podcasterator) downloadFromURL(rawURL string) {
+	if !p.requireTempDir() {
+		return
+	}
+
 	// Check if already added
 	for _, f := range p.files {
-		if f.OriginalPath == path {
+		if f.SourceURL == rawURL {
 			return
 		}
 	}
 
 	id := uuid.New().String()
-	fileName := filepath.Base(path)
+	dir := filepath.Join(p.projectTempDir(), id)
+	projectID, keepExtension := p.projectID, p.keepExtension
+	ctx, cancel := context.WithCancel(context.Background())
+
+	status := widget.NewLabel(rawURL)
+	bar := widget.NewProgressBar()
+	d := dialog.NewCustom("Downloading", "Cancel", container.NewVBox(status, bar), p.window)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(450, 150))
+	d.Show()
 
-	// Rename mp4 and m4b to m4a for better compatibility
-	ext := strings.ToLower(filepath.Ext(fileName))
-	if ext == ".mp4" || ext == ".m4b" {
-		fileName = strings.TrimSuffix(fileName, ext) + ".m4a"
+	go func() {
+		lastPercent := -1
+		path, err := downloadAudio(ctx, nil, rawURL, dir, keepExtension, func(written, total int64) {
+			if total <= 0 {
+				return
+			}
+			// Only hop to the UI thread when the visible value changes
+			percent := int(written * 100 / total)
+			if percent != lastPercent {
+				lastPercent = percent
+				fyne.Do(func() { bar.SetValue(float64(percent) / 100) })
+			}
+		})
+		cancelled := ctx.Err() != nil
+
+		fyne.Do(func() {
+			d.Hide()
+			if err != nil {
+				os.RemoveAll(dir)
+				if !cancelled {
+					dialog.ShowError(fmt.Errorf("download failed: %w", err), p.window)
+				}
+				return
+			}
+			if p.projectID != projectID {
+				os.RemoveAll(dir)
+				return
+			}
+
+			p.appendFile(AudioFile{
+				ID:          id,
+				TempPath:    path,
+				DisplayName: filepath.Base(path),
+				SourceURL:   rawURL,
+			})
+		})
+	}()
+}
+
+func (p *Podcasterator) openExternalURLDialog() {
+	urlEntry := widget.NewEntry()
+	urlEntry.SetPlaceHolder("https://cdn.example.com/episode.mp3")
+	titleEntry := widget.NewEntry()
+	titleEntry.SetPlaceHolder("Defaults to the file name in the URL")
+	lengthEntry := widget.NewEntry()
+	lengthEntry.SetPlaceHolder("Size in bytes (fetched if blank)")
+	typeEntry := widget.NewEntry()
+	typeEntry.SetPlaceHolder("Fetched or detected from the extension")
+
+	// Ask the server for length and type; anything already typed is kept
+	var fetchBtn *widget.Button
+	fetchBtn = widget.NewButton("Fetch details", func() {
+		fetchBtn.Disable()
+		rawURL, length, mimeType := urlEntry.Text, lengthEntry.Text, typeEntry.Text
+		go func() {
+			length, mimeType, err := fillExternalDetails(nil, rawURL, length, mimeType)
+			fyne.Do(func() {
+				fetchBtn.Enable()
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("couldn't fetch details: %w", err), p.window)
+					return
+				}
+				lengthEntry.SetText(length)
+				typeEntry.SetText(mimeType)
+			})
+		}()
+	})
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("URL", urlEntry),
+		widget.NewFormItem("Title", titleEntry),
+		widget.NewFormItem("Length", lengthEntry),
+		widget.NewFormItem("Type", typeEntry),
+		widget.NewFormItem("", fetchBtn),
 	}
 
-	tempPath := filepath.Join(p.tempDir, id, fileName)
-	os.MkdirAll(filepath.Dir(tempPath), 0755)
+	d := dialog.NewForm("Add External URL", "Add", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
 
-	// Copy file
-	if err := copyFile(path, tempPath); err != nil {
-		return
+		rawURL, title, length, mimeType := urlEntry.Text, titleEntry.Text, lengthEntry.Text, typeEntry.Text
+		go func() {
+			// A failed HEAD isn't fatal; validation reports whatever is still missing
+			length, mimeType, _ = fillExternalDetails(nil, rawURL, length, mimeType)
+			file, err := newExternalFile(rawURL, title, length, mimeType)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, p.window)
+					return
+				}
+				p.addExternalFile(file)
+			})
+		}()
+	}, p.window)
+	d.Resize(fyne.NewSize(500, 250))
+	d.Show()
+}
+
+func (p *Podcasterator) addExternalFile(file AudioFile) {
+	// Check if already added
+	for _, f := range p.files {
+		if f.ExternalURL == file.ExternalURL {
+			return
+		}
 	}
 
-	p.files = append(p.files, AudioFile{
-		ID:           id,
-		OriginalPath: path,
-		TempPath:     tempPath,
-		DisplayName:  fileName,
-	})
+	p.appendFile(file)
+}
+
+// appendFile adds a prepared file to the end of the list and updates the UI
+func (p *Podcasterator) appendFile(file AudioFile) {
+	p.appendFiles([]AudioFile{file})
+}
+
+// appendFiles adds several files with a single list refresh
+func (p *Podcasterator) appendFiles(files []AudioFile) {
+	if len(files) == 0 {
+		return
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	taken := takenNames(p.files)
+	for _, file := range files {
+		if file.AddedAt.IsZero() {
+			file.AddedAt = now
+		}
+		// A second "book.m4a" becomes "book (2).m4a"; if its copy can't be
+		// renamed it keeps the name it has
+		if name := uniqueName(file.DisplayName, taken); name != file.DisplayName {
+			if err := p.applyRename(&file, name); err != nil {
+				fmt.Printf("Couldn't rename %s to %s: %v\n", file.DisplayName, name, err)
+			}
+		}
+		taken[strings.ToLower(file.DisplayName)] = true
+		p.episodeDefaults.apply(&file)
+		if !file.IsExternal() {
+			file.refreshStat()
+		}
+		p.files = append(p.files, file)
+	}
+	p.applySeasonSorts()
+	added := make([]string, len(files))
+	for i, file := range files {
+		p.extractMetadata(file)
+		added[i] = file.OriginalPath
+	}
+	p.forgetWatchedRemoved(added)
 
 	if p.fileList != nil {
 		p.fileList.Refresh()
 	}
+	p.updateFileCount()
+	p.markDirty()
+}
+
+// updateFileCount shows how many files there are, and whether the server
+// can be launched with them
+func (p *Podcasterator) updateFileCount() {
 	if p.fileCountLabel != nil {
 		p.fileCountLabel.SetText(fmt.Sprintf("%d files", len(p.files)))
 	}
-	p.saveState()
+	p.updateLaunchButton()
+}
+
+// addFile copies a file into the temp dir and adds it, blocking until the
+// copy is done. The UI uses addFileInBackground instead.
+func (p *Podcasterator) addFile(path string) {
+	p.addFiles([]string{path})
 }
 
 func (p *Podcasterator) addFolder(path string) {
-	filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			return nil
-		}
-		if isSupportedFile(file) {
-			p.addFile(file)
-		}
-		return nil
-	})
+	p.addFilesInBackground(folderFiles(path, p.walkFolderOrder))
 }
 
 func (p *Podcasterator) deleteFile(index int) {
@@ -435,24 +1188,37 @@ func (p *Podcasterator) deleteFile(index int) {
 	}
 
 	file := p.files[index]
-	os.Remove(file.TempPath)
+	if p.detail != nil && p.detail.fileID == file.ID {
+		p.fileList.UnselectAll()
+		p.hideEpisodeDetail()
+	}
 
-	p.files = append(p.files[:index], p.files[index+1:]...)
+	p.removeFiles([]int{index})
 	if p.fileList != nil {
 		p.fileList.Refresh()
 	}
-	if p.fileCountLabel != nil {
-		p.fileCountLabel.SetText(fmt.Sprintf("%d files", len(p.files)))
-	}
-	p.saveState()
+	p.updateFileCount()
+	p.saveNow()
 }
 
 func (p *Podcasterator) renameFile(index int) {
+	p.showRenameDialog(index, false)
+}
+
+// renameAllSequentially walks the list from the top, opening the rename
+// dialog for each file in turn until the end or Cancel.
+func (p *Podcasterator) renameAllSequentially() {
+	p.showRenameDialog(0, true)
+}
+
+// showRenameDialog opens the rename dialog for the file at index. With
+// chain set, confirming opens the dialog for the next file.
+func (p *Podcasterator) showRenameDialog(index int, chain bool) {
 	if index < 0 || index >= len(p.files) {
 		return
 	}
 
-	file := &p.files[index]
+	file := p.files[index]
 
 	// Create entry for new name with appropriate width
 	entry := widget.NewEntry()
@@ -471,306 +1237,927 @@ func (p *Podcasterator) renameFile(index int) {
 	entryContainer := container.NewPadded(entry)
 	entryContainer.Resize(fyne.NewSize(minWidth, 40))
 
+	chainCheck := widget.NewCheck("Then rename the next file", nil)
+	chainCheck.SetChecked(chain)
+	if index == len(p.files)-1 {
+		chainCheck.Disable()
+	}
+
+	title := "Rename File"
+	if chain {
+		title = fmt.Sprintf("Rename File %d of %d", index+1, len(p.files))
+	}
+
 	// Create custom dialog
-	d := dialog.NewCustomConfirm("Rename File", "Rename", "Cancel",
+	d := dialog.NewCustomConfirm(title, "Rename", "Cancel",
 		container.NewVBox(
 			widget.NewLabel("New Name:"),
 			entryContainer,
+			chainCheck,
 		),
 		func(confirmed bool) {
-			if confirmed && entry.Text != "" && entry.Text != file.DisplayName {
-				// Get extension from old name
-				oldExt := filepath.Ext(file.DisplayName)
-				newName := entry.Text
-
-				// Ensure new name has an extension
-				if filepath.Ext(newName) == "" {
-					newName = newName + oldExt
-				}
-
-				// Rename temp file
-				newTempPath := filepath.Join(filepath.Dir(file.TempPath), newName)
-				if err := os.Rename(file.TempPath, newTempPath); err == nil {
-					file.DisplayName = newName
-					file.TempPath = newTempPath
-					p.fileList.Refresh()
-					p.saveState()
-				}
+			if !confirmed {
+				return
 			}
+			next, err := p.renameAndAdvance(file.ID, entry.Text, chainCheck.Checked)
+			if err != nil {
+				dialog.ShowError(err, p.window)
+				return
+			}
+			p.showRenameDialog(next, true)
 		},
 		p.window,
 	)
 
+	// Enter confirms, so a whole list can be renamed from the keyboard
+	entry.OnSubmitted = func(string) { d.Confirm() }
+
 	// Resize the dialog itself
 	d.Resize(fyne.NewSize(minWidth+100, 150))
 	d.Show()
+	p.window.Canvas().Focus(entry)
 }
 
-func (p *Podcasterator) moveUp(index int) {
-	if index > 0 && index < len(p.files) {
-		p.files[index], p.files[index-1] = p.files[index-1], p.files[index]
-		if p.fileList != nil {
-			p.fileList.Refresh()
+// renameAndAdvance renames the file with id and returns the index of the
+// next file to rename, or -1 when there isn't one or chain is off.
+func (p *Podcasterator) renameAndAdvance(id, name string, chain bool) (int, error) {
+	for i := range p.files {
+		if p.files[i].ID != id {
+			continue
 		}
-		p.saveState()
+		if err := p.applyRename(&p.files[i], name); err != nil {
+			return -1, err
+		}
+		if !chain || i+1 >= len(p.files) {
+			return -1, nil
+		}
+		return i + 1, nil
 	}
+	return -1, fmt.Errorf("file not found")
 }
 
-func (p *Podcasterator) moveDown(index int) {
-	if index >= 0 && index < len(p.files)-1 {
-		p.files[index], p.files[index+1] = p.files[index+1], p.files[index]
-		if p.fileList != nil {
-			p.fileList.Refresh()
-		}
-		p.saveState()
+// applyRename gives file a new display name, renaming its temp copy to
+// match. The old extension is kept when the new name has none.
+func (p *Podcasterator) applyRename(file *AudioFile, name string) error {
+	if name == "" || name == file.DisplayName {
+		return nil
 	}
-}
 
-func (p *Podcasterator) clearAll() {
-	if len(p.files) == 0 {
-		return
+	// Get extension from old name
+	oldExt := filepath.Ext(file.DisplayName)
+	newName := name
+
+	// Ensure new name has an extension
+	if filepath.Ext(newName) == "" {
+		newName = newName + oldExt
 	}
 
-	// Remove all temp files
-	for _, file := range p.files {
-		os.Remove(file.TempPath)
+	// External files have nothing on disk to rename
+	if !file.IsExternal() {
+		newTempPath := filepath.Join(filepath.Dir(file.TempPath), newName)
+		if err := os.Rename(file.TempPath, newTempPath); err != nil {
+			return err
+		}
+		file.TempPath = newTempPath
+		file.invalidateStat()
 	}
 
-	p.files = []AudioFile{}
+	file.DisplayName = newName
 	if p.fileList != nil {
 		p.fileList.Refresh()
 	}
-	if p.fileCountLabel != nil {
-		p.fileCountLabel.SetText(fmt.Sprintf("%d files", len(p.files)))
-	}
-	p.saveState()
+	p.markDirty()
+	return nil
 }
 
-func (p *Podcasterator) alphabetize() {
-	if len(p.files) <= 1 {
-		return
-	}
+// setServeOriginal switches a file between serving its temp copy and its
+// original. Switching to the original requires that it still exists.
+func (p *Podcasterator) setServeOriginal(id string, serveOriginal bool) error {
+	for i := range p.files {
+		file := &p.files[i]
+		if file.ID != id {
+			continue
+		}
+		if file.IsExternal() {
+			return fmt.Errorf("external files are not served locally")
+		}
+		if serveOriginal && needsTranscode(file.OriginalPath) {
+			return fmt.Errorf("the original is in a format podcast apps can't play; the converted copy is served")
+		}
+		if serveOriginal && file.StartOffset > 0 {
+			return fmt.Errorf("the original can't be cut; clear Start At to serve it")
+		}
+		if file.ServeOriginal == serveOriginal {
+			return nil
+		}
+		if serveOriginal && !fileExists(file.OriginalPath) {
+			return fmt.Errorf("original file not found: %s", file.OriginalPath)
+		}
+		file.ServeOriginal = serveOriginal
+		file.refreshStat()
+		p.markDirty()
+		return nil
+	}
+	return fmt.Errorf("file not found")
+}
 
-	// Sort files alphabetically by display name
-	sortedFiles := make([]AudioFile, len(p.files))
-	copy(sortedFiles, p.files)
+func (p *Podcasterator) openSeasonsDialog() {
+	if len(p.files) == 0 {
+		return
+	}
 
-	// Simple bubble sort (or use sort.Slice for efficiency)
-	for i := 0; i < len(sortedFiles)-1; i++ {
-		for j := 0; j < len(sortedFiles)-i-1; j++ {
-			if strings.ToLower(sortedFiles[j].DisplayName) > strings.ToLower(sortedFiles[j+1].DisplayName) {
-				sortedFiles[j], sortedFiles[j+1] = sortedFiles[j+1], sortedFiles[j]
-			}
+	// Assign a range of list positions to a season
+	fromEntry := widget.NewEntry()
+	fromEntry.SetText("1")
+	toEntry := widget.NewEntry()
+	toEntry.SetText(strconv.Itoa(len(p.files)))
+	seasonEntry := widget.NewEntry()
+	seasonEntry.SetText("1")
+
+	var seasonSelect *widget.Select
+	seasonOptions := func() []string {
+		options := []string{}
+		for _, season := range seasonNumbers(p.files) {
+			options = append(options, strconv.Itoa(season))
 		}
+		return options
 	}
 
-	p.files = sortedFiles
-	if p.fileList != nil {
-		p.fileList.Refresh()
+	assignBtn := widget.NewButton("Assign", func() {
+		from, err1 := strconv.Atoi(fromEntry.Text)
+		to, err2 := strconv.Atoi(toEntry.Text)
+		season, err3 := strconv.Atoi(seasonEntry.Text)
+		if err1 != nil || err2 != nil || err3 != nil {
+			dialog.ShowError(fmt.Errorf("positions and season must be numbers"), p.window)
+			return
+		}
+		p.assignSeason(from-1, to-1, season)
+		seasonSelect.SetOptions(seasonOptions())
+	})
+
+	assignRow := container.NewHBox(
+		widget.NewLabel("Files"), fromEntry,
+		widget.NewLabel("to"), toEntry,
+		widget.NewLabel("→ season"), seasonEntry,
+		assignBtn,
+	)
+
+	// Per-season sort, remembered and reapplied as files are added
+	modeOptions := []string{
+		seasonSortLabels[seasonSortNone],
+		seasonSortLabels[seasonSortNameAsc],
+		seasonSortLabels[seasonSortNameDesc],
 	}
-	p.saveState()
+	modeSelect := widget.NewSelect(modeOptions, nil)
+	seasonSelect = widget.NewSelect(seasonOptions(), func(s string) {
+		season, _ := strconv.Atoi(s)
+		modeSelect.SetSelected(seasonSortLabels[p.seasonSorts[season]])
+	})
+	sortBtn := widget.NewButton("Sort", func() {
+		if seasonSelect.Selected == "" {
+			return
+		}
+		season, _ := strconv.Atoi(seasonSelect.Selected)
+		for mode, label := range seasonSortLabels {
+			if label == modeSelect.Selected {
+				p.sortSeason(season, mode)
+			}
+		}
+	})
+
+	sortRow := container.NewHBox(
+		widget.NewLabel("Season"), seasonSelect,
+		widget.NewLabel("order"), modeSelect,
+		sortBtn,
+	)
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Assign", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		assignRow,
+		widget.NewLabelWithStyle("Sort within a season", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		sortRow,
+		widget.NewLabel("Season 0 holds files without a season."),
+	)
+
+	dialog.ShowCustom("Seasons", "Close", content, p.window)
 }
 
-func (p *Podcasterator) reverse() {
-	if len(p.files) <= 1 {
+// replaceFile asks for a new source and swaps it in for the file at index
+func (p *Podcasterator) replaceFile(index int) {
+	if index < 0 || index >= len(p.files) || p.files[index].IsExternal() {
 		return
 	}
 
-	// Reverse the order of files
-	reversed := make([]AudioFile, len(p.files))
-	for i, file := range p.files {
-		reversed[len(p.files)-1-i] = file
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		path := reader.URI().Path()
+		if !isSupportedFile(path) {
+			dialog.ShowError(fmt.Errorf("unsupported file type: %s", filepath.Ext(path)), p.window)
+			return
+		}
+		p.replaceAudioInBackground(index, path)
+	}, p.window)
+}
+
+// replaceAudioInBackground copies path for the file at index off the UI
+// thread, with a cancellable progress dialog for large files. The new audio
+// is swapped in once the copy is complete.
+func (p *Podcasterator) replaceAudioInBackground(index int, path string) {
+	tmpPath, err := p.prepareReplace(index, path)
+	if err != nil {
+		if p.window != nil {
+			dialog.ShowError(err, p.window)
+		}
+		return
 	}
 
-	p.files = reversed
+	ctx, cancel := context.WithCancel(context.Background())
+	bar, d := p.showCopyProgress("Replacing Audio", path, cancel)
+	id, partialDir, verify := p.files[index].ID, filepath.Join(p.projectTempDir(), partialDirName), p.verifyCopies
+	go func() {
+		sum, err := importAudio(ctx, path, tmpPath, partialDir, verify, copyProgress(bar))
+		cancelled := ctx.Err() != nil
+		cancel()
+
+		fyne.Do(func() {
+			if d != nil {
+				d.Hide()
+			}
+			if err == nil {
+				err = p.finishReplace(id, path, tmpPath, sum)
+			}
+			if err != nil && !cancelled && p.window != nil {
+				dialog.ShowError(err, p.window)
+			}
+		})
+	}()
+}
+
+// prepareReplace checks that the audio of the file at index can be
+// replaced by path and returns where the new audio is copied to, beside
+// the old audio so a failed copy leaves it intact
+func (p *Podcasterator) prepareReplace(index int, path string) (string, error) {
+	if index < 0 || index >= len(p.files) {
+		return "", fmt.Errorf("file not found")
+	}
+	if err := p.tempDirErr(); err != nil {
+		return "", err
+	}
+	file := p.files[index]
+	if file.IsExternal() {
+		return "", fmt.Errorf("external files have no local audio to replace")
+	}
+	if err := checkFreeSpace(p.tempDir, sourcesSize([]string{path})); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(p.projectTempDir(), file.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, replacedName(file.DisplayName, path, p.keepExtension)+".tmp"), nil
+}
+
+// replacedName is the name of a file called name once its audio is
+// replaced by path: only the extension follows the new source
+func replacedName(name, path string, keepExtension bool) string {
+	newExt := filepath.Ext(podcastFileName(filepath.Base(path), keepExtension))
+	return strings.TrimSuffix(name, filepath.Ext(name)) + newExt
+}
+
+// finishReplace swaps the audio copied from path to tmpPath in for the
+// file with id. The ID, position, title and episode details are kept. The
+// old chapters and transcript were timed to the old audio, so they're
+// dropped; the new source's embedded chapters are read again and a
+// transcript beside it is copied, as when a file is added.
+func (p *Podcasterator) finishReplace(id, path, tmpPath, sum string) error {
+	index := p.fileIndex(id)
+	if index < 0 {
+		os.Remove(tmpPath)
+		return fmt.Errorf("episode no longer exists")
+	}
+	file := &p.files[index]
+	// Only the extension follows the new source, and the name it makes may
+	// clash with another episode's
+	taken := takenNames(p.files)
+	delete(taken, strings.ToLower(file.DisplayName))
+	name := uniqueName(replacedName(file.DisplayName, path, p.keepExtension), taken)
+	newTempPath := filepath.Join(filepath.Dir(tmpPath), name)
+	if err := os.Rename(tmpPath, newTempPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if file.TempPath != "" && file.TempPath != newTempPath {
+		os.Remove(file.TempPath)
+	}
+
+	file.TempPath = newTempPath
+	file.DisplayName = name
+	file.OriginalPath = path
+	file.OriginalMissing = false
+	// The new audio is served from its copy, like any file just added
+	file.ServeOriginal = false
+	file.SourceURL = ""
+	file.Duration = 0
+	file.SHA256 = sum
+	file.refreshStat()
+
+	applyChapters(file, nil)
+	if file.TranscriptPath != "" {
+		os.Remove(file.TranscriptPath)
+		file.TranscriptPath = ""
+	}
+	if transcript := sidecarTranscript(path); transcript != "" {
+		if dst, err := copyTranscript(transcript, newTempPath); err == nil {
+			file.TranscriptPath = dst
+		} else {
+			fmt.Printf("Couldn't copy transcript %s: %v\n", transcript, err)
+		}
+	}
+
+	// The start is cut from the new audio too
+	if offset := file.StartOffset; offset > 0 {
+		file.StartOffset = 0
+		if err := p.setStartOffset(file.ID, offset); err != nil {
+			p.applyStartOffset(file.ID, 0, "")
+			return fmt.Errorf("the audio was replaced, but its start couldn't be cut, so the whole file is served: %w", err)
+		}
+	}
+	p.extractMetadata(*file)
+
 	if p.fileList != nil {
-		p.fileList.Refresh()
+		p.fileList.RefreshItem(index)
 	}
-	p.saveState()
+	if p.detail != nil && p.detail.fileID == file.ID {
+		p.showEpisodeDetail(file.ID)
+	}
+	p.markDirty()
+	return nil
 }
 
-func (p *Podcasterator) launchServer() {
-	if p.serverRunning || len(p.files) == 0 {
+func (p *Podcasterator) revealOriginal(index int) {
+	if index < 0 || index >= len(p.files) {
 		return
 	}
 
-	// Update file modification times to match order
-	p.modifyFileDates()
+	path := p.files[index].OriginalPath
+	if !p.checkOriginal(index) {
+		return
+	}
 
-	// Get local IP
-	localIP := getLocalIP()
-	baseURL := fmt.Sprintf("http://%s:%d", localIP, serverPort)
+	if err := revealInFileManager(path); err != nil {
+		fmt.Println("Error revealing file:", err)
+		if p.window != nil {
+			dialog.ShowError(err, p.window)
+		}
+	}
+}
 
-	// Generate RSS feed
-	feed := &feeds.Feed{
-		Title:       p.podcastName,
-		Link:        &feeds.Link{Href: baseURL},
-		Description: "Local podcast feed",
-		Created:     time.Now(),
+func (p *Podcasterator) moveUp(index int) {
+	if index > 0 && index < len(p.files) {
+		p.files[index], p.files[index-1] = p.files[index-1], p.files[index]
+		if p.fileList != nil {
+			p.fileList.Refresh()
+		}
+		p.markDirty()
 	}
+}
 
-	// Add artwork if available
-	if p.artworkPath != "" && fileExists(p.artworkPath) {
-		artworkURL := fmt.Sprintf("%s/artwork.jpg", baseURL)
-		feed.Image = &feeds.Image{
-			Url:   artworkURL,
-			Title: p.podcastName,
-			Link:  baseURL,
+func (p *Podcasterator) moveDown(index int) {
+	if index >= 0 && index < len(p.files)-1 {
+		p.files[index], p.files[index+1] = p.files[index+1], p.files[index]
+		if p.fileList != nil {
+			p.fileList.Refresh()
 		}
+		p.markDirty()
 	}
+}
 
-	items := []*feeds.Item{}
-	for _, file := range p.files {
-		info, err := os.Stat(file.TempPath)
-		if err != nil {
-			continue
+// moveToTop moves the file at index to the start of the list
+func (p *Podcasterator) moveToTop(index int) {
+	if index > 0 && index < len(p.files) {
+		file := p.files[index]
+		copy(p.files[1:index+1], p.files[:index])
+		p.files[0] = file
+		if p.fileList != nil {
+			p.fileList.Refresh()
 		}
+		p.markDirty()
+	}
+}
 
-		ext := strings.ToLower(filepath.Ext(file.TempPath))
-		mimeType := "audio/mpeg"
-		if ext == ".m4a" || ext == ".mp4" || ext == ".m4b" {
-			mimeType = "audio/mp4"
+// moveToBottom moves the file at index to the end of the list
+func (p *Podcasterator) moveToBottom(index int) {
+	if index >= 0 && index < len(p.files)-1 {
+		file := p.files[index]
+		copy(p.files[index:], p.files[index+1:])
+		p.files[len(p.files)-1] = file
+		if p.fileList != nil {
+			p.fileList.Refresh()
 		}
+		p.markDirty()
+	}
+}
 
-		encodedName := url.PathEscape(file.DisplayName)
-		fileURL := fmt.Sprintf("%s/files/%s/%s", baseURL, file.ID, encodedName)
+func (p *Podcasterator) clearAll() {
+	if len(p.files) == 0 {
+		return
+	}
 
-		item := &feeds.Item{
-			Title:   file.DisplayName,
-			Link:    &feeds.Link{Href: fileURL},
-			Created: info.ModTime(),
-			Enclosure: &feeds.Enclosure{
-				Url:    fileURL,
-				Length: fmt.Sprintf("%d", info.Size()),
-				Type:   mimeType,
-			},
-			Id: file.ID,
-		}
-		items = append(items, item)
+	// Move every temp file to the trash so the clear can be undone
+	all := make([]int, len(p.files))
+	for i := range all {
+		all[i] = i
 	}
-	feed.Items = items
+	p.removeFiles(all)
+	if p.fileList != nil {
+		p.fileList.UnselectAll()
+		p.fileList.Refresh()
+	}
+	p.hideEpisodeDetail()
+	p.updateFileCount()
+	p.saveNow()
+}
 
-	// Create HTTP handler
-	mux := http.NewServeMux()
+func (p *Podcasterator) alphabetize() {
+	if len(p.files) <= 1 {
+		return
+	}
 
-	mux.HandleFunc("/feed.xml", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/rss+xml")
-		rss, _ := feed.ToRss()
-		w.Write([]byte(rss))
+	// Sort files by display name, ignoring case and with numbers in order
+	sort.SliceStable(p.files, func(i, j int) bool {
+		return naturalLess(p.files[i].DisplayName, p.files[j].DisplayName)
 	})
 
-	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
-		urlPath := r.URL.Path
-		parts := strings.SplitN(strings.TrimPrefix(urlPath, "/files/"), "/", 2)
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+}
 
-		if len(parts) != 2 {
-			http.Error(w, "Invalid path", http.StatusBadRequest)
-			return
+// sortByAddedAt orders files oldest added first. Files without a time keep
+// their relative order after the rest.
+func (p *Podcasterator) sortByAddedAt() {
+	if len(p.files) <= 1 {
+		return
+	}
+
+	sort.SliceStable(p.files, func(i, j int) bool {
+		a, b := p.files[i].AddedAt, p.files[j].AddedAt
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
 		}
+		return a.Before(b)
+	})
+
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+}
 
-		id := parts[0]
-		decodedName, _ := url.PathUnescape(parts[1])
+// sortBySize orders files largest first, re-reading the size of each served
+// file. External files and files that can't be read go last in their
+// current order.
+func (p *Podcasterator) sortBySize() {
+	if len(p.files) <= 1 {
+		return
+	}
 
-		// Security checks
-		if strings.Contains(id, "..") || strings.Contains(id, "/") || strings.Contains(id, "\\") {
-			http.Error(w, "Invalid path", http.StatusBadRequest)
-			return
+	sizes := make(map[string]int64, len(p.files))
+	for i := range p.files {
+		f := &p.files[i]
+		if f.IsExternal() || f.refreshStat() != nil {
+			continue
 		}
+		sizes[f.ID] = f.Size
+	}
 
-		if strings.Contains(decodedName, "..") || strings.HasPrefix(decodedName, "/") || strings.HasPrefix(decodedName, "\\") {
-			http.Error(w, "Invalid path", http.StatusBadRequest)
-			return
+	sort.SliceStable(p.files, func(i, j int) bool {
+		a, aok := sizes[p.files[i].ID]
+		b, bok := sizes[p.files[j].ID]
+		if !aok || !bok {
+			return aok && !bok
 		}
+		return a > b
+	})
 
-		filePath := filepath.Join(p.tempDir, id, decodedName)
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+}
 
-		// Verify path is within temp directory
-		absTemp, _ := filepath.Abs(p.tempDir)
-		absFile, _ := filepath.Abs(filePath)
-		if !strings.HasPrefix(absFile, absTemp) {
-			http.Error(w, "Access denied", http.StatusForbidden)
-			return
-		}
+// sortMenu lists the ways the file list can be sorted
+func (p *Podcasterator) sortMenu() *fyne.Menu {
+	return fyne.NewMenu("",
+		fyne.NewMenuItem("Alphabetically", p.alphabetize),
+		fyne.NewMenuItem("By Track Number", p.sortByTrack),
+		fyne.NewMenuItem("By Date Added", p.sortByAddedAt),
+		fyne.NewMenuItem("By Size (Largest First)", p.sortBySize),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Number Titles in This Order", func() {
+			p.showRenameErrors(p.numberEpisodes())
+		}),
+		fyne.NewMenuItem("Remove Title Numbers", func() {
+			p.showRenameErrors(p.stripEpisodeNumbers())
+		}),
+	)
+}
 
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			http.Error(w, "File not found", http.StatusNotFound)
-			return
-		}
+// showRenameErrors reports the files a bulk rename couldn't rename
+func (p *Podcasterator) showRenameErrors(err error) {
+	if err != nil && p.window != nil {
+		dialog.ShowError(fmt.Errorf("couldn't rename some files:\n%w", err), p.window)
+	}
+}
 
-		ext := strings.ToLower(filepath.Ext(decodedName))
-		contentType := "application/octet-stream"
-		if ext == ".mp3" {
-			contentType = "audio/mpeg"
-		} else if ext == ".m4a" || ext == ".mp4" || ext == ".m4b" {
-			contentType = "audio/mp4"
-		}
+func (p *Podcasterator) reverse() {
+	if len(p.files) <= 1 {
+		return
+	}
 
-		w.Header().Set("Content-Type", contentType)
-		http.ServeFile(w, r, filePath)
-	})
+	// Reverse the order of files
+	reversed := make([]AudioFile, len(p.files))
+	for i, file := range p.files {
+		reversed[len(p.files)-1-i] = file
+	}
 
-	// Artwork endpoint
-	mux.HandleFunc("/artwork.jpg", func(w http.ResponseWriter, r *http.Request) {
-		if p.artworkPath == "" || !fileExists(p.artworkPath) {
-			http.Error(w, "Artwork not found", http.StatusNotFound)
-			return
+	p.files = reversed
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+}
+
+// launchServer builds the feed and starts serving it on the configured port.
+// Repeated or concurrent calls while a server is running or starting are
+// no-ops; it reports whether this call started the server.
+func (p *Podcasterator) launchServer() bool {
+	return p.launchServerOn(p.serverPort())
+}
+
+// launchServerOn is launchServer trying wantPort first
+func (p *Podcasterator) launchServerOn(wantPort int) bool {
+	if !p.requireTempDir() {
+		return false
+	}
+	if p.auth.Enabled && !p.auth.ready() {
+		if p.window != nil {
+			dialog.ShowError(fmt.Errorf("set a username and password in Settings, or turn off password protection"), p.window)
+		}
+		return false
+	}
+	tlsConfig, err := p.serverTLSConfig()
+	if err != nil {
+		if p.window != nil {
+			dialog.ShowError(fmt.Errorf("couldn't set up HTTPS: %w", err), p.window)
 		}
+		return false
+	}
 
-		w.Header().Set("Content-Type", "image/jpeg")
-		http.ServeFile(w, r, p.artworkPath)
-	})
+	p.serverMux.Lock()
+	if p.serverRunning || p.launching || len(p.files) == 0 {
+		p.serverMux.Unlock()
+		return false
+	}
+	p.launching = true
+	p.serverMux.Unlock()
+
+	if p.launchBtn != nil {
+		p.launchBtn.Disable()
+	}
+
+	// Bind before anything else so the feed URLs use the port we actually got
+	ln, err := listenFrom(p.localOnly, wantPort)
+	if err != nil {
+		p.serverMux.Lock()
+		p.launching = false
+		p.serverMux.Unlock()
+		p.updateLaunchButton()
+		if p.window != nil {
+			dialog.ShowError(fmt.Errorf("couldn't start the server on port %d: %w", wantPort, err), p.window)
+		}
+		return false
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	baseURL := p.resolveBaseURL(fmt.Sprintf("%s://%s:%d", p.serverScheme(), p.advertisedHost(), port))
+	// Rebuilt by publishFeed as the podcast is edited
+	live := newFeedServer(baseURL, p.tempDir)
+	p.ensureChannelGUID(live.feedURL)
+	p.ensureFeedEpoch()
+	p.publishTo(live)
+
+	var handler http.Handler = newServeMux(live, time.Now())
+	authUser := ""
+	if p.auth.Enabled {
+		handler = basicAuth(handler, p.auth)
+		authUser = p.auth.User
+	}
+	// Ahead of auth, so password guesses count too
+	handler = p.limits.wrap(handler)
+	if p.allowCORS {
+		// Outside auth, since browsers send preflights without credentials
+		handler = allowCORS(handler)
+	}
+	if p.activity == nil {
+		p.activity = newActivityLog(activityLogSize)
+	}
+	handler = logActivity(handler, p.activity)
 
 	// Start server
-	p.server = &http.Server{
-		Addr:    fmt.Sprintf("0.0.0.0:%d", serverPort),
-		Handler: mux,
+	server := &http.Server{
+		Addr:      ln.Addr().String(),
+		Handler:   handler,
+		TLSConfig: tlsConfig,
 	}
 
+	p.serverMux.Lock()
+	p.server = server
+	p.live = live
+	p.serverRunning = true
+	p.launching = false
+	p.serverURL = live.feedURL
+	p.serverAuthUser = authUser
+	p.serverMux.Unlock()
+
 	go func() {
-		if err := p.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		serve := server.Serve
+		if tlsConfig != nil {
+			// The certificate is already in TLSConfig
+			serve = func(ln net.Listener) error { return server.ServeTLS(ln, "", "") }
+		}
+		if err := serve(ln); err != nil && err != http.ErrServerClosed {
 			fmt.Println("Server error:", err)
+			if p.clearServer(server) {
+				fyne.Do(func() {
+					p.serverWasRunning = false
+					p.markDirty()
+					p.showServerControls(false)
+					if p.window != nil {
+						dialog.ShowError(fmt.Errorf("the server stopped unexpectedly: %w", err), p.window)
+					}
+				})
+			}
 		}
 	}()
 
-	p.serverRunning = true
-	p.serverURL = fmt.Sprintf("%s/feed.xml", baseURL)
+	if !p.localOnly {
+		p.startAdvertising(port)
+	}
 
-	p.launchBtn.Hide()
-	p.podcastEntry.Disable()
-	p.stopBtn.Show()
-	p.urlLabel.SetText(p.serverURL)
-	p.urlLabel.Show()
-	p.copyBtn.Show()
+	// Remembered so the next launch can bring the same feed URL back up
+	p.serverWasRunning = true
+	p.lastServerPort = port
+	p.markDirty()
+
+	p.showServerControls(true)
+	if port != wantPort && p.window != nil {
+		dialog.ShowInformation("Port Changed",
+			fmt.Sprintf("Port %d is in use, so the server is running on port %d.", wantPort, port), p.window)
+	}
+	return true
 }
 
+// feedFor builds the feed and its extra elements with every link under
+// baseURL. The server and the static export share it. It only reads the
+// state, since markDirty publishes by calling it; durations not read yet
+// are left out (see extractMissingDurations).
+func (p *Podcasterator) feedFor(baseURL string) (*feeds.Feed, feedExtras) {
+	feedURL := feedURLFor(baseURL)
+	channelArt := ""
+	if p.artworkPath != "" && fileExists(p.artworkPath) {
+		channelArt = artworkURL(baseURL, p.artworkPath)
+	}
+	names := make([]string, len(p.files))
+	for i := range p.files {
+		names[i] = p.servedName(i)
+	}
+
+	epoch := p.feedEpoch
+	if epoch.IsZero() {
+		epoch = feedEpoch(p.files, time.Now())
+	}
+	feed := buildFeed(p.files, names, p.podcastName, baseURL, channelArt, epoch)
+	feed.Description = p.feedSummary()
+
+	extras := feedExtras{Items: map[string][]channelElement{}}
+	for _, file := range p.files {
+		extras.Items[file.ID] = append(itemElementsFor(file), itemImageElements(file, baseURL, channelArt)...)
+		extras.Items[file.ID] = append(extras.Items[file.ID], itemChaptersElements(file, baseURL)...)
+		extras.Items[file.ID] = append(extras.Items[file.ID], itemTranscriptElements(file, baseURL)...)
+	}
+	generated := append(p.itunesChannelElements(baseURL), p.podcastChannelElements(feedURL)...)
+	extras.Channel = mergeChannelElements(generated, p.channelElements)
+	return feed, extras
+}
+
+// resolveBaseURL returns the user's public URL override if set, otherwise
+// localURL. The override is ignored in local-only mode.
+func (p *Podcasterator) resolveBaseURL(localURL string) string {
+	if p.publicURL != "" && !p.localOnly {
+		return strings.TrimRight(p.publicURL, "/")
+	}
+	return localURL
+}
+
+// stopServer stops the server at the user's request, so it stays stopped
+// on the next launch. Quitting doesn't go through here.
 func (p *Podcasterator) stopServer() {
+	p.shutdownServer()
+	p.serverWasRunning = false
+	p.markDirty()
+	p.showServerControls(false)
+}
+
+// Tips for the launch button, which is disabled while there's nothing to serve
+const (
+	launchTip        = "Serve the feed so podcast apps can subscribe"
+	launchNoFilesTip = "Add audio files before launching the server"
+)
+
+// updateLaunchButton enables the launch button only while there are files
+// to serve, and explains why when it's disabled. A launch in progress keeps
+// it disabled.
+func (p *Podcasterator) updateLaunchButton() {
+	if p.launchBtn == nil {
+		return
+	}
+	p.serverMux.Lock()
+	launching := p.launching
+	p.serverMux.Unlock()
+
+	empty := len(p.files) == 0
+	switch {
+	case empty:
+		p.launchBtn.Disable()
+		p.launchBtn.SetTip(launchNoFilesTip)
+	case !launching:
+		p.launchBtn.Enable()
+		p.launchBtn.SetTip(launchTip)
+	}
+	if p.launchHint != nil {
+		if empty && p.launchBtn.Visible() {
+			p.launchHint.Show()
+		} else {
+			p.launchHint.Hide()
+		}
+	}
+}
+
+// showServerControls switches the server controls between the running and
+// stopped states. It's only called once the listener is known to be up, so
+// the URL shown always works.
+func (p *Podcasterator) showServerControls(running bool) {
+	if p.launchBtn == nil {
+		return
+	}
+
+	if running {
+		p.launchBtn.Hide()
+		p.launchHint.Hide()
+		p.projectSelect.Disable()
+		p.projectNewBtn.Disable()
+		p.projectDelBtn.Disable()
+		p.publicURLEntry.Disable()
+		p.localOnlyCheck.Disable()
+		p.portEntry.Disable()
+		p.stopBtn.Show()
+		text := p.serverURL
+		if p.mdnsURL != "" {
+			text += "\nor " + p.mdnsURL
+		}
+		if p.serverAuthUser != "" {
+			text += fmt.Sprintf("\nSign in as %q with the password you set", p.serverAuthUser)
+		}
+		p.urlLabel.SetText(text)
+		p.urlLabel.Show()
+		p.showLiveUpdated(p.published())
+		p.liveLabel.Show()
+		p.copyBtn.Show()
+		p.activityBtn.Show()
+		return
+	}
+
+	p.launchBtn.Show()
+	p.updateLaunchButton()
+	p.projectSelect.Enable()
+	p.projectNewBtn.Enable()
+	p.refreshProjectSelect()
+	p.publicURLEntry.Enable()
+	p.localOnlyCheck.Enable()
+	p.portEntry.Enable()
+	p.stopBtn.Hide()
+	p.urlLabel.Hide()
+	p.liveLabel.Hide()
+	p.copyBtn.Hide()
+	p.activityBtn.Hide()
+}
+
+// clearServer forgets server after it stopped on its own. It reports false
+// if server was already stopped or replaced.
+func (p *Podcasterator) clearServer(server *http.Server) bool {
+	p.serverMux.Lock()
+	defer p.serverMux.Unlock()
+
+	if p.server != server {
+		return false
+	}
+	p.server = nil
+	p.live = nil
+	p.serverRunning = false
+	p.serverURL = ""
+	p.serverAuthUser = ""
+	p.stopAdvertising()
+	p.activity.clear()
+	return true
+}
+
+// shutdownServer stops the HTTP server, giving in-flight downloads
+// shutdownTimeout to finish before connections are forcibly closed.
+func (p *Podcasterator) shutdownServer() {
 	p.serverMux.Lock()
 	defer p.serverMux.Unlock()
 
 	if p.server != nil {
-		p.server.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := p.server.Shutdown(ctx); err != nil {
+			p.server.Close()
+		}
 		p.server = nil
 	}
+	p.stopAdvertising()
+	p.activity.clear()
 
+	p.live = nil
 	p.serverRunning = false
 	p.serverURL = ""
+	p.serverAuthUser = ""
+}
 
-	p.launchBtn.Show()
-	p.podcastEntry.Enable()
-	p.stopBtn.Hide()
-	p.urlLabel.Hide()
-	p.copyBtn.Hide()
+// shutdown is the cleanup path for process exit: stop serving and persist
+// state. It runs once, after the UI has stopped, so it's the only goroutine
+// left touching the state; a pending autosave is superseded by its save.
+func (p *Podcasterator) shutdown() {
+	if p.saveTimer != nil {
+		p.saveTimer.Stop()
+		p.saveTimer = nil
+	}
+	if p.publishTimer != nil {
+		p.publishTimer.Stop()
+		p.publishTimer = nil
+	}
+	p.stopWatching()
+	p.shutdownServer()
+	// A move of the temp dir is waited for, so the state saved points at
+	// wherever the files ended up
+	if m := p.movingTempDir; m != nil {
+		<-m.moved
+		p.finishTempDirMove()
+	}
+	p.saveState()
+	p.purgeTrash()
 }
 
-func (p *Podcasterator) modifyFileDates() {
-	baseTime := time.Now()
-	fileCount := len(p.files)
+// episodeTime returns the publish time for the file at index so that
+// the first file in the list is the newest episode.
+func episodeTime(baseTime time.Time, index int) time.Time {
+	return baseTime.Add(-time.Duration(index) * time.Second)
+}
 
-	for i, file := range p.files {
-		// Reverse order: first file gets newest date
-		offset := time.Duration(fileCount-i-1) * time.Second
-		newTime := baseTime.Add(offset)
-		os.Chtimes(file.TempPath, newTime, newTime)
+// feedEpoch is the publish time of the first file before one is saved (see
+// ensureFeedEpoch): the earliest AddedAt in the list, or now if no file has
+// one. Because it doesn't depend on when the server starts, unchanged
+// episodes keep their dates across launches, and files appended to the end
+// don't move the others.
+func feedEpoch(files []AudioFile, now time.Time) time.Time {
+	var epoch time.Time
+	for _, f := range files {
+		if !f.AddedAt.IsZero() && (epoch.IsZero() || f.AddedAt.Before(epoch)) {
+			epoch = f.AddedAt
+		}
+	}
+	if epoch.IsZero() {
+		return now
+	}
+	return epoch
+}
+
+// ensureFeedEpoch fixes the publish time of the first file the first time
+// the feed is served or exported, and keeps it from then on, so removing
+// the oldest file doesn't move every other date. Like ensureChannelGUID,
+// it's called before the feed is built, since building it doesn't change
+// the state.
+func (p *Podcasterator) ensureFeedEpoch() {
+	if p.feedEpoch.IsZero() {
+		p.feedEpoch = feedEpoch(p.files, time.Now().UTC().Truncate(time.Second))
+		p.markDirty()
 	}
 }
 
@@ -793,24 +2180,224 @@ func (p *Podcasterator) openImageDialog() {
 
 		path := reader.URI().Path()
 		if isImageFile(path) {
-			p.setArtwork(path)
+			p.chooseArtwork(path)
 		}
 	}, p.window)
 }
 
-func (p *Podcasterator) setArtwork(path string) {
-	// Convert and resize image
-	artworkPath := filepath.Join(p.tempDir, "artwork.jpg")
-	if err := convertAndResizeImage(path, artworkPath, artworkSize); err != nil {
-		fmt.Println("Error converting artwork:", err)
+// chooseArtwork lets the user crop the image at path, then sets it as the
+// podcast's artwork
+func (p *Podcasterator) chooseArtwork(path string) {
+	p.pickArtworkCrop(path, func(crop float64) {
+		p.setArtwork(path, crop)
+	})
+}
+
+// setArtwork makes the image at path, cropped as chosen, the podcast's
+// artwork. Decoding and resizing a big image takes a moment, so it's
+// converted off the UI thread while a spinner shows.
+func (p *Podcasterator) setArtwork(path string, crop float64) {
+	if !p.requireTempDir() {
 		return
 	}
 
+	dir, ext, enc := p.projectTempDir(), p.artworkFileExt(), p.artworkEncoder()
+	artworkPath := filepath.Join(dir, "artwork"+ext)
+	p.convertArtworkInBackground(filepath.Base(path), artworkPath, func(tmpPath string) error {
+		return convertAndCropImageWith(path, tmpPath, artworkSize, crop, enc)
+	}, func(tmpPath string) {
+		p.useArtwork(tmpPath, artworkPath)
+		if !p.offerUpscale(path, crop) {
+			p.checkArtwork()
+		}
+	})
+}
+
+// convertArtworkInBackground runs convert, which writes artwork to the
+// temporary path beside artworkPath it's given, off the UI thread while a
+// spinner shows name. done is then called on the UI thread with that path,
+// unless the conversion failed or another project was opened meanwhile. The
+// returned channel is closed once it's all over.
+func (p *Podcasterator) convertArtworkInBackground(name, artworkPath string, convert func(tmpPath string) error, done func(tmpPath string)) <-chan struct{} {
+	var progress dialog.Dialog
+	if p.window != nil {
+		progress = dialog.NewCustomWithoutButtons("Converting Artwork",
+			container.NewVBox(widget.NewLabel(name), widget.NewProgressBarInfinite()), p.window)
+		progress.Show()
+	}
+
+	dir, projectID := filepath.Dir(artworkPath), p.projectID
+	finished := make(chan struct{})
+	go func() {
+		// Converted beside the current artwork, which is replaced once done
+		os.MkdirAll(dir, 0755)
+		tmpPath := filepath.Join(dir, ".artwork-"+uuid.New().String()+filepath.Ext(artworkPath))
+		err := convert(tmpPath)
+		fyne.Do(func() {
+			defer close(finished)
+			if progress != nil {
+				progress.Hide()
+			}
+			if err != nil {
+				os.Remove(tmpPath)
+				err = fmt.Errorf("couldn't convert %s: %w", name, err)
+				if p.window != nil {
+					dialog.ShowError(err, p.window)
+				} else {
+					fmt.Println("Error converting artwork:", err)
+				}
+				return
+			}
+			// Another project's artwork now
+			if p.projectID != projectID {
+				os.Remove(tmpPath)
+				return
+			}
+			done(tmpPath)
+		})
+	}()
+	return finished
+}
+
+// useArtwork moves the artwork converted to tmpPath into place at
+// artworkPath and shows it
+func (p *Podcasterator) useArtwork(tmpPath, artworkPath string) {
+	if err := os.Rename(tmpPath, artworkPath); err != nil {
+		os.Remove(tmpPath)
+		if p.window != nil {
+			dialog.ShowError(err, p.window)
+		}
+		return
+	}
+	if p.artworkPath != "" && p.artworkPath != artworkPath {
+		// Artwork in the format used before
+		os.Remove(p.artworkPath)
+	}
+
 	p.artworkPath = artworkPath
 	p.artworkImage.File = artworkPath
 	p.artworkImage.Refresh()
 	p.artworkBtn.SetText("Delete artwork")
-	p.saveState()
+	p.updateArtworkInfo()
+	p.markDirty()
+}
+
+// offerUpscale warns when the converted artwork is under Apple's minimum
+// size, offering to scale the source at path up to it. It reports whether it
+// warned; the size check in checkArtwork then follows an upscale.
+func (p *Podcasterator) offerUpscale(path string, crop float64) bool {
+	info, err := readArtworkInfo(p.artworkPath)
+	if err != nil || !info.undersized() {
+		return false
+	}
+	message := undersizedArtworkMessage(info)
+	if p.window == nil {
+		fmt.Println(message)
+		return true
+	}
+
+	dialog.ShowConfirm("Small Artwork", message+fmt.Sprintf("\n\nUpscale it to %d×%d? It may look soft.", artworkSize, artworkSize),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			p.upscaleArtwork(path, crop)
+		}, p.window)
+	return true
+}
+
+// upscaleArtwork replaces the artwork with the source at path, cropped as
+// before, scaled up to artworkSize in the background. The returned channel
+// is closed once it's done.
+func (p *Podcasterator) upscaleArtwork(path string, crop float64) <-chan struct{} {
+	artworkPath, enc := p.artworkPath, p.artworkEncoder()
+	return p.convertArtworkInBackground(filepath.Base(path), artworkPath, func(tmpPath string) error {
+		return upscaleImageWith(path, tmpPath, artworkSize, crop, enc)
+	}, func(tmpPath string) {
+		p.replaceArtwork(tmpPath, artworkPath)
+		p.checkArtwork()
+	})
+}
+
+// replaceArtwork moves artwork rewritten to tmpPath over artworkPath, unless
+// the artwork was deleted or set again while it was being rewritten
+func (p *Podcasterator) replaceArtwork(tmpPath, artworkPath string) {
+	if p.artworkPath != artworkPath {
+		os.Remove(tmpPath)
+		return
+	}
+	p.useArtwork(tmpPath, artworkPath)
+}
+
+// updateArtworkInfo shows the converted artwork's dimensions and size under
+// the thumbnail, flagging anything podcast directories would reject.
+func (p *Podcasterator) updateArtworkInfo() {
+	if p.artworkInfo == nil {
+		return
+	}
+	if p.artworkPath == "" {
+		p.artworkInfo.Hide()
+		return
+	}
+
+	info, err := readArtworkInfo(p.artworkPath)
+	if err != nil {
+		p.artworkInfo.Importance = widget.DangerImportance
+		p.artworkInfo.SetText("Artwork can't be read")
+		p.artworkInfo.Show()
+		return
+	}
+
+	text := info.String()
+	p.artworkInfo.Importance = widget.MediumImportance
+	if problems := info.Problems(); len(problems) > 0 {
+		text += " ⚠ " + strings.Join(problems, ", ")
+		p.artworkInfo.Importance = widget.WarningImportance
+	}
+	p.artworkInfo.SetText(text)
+	p.artworkInfo.Show()
+}
+
+// artworkWarnBytes returns the artwork size above which the user is warned
+func (p *Podcasterator) artworkWarnBytes() int64 {
+	kb := p.artworkWarnKB
+	if kb <= 0 {
+		kb = defaultArtworkWarnKB
+	}
+	return int64(kb) * 1024
+}
+
+// checkArtwork runs the artwork validation pass and, if the file is too
+// large, offers to re-encode it at a lower quality. PNG artwork has no
+// quality to lower, so it's only warned about.
+func (p *Podcasterator) checkArtwork() {
+	warnings := validateArtwork(p.artworkPath, p.artworkWarnBytes())
+	if len(warnings) == 0 || p.window == nil {
+		return
+	}
+	if isPNGArtwork(p.artworkPath) {
+		dialog.ShowInformation("Large Artwork", strings.Join(warnings, "\n\n")+"\n\nJPEG artwork is usually much smaller.", p.window)
+		return
+	}
+
+	message := strings.Join(warnings, "\n\n") + fmt.Sprintf("\n\nRe-encode at quality %d?", artworkReducedQuality)
+	dialog.ShowConfirm("Large Artwork", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		p.shrinkArtwork(baselineJPEGEncoder{Quality: artworkReducedQuality})
+	}, p.window)
+}
+
+// shrinkArtwork re-encodes the artwork with enc in the background. The
+// returned channel is closed once it's done.
+func (p *Podcasterator) shrinkArtwork(enc artworkEncoder) <-chan struct{} {
+	artworkPath := p.artworkPath
+	return p.convertArtworkInBackground(filepath.Base(artworkPath), artworkPath, func(tmpPath string) error {
+		return reencodeArtwork(artworkPath, tmpPath, enc)
+	}, func(tmpPath string) {
+		p.replaceArtwork(tmpPath, artworkPath)
+	})
 }
 
 func (p *Podcasterator) deleteArtwork() {
@@ -826,15 +2413,139 @@ func (p *Podcasterator) deleteArtwork() {
 		p.artworkImage.Refresh()
 
 		p.artworkBtn.SetText("No artwork set")
+		p.updateArtworkInfo()
+		p.markDirty()
+	}
+}
+
+// markDirty records that state changed. It's written shortly after by
+// autosave, or on quit, rather than on every mutation, so a burst of edits
+// like typing the podcast name is one write. A running server publishes the
+// change once the burst pauses (see schedulePublish).
+func (p *Podcasterator) markDirty() {
+	p.dirty = true
+	p.scheduleSave()
+	p.schedulePublish()
+}
+
+// schedulePublish republishes a running feed publishDelay after the last
+// change, restarting the wait on each one, so typing a title rebuilds the
+// feed once rather than on every keystroke. With no delay set it publishes
+// straight away. The publish happens on the UI goroutine.
+func (p *Podcasterator) schedulePublish() {
+	if p.publishDelay <= 0 {
+		p.publishFeed()
+		return
+	}
+	if p.publishTimer != nil {
+		p.publishTimer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(p.publishDelay, func() {
+		fyne.Do(func() {
+			// A later change restarted the wait; its timer publishes
+			if p.publishTimer != timer {
+				return
+			}
+			p.publishTimer = nil
+			p.publishFeed()
+		})
+	})
+	p.publishTimer = timer
+}
+
+// saveNow records a change and writes it straight away. Removals use it:
+// their copies are already in the trash, so the saved list shouldn't go on
+// listing them.
+func (p *Podcasterator) saveNow() {
+	p.markDirty()
+	p.flushState()
+}
+
+// flushState writes state if anything changed since the last write
+func (p *Podcasterator) flushState() {
+	if p.dirty {
 		p.saveState()
 	}
 }
 
+// startAutosave has changes written delay after the last one in a burst.
+// Until it's called, e.g. in tests, nothing is written until flushState.
+func (p *Podcasterator) startAutosave(delay time.Duration) {
+	p.autosaveDelay = delay
+	if p.dirty {
+		p.scheduleSave()
+	}
+}
+
+// scheduleSave writes state autosaveDelay after the last change,
+// restarting the wait on each one as schedulePublish does, so a burst of
+// edits is one write. The write happens on the UI goroutine, which owns the
+// file list.
+func (p *Podcasterator) scheduleSave() {
+	if p.autosaveDelay <= 0 {
+		return
+	}
+	if p.saveTimer != nil {
+		p.saveTimer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(p.autosaveDelay, func() {
+		fyne.Do(func() {
+			// A later change restarted the wait; its timer saves
+			if p.saveTimer != timer {
+				return
+			}
+			p.saveTimer = nil
+			p.flushState()
+		})
+	})
+	p.saveTimer = timer
+}
+
+// saveState writes state immediately and clears the dirty flag. Nothing is
+// written while the temp dir is unavailable, so the last good state survives.
+// While it's being moved the dirty flag keeps the save queued, and it's
+// written once the move is done (see finishTempDirMove).
 func (p *Podcasterator) saveState() {
+	if p.tempDirUnavailable || p.movingTempDir != nil {
+		return
+	}
+
 	state := AppState{
-		Files:       p.files,
-		PodcastName: p.podcastName,
-		ArtworkPath: p.artworkPath,
+		Project:  p.currentProject(),
+		Projects: p.projects,
+
+		ProgressiveJPEG: p.progressiveJPEG,
+		ArtworkWarnKB:   p.artworkWarnKB,
+		ArtworkFormat:   p.artworkFormat,
+		ArtworkQuality:  p.artworkQuality,
+
+		KeepExtension:  p.keepExtension,
+		DedupByContent: p.dedupByContent,
+
+		WalkFolderOrder: p.walkFolderOrder,
+		VerifyCopies:    p.verifyCopies,
+
+		LocalOnly: p.localOnly,
+		Port:      p.port,
+
+		ResumeServer:     p.resumeServer,
+		ServerWasRunning: p.serverWasRunning,
+		LastServerPort:   p.lastServerPort,
+
+		ServerAddress: p.serverAddress,
+
+		Auth:     p.auth,
+		UseHTTPS: p.useHTTPS,
+
+		AllowCORS: p.allowCORS,
+		Limits:    p.limits,
+
+		TempDir: p.customTempDir,
+
+		ListView: p.listView,
+		Theme:    p.themeMode,
 	}
 
 	data, err := json.MarshalIndent(state, "", "  ")
@@ -843,36 +2554,88 @@ func (p *Podcasterator) saveState() {
 	}
 
 	statePath := filepath.Join(p.configDir, "state.json")
-	os.WriteFile(statePath, data, 0644)
+	backupState(statePath)
+	if err := writeFileAtomic(statePath, data, 0644); err != nil {
+		fmt.Println("Error saving state:", err)
+		return
+	}
+	p.dirty = false
 }
 
-func (p *Podcasterator) loadState() {
-	statePath := filepath.Join(p.configDir, "state.json")
-	data, err := os.ReadFile(statePath)
-	if err != nil {
+// stateBackupSuffix names the last good state kept beside state.json
+const stateBackupSuffix = ".bak"
+
+// backupState moves the state at path aside as its backup before it's
+// replaced. A damaged file is left where it is, so it never replaces the
+// last good backup.
+func backupState(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil || !json.Valid(data) {
 		return
 	}
+	os.Rename(path, path+stateBackupSuffix)
+}
 
+// readState reads the state saved at path
+func readState(path string) (AppState, error) {
 	var state AppState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
 	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
 
-	// Verify temp files still exist
-	validFiles := []AudioFile{}
-	for _, file := range state.Files {
-		if _, err := os.Stat(file.TempPath); err == nil {
-			validFiles = append(validFiles, file)
+// loadState restores the saved state. If state.json is missing or damaged,
+// e.g. by a crash mid-save, the backup of the one before is used instead.
+func (p *Podcasterator) loadState() {
+	statePath := filepath.Join(p.configDir, "state.json")
+	state, err := readState(statePath)
+	if err != nil {
+		var backupErr error
+		if state, backupErr = readState(statePath + stateBackupSuffix); backupErr != nil {
+			return
+		}
+		if !os.IsNotExist(err) {
+			fmt.Println("Error loading state, using the backup:", err)
 		}
 	}
 
-	p.files = validFiles
-	if state.PodcastName != "" {
-		p.podcastName = state.PodcastName
+	p.customTempDir = state.TempDir
+	if p.customTempDir != "" {
+		p.tempDir = p.customTempDir
 	}
-	if state.ArtworkPath != "" && fileExists(state.ArtworkPath) {
-		p.artworkPath = state.ArtworkPath
+
+	// Verify temp files still exist, unless the whole temp dir is gone. Then
+	// the files are kept as they were and nothing is saved until it's back.
+	p.tempDirUnavailable = p.tempDirMissingFor(state.Files)
+	if state.PodcastName == "" {
+		state.PodcastName = p.podcastName
 	}
+	p.applyProject(state.Project)
+	p.projects = state.Projects
+
+	p.progressiveJPEG = state.ProgressiveJPEG
+	p.artworkWarnKB = state.ArtworkWarnKB
+	p.artworkFormat = state.ArtworkFormat
+	p.artworkQuality = state.ArtworkQuality
+	p.keepExtension = state.KeepExtension
+	p.dedupByContent = state.DedupByContent
+	p.walkFolderOrder = state.WalkFolderOrder
+	p.verifyCopies = state.VerifyCopies
+	p.localOnly = state.LocalOnly
+	p.port = state.Port
+	p.resumeServer = state.ResumeServer
+	p.serverWasRunning = state.ServerWasRunning
+	p.lastServerPort = state.LastServerPort
+	p.serverAddress = state.ServerAddress
+	p.auth = state.Auth
+	p.useHTTPS = state.UseHTTPS
+	p.allowCORS = state.AllowCORS
+	p.limits = state.Limits
+	p.listView = state.ListView
+	p.themeMode = state.Theme
 }
 
 // Helper functions
@@ -906,6 +2669,24 @@ func setupWaylandSupport() {
 	}
 }
 
+// podcastFileName renames mp4 and m4b to m4a for better compatibility,
+// unless keepExtension is set. Files that are transcoded always get the
+// extension of the transcoded output.
+func podcastFileName(name string, keepExtension bool) string {
+	if needsTranscode(name) {
+		return strings.TrimSuffix(name, filepath.Ext(name)) + transcodeExtension
+	}
+	if keepExtension {
+		return name
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == ".mp4" || ext == ".m4b" {
+		return strings.TrimSuffix(name, filepath.Ext(name)) + ".m4a"
+	}
+	return name
+}
+
 func truncateFilename(name string) string {
 	if len(name) > maxFilenameLength {
 		return name[:maxFilenameLength-3] + "..."
@@ -920,7 +2701,16 @@ func isSupportedFile(path string) bool {
 			return true
 		}
 	}
-	return false
+	return needsTranscode(path)
+}
+
+// mimeTypeFor is the MIME type served and published for an audio file with
+// extension ext, e.g. ".mp3". Unknown extensions are generic binary data.
+func mimeTypeFor(ext string) string {
+	if mimeType, ok := audioMIMETypes[strings.ToLower(ext)]; ok {
+		return mimeType
+	}
+	return "application/octet-stream"
 }
 
 func isImageFile(path string) bool {
@@ -933,21 +2723,35 @@ func isImageFile(path string) bool {
 	return false
 }
 
-func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
+// writeFileAtomic writes data to a temp file beside path and renames it into
+// place, so a crash mid-write never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	defer sourceFile.Close()
+	tmpPath := tmp.Name()
 
-	destFile, err := os.Create(dst)
-	if err != nil {
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
 		return err
 	}
-	defer destFile.Close()
-
-	_, err = io.Copy(destFile, sourceFile)
-	return err
+	// On disk before the rename, so a crash can't leave an empty file
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
 }
 
 func fileExists(path string) bool {
@@ -955,6 +2759,24 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
+// revealCommand returns the command that shows path in the file manager
+// for the given GOOS. Linux has no standard "select" so the parent folder is opened.
+func revealCommand(goos, path string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{"-R", path}
+	case "windows":
+		return "explorer", []string{"/select," + path}
+	default:
+		return "xdg-open", []string{filepath.Dir(path)}
+	}
+}
+
+func revealInFileManager(path string) error {
+	name, args := revealCommand(runtime.GOOS, path)
+	return exec.Command(name, args...).Start()
+}
+
 func getLocalIP() string {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
@@ -971,7 +2793,26 @@ func getLocalIP() string {
 	return "localhost"
 }
 
-func convertAndResizeImage(srcPath, dstPath string, size uint) error {
+// convertAndCropImageWith crops the image at srcPath to a square at crop
+// (see cropSquareAt), shrinks it to fit size and writes it with enc
+func convertAndCropImageWith(srcPath, dstPath string, size uint, crop float64, enc artworkEncoder) error {
+	return convertImageWith(srcPath, dstPath, enc, func(img image.Image) image.Image {
+		// Crop to square rather than letterbox, then resize
+		return resize.Thumbnail(size, size, cropSquareAt(img, crop), resize.Lanczos3)
+	})
+}
+
+// upscaleImageWith is convertAndCropImageWith for sources smaller than size:
+// the square is scaled up to exactly size on a side
+func upscaleImageWith(srcPath, dstPath string, size uint, crop float64, enc artworkEncoder) error {
+	return convertImageWith(srcPath, dstPath, enc, func(img image.Image) image.Image {
+		return resize.Resize(size, size, cropSquareAt(img, crop), resize.Lanczos3)
+	})
+}
+
+// convertImageWith decodes the image at srcPath, transforms it and writes the
+// result to dstPath with enc
+func convertImageWith(srcPath, dstPath string, enc artworkEncoder, transform func(image.Image) image.Image) error {
 	// Open and decode the source image
 	file, err := os.Open(srcPath)
 	if err != nil {
@@ -984,9 +2825,6 @@ func convertAndResizeImage(srcPath, dstPath string, size uint) error {
 		return err
 	}
 
-	// Resize to square artwork
-	resized := resize.Thumbnail(size, size, img, resize.Lanczos3)
-
 	// Save as JPEG
 	outFile, err := os.Create(dstPath)
 	if err != nil {
@@ -994,5 +2832,5 @@ func convertAndResizeImage(srcPath, dstPath string, size uint) error {
 	}
 	defer outFile.Close()
 
-	return jpeg.Encode(outFile, resized, &jpeg.Options{Quality: 90})
+	return enc.Encode(outFile, transform(img))
 }
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"image"
 	"image/color"
 	"image/png"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // =============================================================================
@@ -70,9 +75,10 @@ func TestIsSupportedFile(t *testing.T) {
 		{"m4a file", "audiobook.m4a", true},
 		{"mp4 file", "video.mp4", true},
 		{"m4b file", "book.m4b", true},
-		{"wav file not supported", "audio.wav", false},
-		{"flac file not supported", "audio.flac", false},
-		{"ogg file not supported", "audio.ogg", false},
+		{"wav file transcoded", "audio.wav", true},
+		{"flac file transcoded", "audio.FLAC", true},
+		{"ogg file transcoded", "audio.ogg", true},
+		{"aiff file not supported", "audio.aiff", false},
 		{"image file not supported", "cover.jpg", false},
 		{"text file not supported", "readme.txt", false},
 		{"no extension", "audiofile", false},
@@ -91,6 +97,37 @@ func TestIsSupportedFile(t *testing.T) {
 	}
 }
 
+func TestMimeTypeFor(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{".mp3", "audio/mpeg"},
+		{".m4a", "audio/mp4"},
+		{".mp4", "audio/mp4"},
+		{".m4b", "audio/mp4"},
+		{".MP3", "audio/mpeg"},
+		{".M4B", "audio/mp4"},
+		{".wav", "audio/wav"},
+		{".flac", "audio/flac"},
+		{".ogg", "audio/ogg"},
+		{".txt", "application/octet-stream"},
+		{"", "application/octet-stream"},
+	}
+	for _, tc := range tests {
+		if got := mimeTypeFor(tc.ext); got != tc.want {
+			t.Errorf("mimeTypeFor(%q) = %q; want %q", tc.ext, got, tc.want)
+		}
+	}
+
+	// Every extension the app accepts has a real type
+	for _, ext := range append(append([]string{}, supportedExtensions...), transcodedExtensions...) {
+		if !strings.HasPrefix(mimeTypeFor(ext), "audio/") {
+			t.Errorf("mimeTypeFor(%q) = %q; want an audio type", ext, mimeTypeFor(ext))
+		}
+	}
+}
+
 func TestIsImageFile(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -124,6 +161,34 @@ func TestIsImageFile(t *testing.T) {
 	}
 }
 
+func TestPodcastFileName(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		keepExtension bool
+		expected      string
+	}{
+		{"mp3 unchanged", "song.mp3", false, "song.mp3"},
+		{"m4a unchanged", "song.m4a", false, "song.m4a"},
+		{"mp4 renamed", "video.mp4", false, "video.m4a"},
+		{"m4b renamed", "book.m4b", false, "book.m4a"},
+		{"uppercase M4B renamed", "BOOK.M4B", false, "BOOK.m4a"},
+		{"mp4 kept", "video.mp4", true, "video.mp4"},
+		{"m4b kept", "book.m4b", true, "book.m4b"},
+		{"wav transcoded", "take.wav", false, "take.m4a"},
+		{"flac transcoded despite keep", "Album.FLAC", true, "Album.m4a"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := podcastFileName(tc.input, tc.keepExtension)
+			if result != tc.expected {
+				t.Errorf("podcastFileName(%q, %v) = %q; want %q", tc.input, tc.keepExtension, result, tc.expected)
+			}
+		})
+	}
+}
+
 func TestFileExists(t *testing.T) {
 	// Create a temp file for testing
 	tmpFile, err := os.CreateTemp("", "test_exists_*.txt")
@@ -154,66 +219,6 @@ func TestFileExists(t *testing.T) {
 	}
 }
 
-func TestCopyFile(t *testing.T) {
-	// Create temp directory
-	tmpDir, err := os.MkdirTemp("", "test_copy_*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	t.Run("successful copy", func(t *testing.T) {
-		srcPath := filepath.Join(tmpDir, "source.txt")
-		dstPath := filepath.Join(tmpDir, "dest.txt")
-		content := "Hello, World!"
-
-		// Create source file
-		if err := os.WriteFile(srcPath, []byte(content), 0644); err != nil {
-			t.Fatalf("Failed to create source file: %v", err)
-		}
-
-		// Copy file
-		if err := copyFile(srcPath, dstPath); err != nil {
-			t.Errorf("copyFile() error = %v", err)
-		}
-
-		// Verify copy
-		copied, err := os.ReadFile(dstPath)
-		if err != nil {
-			t.Errorf("Failed to read copied file: %v", err)
-		}
-
-		if string(copied) != content {
-			t.Errorf("Copied content = %q; want %q", string(copied), content)
-		}
-	})
-
-	t.Run("source file not found", func(t *testing.T) {
-		srcPath := filepath.Join(tmpDir, "nonexistent.txt")
-		dstPath := filepath.Join(tmpDir, "dest2.txt")
-
-		err := copyFile(srcPath, dstPath)
-		if err == nil {
-			t.Error("copyFile() expected error for non-existent source, got nil")
-		}
-	})
-
-	t.Run("invalid destination path", func(t *testing.T) {
-		srcPath := filepath.Join(tmpDir, "source_for_invalid.txt")
-		dstPath := filepath.Join(tmpDir, "nonexistent_dir", "dest.txt")
-
-		// Create source file
-		if err := os.WriteFile(srcPath, []byte("test"), 0644); err != nil {
-			t.Fatalf("Failed to create source file: %v", err)
-		}
-
-		err := copyFile(srcPath, dstPath)
-		if err == nil {
-			t.Error("copyFile() expected error for invalid destination, got nil")
-		}
-	})
-}
-
 func TestGetLocalIP(t *testing.T) {
 	ip := getLocalIP()
 
@@ -232,6 +237,34 @@ func TestGetLocalIP(t *testing.T) {
 	}
 }
 
+func TestRevealCommand(t *testing.T) {
+	path := filepath.Join("music", "album", "song.mp3")
+
+	tests := []struct {
+		name         string
+		goos         string
+		expectedCmd  string
+		expectedArgs []string
+	}{
+		{"macOS selects file", "darwin", "open", []string{"-R", path}},
+		{"windows selects file", "windows", "explorer", []string{"/select," + path}},
+		{"linux opens parent folder", "linux", "xdg-open", []string{filepath.Dir(path)}},
+		{"other unix opens parent folder", "freebsd", "xdg-open", []string{filepath.Dir(path)}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, args := revealCommand(tc.goos, path)
+			if cmd != tc.expectedCmd {
+				t.Errorf("revealCommand(%q) command = %q; want %q", tc.goos, cmd, tc.expectedCmd)
+			}
+			if strings.Join(args, " ") != strings.Join(tc.expectedArgs, " ") {
+				t.Errorf("revealCommand(%q) args = %v; want %v", tc.goos, args, tc.expectedArgs)
+			}
+		})
+	}
+}
+
 // =============================================================================
 // Podcasterator Method Tests
 // =============================================================================
@@ -274,10 +307,10 @@ func TestMoveUp(t *testing.T) {
 	}
 
 	tests := []struct {
-		name           string
-		index          int
-		expectedOrder  []string
-		shouldChange   bool
+		name          string
+		index         int
+		expectedOrder []string
+		shouldChange  bool
 	}{
 		{"move second up", 1, []string{"second.mp3", "first.mp3", "third.mp3"}, true},
 		{"move first up (no change)", 0, []string{"first.mp3", "second.mp3", "third.mp3"}, false},
@@ -343,6 +376,86 @@ func TestMoveDown(t *testing.T) {
 	}
 }
 
+func TestMoveToTop(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	tests := []struct {
+		name          string
+		index         int
+		expectedOrder []string
+		shouldChange  bool
+	}{
+		{"move last to top", 3, []string{"fourth.mp3", "first.mp3", "second.mp3", "third.mp3"}, true},
+		{"move second to top", 1, []string{"second.mp3", "first.mp3", "third.mp3", "fourth.mp3"}, true},
+		{"move first to top (no change)", 0, []string{"first.mp3", "second.mp3", "third.mp3", "fourth.mp3"}, false},
+		{"negative index (no change)", -1, []string{"first.mp3", "second.mp3", "third.mp3", "fourth.mp3"}, false},
+		{"out of bounds (no change)", 10, []string{"first.mp3", "second.mp3", "third.mp3", "fourth.mp3"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Reset files
+			p.files = []AudioFile{
+				{ID: "1", DisplayName: "first.mp3"},
+				{ID: "2", DisplayName: "second.mp3"},
+				{ID: "3", DisplayName: "third.mp3"},
+				{ID: "4", DisplayName: "fourth.mp3"},
+			}
+			p.dirty = false
+
+			p.moveToTop(tc.index)
+
+			if got := strings.Join(displayNames(p.files), ","); got != strings.Join(tc.expectedOrder, ",") {
+				t.Errorf("After moveToTop(%d) order = %s; want %s", tc.index, got, strings.Join(tc.expectedOrder, ","))
+			}
+			if p.dirty != tc.shouldChange {
+				t.Errorf("After moveToTop(%d) dirty = %v; want %v", tc.index, p.dirty, tc.shouldChange)
+			}
+		})
+	}
+}
+
+func TestMoveToBottom(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	tests := []struct {
+		name          string
+		index         int
+		expectedOrder []string
+		shouldChange  bool
+	}{
+		{"move first to bottom", 0, []string{"second.mp3", "third.mp3", "fourth.mp3", "first.mp3"}, true},
+		{"move third to bottom", 2, []string{"first.mp3", "second.mp3", "fourth.mp3", "third.mp3"}, true},
+		{"move last to bottom (no change)", 3, []string{"first.mp3", "second.mp3", "third.mp3", "fourth.mp3"}, false},
+		{"negative index (no change)", -1, []string{"first.mp3", "second.mp3", "third.mp3", "fourth.mp3"}, false},
+		{"out of bounds (no change)", 10, []string{"first.mp3", "second.mp3", "third.mp3", "fourth.mp3"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Reset files
+			p.files = []AudioFile{
+				{ID: "1", DisplayName: "first.mp3"},
+				{ID: "2", DisplayName: "second.mp3"},
+				{ID: "3", DisplayName: "third.mp3"},
+				{ID: "4", DisplayName: "fourth.mp3"},
+			}
+			p.dirty = false
+
+			p.moveToBottom(tc.index)
+
+			if got := strings.Join(displayNames(p.files), ","); got != strings.Join(tc.expectedOrder, ",") {
+				t.Errorf("After moveToBottom(%d) order = %s; want %s", tc.index, got, strings.Join(tc.expectedOrder, ","))
+			}
+			if p.dirty != tc.shouldChange {
+				t.Errorf("After moveToBottom(%d) dirty = %v; want %v", tc.index, p.dirty, tc.shouldChange)
+			}
+		})
+	}
+}
+
 func TestAlphabetize(t *testing.T) {
 	p, cleanup := newTestPodcasterator(t)
 	defer cleanup()
@@ -475,6 +588,257 @@ func TestReverse(t *testing.T) {
 	}
 }
 
+func TestAddFileExtensionSetting(t *testing.T) {
+	tests := []struct {
+		name          string
+		keepExtension bool
+		expectedName  string
+	}{
+		{"renamed to m4a by default", false, "book.m4a"},
+		{"original extension kept", true, "book.m4b"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, cleanup := newTestPodcasterator(t)
+			defer cleanup()
+			p.keepExtension = tc.keepExtension
+
+			srcPath := filepath.Join(t.TempDir(), "book.m4b")
+			if err := os.WriteFile(srcPath, []byte("audio"), 0644); err != nil {
+				t.Fatalf("Failed to create source file: %v", err)
+			}
+
+			p.addFile(srcPath)
+			if len(p.files) != 1 {
+				t.Fatalf("addFile() resulted in %d files; want 1", len(p.files))
+			}
+
+			file := p.files[0]
+			if file.DisplayName != tc.expectedName {
+				t.Errorf("DisplayName = %q; want %q", file.DisplayName, tc.expectedName)
+			}
+			if filepath.Base(file.TempPath) != tc.expectedName {
+				t.Errorf("Temp file name = %q; want %q", filepath.Base(file.TempPath), tc.expectedName)
+			}
+
+			item, ok := feedItemNamed(file, file.DisplayName, "http://localhost:8080", time.Now())
+			if !ok {
+				t.Fatal("feedItemNamed() skipped the added file")
+			}
+			if item.Enclosure.Type != "audio/mp4" {
+				t.Errorf("Enclosure type = %q; want %q", item.Enclosure.Type, "audio/mp4")
+			}
+			if !strings.HasSuffix(item.Enclosure.Url, "/"+tc.expectedName) {
+				t.Errorf("Enclosure URL = %q; want it to end in %q", item.Enclosure.Url, tc.expectedName)
+			}
+		})
+	}
+}
+
+func TestRenameAndAdvance(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	srcDir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.mp3", "c.mp3"} {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte("audio"), 0644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		p.addFile(path)
+	}
+
+	tests := []struct {
+		name     string
+		index    int
+		newName  string
+		chain    bool
+		wantNext int
+	}{
+		{"chain moves to next", 0, "First", true, 1},
+		{"chain continues", 1, "Second", true, 2},
+		{"chain ends at last file", 2, "Third", true, -1},
+		{"single rename does not chain", 0, "One", false, -1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next, err := p.renameAndAdvance(p.files[tc.index].ID, tc.newName, tc.chain)
+			if err != nil {
+				t.Fatalf("renameAndAdvance() error = %v", err)
+			}
+			if next != tc.wantNext {
+				t.Errorf("next = %d; want %d", next, tc.wantNext)
+			}
+			if want := tc.newName + ".mp3"; p.files[tc.index].DisplayName != want {
+				t.Errorf("DisplayName = %q; want %q", p.files[tc.index].DisplayName, want)
+			}
+		})
+	}
+
+	if _, err := p.renameAndAdvance("missing", "x", true); err == nil {
+		t.Error("renameAndAdvance() with unknown ID succeeded")
+	}
+}
+
+func TestReplaceAudio(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	srcDir := t.TempDir()
+	for _, name := range []string{"one.mp3", "two.mp3", "three.mp3"} {
+		path := filepath.Join(srcDir, name)
+		if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+		p.addFile(path)
+	}
+	if err := p.applyRename(&p.files[1], "Chapter Two"); err != nil {
+		t.Fatalf("applyRename() error = %v", err)
+	}
+	p.files[1].Description = "notes"
+	p.files[1].ServeOriginal = true
+	before := p.files[1]
+
+	newPath := filepath.Join(srcDir, "two-rerecorded.m4a")
+	if err := os.WriteFile(newPath, []byte("new recording"), 0644); err != nil {
+		t.Fatalf("Failed to create replacement file: %v", err)
+	}
+
+	if err := replaceAndFinish(p, 1, newPath); err != nil {
+		t.Fatalf("replacing the audio error = %v", err)
+	}
+
+	after := p.files[1]
+	if after.ID != before.ID {
+		t.Errorf("ID = %q; want %q", after.ID, before.ID)
+	}
+	if got := displayNames(p.files); got[0] != "one.mp3" || got[2] != "three.mp3" {
+		t.Errorf("order changed: %v", got)
+	}
+	if after.DisplayName != "Chapter Two.m4a" {
+		t.Errorf("DisplayName = %q; want %q", after.DisplayName, "Chapter Two.m4a")
+	}
+	if after.Description != "notes" {
+		t.Errorf("Description = %q; want %q", after.Description, "notes")
+	}
+	if after.OriginalPath != newPath {
+		t.Errorf("OriginalPath = %q; want %q", after.OriginalPath, newPath)
+	}
+	if after.ServeOriginal {
+		t.Error("the new audio is served from its source, not its copy")
+	}
+	if after.Size != int64(len("new recording")) {
+		t.Errorf("Size = %d; want %d", after.Size, len("new recording"))
+	}
+
+	data, err := os.ReadFile(after.TempPath)
+	if err != nil || string(data) != "new recording" {
+		t.Errorf("temp file content = %q, %v; want new recording", data, err)
+	}
+	if fileExists(before.TempPath) {
+		t.Errorf("old temp file %s was left behind", before.TempPath)
+	}
+
+	p.files[0].ExternalURL = "https://example.com/a.mp3"
+	if err := replaceAndFinish(p, 0, newPath); err == nil {
+		t.Error("replacing the audio of an external file succeeded")
+	}
+}
+
+func TestReplaceAudioKeepsNamesUnique(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "intro.mp3", "intro.m4a")
+
+	newPath := filepath.Join(t.TempDir(), "retake.m4a")
+	os.WriteFile(newPath, []byte("new recording"), 0644)
+	if err := replaceAndFinish(p, 0, newPath); err != nil {
+		t.Fatalf("replacing the audio error = %v", err)
+	}
+	if got := displayNames(p.files); got[0] != "intro (2).m4a" || got[1] != "intro.m4a" {
+		t.Errorf("names after the replace = %v; want intro (2).m4a beside intro.m4a", got)
+	}
+	if filepath.Base(p.files[0].TempPath) != p.files[0].DisplayName || !fileExists(p.files[0].TempPath) {
+		t.Errorf("temp copy %s doesn't follow the name", p.files[0].TempPath)
+	}
+}
+
+func TestReplaceAudioDropsOldSidecars(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	srcDir := t.TempDir()
+	oldPath := filepath.Join(srcDir, "take1.mp3")
+	os.WriteFile(oldPath, []byte("old"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "take1.vtt"), []byte("WEBVTT\n\nold"), 0644)
+	p.addFile(oldPath)
+	applyChapters(&p.files[0], []byte(`{"chapters":[]}`))
+	oldChapters, oldTranscript := p.files[0].ChaptersPath, p.files[0].TranscriptPath
+	if oldTranscript == "" {
+		t.Fatal("the old source's transcript wasn't copied")
+	}
+
+	// A new source without a transcript leaves the episode with none
+	bare := filepath.Join(srcDir, "take2.mp3")
+	os.WriteFile(bare, []byte("new"), 0644)
+	if err := replaceAndFinish(p, 0, bare); err != nil {
+		t.Fatalf("replacing the audio error = %v", err)
+	}
+	if f := p.files[0]; f.ChaptersPath != "" || f.TranscriptPath != "" || fileExists(oldChapters) || fileExists(oldTranscript) {
+		t.Errorf("chapters %q, transcript %q kept after replacing the audio; want both dropped", f.ChaptersPath, f.TranscriptPath)
+	}
+
+	// One with a transcript beside it brings it along
+	withTranscript := filepath.Join(srcDir, "take3.mp3")
+	os.WriteFile(withTranscript, []byte("newer"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "take3.srt"), []byte("1\nnew"), 0644)
+	if err := replaceAndFinish(p, 0, withTranscript); err != nil {
+		t.Fatalf("replacing the audio error = %v", err)
+	}
+	if data, _ := os.ReadFile(p.files[0].TranscriptPath); string(data) != "1\nnew" {
+		t.Errorf("transcript = %q; want the new source's", data)
+	}
+}
+
+// replaceAndFinish swaps path in for the audio of the file at index as
+// replaceAudioInBackground does, copying it on the calling goroutine
+func replaceAndFinish(p *Podcasterator, index int, path string) error {
+	tmpPath, err := p.prepareReplace(index, path)
+	if err != nil {
+		return err
+	}
+	sum, err := importAudio(context.Background(), path, tmpPath, filepath.Join(p.projectTempDir(), partialDirName), p.verifyCopies, nil)
+	if err != nil {
+		return err
+	}
+	return p.finishReplace(p.files[index].ID, path, tmpPath, sum)
+}
+
+func TestFinishReplaceAfterRemove(t *testing.T) {
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+
+	src := filepath.Join(t.TempDir(), "take2.mp3")
+	os.WriteFile(src, []byte("take two"), 0644)
+	tmpPath, err := p.prepareReplace(0, src)
+	if err != nil {
+		t.Fatalf("prepareReplace() error = %v", err)
+	}
+	os.WriteFile(tmpPath, []byte("take two"), 0644)
+
+	// The episode is removed while its new audio copies
+	id := p.files[0].ID
+	p.files = p.files[1:]
+	if err := p.finishReplace(id, src, tmpPath, ""); err == nil {
+		t.Error("finishReplace() for a removed episode succeeded")
+	}
+	if fileExists(tmpPath) {
+		t.Error("finishReplace() for a removed episode left its copy behind")
+	}
+}
+
 func TestClearAll(t *testing.T) {
 	p, cleanup := newTestPodcasterator(t)
 	defer cleanup()
@@ -565,10 +929,160 @@ func TestDeleteFile(t *testing.T) {
 	})
 }
 
+func TestHandleSignals(t *testing.T) {
+	t.Run("signal triggers shutdown", func(t *testing.T) {
+		sigs := make(chan os.Signal, 1)
+		called := make(chan struct{})
+
+		go handleSignals(sigs, func() { close(called) })
+		sigs <- syscall.SIGTERM
+
+		select {
+		case <-called:
+		case <-time.After(time.Second):
+			t.Fatal("handleSignals() did not call shutdown after a signal")
+		}
+	})
+
+	t.Run("closed channel skips shutdown", func(t *testing.T) {
+		sigs := make(chan os.Signal)
+		close(sigs)
+
+		called := false
+		handleSignals(sigs, func() { called = true })
+		if called {
+			t.Error("handleSignals() called shutdown for a closed channel")
+		}
+	})
+}
+
+func TestShutdownSavesState(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.podcastName = "Unsaved Name"
+	p.shutdown()
+
+	data, err := os.ReadFile(filepath.Join(p.configDir, "state.json"))
+	if err != nil {
+		t.Fatalf("shutdown() did not write state: %v", err)
+	}
+
+	var state AppState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Failed to unmarshal state: %v", err)
+	}
+	if state.PodcastName != "Unsaved Name" {
+		t.Errorf("Saved podcast name = %q; want %q", state.PodcastName, "Unsaved Name")
+	}
+	if p.serverRunning {
+		t.Error("shutdown() left serverRunning set")
+	}
+}
+
 // =============================================================================
 // State Persistence Tests
 // =============================================================================
 
+func TestDirtyTrackingAndFlush(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	statePath := filepath.Join(p.configDir, "state.json")
+
+	src := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(src, []byte("audio"), 0644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+
+	// Mutations only mark state dirty
+	p.addFile(src)
+	if !p.dirty {
+		t.Error("addFile() did not set the dirty flag")
+	}
+	if fileExists(statePath) {
+		t.Error("addFile() wrote state before a flush")
+	}
+
+	p.flushState()
+	if p.dirty {
+		t.Error("flushState() did not clear the dirty flag")
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("flushState() did not write state: %v", err)
+	}
+	var state AppState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Failed to unmarshal state: %v", err)
+	}
+	if len(state.Files) != 1 || state.Files[0].ID != p.files[0].ID {
+		t.Errorf("Saved files = %+v; want the added file", state.Files)
+	}
+
+	// A clean flush leaves the file alone
+	os.Remove(statePath)
+	p.flushState()
+	if fileExists(statePath) {
+		t.Error("flushState() wrote state when nothing changed")
+	}
+
+	p.moveDown(0)
+	p.deleteFile(0)
+	if p.dirty {
+		t.Error("deleteFile() left the removal unsaved")
+	}
+	data, _ = os.ReadFile(statePath)
+	state = AppState{}
+	json.Unmarshal(data, &state)
+	if len(state.Files) != 0 {
+		t.Errorf("Saved files after delete = %+v; want none", state.Files)
+	}
+
+	// No temp files are left behind by the atomic write
+	entries, _ := os.ReadDir(p.configDir)
+	for _, e := range entries {
+		if e.Name() != "state.json" && e.Name() != "state.json"+stateBackupSuffix {
+			t.Errorf("config dir has %s; want only state.json and its backup", e.Name())
+		}
+	}
+}
+
+func TestAutosaveCoalescesEdits(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	statePath := filepath.Join(p.configDir, "state.json")
+
+	// Without autosave started, nothing is scheduled
+	p.markDirty()
+	if p.saveTimer != nil {
+		t.Fatal("markDirty() scheduled a save before autosave started")
+	}
+
+	p.startAutosave(time.Hour)
+	first := p.saveTimer
+	if first == nil {
+		t.Fatal("startAutosave() didn't schedule the pending change")
+	}
+	// A name typed a letter at a time is one write, an hour after the last
+	// letter
+	for _, name := range []string{"P", "Po", "Pod"} {
+		p.podcastName = name
+		p.markDirty()
+	}
+	defer p.saveTimer.Stop()
+	if first.Stop() || p.saveTimer == first || fileExists(statePath) {
+		t.Error("edits in a burst didn't restart the wait for the one pending save")
+	}
+
+	// Removals don't wait for it
+	addNamedFiles(t, p, "a.mp3")
+	p.deleteFile(0)
+	if state, err := readState(statePath); err != nil || state.PodcastName != "Pod" || p.dirty {
+		t.Errorf("saved %q (%v), dirty %v after a delete; want everything saved", state.PodcastName, err, p.dirty)
+	}
+}
+
 func TestSaveAndLoadState(t *testing.T) {
 	p, cleanup := newTestPodcasterator(t)
 	defer cleanup()
@@ -641,13 +1155,13 @@ func TestLoadStateWithMissingTempFiles(t *testing.T) {
 	defer cleanup()
 
 	// Create state with files that don't exist
-	state := AppState{
+	state := AppState{Project: Project{
 		Files: []AudioFile{
 			{ID: "1", TempPath: "/nonexistent/file1.mp3", DisplayName: "file1.mp3"},
 			{ID: "2", TempPath: "/nonexistent/file2.mp3", DisplayName: "file2.mp3"},
 		},
 		PodcastName: "Test",
-	}
+	}}
 
 	data, _ := json.Marshal(state)
 	statePath := filepath.Join(p.configDir, "state.json")
@@ -678,11 +1192,245 @@ func TestLoadStateCorruptedJSON(t *testing.T) {
 	}
 }
 
+func TestLoadStateFallsBackToBackup(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	statePath := filepath.Join(p.configDir, "state.json")
+
+	p.podcastName = "Saved Podcast"
+	p.saveState()
+	p.podcastName = "Renamed Podcast"
+	p.saveState()
+	backup, err := readState(statePath + stateBackupSuffix)
+	if err != nil || backup.PodcastName != "Saved Podcast" {
+		t.Fatalf("backup = %q (%v); want the state before the last save", backup.PodcastName, err)
+	}
+
+	// A save cut short leaves state.json truncated
+	data, _ := os.ReadFile(statePath)
+	os.WriteFile(statePath, data[:len(data)/2], 0644)
+
+	p2 := &Podcasterator{
+		tempDir:     p.tempDir,
+		configDir:   p.configDir,
+		podcastName: "Default Name",
+	}
+	p2.loadState()
+	if p2.podcastName != "Saved Podcast" {
+		t.Errorf("Loaded podcast name = %q; want the backup's", p2.podcastName)
+	}
+
+	// Saving over the damaged file keeps the good backup
+	p2.podcastName = "Recovered Podcast"
+	p2.saveState()
+	if backup, _ := readState(statePath + stateBackupSuffix); backup.PodcastName != "Saved Podcast" {
+		t.Errorf("backup after saving over a damaged state = %q; want it kept", backup.PodcastName)
+	}
+	if state, _ := readState(statePath); state.PodcastName != "Recovered Podcast" {
+		t.Errorf("saved podcast name = %q", state.PodcastName)
+	}
+}
+
+func TestLoadStateKeepsExternalFiles(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	state := AppState{Project: Project{
+		Files: []AudioFile{
+			{ID: "1", TempPath: "/nonexistent/file1.mp3", DisplayName: "file1.mp3"},
+			{ID: "2", DisplayName: "Hosted", ExternalURL: "https://cdn.example.com/ep.mp3", ExternalType: "audio/mpeg"},
+		},
+	}}
+
+	data, _ := json.Marshal(state)
+	os.WriteFile(filepath.Join(p.configDir, "state.json"), data, 0644)
+
+	p.loadState()
+
+	if len(p.files) != 1 || p.files[0].ID != "2" {
+		t.Errorf("loadState() should keep external files without temp copies; got %+v", p.files)
+	}
+}
+
+// randomStateString returns valid UTF-8 mixing ASCII, accents, CJK, emoji,
+// quotes and control characters, since that's what real titles look like.
+func randomStateString(r *rand.Rand) string {
+	pieces := []string{"", "a", "Z", "é", "ñ", "日本", "🎧", "\"", "\\", "<tag>", "&amp;", "\n", "\t", " ", "/", "..", "\u0000", "\u2028"}
+	var b strings.Builder
+	for i := r.Intn(8); i > 0; i-- {
+		b.WriteString(pieces[r.Intn(len(pieces))])
+	}
+	return b.String()
+}
+
+func randomAppState(r *rand.Rand) AppState {
+	state := AppState{
+		Project:         randomProject(r),
+		ProgressiveJPEG: r.Intn(2) == 0,
+		ArtworkWarnKB:   r.Intn(2048),
+		ArtworkFormat:   []string{"", artworkFormatJPEG, artworkFormatPNG}[r.Intn(3)],
+		ArtworkQuality:  r.Intn(101),
+		KeepExtension:   r.Intn(2) == 0,
+		DedupByContent:  r.Intn(2) == 0,
+		WalkFolderOrder: r.Intn(2) == 0,
+		VerifyCopies:    r.Intn(2) == 0,
+		LocalOnly:       r.Intn(2) == 0,
+		Port:            r.Intn(65536),
+		ServerAddress:   randomStateString(r),
+		Auth: feedAuth{
+			Enabled: r.Intn(2) == 0,
+			User:    randomStateString(r),
+			Salt:    randomStateString(r),
+			Hash:    randomStateString(r),
+		},
+		UseHTTPS:         r.Intn(2) == 0,
+		AllowCORS:        r.Intn(2) == 0,
+		ResumeServer:     r.Intn(2) == 0,
+		ServerWasRunning: r.Intn(2) == 0,
+		LastServerPort:   r.Intn(65536),
+		TempDir:          randomStateString(r),
+		ListView:         randomStateString(r),
+		Theme:            []string{themeSystem, themeLight, themeDark}[r.Intn(3)],
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		state.Projects = append(state.Projects, randomProject(r))
+	}
+	return state
+}
+
+func randomProject(r *rand.Rand) Project {
+	project := Project{
+		ID:               randomStateString(r),
+		PodcastName:      randomStateString(r),
+		ArtworkPath:      randomStateString(r),
+		PublicURL:        randomStateString(r),
+		NumberEnclosures: r.Intn(2) == 0,
+		ASCIIFileNames:   r.Intn(2) == 0,
+		WatchFolder:      randomStateString(r),
+		WatchRemovals:    r.Intn(2) == 0,
+		ChannelGUID:      randomStateString(r),
+		FeedEpoch:        time.Unix(r.Int63n(4e9), 0).UTC(),
+		FeedLocked:       r.Intn(2) == 0,
+		OwnerEmail:       randomStateString(r),
+		OwnerName:        randomStateString(r),
+		Author:           randomStateString(r),
+		Summary:          randomStateString(r),
+		Explicit:         r.Intn(2) == 0,
+		Category:         randomStateString(r),
+		Language:         randomStateString(r),
+		EpisodeDefaults: episodeDefaults{
+			Explicit:    r.Intn(2) == 0,
+			Language:    randomStateString(r),
+			Author:      randomStateString(r),
+			EpisodeType: randomStateString(r),
+		},
+	}
+
+	// Occasionally generate a very large list
+	count := r.Intn(20)
+	if r.Intn(10) == 0 {
+		count = 2000
+	}
+	if count > 0 || r.Intn(2) == 0 {
+		project.Files = make([]AudioFile, count)
+	}
+	for i := range project.Files {
+		project.Files[i] = AudioFile{
+			ID:             randomStateString(r),
+			OriginalPath:   randomStateString(r),
+			TempPath:       randomStateString(r),
+			DisplayName:    randomStateString(r),
+			ExternalURL:    randomStateString(r),
+			ExternalLength: r.Int63(),
+			ExternalType:   randomStateString(r),
+			Season:         r.Intn(5),
+			SourceURL:      randomStateString(r),
+			Size:           r.Int63(),
+			ModTime:        time.Unix(r.Int63n(4e9), r.Int63n(1e9)).UTC(),
+			Duration:       time.Duration(r.Int63()),
+			Description:    randomStateString(r),
+			Episode:        r.Intn(100),
+			Explicit:       r.Intn(2) == 0,
+			Author:         randomStateString(r),
+			Language:       randomStateString(r),
+			EpisodeType:    randomStateString(r),
+			Artist:         randomStateString(r),
+			Album:          randomStateString(r),
+			Track:          r.Intn(30),
+			AddedAt:        time.Unix(r.Int63n(4e9), 0).UTC(),
+			SHA256:         randomStateString(r),
+			ArtworkPath:    randomStateString(r),
+		}
+		if r.Intn(2) == 0 {
+			project.Files[i].PubDate = time.Unix(r.Int63n(4e9), 0).UTC()
+		}
+		project.Files[i].OriginalMissing = r.Intn(2) == 0
+	}
+
+	if r.Intn(2) == 0 {
+		project.SeasonSorts = map[int]string{r.Intn(5): randomStateString(r)}
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		project.WatchRemoved = append(project.WatchRemoved, randomStateString(r))
+	}
+	for i := r.Intn(3); i > 0; i-- {
+		project.ChannelElements = append(project.ChannelElements, channelElement{
+			Name:  randomStateString(r),
+			Value: randomStateString(r),
+		})
+	}
+	return project
+}
+
+func TestAppStateRoundTripRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		original := randomAppState(r)
+
+		data, err := json.Marshal(original)
+		if err != nil {
+			t.Fatalf("Failed to marshal state %d: %v", i, err)
+		}
+
+		var restored AppState
+		if err := json.Unmarshal(data, &restored); err != nil {
+			t.Fatalf("Failed to unmarshal state %d: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(original, restored) {
+			t.Fatalf("State %d did not round-trip:\n got: %+v\nwant: %+v", i, restored, original)
+		}
+	}
+}
+
+func FuzzLoadState(f *testing.F) {
+	seed, _ := json.Marshal(randomAppState(rand.New(rand.NewSource(2))))
+	f.Add(seed)
+	f.Add([]byte(`{"files":[{"id":"1","temp_path":"","external_url":"x"}],"podcast_name":"🎧"}`))
+	f.Add([]byte(`{"files":null,"season_sorts":{"1":"name"}}`))
+	f.Add([]byte(`{"files":[null]}`))
+	f.Add([]byte(`{invalid json`))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p, cleanup := newTestPodcasterator(t)
+		defer cleanup()
+
+		if err := os.WriteFile(filepath.Join(p.configDir, "state.json"), data, 0644); err != nil {
+			t.Fatalf("Failed to write state: %v", err)
+		}
+
+		// Must never panic, whatever is on disk
+		p.loadState()
+	})
+}
+
 // =============================================================================
 // Image Processing Tests
 // =============================================================================
 
-func TestConvertAndResizeImage(t *testing.T) {
+func TestConvertAndCropImage(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "img_test_*")
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
@@ -713,13 +1461,13 @@ func TestConvertAndResizeImage(t *testing.T) {
 		file.Close()
 
 		// Convert and resize
-		if err := convertAndResizeImage(srcPath, dstPath, 100); err != nil {
-			t.Errorf("convertAndResizeImage() error = %v", err)
+		if err := convertAndCropImageWith(srcPath, dstPath, 100, cropCenter, artworkEncoders[encoderBaseline]); err != nil {
+			t.Errorf("convertAndCropImageWith() error = %v", err)
 		}
 
 		// Verify output exists
 		if !fileExists(dstPath) {
-			t.Error("convertAndResizeImage() did not create output file")
+			t.Error("convertAndCropImageWith() did not create output file")
 		}
 
 		// Verify dimensions
@@ -742,9 +1490,9 @@ func TestConvertAndResizeImage(t *testing.T) {
 	})
 
 	t.Run("source file not found", func(t *testing.T) {
-		err := convertAndResizeImage("/nonexistent/image.png", filepath.Join(tmpDir, "out.jpg"), 100)
+		err := convertAndCropImageWith("/nonexistent/image.png", filepath.Join(tmpDir, "out.jpg"), 100, cropCenter, artworkEncoders[encoderBaseline])
 		if err == nil {
-			t.Error("convertAndResizeImage() expected error for non-existent source")
+			t.Error("convertAndCropImageWith() expected error for non-existent source")
 		}
 	})
 
@@ -753,9 +1501,9 @@ func TestConvertAndResizeImage(t *testing.T) {
 		invalidPath := filepath.Join(tmpDir, "not_an_image.png")
 		os.WriteFile(invalidPath, []byte("not an image"), 0644)
 
-		err := convertAndResizeImage(invalidPath, filepath.Join(tmpDir, "out2.jpg"), 100)
+		err := convertAndCropImageWith(invalidPath, filepath.Join(tmpDir, "out2.jpg"), 100, cropCenter, artworkEncoders[encoderBaseline])
 		if err == nil {
-			t.Error("convertAndResizeImage() expected error for invalid image")
+			t.Error("convertAndCropImageWith() expected error for invalid image")
 		}
 	})
 }
@@ -797,14 +1545,14 @@ func TestAudioFileJSONMarshaling(t *testing.T) {
 }
 
 func TestAppStateJSONMarshaling(t *testing.T) {
-	original := AppState{
+	original := AppState{Project: Project{
 		Files: []AudioFile{
 			{ID: "1", DisplayName: "file1.mp3"},
 			{ID: "2", DisplayName: "file2.mp3"},
 		},
 		PodcastName: "Test Podcast",
 		ArtworkPath: "/path/to/artwork.jpg",
-	}
+	}}
 
 	data, err := json.Marshal(original)
 	if err != nil {
@@ -868,12 +1616,89 @@ func TestAlphabetizeIsStable(t *testing.T) {
 
 	p.alphabetize()
 
-	// Should maintain relative order for equal elements (stable sort)
-	// Actually bubble sort is stable, so the order should be maintained
-	// But the implementation compares ToLower, so "Same" and "same" are equal
-	// The test just verifies no crash and both files remain
-	if len(p.files) != 2 {
-		t.Errorf("alphabetize() with same names resulted in %d files; want 2", len(p.files))
+	// "Same" and "same" compare equal ignoring case, so they keep their order
+	if len(p.files) != 2 || p.files[0].ID != "1" || p.files[1].ID != "2" {
+		t.Errorf("alphabetize() with same names = %+v; want the original order", p.files)
+	}
+}
+
+func TestSortByAddedAt(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.files = []AudioFile{
+		{ID: "1", DisplayName: "c", AddedAt: base.Add(2 * time.Hour)},
+		{ID: "2", DisplayName: "x"},
+		{ID: "3", DisplayName: "a", AddedAt: base},
+		{ID: "4", DisplayName: "y"},
+		{ID: "5", DisplayName: "b", AddedAt: base.Add(time.Hour)},
+	}
+	p.sortByAddedAt()
+
+	if got := strings.Join(displayNames(p.files), ","); got != "a,b,c,x,y" {
+		t.Errorf("order = %s; want a,b,c,x,y", got)
+	}
+	if !p.dirty {
+		t.Error("sortByAddedAt() didn't mark the state dirty")
+	}
+}
+
+func TestSortBySize(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	write := func(name string, size int) string {
+		path := filepath.Join(p.tempDir, name)
+		os.WriteFile(path, make([]byte, size), 0644)
+		return path
+	}
+	p.files = []AudioFile{
+		// A stale cached size is re-read from disk
+		{ID: "1", DisplayName: "small", TempPath: write("small", 10), Size: 9999},
+		{ID: "2", DisplayName: "external", ExternalURL: "https://example.com/e.mp3", Size: 5000},
+		{ID: "3", DisplayName: "big", TempPath: write("big", 300)},
+		{ID: "4", DisplayName: "gone", TempPath: filepath.Join(p.tempDir, "gone")},
+		{ID: "5", DisplayName: "medium", TempPath: write("medium", 100)},
+	}
+	p.sortBySize()
+
+	if got := strings.Join(displayNames(p.files), ","); got != "big,medium,small,external,gone" {
+		t.Errorf("order = %s; want big,medium,small,external,gone", got)
+	}
+	if p.files[2].Size != 10 {
+		t.Errorf("cached size = %d; want it refreshed to 10", p.files[2].Size)
+	}
+	if !p.dirty {
+		t.Error("sortBySize() didn't mark the state dirty")
+	}
+}
+
+func TestSortMenu(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.files = []AudioFile{{ID: "1", DisplayName: "b"}, {ID: "2", DisplayName: "a"}}
+
+	menu := p.sortMenu()
+	if len(menu.Items) != 7 {
+		t.Fatalf("sort menu has %d items; want 4 sorts, a separator and 2 numbering actions", len(menu.Items))
+	}
+	menu.Items[0].Action()
+	if got := strings.Join(displayNames(p.files), ","); got != "a,b" {
+		t.Errorf("Alphabetically sorted to %s", got)
+	}
+
+	// Hosted episodes are renamed without touching the disk
+	for i := range p.files {
+		p.files[i].ExternalURL = "https://example.com/" + p.files[i].ID
+	}
+	menu.Items[5].Action()
+	if got := strings.Join(displayNames(p.files), ","); got != "01 - a,02 - b" {
+		t.Errorf("numbered titles = %s", got)
+	}
+	menu.Items[6].Action()
+	if got := strings.Join(displayNames(p.files), ","); got != "a,b" {
+		t.Errorf("titles with numbers removed = %s", got)
 	}
 }
 
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// The running server is advertised over mDNS (Bonjour) so it can be reached
+// as podcasterator.local and found by service browsers, whatever IP the
+// machine has today.
+const (
+	mdnsHostName    = "podcasterator.local."
+	mdnsServiceList = "_services._dns-sd._udp.local."
+	mdnsTTL         = 120
+	mdnsPort        = 5353
+
+	// mdnsCacheFlush marks records only we answer for, so caches replace
+	// rather than add to what they had
+	mdnsCacheFlush = dnsmessage.Class(1 << 15)
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: mdnsPort}
+
+// mdnsHost is the host name the server is advertised under, without the
+// trailing dot
+func mdnsHost() string {
+	return strings.TrimSuffix(mdnsHostName, ".")
+}
+
+// mdnsServiceType is the DNS-SD service type for a server reached with
+// scheme, http or https
+func mdnsServiceType(scheme string) string {
+	return "_" + scheme + "._tcp.local."
+}
+
+// mdnsAdvert answers mDNS queries for the server until it's stopped
+type mdnsAdvert struct {
+	conn     *net.UDPConn
+	service  string // service type, e.g. "_http._tcp.local."
+	instance string // service instance name, e.g. "My Podcast._http._tcp.local."
+	ip       net.IP
+	port     int
+	path     string
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newMDNSAdvert describes the service without starting it. name is the
+// podcast name shown in service browsers and scheme is http or https.
+func newMDNSAdvert(name, scheme string, ip net.IP, port int, path string) (*mdnsAdvert, error) {
+	ip4 := ip.To4()
+	if ip4 == nil || ip4.IsLoopback() || ip4.IsUnspecified() {
+		return nil, fmt.Errorf("mdns: %v is not a LAN IPv4 address", ip)
+	}
+	return &mdnsAdvert{
+		service:  mdnsServiceType(scheme),
+		instance: mdnsInstanceLabel(name) + "." + mdnsServiceType(scheme),
+		ip:       ip4,
+		port:     port,
+		path:     path,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// mdnsInstanceLabel makes name usable as a single DNS label: no dots and
+// no more than 63 bytes
+func mdnsInstanceLabel(name string) string {
+	label := strings.TrimSpace(strings.ReplaceAll(name, ".", " "))
+	if label == "" {
+		label = "Podcasterator"
+	}
+	for len(label) > 63 {
+		_, size := utf8.DecodeLastRuneInString(label)
+		label = label[:len(label)-size]
+	}
+	return label
+}
+
+// startMDNS advertises the server at ip and port until stop is called
+func startMDNS(name, scheme string, ip net.IP, port int, path string) (*mdnsAdvert, error) {
+	a, err := newMDNSAdvert(name, scheme, ip, port, path)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+	a.conn = conn
+
+	go a.serve()
+
+	// Announce twice, a second apart, so caches pick the service up even
+	// if the first packet is lost
+	go func() {
+		for i := 0; i < 2; i++ {
+			if msg, err := a.announcement(mdnsTTL); err == nil {
+				a.conn.WriteToUDP(msg, mdnsGroup)
+			}
+			select {
+			case <-a.done:
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+	return a, nil
+}
+
+// stop sends a goodbye so caches drop the records, then stops answering
+func (a *mdnsAdvert) stop() {
+	a.stopOnce.Do(func() {
+		close(a.done)
+		if msg, err := a.announcement(0); err == nil {
+			a.conn.WriteToUDP(msg, mdnsGroup)
+		}
+		a.conn.Close()
+	})
+}
+
+// serve answers queries until the connection is closed
+func (a *mdnsAdvert) serve() {
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		reply, ok := a.answer(buf[:n], src.Port != mdnsPort)
+		if !ok {
+			continue
+		}
+		// Queries from a port other than 5353 come from a plain resolver and
+		// are answered directly; the rest go to the group
+		dest := mdnsGroup
+		if src.Port != mdnsPort {
+			dest = src
+		}
+		a.conn.WriteToUDP(reply, dest)
+	}
+}
+
+// answer builds the reply to the query in msg, if it asks about this
+// server. A legacy (unicast) reply echoes the query's ID and questions.
+func (a *mdnsAdvert) answer(msg []byte, legacy bool) ([]byte, bool) {
+	var p dnsmessage.Parser
+	header, err := p.Start(msg)
+	if err != nil || header.Response {
+		return nil, false
+	}
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return nil, false
+	}
+
+	var answers, extra []dnsmessage.Resource
+	seen := map[string]bool{}
+	add := func(list *[]dnsmessage.Resource, records ...dnsmessage.Resource) {
+		for _, r := range records {
+			key := r.Header.Name.String() + "/" + r.Header.Type.String()
+			if !seen[key] {
+				seen[key] = true
+				*list = append(*list, r)
+			}
+		}
+	}
+
+	ttl := uint32(mdnsTTL)
+	if legacy {
+		ttl = 10 // plain resolvers shouldn't cache mDNS answers for long
+	}
+	host, srv, txt, ptr, list := a.records(ttl)
+	for _, q := range questions {
+		if q.Class&^mdnsCacheFlush != dnsmessage.ClassINET && q.Class&^mdnsCacheFlush != dnsmessage.ClassANY {
+			continue
+		}
+		name := strings.ToLower(q.Name.String())
+		switch {
+		case name == mdnsHostName && (q.Type == dnsmessage.TypeA || q.Type == dnsmessage.TypeALL):
+			add(&answers, host)
+		case name == a.service && (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL):
+			add(&answers, ptr)
+			add(&extra, srv, txt, host)
+		case name == strings.ToLower(a.instance) && (q.Type == dnsmessage.TypeSRV || q.Type == dnsmessage.TypeALL):
+			add(&answers, srv)
+			if q.Type == dnsmessage.TypeALL {
+				add(&answers, txt)
+			}
+			add(&extra, host)
+		case name == strings.ToLower(a.instance) && q.Type == dnsmessage.TypeTXT:
+			add(&answers, txt)
+		case name == mdnsServiceList && (q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL):
+			add(&answers, list)
+		}
+	}
+	if len(answers) == 0 {
+		return nil, false
+	}
+
+	reply := dnsmessage.Message{
+		Header:      dnsmessage.Header{Response: true, Authoritative: true},
+		Answers:     answers,
+		Additionals: extra,
+	}
+	if legacy {
+		reply.Header.ID = header.ID
+		reply.Questions = questions
+		for _, list := range [][]dnsmessage.Resource{answers, extra} {
+			for i := range list {
+				list[i].Header.Class &^= mdnsCacheFlush
+			}
+		}
+	}
+	out, err := reply.Pack()
+	return out, err == nil
+}
+
+// announcement lists every record, unprompted. A TTL of 0 says goodbye.
+func (a *mdnsAdvert) announcement(ttl uint32) ([]byte, error) {
+	host, srv, txt, ptr, list := a.records(ttl)
+	msg := dnsmessage.Message{
+		Header:  dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{ptr, srv, txt, host, list},
+	}
+	return msg.Pack()
+}
+
+// records are the host's address, the service's location and feed path,
+// and the pointers browsers follow to find it
+func (a *mdnsAdvert) records(ttl uint32) (host, srv, txt, ptr, list dnsmessage.Resource) {
+	hostName := dnsmessage.MustNewName(mdnsHostName)
+	instance := dnsmessage.MustNewName(a.instance)
+	service := dnsmessage.MustNewName(a.service)
+	unique := dnsmessage.ClassINET | mdnsCacheFlush
+
+	var ip [4]byte
+	copy(ip[:], a.ip)
+	host = dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: hostName, Type: dnsmessage.TypeA, Class: unique, TTL: ttl},
+		Body:   &dnsmessage.AResource{A: ip},
+	}
+	srv = dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: instance, Type: dnsmessage.TypeSRV, Class: unique, TTL: ttl},
+		Body:   &dnsmessage.SRVResource{Port: uint16(a.port), Target: hostName},
+	}
+	txt = dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: instance, Type: dnsmessage.TypeTXT, Class: unique, TTL: ttl},
+		Body:   &dnsmessage.TXTResource{TXT: []string{"path=" + a.path}},
+	}
+	ptr = dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: service, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: ttl},
+		Body:   &dnsmessage.PTRResource{PTR: instance},
+	}
+	list = dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(mdnsServiceList), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: ttl},
+		Body:   &dnsmessage.PTRResource{PTR: service},
+	}
+	return host, srv, txt, ptr, list
+}
+
+// startAdvertising advertises the server on port over mDNS. It's best
+// effort: without multicast or a LAN address the server is only reachable
+// by IP, as before.
+func (p *Podcasterator) startAdvertising(port int) {
+	ip := net.ParseIP(p.advertisedHost())
+	scheme := p.serverScheme()
+	advert, err := startMDNS(p.podcastName, scheme, ip, port, "/feed.xml")
+	if err != nil {
+		fmt.Println("mDNS advertisement unavailable:", err)
+		return
+	}
+
+	p.serverMux.Lock()
+	defer p.serverMux.Unlock()
+	if !p.serverRunning {
+		advert.stop() // the server died while this started
+		return
+	}
+	p.mdns = advert
+	p.mdnsURL = fmt.Sprintf("%s://%s:%d/feed.xml", scheme, mdnsHost(), port)
+}
+
+// stopAdvertising withdraws the mDNS advertisement. The caller holds
+// serverMux.
+func (p *Podcasterator) stopAdvertising() {
+	if p.mdns != nil {
+		p.mdns.stop()
+		p.mdns = nil
+	}
+	p.mdnsURL = ""
+}
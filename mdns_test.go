@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// =============================================================================
+// mDNS Tests
+// =============================================================================
+
+// mdnsQuery packs a query for name and type with the given ID
+func mdnsQuery(t *testing.T, id uint16, name string, typ dnsmessage.Type) []byte {
+	t.Helper()
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id},
+		Questions: []dnsmessage.Question{
+			{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET},
+		},
+	}
+	b, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMDNSAnswer(t *testing.T) {
+	a, err := newMDNSAdvert("Mr. Show", "http", net.ParseIP("192.168.1.20"), 8080, "/feed.xml")
+	if err != nil {
+		t.Fatalf("newMDNSAdvert() error = %v", err)
+	}
+	if a.instance != "Mr  Show._http._tcp.local." {
+		t.Errorf("instance = %q; want the dot replaced", a.instance)
+	}
+
+	tests := []struct {
+		name    string
+		qname   string
+		qtype   dnsmessage.Type
+		answers []dnsmessage.Type
+	}{
+		{"host address", "Podcasterator.local.", dnsmessage.TypeA, []dnsmessage.Type{dnsmessage.TypeA}},
+		{"service browse", "_http._tcp.local.", dnsmessage.TypePTR, []dnsmessage.Type{dnsmessage.TypePTR}},
+		{"instance location", a.instance, dnsmessage.TypeSRV, []dnsmessage.Type{dnsmessage.TypeSRV}},
+		{"feed path", a.instance, dnsmessage.TypeTXT, []dnsmessage.Type{dnsmessage.TypeTXT}},
+		{"service types", mdnsServiceList, dnsmessage.TypePTR, []dnsmessage.Type{dnsmessage.TypePTR}},
+		{"other host", "printer.local.", dnsmessage.TypeA, nil},
+		{"IPv6 address", mdnsHostName, dnsmessage.TypeAAAA, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reply, ok := a.answer(mdnsQuery(t, 0, tc.qname, tc.qtype), false)
+			if ok != (tc.answers != nil) {
+				t.Fatalf("answer() ok = %v; want %v", ok, tc.answers != nil)
+			}
+			if !ok {
+				return
+			}
+			var msg dnsmessage.Message
+			if err := msg.Unpack(reply); err != nil {
+				t.Fatalf("reply doesn't parse: %v", err)
+			}
+			var got []dnsmessage.Type
+			for _, r := range msg.Answers {
+				got = append(got, r.Header.Type)
+			}
+			if len(got) != len(tc.answers) || got[0] != tc.answers[0] {
+				t.Errorf("answers = %v; want %v", got, tc.answers)
+			}
+		})
+	}
+}
+
+func TestMDNSServiceType(t *testing.T) {
+	a, _ := newMDNSAdvert("Show", "https", net.ParseIP("10.0.0.5"), 9000, "/feed.xml")
+
+	if _, ok := a.answer(mdnsQuery(t, 0, "_https._tcp.local.", dnsmessage.TypePTR), false); !ok {
+		t.Error("HTTPS server didn't answer an _https._tcp browse")
+	}
+	if _, ok := a.answer(mdnsQuery(t, 0, "_http._tcp.local.", dnsmessage.TypePTR), false); ok {
+		t.Error("HTTPS server answered an _http._tcp browse")
+	}
+}
+
+func TestMDNSAnswerRecords(t *testing.T) {
+	a, _ := newMDNSAdvert("Show", "http", net.ParseIP("10.0.0.5"), 9000, "/feed.xml")
+
+	reply, ok := a.answer(mdnsQuery(t, 42, "_http._tcp.local.", dnsmessage.TypePTR), true)
+	if !ok {
+		t.Fatal("answer() didn't reply to a service browse")
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(reply); err != nil {
+		t.Fatalf("reply doesn't parse: %v", err)
+	}
+
+	// Plain resolvers get their ID and question back
+	if msg.ID != 42 || len(msg.Questions) != 1 {
+		t.Errorf("legacy reply ID %d with %d questions; want 42 and 1", msg.ID, len(msg.Questions))
+	}
+
+	var srv *dnsmessage.SRVResource
+	var txt *dnsmessage.TXTResource
+	var host *dnsmessage.AResource
+	for _, r := range msg.Additionals {
+		if r.Header.Class&mdnsCacheFlush != 0 {
+			t.Errorf("legacy reply sets cache-flush on %v", r.Header.Type)
+		}
+		switch body := r.Body.(type) {
+		case *dnsmessage.SRVResource:
+			srv = body
+		case *dnsmessage.TXTResource:
+			txt = body
+		case *dnsmessage.AResource:
+			host = body
+		}
+	}
+	if srv == nil || srv.Port != 9000 || srv.Target.String() != mdnsHostName {
+		t.Errorf("SRV = %+v; want port 9000 on %s", srv, mdnsHostName)
+	}
+	if txt == nil || strings.Join(txt.TXT, ",") != "path=/feed.xml" {
+		t.Errorf("TXT = %+v; want the feed path", txt)
+	}
+	if host == nil || net.IP(host.A[:]).String() != "10.0.0.5" {
+		t.Errorf("A = %+v; want 10.0.0.5", host)
+	}
+}
+
+func TestMDNSIgnores(t *testing.T) {
+	a, _ := newMDNSAdvert("Show", "http", net.ParseIP("10.0.0.5"), 9000, "/feed.xml")
+
+	// Other responders' announcements aren't questions for us
+	announcement, _ := a.announcement(mdnsTTL)
+	if _, ok := a.answer(announcement, false); ok {
+		t.Error("answer() replied to a response")
+	}
+	if _, ok := a.answer([]byte("junk"), false); ok {
+		t.Error("answer() replied to garbage")
+	}
+
+	for _, ip := range []string{"127.0.0.1", "0.0.0.0", "::1"} {
+		if _, err := newMDNSAdvert("Show", "http", net.ParseIP(ip), 9000, "/feed.xml"); err == nil {
+			t.Errorf("newMDNSAdvert(%s) succeeded; want an error", ip)
+		}
+	}
+	if _, err := newMDNSAdvert("Show", "http", nil, 9000, "/feed.xml"); err == nil {
+		t.Error("newMDNSAdvert(nil) succeeded; want an error")
+	}
+}
+
+func TestMDNSInstanceLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"My Podcast", "My Podcast"},
+		{"  ", "Podcasterator"},
+		{"v1.2", "v1 2"},
+		{strings.Repeat("é", 40), strings.Repeat("é", 31)},
+	}
+
+	for _, tc := range tests {
+		if got := mdnsInstanceLabel(tc.name); got != tc.want {
+			t.Errorf("mdnsInstanceLabel(%q) = %q; want %q", tc.name, got, tc.want)
+		}
+	}
+}
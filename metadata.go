@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"fyne.io/fyne/v2"
+)
+
+// metadataExtractor reads one kind of metadata from a local audio file. The
+// returned function applies the result to an AudioFile; it must only touch
+// the fields this extractor owns so results can arrive in any order.
+type metadataExtractor interface {
+	Name() string
+	Extract(path string) (func(f *AudioFile), error)
+}
+
+// metadataUpdate is a single result from the pipeline. Apply is nil for the
+// final Done update of a file, and for extractors that failed.
+type metadataUpdate struct {
+	FileID    string
+	Extractor string
+	Apply     func(f *AudioFile)
+	Err       error
+	Done      bool
+}
+
+type metadataJob struct {
+	fileID    string
+	path      string
+	extractor metadataExtractor
+	pending   *sync.WaitGroup
+}
+
+// metadataPipeline runs every extractor on each submitted file using a
+// bounded pool of workers. Results are delivered through onUpdate as they
+// arrive, followed by one Done update per file once all extractors finished.
+type metadataPipeline struct {
+	extractors []metadataExtractor
+	onUpdate   func(metadataUpdate)
+	jobs       chan metadataJob
+	workers    sync.WaitGroup
+	files      sync.WaitGroup
+}
+
+func newMetadataPipeline(workers int, extractors []metadataExtractor, onUpdate func(metadataUpdate)) *metadataPipeline {
+	if workers < 1 {
+		workers = 1
+	}
+
+	mp := &metadataPipeline{
+		extractors: extractors,
+		onUpdate:   onUpdate,
+		jobs:       make(chan metadataJob),
+	}
+
+	for i := 0; i < workers; i++ {
+		mp.workers.Add(1)
+		go mp.work()
+	}
+	return mp
+}
+
+func (mp *metadataPipeline) work() {
+	defer mp.workers.Done()
+
+	for job := range mp.jobs {
+		apply, err := job.extractor.Extract(job.path)
+		update := metadataUpdate{FileID: job.fileID, Extractor: job.extractor.Name(), Err: err}
+		if err == nil {
+			update.Apply = apply
+		}
+		mp.onUpdate(update)
+		job.pending.Done()
+	}
+}
+
+// Submit queues a file for extraction without blocking the caller.
+func (mp *metadataPipeline) Submit(file AudioFile) {
+	mp.SubmitTo(file, mp.extractors)
+}
+
+// SubmitTo is Submit running only extractors, for files that already have
+// the rest
+func (mp *metadataPipeline) SubmitTo(file AudioFile, extractors []metadataExtractor) {
+	pending := &sync.WaitGroup{}
+	pending.Add(len(extractors))
+	mp.files.Add(1)
+
+	go func() {
+		for _, extractor := range extractors {
+			mp.jobs <- metadataJob{fileID: file.ID, path: file.ServedPath(), extractor: extractor, pending: pending}
+		}
+	}()
+
+	go func() {
+		pending.Wait()
+		mp.onUpdate(metadataUpdate{FileID: file.ID, Done: true})
+		mp.files.Done()
+	}()
+}
+
+// Close waits for submitted files to finish and stops the workers.
+func (mp *metadataPipeline) Close() {
+	mp.files.Wait()
+	close(mp.jobs)
+	mp.workers.Wait()
+}
+
+// sizeExtractor records the file size and modification time
+type sizeExtractor struct{}
+
+func (sizeExtractor) Name() string { return "size" }
+
+func (sizeExtractor) Extract(path string) (func(f *AudioFile), error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	size, modTime := info.Size(), info.ModTime()
+	return func(f *AudioFile) {
+		f.Size = size
+		f.ModTime = modTime
+	}, nil
+}
+
+// durationExtractor records the playback length. Files whose length can't
+// be read keep a zero Duration, which leaves it out of the feed.
+type durationExtractor struct{}
+
+func (durationExtractor) Name() string { return "duration" }
+
+func (durationExtractor) Extract(path string) (func(f *AudioFile), error) {
+	d, err := audioDuration(path)
+	if err != nil {
+		return nil, err
+	}
+	return func(f *AudioFile) {
+		f.Duration = d
+	}, nil
+}
+
+// defaultExtractors is the set run on every newly added file
+func defaultExtractors() []metadataExtractor {
+	return []metadataExtractor{
+		sizeExtractor{},
+		durationExtractor{},
+		chaptersExtractor{},
+	}
+}
+
+// startMetadataPipeline creates the background pipeline whose results are
+// applied on the UI thread.
+func (p *Podcasterator) startMetadataPipeline() {
+	p.processing = map[string]bool{}
+	p.metadata = newMetadataPipeline(runtime.NumCPU(), defaultExtractors(), func(u metadataUpdate) {
+		fyne.Do(func() { p.applyMetadataUpdate(u) })
+	})
+	p.extractMissingDurations()
+}
+
+// extractMetadata queues a newly added local file for processing
+func (p *Podcasterator) extractMetadata(file AudioFile) {
+	if p.metadata == nil || file.IsExternal() {
+		return
+	}
+	p.processing[file.ID] = true
+	p.metadata.Submit(file)
+}
+
+// extractMissingDurations queues the local files without a duration, such
+// as those saved before durations were recorded or whose cut was lost, so
+// building the feed never has to read audio itself
+func (p *Podcasterator) extractMissingDurations() {
+	if p.metadata == nil || p.tempDirUnavailable {
+		return
+	}
+	for _, file := range p.files {
+		if file.Duration == 0 && !file.IsExternal() {
+			p.processing[file.ID] = true
+			p.metadata.SubmitTo(file, []metadataExtractor{durationExtractor{}})
+		}
+	}
+}
+
+// applyMetadataUpdate merges a pipeline result into the matching file. Files
+// may have been reordered or removed since they were submitted, so they're
+// looked up by ID.
+func (p *Podcasterator) applyMetadataUpdate(u metadataUpdate) {
+	if u.Err != nil {
+		fmt.Printf("Metadata %s failed for %s: %v\n", u.Extractor, u.FileID, u.Err)
+	}
+
+	if u.Done {
+		delete(p.processing, u.FileID)
+		p.markDirty()
+	}
+
+	for i := range p.files {
+		if p.files[i].ID == u.FileID {
+			if u.Apply != nil {
+				u.Apply(&p.files[i])
+			}
+			if p.fileList != nil {
+				p.fileList.RefreshItem(i)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Metadata Pipeline Tests
+// =============================================================================
+
+// fakeExtractor sleeps a random amount so results arrive out of order
+type fakeExtractor struct {
+	name string
+	fail bool
+}
+
+func (e fakeExtractor) Name() string { return e.name }
+
+func (e fakeExtractor) Extract(path string) (func(f *AudioFile), error) {
+	time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+	if e.fail {
+		return nil, errors.New("extract failed")
+	}
+	value := e.name + ":" + filepath.Base(path)
+	return func(f *AudioFile) {
+		if f.DisplayName != "" {
+			f.DisplayName += ","
+		}
+		f.DisplayName += value
+	}, nil
+}
+
+func TestMetadataPipelineOrderIndependent(t *testing.T) {
+	const fileCount = 20
+
+	var mu sync.Mutex
+	files := map[string]*AudioFile{}
+	applied := map[string]int{}
+	done := map[string]int{}
+	doneBeforeAll := []string{}
+
+	extractors := []metadataExtractor{fakeExtractor{name: "a"}, fakeExtractor{name: "b"}, fakeExtractor{name: "c"}}
+	mp := newMetadataPipeline(4, extractors, func(u metadataUpdate) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if u.Done {
+			done[u.FileID]++
+			if applied[u.FileID] != len(extractors) {
+				doneBeforeAll = append(doneBeforeAll, u.FileID)
+			}
+			return
+		}
+		if u.Apply != nil {
+			u.Apply(files[u.FileID])
+			applied[u.FileID]++
+		}
+	})
+
+	for i := 0; i < fileCount; i++ {
+		id := fmt.Sprintf("id-%d", i)
+		file := AudioFile{ID: id, TempPath: fmt.Sprintf("/tmp/%d.mp3", i)}
+		mu.Lock()
+		files[id] = &file
+		mu.Unlock()
+		mp.Submit(file)
+	}
+	mp.Close()
+
+	if len(doneBeforeAll) != 0 {
+		t.Errorf("Done reported before all extractors finished for %v", doneBeforeAll)
+	}
+
+	for i := 0; i < fileCount; i++ {
+		id := fmt.Sprintf("id-%d", i)
+		if done[id] != 1 {
+			t.Errorf("File %s got %d Done updates; want 1", id, done[id])
+		}
+		if applied[id] != len(extractors) {
+			t.Errorf("File %s got %d results; want %d", id, applied[id], len(extractors))
+		}
+
+		// Each extractor's value is present exactly once, whatever the order
+		for _, e := range extractors {
+			want := fmt.Sprintf("%s:%d.mp3", e.Name(), i)
+			count := 0
+			for _, part := range strings.Split(files[id].DisplayName, ",") {
+				if part == want {
+					count++
+				}
+			}
+			if count != 1 {
+				t.Errorf("File %s has %q %d time(s) in %q; want 1", id, want, count, files[id].DisplayName)
+			}
+		}
+	}
+}
+
+func TestMetadataPipelineReportsErrors(t *testing.T) {
+	var mu sync.Mutex
+	var failures, doneCount int
+
+	extractors := []metadataExtractor{fakeExtractor{name: "ok"}, fakeExtractor{name: "bad", fail: true}}
+	mp := newMetadataPipeline(2, extractors, func(u metadataUpdate) {
+		mu.Lock()
+		defer mu.Unlock()
+		if u.Err != nil {
+			failures++
+			if u.Apply != nil {
+				t.Error("Failed extractor update should not carry an Apply func")
+			}
+		}
+		if u.Done {
+			doneCount++
+		}
+	})
+
+	mp.Submit(AudioFile{ID: "1", TempPath: "/tmp/1.mp3"})
+	mp.Close()
+
+	if failures != 1 {
+		t.Errorf("Got %d failure updates; want 1", failures)
+	}
+	if doneCount != 1 {
+		t.Errorf("Got %d Done updates; want 1 even when an extractor fails", doneCount)
+	}
+}
+
+func TestSizeExtractor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(path, make([]byte, 1234), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	apply, err := sizeExtractor{}.Extract(path)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	var file AudioFile
+	apply(&file)
+	if file.Size != 1234 {
+		t.Errorf("Size = %d; want 1234", file.Size)
+	}
+
+	if _, err := (sizeExtractor{}).Extract(filepath.Join(t.TempDir(), "missing.mp3")); err == nil {
+		t.Error("Extract() expected error for missing file")
+	}
+}
+
+func TestApplyMetadataUpdateByID(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.processing = map[string]bool{"b": true}
+	p.files = []AudioFile{{ID: "a"}, {ID: "b"}}
+
+	// The file moved since it was submitted; the update still finds it
+	p.files[0], p.files[1] = p.files[1], p.files[0]
+	p.applyMetadataUpdate(metadataUpdate{FileID: "b", Apply: func(f *AudioFile) { f.Size = 42 }})
+	if p.files[0].Size != 42 {
+		t.Errorf("Update applied to wrong file: %+v", p.files)
+	}
+
+	p.applyMetadataUpdate(metadataUpdate{FileID: "b", Done: true})
+	if p.processing["b"] {
+		t.Error("Done update did not clear processing state")
+	}
+
+	// Updates for deleted files are ignored
+	p.applyMetadataUpdate(metadataUpdate{FileID: "gone", Apply: func(f *AudioFile) { f.Size = 1 }})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// episodeNumber matches the "01 - " numberEpisodes puts before a name
+var episodeNumber = regexp.MustCompile(`^\d{2,} - `)
+
+// copySuffix matches the " (2)" uniqueName adds before an extension
+var copySuffix = regexp.MustCompile(` \((\d+)\)$`)
+
+// takenNames is the set of display names in files, lowercased, since names
+// differing only in case are just as confusing in the list
+func takenNames(files []AudioFile) map[string]bool {
+	taken := make(map[string]bool, len(files))
+	for _, f := range files {
+		taken[strings.ToLower(f.DisplayName)] = true
+	}
+	return taken
+}
+
+// uniqueName returns name, or when it's taken, name with the first free
+// numeric suffix before its extension: "book.m4a" becomes "book (2).m4a".
+// A name that already carries a suffix counts on from it, as long as the
+// name without it is taken too; otherwise "Dune (1965)" is just a title.
+func uniqueName(name string, taken map[string]bool) string {
+	if !taken[strings.ToLower(name)] {
+		return name
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	n := 2
+	if m := copySuffix.FindStringSubmatch(base); m != nil {
+		original := strings.TrimSuffix(base, m[0])
+		if k, err := strconv.Atoi(m[1]); err == nil && taken[strings.ToLower(original+ext)] {
+			base, n = original, k+1
+		}
+	}
+	for ; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if !taken[strings.ToLower(candidate)] {
+			return candidate
+		}
+	}
+}
+
+// numberWidth is the digits needed to number count items, at least two so
+// the numbers line up
+func numberWidth(count int) int {
+	return max(2, len(strconv.Itoa(count)))
+}
+
+// stripEpisodeNumber is name without the number numberEpisodes gave it
+func stripEpisodeNumber(name string) string {
+	if stripped := episodeNumber.ReplaceAllString(name, ""); stripped != "" {
+		return stripped
+	}
+	return name
+}
+
+// numberedName is name prefixed with its position at index of count, e.g.
+// "03 - Intro.mp3". An earlier number is replaced rather than stacked.
+func numberedName(name string, index, count int) string {
+	return fmt.Sprintf("%0*d - %s", numberWidth(count), index+1, stripEpisodeNumber(name))
+}
+
+// numberEpisodes renames every file with its position in the list, so
+// players that sort by title play them in order. Running it again after
+// reordering renumbers them. Files that can't be renamed are skipped and
+// their errors returned.
+func (p *Podcasterator) numberEpisodes() error {
+	var errs []error
+	for i := range p.files {
+		name := numberedName(p.files[i].DisplayName, i, len(p.files))
+		if err := p.applyRename(&p.files[i], name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.files[i].DisplayName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stripEpisodeNumbers undoes numberEpisodes. A name that would then clash
+// with another file's gets a suffix, as when it was added.
+func (p *Podcasterator) stripEpisodeNumbers() error {
+	taken := map[string]bool{}
+	for _, f := range p.files {
+		if stripEpisodeNumber(f.DisplayName) == f.DisplayName {
+			taken[strings.ToLower(f.DisplayName)] = true
+		}
+	}
+	var errs []error
+	for i := range p.files {
+		name := stripEpisodeNumber(p.files[i].DisplayName)
+		if name == p.files[i].DisplayName {
+			continue
+		}
+		name = uniqueName(name, taken)
+		taken[strings.ToLower(name)] = true
+		if err := p.applyRename(&p.files[i], name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.files[i].DisplayName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
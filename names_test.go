@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Display Name Collision Tests
+// =============================================================================
+
+func TestUniqueName(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{"book.m4a", nil, "book.m4a"},
+		{"book.m4a", []string{"book.m4a"}, "book (2).m4a"},
+		{"book.m4a", []string{"book.m4a", "book (2).m4a"}, "book (3).m4a"},
+		{"Book.M4A", []string{"book.m4a"}, "Book (2).M4A"},
+		{"book (2).m4a", []string{"book.m4a", "book (2).m4a"}, "book (3).m4a"},
+		{"Dune (1965).m4b", []string{"dune (1965).m4b"}, "Dune (1965) (2).m4b"},
+		{"README", []string{"readme"}, "README (2)"},
+		{"v1.0.mp3", []string{"v1.0.mp3"}, "v1.0 (2).mp3"},
+	}
+
+	for _, tc := range tests {
+		taken := map[string]bool{}
+		for _, n := range tc.taken {
+			taken[n] = true
+		}
+		if got := uniqueName(tc.name, taken); got != tc.want {
+			t.Errorf("uniqueName(%q, %v) = %q; want %q", tc.name, tc.taken, got, tc.want)
+		}
+	}
+}
+
+func TestAppendFilesUniqueNames(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	copyOf := func(id, name string) AudioFile {
+		path := filepath.Join(p.tempDir, id, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("audio"), 0644)
+		return AudioFile{ID: id, DisplayName: name, TempPath: path}
+	}
+	p.appendFiles([]AudioFile{copyOf("1", "book.m4a")})
+	// Two more in one batch, such as an .mp4 and .m4b served as .m4a
+	p.appendFiles([]AudioFile{copyOf("2", "book.m4a"), copyOf("3", "book.m4a")})
+
+	if got := strings.Join(displayNames(p.files), ","); got != "book.m4a,book (2).m4a,book (3).m4a" {
+		t.Errorf("names = %s", got)
+	}
+	if filepath.Base(p.files[1].TempPath) != "book (2).m4a" || !fileExists(p.files[1].TempPath) {
+		t.Errorf("copy at %s; want it renamed to match", p.files[1].TempPath)
+	}
+
+	// External files have nothing to rename on disk
+	p.appendFiles([]AudioFile{{ID: "4", DisplayName: "book.m4a", ExternalURL: "https://example.com/b.m4a"}})
+	if p.files[3].DisplayName != "book (4).m4a" {
+		t.Errorf("external file named %q; want book (4).m4a", p.files[3].DisplayName)
+	}
+}
+
+func TestNumberedName(t *testing.T) {
+	tests := []struct {
+		name         string
+		index, count int
+		want         string
+	}{
+		{"Intro.mp3", 0, 3, "01 - Intro.mp3"},
+		{"Intro.mp3", 9, 120, "010 - Intro.mp3"},
+		{"03 - Intro.mp3", 0, 3, "01 - Intro.mp3"},
+		{"2001 A Space Odyssey.m4b", 1, 3, "02 - 2001 A Space Odyssey.m4b"},
+	}
+	for _, tc := range tests {
+		if got := numberedName(tc.name, tc.index, tc.count); got != tc.want {
+			t.Errorf("numberedName(%q, %d, %d) = %q; want %q", tc.name, tc.index, tc.count, got, tc.want)
+		}
+	}
+	if got := stripEpisodeNumber("2001 A Space Odyssey.m4b"); got != "2001 A Space Odyssey.m4b" {
+		t.Errorf("stripEpisodeNumber() = %q; want a title's own number kept", got)
+	}
+}
+
+func TestNumberEpisodes(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "b.mp3", "a.mp3", "03 - a.mp3")
+	p.appendFiles([]AudioFile{{ID: "ext", DisplayName: "hosted.mp3", ExternalURL: "https://example.com/h.mp3"}})
+
+	if err := p.numberEpisodes(); err != nil {
+		t.Fatalf("numberEpisodes() error = %v", err)
+	}
+	want := "01 - b.mp3,02 - a.mp3,03 - a.mp3,04 - hosted.mp3"
+	if got := strings.Join(displayNames(p.files), ","); got != want {
+		t.Errorf("names = %s; want %s", got, want)
+	}
+	for _, f := range p.files[:3] {
+		if filepath.Base(f.TempPath) != f.DisplayName || !fileExists(f.TempPath) {
+			t.Errorf("copy at %s; want it renamed to %s", f.TempPath, f.DisplayName)
+		}
+	}
+
+	// Renumbering after a reorder replaces the numbers
+	p.reverse()
+	p.numberEpisodes()
+	want = "01 - hosted.mp3,02 - a.mp3,03 - a.mp3,04 - b.mp3"
+	if got := strings.Join(displayNames(p.files), ","); got != want {
+		t.Errorf("names after renumbering = %s; want %s", got, want)
+	}
+
+	if err := p.stripEpisodeNumbers(); err != nil {
+		t.Fatalf("stripEpisodeNumbers() error = %v", err)
+	}
+	want = "hosted.mp3,a.mp3,a (2).mp3,b.mp3"
+	if got := strings.Join(displayNames(p.files), ","); got != want {
+		t.Errorf("names after stripping = %s; want %s", got, want)
+	}
+	if !fileExists(p.files[2].TempPath) || filepath.Base(p.files[2].TempPath) != "a (2).mp3" {
+		t.Errorf("copy at %s; want it renamed back", p.files[2].TempPath)
+	}
+}
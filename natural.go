@@ -0,0 +1,79 @@
+package main
+
+import (
+	"cmp"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// naturalLess reports whether a sorts before b in natural order: ignoring
+// case, with runs of digits compared as numbers, so "Chapter 2" comes before
+// "Chapter 10"
+func naturalLess(a, b string) bool {
+	return naturalCompare(a, b) < 0
+}
+
+// naturalCompare compares a and b in natural order, returning -1, 0 or +1.
+// Numbers that differ only in zero-padding ("7" and "007") are equal unless
+// nothing else tells the names apart; then the less padded one comes first.
+func naturalCompare(a, b string) int {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	padding := 0
+	for a != "" && b != "" {
+		if isASCIIDigit(a[0]) && isASCIIDigit(b[0]) {
+			da, db := digitPrefix(a), digitPrefix(b)
+			a, b = a[len(da):], b[len(db):]
+
+			// Without leading zeros, a longer run is a bigger number
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			if padding == 0 {
+				padding = cmp.Compare(len(da), len(db))
+			}
+			continue
+		}
+
+		ra, sizeA := utf8.DecodeRuneInString(a)
+		rb, sizeB := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			return cmp.Compare(ra, rb)
+		}
+		a, b = a[sizeA:], b[sizeB:]
+	}
+	if c := cmp.Compare(len(a), len(b)); c != 0 {
+		return c
+	}
+	return padding
+}
+
+// naturalPathLess is naturalLess for paths, compared a folder at a time so
+// "Disc 2/10.mp3" comes after "Disc 2/9.mp3" and before "Disc 10/1.mp3"
+func naturalPathLess(a, b string) bool {
+	pa := strings.Split(filepath.ToSlash(a), "/")
+	pb := strings.Split(filepath.ToSlash(b), "/")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if c := naturalCompare(pa[i], pb[i]); c != 0 {
+			return c < 0
+		}
+	}
+	return len(pa) < len(pb)
+}
+
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// digitPrefix is the run of ASCII digits s starts with
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && isASCIIDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
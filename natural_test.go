@@ -0,0 +1,92 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Natural Sort Tests
+// =============================================================================
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"Chapter 2.mp3", "Chapter 10.mp3", -1},
+		{"track2", "track10", -1},
+		{"track10", "track9", 1},
+		{"Track 3", "track 3", 0},
+		{"abc", "abd", -1},
+		{"abc", "abc1", -1},
+		{"1", "a", -1},
+		// Zero-padding only breaks ties
+		{"ep 007", "ep 7", 1},
+		{"ep 007", "ep 8", -1},
+		{"ep 07 b", "ep 7 a", 1},
+		{"ep 0", "ep 00", -1},
+		// Numbers too long for an int still compare by value
+		{"x 99999999999999999999999", "x 100000000000000000000000", -1},
+		// Several numbers compare left to right
+		{"Book 2 Chapter 10", "Book 2 Chapter 9", 1},
+		{"Book 10 Chapter 1", "Book 2 Chapter 9", 1},
+		{"Disc 1 - 2", "Disc 1 - 02", -1},
+		{"Ünïcode 2", "ünïcode 10", -1},
+	}
+
+	for _, tc := range tests {
+		if got := naturalCompare(tc.a, tc.b); got != tc.want {
+			t.Errorf("naturalCompare(%q, %q) = %d; want %d", tc.a, tc.b, got, tc.want)
+		}
+		if got := naturalCompare(tc.b, tc.a); got != -tc.want {
+			t.Errorf("naturalCompare(%q, %q) = %d; want %d", tc.b, tc.a, got, -tc.want)
+		}
+	}
+}
+
+func TestNaturalSortMixedWidths(t *testing.T) {
+	names := []string{"10.mp3", "2.mp3", "001.mp3", "1.mp3", "Chapter 11", "chapter 1", "Chapter 02", "09.mp3"}
+	sort.SliceStable(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+
+	want := "1.mp3,001.mp3,2.mp3,09.mp3,10.mp3,chapter 1,Chapter 02,Chapter 11"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("sorted = %s; want %s", got, want)
+	}
+}
+
+func TestNaturalPathLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"/m/Disc 2/9.mp3", "/m/Disc 2/10.mp3", true},
+		{"/m/Disc 2/10.mp3", "/m/Disc 10/1.mp3", true},
+		// Folders compare as a whole, not against a file name's characters
+		{"/m/Disc 1/2.mp3", "/m/Disc 1 Extras/1.mp3", true},
+		{"/m/b.mp3", "/m/a/z.mp3", false},
+		{"/m/a", "/m/a/b.mp3", true},
+	}
+
+	for _, tc := range tests {
+		if got := naturalPathLess(tc.a, tc.b); got != tc.want {
+			t.Errorf("naturalPathLess(%q, %q) = %v; want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestAlphabetizeNatural(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.files = []AudioFile{
+		{ID: "1", DisplayName: "Chapter 10.mp3"},
+		{ID: "2", DisplayName: "Chapter 2.mp3"},
+		{ID: "3", DisplayName: "chapter 1.mp3"},
+	}
+
+	p.alphabetize()
+	if got := strings.Join(displayNames(p.files), ","); got != "chapter 1.mp3,Chapter 2.mp3,Chapter 10.mp3" {
+		t.Errorf("alphabetize() order = %s", got)
+	}
+}
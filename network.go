@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// autoAddressLabel is the picker entry for letting getLocalIP choose
+const autoAddressLabel = "Automatic"
+
+// netAddress is an IPv4 address on one of this machine's interfaces
+type netAddress struct {
+	Interface string
+	IP        string
+}
+
+// label is how the address is shown in the picker
+func (a netAddress) label() string {
+	return fmt.Sprintf("%s (%s)", a.IP, a.Interface)
+}
+
+// networkAddresses lists the IPv4 addresses of the interfaces that are up,
+// leaving out loopback
+func networkAddresses() []netAddress {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	return interfaceAddresses(ifaces, func(iface net.Interface) ([]net.Addr, error) {
+		return iface.Addrs()
+	})
+}
+
+// interfaceAddresses lists the usable IPv4 addresses of ifaces, in order
+func interfaceAddresses(ifaces []net.Interface, addrsOf func(net.Interface) ([]net.Addr, error)) []netAddress {
+	var list []netAddress
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := addrsOf(iface)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil || ipNet.IP.IsLoopback() {
+				continue
+			}
+			list = append(list, netAddress{Interface: iface.Name, IP: ipNet.IP.String()})
+		}
+	}
+	return list
+}
+
+// chooseAddress is the address the server is advertised at: chosen if it's
+// still one of addrs, otherwise getLocalIP's pick. An unplugged interface
+// falls back rather than leaving the feed unreachable.
+func chooseAddress(addrs []netAddress, chosen string) string {
+	if chosen != "" {
+		for _, a := range addrs {
+			if a.IP == chosen {
+				return chosen
+			}
+		}
+	}
+	return getLocalIP()
+}
+
+// advertisedHost is the host put in feed URLs and advertised over mDNS
+func (p *Podcasterator) advertisedHost() string {
+	return serverHost(p.localOnly, p.serverAddress)
+}
+
+// networkSection is the Settings section for how the server is reached: the
+// address the feed is advertised at and whether it's served over HTTPS. The
+// address list is read fresh each time Settings opens so newly connected
+// interfaces show up.
+func (p *Podcasterator) networkSection() fyne.CanvasObject {
+	addrs := networkAddresses()
+	options := []string{autoAddressLabel}
+	selected := autoAddressLabel
+	for _, a := range addrs {
+		options = append(options, a.label())
+		if a.IP == p.serverAddress {
+			selected = a.label()
+		}
+	}
+	if selected == autoAddressLabel && p.serverAddress != "" {
+		// Keep a saved address that's not connected right now
+		missing := p.serverAddress + " (not connected)"
+		options = append(options, missing)
+		selected = missing
+	}
+
+	picker := widget.NewSelect(options, func(label string) {
+		address := p.serverAddress
+		if label == autoAddressLabel {
+			address = ""
+		}
+		for _, a := range addrs {
+			if a.label() == label {
+				address = a.IP
+			}
+		}
+		if address != p.serverAddress {
+			p.serverAddress = address
+			p.markDirty()
+		}
+	})
+	picker.SetSelected(selected)
+
+	note := widget.NewLabel("The address put in the feed URL. The server still listens on\nevery interface. Applies the next time the server starts.")
+	note.Importance = widget.LowImportance
+
+	httpsCheck := widget.NewCheck("Serve over HTTPS (self-signed certificate)", func(checked bool) {
+		p.useHTTPS = checked
+		p.markDirty()
+	})
+	httpsCheck.SetChecked(p.useHTTPS)
+
+	certPath, _ := certPaths(p.certDir())
+	httpsNote := widget.NewLabel("Your devices must trust the certificate before podcast apps\nwill connect. It's created on first use at:\n" + certPath)
+	httpsNote.Importance = widget.LowImportance
+	httpsNote.Wrapping = fyne.TextWrapBreak
+
+	corsCheck := widget.NewCheck("Allow web players in other sites to fetch the feed (CORS)", func(checked bool) {
+		p.allowCORS = checked
+		p.markDirty()
+	})
+	corsCheck.SetChecked(p.allowCORS)
+
+	corsNote := widget.NewLabel("Lets any web page open in a browser on your network read the\nfeed and episodes. Applies the next time the server starts.")
+	corsNote.Importance = widget.LowImportance
+
+	resumeCheck := widget.NewCheck("Start the server on launch if it was running at exit", func(checked bool) {
+		p.resumeServer = checked
+		p.markDirty()
+	})
+	resumeCheck.SetChecked(p.resumeServer)
+
+	resumeNote := widget.NewLabel("Brings the feed back up on the same port after a restart, so\nsubscribed apps keep working. A server you stopped stays stopped.")
+	resumeNote.Importance = widget.LowImportance
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("Network", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewBorder(nil, nil, widget.NewLabel("Feed address:"), nil, picker),
+		note,
+		httpsCheck,
+		httpsNote,
+		corsCheck,
+		corsNote,
+		resumeCheck,
+		resumeNote,
+	)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// =============================================================================
+// Network Address Tests
+// =============================================================================
+
+func TestInterfaceAddresses(t *testing.T) {
+	ifaces := []net.Interface{
+		{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+		{Name: "docker0", Flags: net.FlagUp},
+		{Name: "en0", Flags: net.FlagUp},
+		{Name: "wlan0"}, // down
+		{Name: "tun0", Flags: net.FlagUp},
+	}
+	addrs := map[string][]net.Addr{
+		"lo":      {&net.IPNet{IP: net.ParseIP("127.0.0.1")}},
+		"docker0": {&net.IPNet{IP: net.ParseIP("172.17.0.1")}},
+		"en0":     {&net.IPNet{IP: net.ParseIP("fe80::1")}, &net.IPNet{IP: net.ParseIP("192.168.1.20")}},
+		"wlan0":   {&net.IPNet{IP: net.ParseIP("192.168.1.30")}},
+	}
+	got := interfaceAddresses(ifaces, func(iface net.Interface) ([]net.Addr, error) {
+		if iface.Name == "tun0" {
+			return nil, errors.New("no addresses")
+		}
+		return addrs[iface.Name], nil
+	})
+
+	want := []netAddress{{"docker0", "172.17.0.1"}, {"en0", "192.168.1.20"}}
+	if len(got) != len(want) {
+		t.Fatalf("interfaceAddresses() = %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("address %d = %v; want %v", i, got[i], want[i])
+		}
+	}
+	if label := got[1].label(); label != "192.168.1.20 (en0)" {
+		t.Errorf("label() = %q", label)
+	}
+}
+
+func TestChooseAddress(t *testing.T) {
+	addrs := []netAddress{{"docker0", "172.17.0.1"}, {"en0", "192.168.1.20"}}
+
+	if got := chooseAddress(addrs, "192.168.1.20"); got != "192.168.1.20" {
+		t.Errorf("chooseAddress() = %q; want the chosen address", got)
+	}
+	// A chosen address that's gone falls back to the automatic pick
+	if got := chooseAddress(addrs, "10.8.0.2"); got != getLocalIP() {
+		t.Errorf("chooseAddress() with a missing address = %q; want %q", got, getLocalIP())
+	}
+	if got := chooseAddress(addrs, ""); got != getLocalIP() {
+		t.Errorf("chooseAddress() automatic = %q; want %q", got, getLocalIP())
+	}
+}
+
+func TestNetworkSection(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.serverAddress = "192.0.2.1" // not on this machine
+
+	picker := p.networkSection().(*fyne.Container).Objects[1].(*fyne.Container).Objects[0].(*widget.Select)
+	if picker.Selected != "192.0.2.1 (not connected)" {
+		t.Errorf("picker shows %q; want the saved address marked not connected", picker.Selected)
+	}
+	if p.dirty {
+		t.Error("opening the picker marked state dirty")
+	}
+
+	picker.SetSelected(autoAddressLabel)
+	if p.serverAddress != "" || !p.dirty {
+		t.Errorf("after choosing Automatic serverAddress = %q, dirty %v", p.serverAddress, p.dirty)
+	}
+}
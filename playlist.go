@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+var playlistExtensions = []string{".m3u", ".m3u8"}
+
+func isPlaylistFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, supported := range playlistExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// playlistSkip is a playlist entry that wasn't added, and why
+type playlistSkip struct {
+	Entry  string
+	Reason string
+}
+
+// playlistEntries returns the file entries of an M3U/M3U8 playlist in
+// order, leaving out blank lines, comments and #EXT directives
+func playlistEntries(data []byte) []string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := string(data)
+	// Plain .m3u files from older players are Latin-1 rather than UTF-8
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	}
+
+	var entries []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+// resolvePlaylistEntry turns an entry into a local path, relative entries
+// being relative to the playlist's folder dir. Web addresses aren't local
+// and report false.
+func resolvePlaylistEntry(entry, dir string) (string, bool) {
+	if u, err := url.Parse(entry); err == nil && len(u.Scheme) > 1 {
+		if u.Scheme != "file" {
+			return "", false
+		}
+		path := u.Path
+		// file:///C:/Music/a.mp3 has the path /C:/Music/a.mp3
+		if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		return filepath.Clean(filepath.FromSlash(path)), true
+	}
+
+	path := filepath.FromSlash(entry)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path), true
+}
+
+// readPlaylist returns the audio files a playlist lists, in its order, and
+// the entries that were skipped because they're missing or can't be added
+func readPlaylist(path string) ([]string, []playlistSkip, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var paths []string
+	var skipped []playlistSkip
+	for _, entry := range playlistEntries(data) {
+		resolved, ok := resolvePlaylistEntry(entry, filepath.Dir(path))
+		if !ok {
+			skipped = append(skipped, playlistSkip{entry, "not a local file"})
+			continue
+		}
+		info, err := os.Stat(resolved)
+		switch {
+		case err != nil:
+			skipped = append(skipped, playlistSkip{entry, "not found"})
+		case info.IsDir() || !isSupportedFile(resolved):
+			skipped = append(skipped, playlistSkip{entry, "not a supported audio file"})
+		default:
+			paths = append(paths, resolved)
+		}
+	}
+	return paths, skipped, nil
+}
+
+// playlistSummary says how many of a playlist's files are being added and
+// lists the first few that were skipped
+func playlistSummary(added int, skipped []playlistSkip) string {
+	summary := fmt.Sprintf("Adding %d files from the playlist.", added)
+	if len(skipped) == 0 {
+		return summary
+	}
+
+	lines := make([]string, 0, 6)
+	for i, s := range skipped {
+		if i == 5 {
+			lines = append(lines, fmt.Sprintf("… and %d more", len(skipped)-5))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s)", filepath.Base(s.Entry), s.Reason))
+	}
+	return summary + fmt.Sprintf("\n\nSkipped %d:\n", len(skipped)) + strings.Join(lines, "\n")
+}
+
+// importPlaylist adds the audio files a playlist lists, in playlist order.
+// Entries that can't be added are reported.
+func (p *Podcasterator) importPlaylist(path string) {
+	if !p.requireTempDir() {
+		return
+	}
+	paths, skipped, err := readPlaylist(path)
+	if err != nil {
+		fmt.Println("Error reading playlist:", err)
+		if p.window != nil {
+			dialog.ShowError(fmt.Errorf("couldn't read the playlist: %w", err), p.window)
+		}
+		return
+	}
+
+	if len(skipped) > 0 && p.window != nil {
+		dialog.ShowInformation("Import Playlist", playlistSummary(len(paths), skipped), p.window)
+	}
+	p.addFilesInBackground(paths)
+}
+
+// openPlaylistDialog asks for a playlist to import
+func (p *Podcasterator) openPlaylistDialog() {
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+		p.importPlaylist(reader.URI().Path())
+	}, p.window)
+	d.SetFilter(storage.NewExtensionFileFilter(playlistExtensions))
+	d.Show()
+}
+
+// playlistEntry is where a playlist should point for file: its original,
+// or for downloaded and hosted episodes their web address. Files with none
+// of these return "".
+func playlistEntry(file AudioFile) string {
+	switch {
+	case file.IsExternal():
+		return file.ExternalURL
+	case file.OriginalPath != "":
+		return file.OriginalPath
+	default:
+		return file.SourceURL
+	}
+}
+
+// writePlaylist writes files as an extended M3U playlist in list order: an
+// #EXTINF line with the length in seconds (-1 if unknown) and name, then
+// the entry. It returns how many files were written.
+func writePlaylist(w io.Writer, files []AudioFile) (int, error) {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	written := 0
+	for _, file := range files {
+		entry := playlistEntry(file)
+		if entry == "" {
+			continue
+		}
+		seconds := -1
+		if file.Duration > 0 {
+			seconds = int(file.Duration.Round(time.Second).Seconds())
+		}
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n%s\n", seconds, file.DisplayName, entry)
+		written++
+	}
+	return written, bw.Flush()
+}
+
+// openExportPlaylistDialog asks where to save the list as a playlist
+func (p *Podcasterator) openExportPlaylistDialog() {
+	if len(p.files) == 0 {
+		return
+	}
+
+	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		written, err := writePlaylist(writer, p.files)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("couldn't save the playlist: %w", err), p.window)
+			return
+		}
+		dialog.ShowInformation("Export Playlist",
+			fmt.Sprintf("Saved %d files to\n%s", written, writer.URI().Path()), p.window)
+	}, p.window)
+
+	name := tagFileName(p.podcastName, ".m3u")
+	if name == "" {
+		name = "playlist.m3u"
+	}
+	d.SetFileName(name)
+	d.SetFilter(storage.NewExtensionFileFilter(playlistExtensions))
+	d.Show()
+}
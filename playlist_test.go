@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Playlist Tests
+// =============================================================================
+
+func TestPlaylistEntries(t *testing.T) {
+	data := "\xef\xbb\xbf#EXTM3U\r\n#EXTINF:61,Intro\r\n01 Intro.mp3\r\n\r\n# a comment\n  Disc 2/02 Next.m4a  \n"
+	got := strings.Join(playlistEntries([]byte(data)), "|")
+	if got != "01 Intro.mp3|Disc 2/02 Next.m4a" {
+		t.Errorf("playlistEntries() = %q", got)
+	}
+
+	// Latin-1, as older players write .m3u
+	if got := playlistEntries([]byte("Caf\xe9.mp3\n")); len(got) != 1 || got[0] != "Café.mp3" {
+		t.Errorf("playlistEntries() of Latin-1 = %q; want Café.mp3", got)
+	}
+}
+
+func TestResolvePlaylistEntry(t *testing.T) {
+	dir := filepath.FromSlash("/music/lists")
+	tests := []struct {
+		entry string
+		want  string
+		ok    bool
+	}{
+		{"01 Intro.mp3", "/music/lists/01 Intro.mp3", true},
+		{"../Book/02.mp3", "/music/Book/02.mp3", true},
+		{"/home/me/03.mp3", "/home/me/03.mp3", true},
+		{"file:///home/me/My%20Book/04.mp3", "/home/me/My Book/04.mp3", true},
+		{"https://example.com/05.mp3", "", false},
+	}
+	for _, tc := range tests {
+		got, ok := resolvePlaylistEntry(tc.entry, dir)
+		if want := filepath.FromSlash(tc.want); got != want || ok != tc.ok {
+			t.Errorf("resolvePlaylistEntry(%q) = %q, %v; want %q, %v", tc.entry, got, ok, want, tc.ok)
+		}
+	}
+}
+
+// writePlaylistFixture makes audio files and a playlist listing them out of
+// name order, plus entries that can't be added
+func writePlaylistFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "Disc 2"), 0755)
+	for _, name := range []string{"a.mp3", "b.mp3", filepath.Join("Disc 2", "c.m4a"), "notes.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+	}
+	playlist := filepath.Join(dir, "book.m3u8")
+	os.WriteFile(playlist, []byte(strings.Join([]string{
+		"#EXTM3U",
+		"Disc 2/c.m4a",
+		"missing.mp3",
+		"b.mp3",
+		"notes.txt",
+		"https://example.com/d.mp3",
+		filepath.Join(dir, "a.mp3"),
+	}, "\n")), 0644)
+	return playlist
+}
+
+func TestReadPlaylist(t *testing.T) {
+	playlist := writePlaylistFixture(t)
+	dir := filepath.Dir(playlist)
+
+	paths, skipped, err := readPlaylist(playlist)
+	if err != nil {
+		t.Fatalf("readPlaylist() error = %v", err)
+	}
+	want := []string{filepath.Join(dir, "Disc 2", "c.m4a"), filepath.Join(dir, "b.mp3"), filepath.Join(dir, "a.mp3")}
+	if strings.Join(paths, "|") != strings.Join(want, "|") {
+		t.Errorf("readPlaylist() paths = %q; want %q", paths, want)
+	}
+	var reasons []string
+	for _, s := range skipped {
+		reasons = append(reasons, s.Entry+": "+s.Reason)
+	}
+	if got := strings.Join(reasons, "|"); got != "missing.mp3: not found|notes.txt: not a supported audio file|https://example.com/d.mp3: not a local file" {
+		t.Errorf("readPlaylist() skipped = %s", got)
+	}
+
+	if _, _, err := readPlaylist(filepath.Join(dir, "gone.m3u")); err == nil {
+		t.Error("readPlaylist() of a missing playlist succeeded")
+	}
+}
+
+func TestPlaylistSummary(t *testing.T) {
+	if got := playlistSummary(3, nil); got != "Adding 3 files from the playlist." {
+		t.Errorf("playlistSummary() = %q", got)
+	}
+
+	var skipped []playlistSkip
+	for i := 0; i < 7; i++ {
+		skipped = append(skipped, playlistSkip{filepath.FromSlash("lost/ep.mp3"), "not found"})
+	}
+	got := playlistSummary(1, skipped)
+	if !strings.Contains(got, "Skipped 7:\nep.mp3 (not found)") || !strings.HasSuffix(got, "… and 2 more") {
+		t.Errorf("playlistSummary() = %q", got)
+	}
+}
+
+func TestPlaylistSetsOrder(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	paths, _, err := readPlaylist(writePlaylistFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.addFiles(paths); err != nil {
+		t.Fatalf("addFiles() error = %v", err)
+	}
+	if got := strings.Join(displayNames(p.files), ","); got != "c.m4a,b.mp3,a.mp3" {
+		t.Errorf("files = %s; want playlist order c.m4a,b.mp3,a.mp3", got)
+	}
+}
+
+func TestWritePlaylist(t *testing.T) {
+	files := []AudioFile{
+		{DisplayName: "Intro.mp3", OriginalPath: "/music/01 Intro.mp3", Duration: 61400 * time.Millisecond},
+		{DisplayName: "Hosted", ExternalURL: "https://example.com/2.mp3"},
+		{DisplayName: "Fetched.mp3", SourceURL: "https://example.com/3.mp3", Duration: time.Hour},
+		{DisplayName: "Nowhere.mp3"},
+	}
+	var buf strings.Builder
+	written, err := writePlaylist(&buf, files)
+	if err != nil {
+		t.Fatalf("writePlaylist() error = %v", err)
+	}
+	want := "#EXTM3U\n" +
+		"#EXTINF:61,Intro.mp3\n/music/01 Intro.mp3\n" +
+		"#EXTINF:-1,Hosted\nhttps://example.com/2.mp3\n" +
+		"#EXTINF:3600,Fetched.mp3\nhttps://example.com/3.mp3\n"
+	if buf.String() != want || written != 3 {
+		t.Errorf("writePlaylist() = %d files\n%s\nwant 3 files\n%s", written, buf.String(), want)
+	}
+}
+
+func TestPlaylistRoundTrip(t *testing.T) {
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.moveToTop(2)
+
+	path := filepath.Join(t.TempDir(), "list.m3u")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writePlaylist(f, p.files); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	paths, skipped, err := readPlaylist(path)
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("readPlaylist() error = %v, skipped %v", err, skipped)
+	}
+	var want []string
+	for _, file := range p.files {
+		want = append(want, file.OriginalPath)
+	}
+	if strings.Join(paths, "|") != strings.Join(want, "|") {
+		t.Errorf("read back %q; want the originals in list order %q", paths, want)
+	}
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/google/uuid"
+)
+
+// Project is one podcast: its files, artwork and feed details. Settings
+// that aren't about a particular podcast stay in AppState.
+type Project struct {
+	ID          string      `json:"project_id,omitempty"`
+	Files       []AudioFile `json:"files"`
+	PodcastName string      `json:"podcast_name"`
+	ArtworkPath string      `json:"artwork_path"`
+	PublicURL   string      `json:"public_url,omitempty"`
+
+	SeasonSorts map[int]string `json:"season_sorts,omitempty"`
+
+	NumberEnclosures bool `json:"number_enclosures,omitempty"`
+	ASCIIFileNames   bool `json:"ascii_file_names,omitempty"`
+
+	// WatchFolder is a folder whose new audio files are added as they
+	// appear. With WatchRemovals, files deleted from it leave the list too.
+	// WatchRemoved holds the files in it removed from the list, which
+	// aren't added back when the folder is caught up on.
+	WatchFolder   string   `json:"watch_folder,omitempty"`
+	WatchRemovals bool     `json:"watch_removals,omitempty"`
+	WatchRemoved  []string `json:"watch_removed,omitempty"`
+
+	ChannelElements []channelElement `json:"channel_elements,omitempty"`
+
+	ChannelGUID string `json:"channel_guid,omitempty"`
+	FeedLocked  bool   `json:"feed_locked,omitempty"`
+	OwnerEmail  string `json:"owner_email,omitempty"`
+	OwnerName   string `json:"owner_name,omitempty"`
+
+	// FeedEpoch is the publish time of the first file, fixed the first time
+	// the feed is served or exported (see ensureFeedEpoch)
+	FeedEpoch time.Time `json:"feed_epoch,omitzero"`
+
+	EpisodeDefaults episodeDefaults `json:"episode_defaults,omitzero"`
+
+	// Show-level iTunes details
+	Author   string `json:"author,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	Explicit bool   `json:"explicit,omitempty"`
+	Category string `json:"category,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+// currentProject snapshots the active project
+func (p *Podcasterator) currentProject() Project {
+	return Project{
+		ID:          p.projectID,
+		Files:       p.files,
+		PodcastName: p.podcastName,
+		ArtworkPath: p.artworkPath,
+		PublicURL:   p.publicURL,
+
+		SeasonSorts: p.seasonSorts,
+
+		NumberEnclosures: p.numberEnclosures,
+		ASCIIFileNames:   p.asciiFileNames,
+
+		WatchFolder:   p.watchFolder,
+		WatchRemovals: p.watchRemovals,
+		WatchRemoved:  p.watchRemoved,
+
+		ChannelElements: p.channelElements,
+
+		ChannelGUID: p.channelGUID,
+		FeedEpoch:   p.feedEpoch,
+		FeedLocked:  p.feedLocked,
+		OwnerEmail:  p.ownerEmail,
+		OwnerName:   p.ownerName,
+
+		EpisodeDefaults: p.episodeDefaults,
+
+		Author:   p.podcastAuthor,
+		Summary:  p.podcastSummary,
+		Explicit: p.podcastExplicit,
+		Category: p.podcastCategory,
+		Language: p.podcastLanguage,
+	}
+}
+
+// applyProject makes pr the active project. Files whose temp copies are gone
+// are dropped, unless the whole temp dir is unavailable.
+func (p *Podcasterator) applyProject(pr Project) {
+	validFiles := []AudioFile{}
+	loadedAt := time.Now().UTC().Truncate(time.Second)
+	for _, file := range pr.Files {
+		// Files saved before AddedAt existed are dated from now on
+		if file.AddedAt.IsZero() {
+			file.AddedAt = loadedAt
+			p.markDirty()
+		}
+
+		if p.tempDirUnavailable {
+			validFiles = append(validFiles, file)
+			continue
+		}
+		if file.ArtworkPath != "" && !fileExists(file.ArtworkPath) {
+			file.ArtworkPath = ""
+		}
+		if file.ChaptersPath != "" && !fileExists(file.ChaptersPath) {
+			file.ChaptersPath = ""
+		}
+		if file.TranscriptPath != "" && !fileExists(file.TranscriptPath) {
+			file.TranscriptPath = ""
+		}
+		if file.IsExternal() {
+			validFiles = append(validFiles, file)
+			continue
+		}
+		if file.OriginalPath != "" {
+			file.OriginalMissing = !fileExists(file.OriginalPath)
+		}
+		// A file served from an original that's gone is served from the
+		// copy kept for switching back, and flagged, rather than dropped
+		if file.ServeOriginal && file.OriginalMissing {
+			fmt.Printf("Serving the copy of %s: its original was moved or deleted\n", file.DisplayName)
+			file.ServeOriginal = false
+			p.markDirty()
+		}
+		// A lost cut falls back to serving the whole file
+		if file.TrimmedPath != "" && !fileExists(file.TrimmedPath) {
+			file.StartOffset = 0
+			file.TrimmedPath = ""
+			file.Duration = 0
+		}
+		// The state file may be older than what's on disk
+		if err := file.refreshStat(); err == nil {
+			validFiles = append(validFiles, file)
+		}
+	}
+
+	p.projectID = pr.ID
+	p.files = validFiles
+	p.podcastName = pr.PodcastName
+	if p.podcastName == "" {
+		p.podcastName = defaultPodcastName
+	}
+	p.artworkPath = ""
+	if pr.ArtworkPath != "" && fileExists(pr.ArtworkPath) {
+		p.artworkPath = pr.ArtworkPath
+	}
+	p.publicURL = pr.PublicURL
+	p.seasonSorts = pr.SeasonSorts
+	p.numberEnclosures = pr.NumberEnclosures
+	p.asciiFileNames = pr.ASCIIFileNames
+	p.watchFolder = pr.WatchFolder
+	p.watchRemovals = pr.WatchRemovals
+	p.watchRemoved = pr.WatchRemoved
+	p.channelElements = pr.ChannelElements
+	p.channelGUID = pr.ChannelGUID
+	p.feedEpoch = pr.FeedEpoch
+	p.feedLocked = pr.FeedLocked
+	p.ownerEmail = pr.OwnerEmail
+	p.ownerName = pr.OwnerName
+	p.episodeDefaults = pr.EpisodeDefaults
+	p.podcastAuthor = pr.Author
+	p.podcastSummary = pr.Summary
+	p.podcastExplicit = pr.Explicit
+	p.podcastCategory = pr.Category
+	p.podcastLanguage = pr.Language
+	p.extractMissingDurations()
+}
+
+// ensureProjectID gives the active project an ID if it has none yet, as
+// on first run or with state saved before there were projects
+func (p *Podcasterator) ensureProjectID() {
+	if p.projectID == "" {
+		p.projectID = uuid.New().String()
+		p.markDirty()
+	}
+}
+
+// projectTempDir is where the active project's files and artwork are
+// copied, so projects never share temp files. Without a project ID it's the
+// temp dir itself.
+func (p *Podcasterator) projectTempDir() string {
+	if p.projectID == "" {
+		return p.tempDir
+	}
+	return filepath.Join(p.tempDir, "projects", p.projectID)
+}
+
+// projectEntry is a project as listed in the project picker
+type projectEntry struct {
+	ID    string
+	Label string
+}
+
+// projectEntries lists every project by name, with a number added to
+// repeated names so each label picks exactly one project
+func (p *Podcasterator) projectEntries() []projectEntry {
+	all := append([]Project{p.currentProject()}, p.projects...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return strings.ToLower(all[i].PodcastName) < strings.ToLower(all[j].PodcastName)
+	})
+
+	entries := make([]projectEntry, len(all))
+	seen := map[string]int{}
+	for i, pr := range all {
+		label := strings.TrimSpace(pr.PodcastName)
+		if label == "" {
+			label = defaultPodcastName
+		}
+		seen[label]++
+		if n := seen[label]; n > 1 {
+			label = fmt.Sprintf("%s (%d)", label, n)
+		}
+		entries[i] = projectEntry{ID: pr.ID, Label: label}
+	}
+	return entries
+}
+
+// switchProject saves the active project and makes the project with id
+// active. It can't be used while the server is running, since the feed was
+// built from the active project.
+func (p *Podcasterator) switchProject(id string) error {
+	if id == p.projectID {
+		return nil
+	}
+	if p.serverRunning {
+		return fmt.Errorf("stop the server before switching projects")
+	}
+
+	index := -1
+	for i, pr := range p.projects {
+		if pr.ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("project not found")
+	}
+
+	next := p.projects[index]
+	p.projects[index] = p.currentProject()
+	p.purgeTrash() // undo positions refer to the project being left
+	p.applyProject(next)
+	p.refreshProjectViews()
+	p.startWatching()
+	p.saveState()
+	return nil
+}
+
+// newProject saves the active project and starts an empty one named name
+func (p *Podcasterator) newProject(name string) error {
+	if p.serverRunning {
+		return fmt.Errorf("stop the server before switching projects")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("enter a name for the project")
+	}
+
+	p.projects = append(p.projects, p.currentProject())
+	p.purgeTrash() // undo positions refer to the project being left
+	p.applyProject(Project{ID: uuid.New().String(), PodcastName: name})
+	p.refreshProjectViews()
+	p.startWatching()
+	p.saveState()
+	return nil
+}
+
+// deleteProject removes the active project and its temp files, then
+// switches to the first remaining project. The last project can't be deleted.
+func (p *Podcasterator) deleteProject() error {
+	if p.serverRunning {
+		return fmt.Errorf("stop the server before deleting a project")
+	}
+	if len(p.projects) == 0 {
+		return fmt.Errorf("the only project can't be deleted; use Clear All to empty it")
+	}
+
+	for _, file := range p.files {
+		if !file.IsExternal() && file.TempPath != "" {
+			os.Remove(file.TempPath)
+		}
+	}
+	if p.projectID != "" {
+		os.RemoveAll(p.projectTempDir())
+	}
+
+	next := p.projects[0]
+	p.projects = p.projects[1:]
+	p.purgeTrash() // undo positions refer to the project being left
+	p.applyProject(next)
+	p.refreshProjectViews()
+	p.startWatching()
+	p.saveState()
+	return nil
+}
+
+// refreshProjectViews redraws everything that shows the active project
+func (p *Podcasterator) refreshProjectViews() {
+	p.refreshFileViews()
+	if p.podcastEntry != nil {
+		p.podcastEntry.SetText(p.podcastName)
+	}
+	if p.publicURLEntry != nil {
+		p.publicURLEntry.SetText(p.publicURL)
+	}
+	if p.artworkImage != nil {
+		p.artworkImage.File = p.artworkPath
+		p.artworkImage.Refresh()
+	}
+	if p.artworkBtn != nil {
+		if p.artworkPath != "" {
+			p.artworkBtn.SetText("Delete artwork")
+		} else {
+			p.artworkBtn.SetText("No artwork set")
+		}
+	}
+	p.updateArtworkInfo()
+	p.refreshProjectSelect()
+}
+
+// refreshProjectSelect lists the projects in the picker with the active one
+// selected
+func (p *Podcasterator) refreshProjectSelect() {
+	if p.projectSelect == nil {
+		return
+	}
+	entries := p.projectEntries()
+	options := make([]string, len(entries))
+	selected := ""
+	for i, e := range entries {
+		options[i] = e.Label
+		if e.ID == p.projectID {
+			selected = e.Label
+		}
+	}
+	p.projectSelect.SetOptions(options)
+	p.projectSelect.SetSelected(selected)
+	if p.projectDelBtn != nil && !p.serverRunning {
+		if len(p.projects) == 0 {
+			p.projectDelBtn.Disable()
+		} else {
+			p.projectDelBtn.Enable()
+		}
+	}
+}
+
+// createProjectRow builds the project picker with its New and Delete buttons
+func (p *Podcasterator) createProjectRow() fyne.CanvasObject {
+	p.projectSelect = widget.NewSelect(nil, func(label string) {
+		for _, e := range p.projectEntries() {
+			if e.Label != label || e.ID == p.projectID {
+				continue
+			}
+			if err := p.switchProject(e.ID); err != nil && p.window != nil {
+				dialog.ShowError(err, p.window)
+				p.refreshProjectSelect()
+			}
+			return
+		}
+	})
+
+	p.projectNewBtn = widget.NewButton("New…", func() {
+		p.openNewProjectDialog()
+	})
+	p.projectDelBtn = widget.NewButton("Delete", func() {
+		p.confirmDeleteProject()
+	})
+	p.refreshProjectSelect()
+
+	return container.NewBorder(nil, nil,
+		widget.NewLabel("Project:"), container.NewHBox(p.projectNewBtn, p.projectDelBtn),
+		p.projectSelect,
+	)
+}
+
+// openNewProjectDialog asks for the new project's podcast name
+func (p *Podcasterator) openNewProjectDialog() {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Podcast name")
+	dialog.ShowForm("New Project", "Create", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Name", entry)},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := p.newProject(entry.Text); err != nil {
+				dialog.ShowError(err, p.window)
+			}
+		}, p.window)
+}
+
+// confirmDeleteProject asks before deleting the active project
+func (p *Podcasterator) confirmDeleteProject() {
+	message := fmt.Sprintf("Delete the project %q and its %d files?\nYour original files are not touched.", p.podcastName, len(p.files))
+	dialog.ShowConfirm("Delete Project", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := p.deleteProject(); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	}, p.window)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Project Tests
+// =============================================================================
+
+func TestLoadStateSingleProjectFormat(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	// State saved before there were projects
+	legacy := `{"files":[{"id":"1","display_name":"Hosted","external_url":"https://cdn.example.com/ep.mp3"}],"podcast_name":"Old Show","port":9000}`
+	os.WriteFile(filepath.Join(p.configDir, "state.json"), []byte(legacy), 0644)
+
+	p.loadState()
+	p.ensureProjectID()
+
+	if p.podcastName != "Old Show" || len(p.files) != 1 || p.port != 9000 {
+		t.Errorf("loaded %q with %d files on port %d; want Old Show with 1 file on 9000", p.podcastName, len(p.files), p.port)
+	}
+	if p.projectID == "" || len(p.projects) != 0 {
+		t.Errorf("projectID = %q with %d other projects; want an ID and none", p.projectID, len(p.projects))
+	}
+}
+
+func TestNewAndSwitchProject(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.ensureProjectID()
+	firstID := p.projectID
+
+	src := t.TempDir()
+	lecture := filepath.Join(src, "lecture.mp3")
+	os.WriteFile(lecture, []byte("lecture"), 0644)
+	song := filepath.Join(src, "song.mp3")
+	os.WriteFile(song, []byte("song"), 0644)
+
+	p.addFile(lecture)
+	p.podcastAuthor = "Prof"
+
+	if err := p.newProject("  Mix  "); err != nil {
+		t.Fatalf("newProject() error = %v", err)
+	}
+	if p.podcastName != "Mix" || len(p.files) != 0 || p.podcastAuthor != "" {
+		t.Fatalf("new project = %q with %d files, author %q; want an empty Mix", p.podcastName, len(p.files), p.podcastAuthor)
+	}
+
+	// Temp copies are kept apart per project
+	p.addFile(song)
+	if want := filepath.Join(p.tempDir, "projects", p.projectID); !strings.HasPrefix(p.files[0].TempPath, want) {
+		t.Errorf("TempPath = %s; want it under %s", p.files[0].TempPath, want)
+	}
+
+	if err := p.switchProject(firstID); err != nil {
+		t.Fatalf("switchProject() error = %v", err)
+	}
+	if p.podcastName != "Test Podcast" || len(p.files) != 1 || p.files[0].OriginalPath != lecture || p.podcastAuthor != "Prof" {
+		t.Errorf("switched back to %q with %+v", p.podcastName, p.files)
+	}
+
+	// Switching saved both projects
+	data, _ := os.ReadFile(filepath.Join(p.configDir, "state.json"))
+	var state AppState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("state.json is not valid JSON: %v", err)
+	}
+	if state.ID != firstID || len(state.Projects) != 1 || state.Projects[0].PodcastName != "Mix" || len(state.Projects[0].Files) != 1 {
+		t.Errorf("saved state = active %s with projects %+v", state.ID, state.Projects)
+	}
+
+	p2 := &Podcasterator{tempDir: p.tempDir, configDir: p.configDir}
+	p2.loadState()
+	if err := p2.switchProject(state.Projects[0].ID); err != nil {
+		t.Fatalf("switchProject() after load error = %v", err)
+	}
+	if p2.podcastName != "Mix" || len(p2.files) != 1 || p2.files[0].OriginalPath != song {
+		t.Errorf("reloaded Mix = %q with %+v", p2.podcastName, p2.files)
+	}
+}
+
+func TestSwitchProjectErrors(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.ensureProjectID()
+	firstID := p.projectID
+
+	if err := p.newProject(" "); err == nil {
+		t.Error("newProject() with a blank name succeeded")
+	}
+	if err := p.switchProject("missing"); err == nil {
+		t.Error("switchProject() to an unknown project succeeded")
+	}
+
+	p.newProject("Second")
+	p.serverRunning = true
+	if err := p.switchProject(firstID); err == nil || p.podcastName != "Second" {
+		t.Errorf("switchProject() while running = %v, now on %q; want an error and no switch", err, p.podcastName)
+	}
+}
+
+func TestProjectEntries(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.projectID = "a"
+	p.podcastName = "Show"
+	p.projects = []Project{
+		{ID: "b", PodcastName: "audiobook"},
+		{ID: "c", PodcastName: "Show"},
+		{ID: "d"},
+	}
+
+	var got []string
+	for _, e := range p.projectEntries() {
+		got = append(got, e.ID+"="+e.Label)
+	}
+	if want := "d=My Podcast,b=audiobook,a=Show,c=Show (2)"; strings.Join(got, ",") != want {
+		t.Errorf("projectEntries() = %s; want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestDeleteProject(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.ensureProjectID()
+	firstID := p.projectID
+
+	if err := p.deleteProject(); err == nil {
+		t.Fatal("deleteProject() deleted the only project")
+	}
+
+	p.newProject("Doomed")
+	src := filepath.Join(t.TempDir(), "ep.mp3")
+	os.WriteFile(src, []byte("ep"), 0644)
+	p.addFile(src)
+	doomedDir := p.projectTempDir()
+
+	if err := p.deleteProject(); err != nil {
+		t.Fatalf("deleteProject() error = %v", err)
+	}
+	if p.projectID != firstID || len(p.projects) != 0 {
+		t.Errorf("after delete on %s with %d other projects; want %s alone", p.projectID, len(p.projects), firstID)
+	}
+	if dirExists(doomedDir) {
+		t.Error("deleted project's temp files are still there")
+	}
+	if !fileExists(src) {
+		t.Error("deleteProject() removed the original file")
+	}
+}
+
+func TestApplyProjectFallsBackToCopy(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	original := filepath.Join(t.TempDir(), "episode.mp3")
+	copyPath := filepath.Join(p.tempDir, "abc", "episode.mp3")
+	os.MkdirAll(filepath.Dir(copyPath), 0755)
+	os.WriteFile(copyPath, []byte("copy"), 0644)
+	pr := p.currentProject()
+	pr.Files = []AudioFile{{ID: "abc", DisplayName: "episode.mp3", OriginalPath: original, TempPath: copyPath, ServeOriginal: true}}
+
+	// The original was moved away since it was last served
+	p.applyProject(pr)
+	if len(p.files) != 1 {
+		t.Fatalf("applyProject() kept %d files; want the one served from a missing original", len(p.files))
+	}
+	f := p.files[0]
+	if f.ServeOriginal || !f.OriginalMissing || f.ServedPath() != copyPath || f.Size != int64(len("copy")) {
+		t.Errorf("file = %+v; want it served from its copy and flagged", f)
+	}
+	if !p.dirty {
+		t.Error("switching to the copy wasn't saved")
+	}
+}
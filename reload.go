@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// hasOriginal reports whether file was copied from a source on disk that was
+// there when last checked
+func hasOriginal(file AudioFile) bool {
+	return !file.IsExternal() && file.OriginalPath != "" && !file.OriginalMissing
+}
+
+// checkOriginal re-checks that the original of the file at index is still
+// there, updating its flag and row when that changed. It reports whether the
+// original exists.
+func (p *Podcasterator) checkOriginal(index int) bool {
+	if index < 0 || index >= len(p.files) {
+		return false
+	}
+	file := &p.files[index]
+	if file.IsExternal() || file.OriginalPath == "" {
+		return false
+	}
+	missing := !fileExists(file.OriginalPath)
+	if missing != file.OriginalMissing {
+		file.OriginalMissing = missing
+		if p.fileList != nil {
+			p.fileList.RefreshItem(index)
+		}
+		p.markDirty()
+	}
+	return !missing
+}
+
+// reloadSource is the original of the file at index to copy over its temp
+// copy again, for when the source audio was edited after it was added
+func (p *Podcasterator) reloadSource(index int) (string, error) {
+	if index < 0 || index >= len(p.files) {
+		return "", fmt.Errorf("file not found")
+	}
+	file := p.files[index]
+	if file.IsExternal() || file.OriginalPath == "" {
+		return "", fmt.Errorf("%s wasn't added from a file on this computer", file.DisplayName)
+	}
+	if !p.checkOriginal(index) {
+		return "", fmt.Errorf("the original %s was moved or deleted", filepath.Base(file.OriginalPath))
+	}
+	return file.OriginalPath, nil
+}
+
+// reloadFile is the row action for reloading from source, copying in the
+// background like Replace Audio
+func (p *Podcasterator) reloadFile(index int) {
+	path, err := p.reloadSource(index)
+	if err != nil {
+		if p.window != nil {
+			dialog.ShowError(err, p.window)
+		}
+		return
+	}
+	p.replaceAudioInBackground(index, path)
+}
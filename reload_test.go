@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// =============================================================================
+// Reload From Source Tests
+// =============================================================================
+
+func TestReloadFile(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.applyRename(&p.files[0], "Intro.mp3")
+
+	// The copy lands on p.files from another goroutine, so it's watched for
+	// through the published snapshot, as the server would see it
+	p.live = &FeedServer{tempDir: p.tempDir}
+	p.publishTo(p.live)
+	id := p.files[0].ID
+	reloaded := func() AudioFile {
+		file, _ := p.live.file(id)
+		return file
+	}
+
+	// The source was edited after it was added
+	os.WriteFile(p.files[0].OriginalPath, []byte("re-recorded audio"), 0644)
+	p.dirty = false
+	p.reloadFile(0)
+	deadline := time.Now().Add(5 * time.Second)
+	for reloaded().Size != int64(len("re-recorded audio")) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	file := reloaded()
+	if data, _ := os.ReadFile(file.TempPath); string(data) != "re-recorded audio" {
+		t.Errorf("copy holds %q; want the edited source", data)
+	}
+	if file.DisplayName != "Intro.mp3" || file.Size != int64(len("re-recorded audio")) {
+		t.Errorf("after reload = %+v; want the name kept and the size updated", file)
+	}
+	if !p.dirty {
+		t.Error("reloadFile() didn't mark the state dirty")
+	}
+
+	if _, err := p.reloadSource(5); err == nil {
+		t.Error("reloadSource() out of range succeeded")
+	}
+	p.files = append(p.files, AudioFile{ID: "x", DisplayName: "x.mp3", ExternalURL: "https://example.com/x.mp3"})
+	if _, err := p.reloadSource(len(p.files) - 1); err == nil {
+		t.Error("reloadSource() of an external file succeeded")
+	}
+}
+
+func TestReloadMissingOriginal(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.fileList = p.newFileList()
+
+	copied, _ := os.ReadFile(p.files[1].TempPath)
+	os.Remove(p.files[1].OriginalPath)
+	p.reloadFile(1)
+	if !p.files[1].OriginalMissing {
+		t.Error("the failed reload didn't flag the original missing")
+	}
+	if data, _ := os.ReadFile(p.files[1].TempPath); string(data) != string(copied) {
+		t.Error("the failed reload touched the copy")
+	}
+
+	item := p.fileList.CreateItem()
+	p.fileList.UpdateItem(1, item)
+	row := item.(*fileRow).content
+	label := row.Objects[9].(*fyne.Container).Objects[0].(*widget.Label)
+	if label.Importance != widget.LowImportance {
+		t.Error("row of a file with a missing original isn't greyed out")
+	}
+	if !row.Objects[6].(*tipButton).Disabled() || !row.Objects[7].(*tipButton).Disabled() {
+		t.Error("reload and reveal are enabled with the original gone")
+	}
+	for _, item := range p.fileRowMenu(1).Items {
+		if item.Label == "Reload from Source" && !item.Disabled {
+			t.Error("compact menu's reload is enabled with the original gone")
+		}
+	}
+
+	p.fileList.UpdateItem(0, item)
+	if label.Importance != widget.MediumImportance || row.Objects[6].(*tipButton).Disabled() {
+		t.Error("a reused row stays greyed for a file whose original exists")
+	}
+}
+
+func TestApplyProjectFlagsMissingOriginals(t *testing.T) {
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	os.Remove(p.files[2].OriginalPath)
+
+	p.applyProject(p.currentProject())
+	if p.files[0].OriginalMissing || !p.files[2].OriginalMissing {
+		t.Errorf("OriginalMissing = %v, %v; want only the deleted original flagged",
+			p.files[0].OriginalMissing, p.files[2].OriginalMissing)
+	}
+
+	// It clears again when the original comes back
+	os.WriteFile(p.files[2].OriginalPath, []byte("audio"), 0644)
+	if !p.checkOriginal(2) || p.files[2].OriginalMissing {
+		t.Error("checkOriginal() didn't clear the flag once the original was back")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// partialDirName is the folder in the temp dir where copies cut short, e.g.
+// by quitting mid-import, are kept until the same file is added again
+const partialDirName = ".partial"
+
+// resumeOverlap is how much of the end of a partial copy is copied again,
+// since after a crash or power cut the last of it may not have reached the
+// disk intact
+const resumeOverlap = 1 << 20
+
+// partialSource records the source of a partial copy as it was when the
+// copy started. The copy is only picked up again while the source still
+// matches, and it's complete once it reaches Size.
+type partialSource struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// partialsInUse holds the partial copies being written, so two imports of
+// the same file don't write the same one
+var partialsInUse sync.Map
+
+// partialCopyDir is where a partial copy of src is kept under partialDir
+func partialCopyDir(partialDir, src string) string {
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		abs = src
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(partialDir, hex.EncodeToString(sum[:8]))
+}
+
+// resumeOffset is how much of src the partial copy in dir already holds.
+// A partial copy of anything else, or of src before it changed, is
+// discarded and 0 returned.
+func resumeOffset(dir string, source partialSource) int64 {
+	data, err := os.ReadFile(filepath.Join(dir, "source.json"))
+	var saved partialSource
+	if err == nil && json.Unmarshal(data, &saved) == nil &&
+		saved.Path == source.Path && saved.Size == source.Size && saved.ModTime.Equal(source.ModTime) {
+		if info, err := os.Stat(filepath.Join(dir, "data")); err == nil && info.Size() <= source.Size {
+			return max(0, info.Size()-resumeOverlap)
+		}
+	}
+	os.RemoveAll(dir)
+	return 0
+}
+
+// copyResuming is copyWithProgress for copies worth picking up again: the
+// copy is made in partialDir and only moved to dst once complete. If it's
+// cut short, other than by cancelling ctx, the next copy of the unchanged
+// src carries on from where it stopped. It returns the SHA-256 of the copy
+// and how much of it was already there.
+func copyResuming(ctx context.Context, src, dst, partialDir string, onProgress func(written, total int64)) (string, int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", 0, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	source := partialSource{Path: src, Size: info.Size(), ModTime: info.ModTime()}
+
+	dir := partialCopyDir(partialDir, src)
+	if _, busy := partialsInUse.LoadOrStore(dir, true); busy {
+		sum, err := copyWithProgress(ctx, src, dst, onProgress)
+		return sum, 0, err
+	}
+	defer partialsInUse.Delete(dir)
+
+	offset := resumeOffset(dir, source)
+	if offset == 0 {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", 0, err
+		}
+		data, _ := json.Marshal(source)
+		if err := writeFileAtomic(filepath.Join(dir, "source.json"), data, 0644); err != nil {
+			return "", 0, err
+		}
+	}
+	partialPath := filepath.Join(dir, "data")
+	out, err := os.OpenFile(partialPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// What's already copied is hashed from the copy, not read again from src
+	h := sha256.New()
+	if err := out.Truncate(offset); err != nil {
+		out.Close()
+		return "", 0, err
+	}
+	if _, err := io.CopyN(h, out, offset); err != nil {
+		out.Close()
+		os.RemoveAll(dir)
+		return "", 0, fmt.Errorf("couldn't read the partial copy of %s: %w", filepath.Base(src), err)
+	}
+	if _, err := in.Seek(offset, io.SeekStart); err != nil {
+		out.Close()
+		return "", 0, err
+	}
+
+	counter := &progressWriter{written: offset, total: info.Size(), onProgress: onProgress}
+	_, err = io.Copy(io.MultiWriter(out, h, counter), contextReader{ctx: ctx, r: in})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && counter.written != source.Size {
+		err = fmt.Errorf("%s changed while it was copied", filepath.Base(src))
+	}
+	if err != nil {
+		// Cancelled copies aren't wanted again; anything else is kept to resume
+		if ctx.Err() != nil {
+			os.RemoveAll(dir)
+			os.Remove(partialDir)
+		}
+		return "", 0, err
+	}
+
+	if err := os.Rename(partialPath, dst); err != nil {
+		return "", 0, err
+	}
+	os.RemoveAll(dir)
+	os.Remove(partialDir) // unless other copies are waiting there
+	return hex.EncodeToString(h.Sum(nil)), offset, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Resumed Copy Tests
+// =============================================================================
+
+// interruptedCopy leaves a partial copy of the first n bytes of src in
+// partialDir, as a copy cut short would
+func interruptedCopy(t *testing.T, partialDir, src string, n int) string {
+	t.Helper()
+	info, err := os.Stat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(src)
+	dir := partialCopyDir(partialDir, src)
+	os.MkdirAll(dir, 0755)
+	source, _ := json.Marshal(partialSource{Path: src, Size: info.Size(), ModTime: info.ModTime()})
+	os.WriteFile(filepath.Join(dir, "source.json"), source, 0644)
+	os.WriteFile(filepath.Join(dir, "data"), data[:n], 0644)
+	return dir
+}
+
+// audiobook writes a source big enough to resume part way through
+func audiobook(t *testing.T) (string, []byte) {
+	data := bytes.Repeat([]byte("chapter "), 3*resumeOverlap/8)
+	src := filepath.Join(t.TempDir(), "book.m4b")
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return src, data
+}
+
+func TestCopyResuming(t *testing.T) {
+	src, want := audiobook(t)
+	partialDir := filepath.Join(t.TempDir(), partialDirName)
+	dir := interruptedCopy(t, partialDir, src, 2*resumeOverlap+100)
+
+	var first int64 = -1
+	dst := filepath.Join(t.TempDir(), "book.m4a")
+	sum, resumed, err := copyResuming(context.Background(), src, dst, partialDir, func(written, total int64) {
+		if first < 0 {
+			first = written
+		}
+	})
+	if err != nil {
+		t.Fatalf("copyResuming() error = %v", err)
+	}
+	if resumed != resumeOverlap+100 {
+		t.Errorf("resumed at %d; want a little before where the copy stopped", resumed)
+	}
+	if first <= resumed {
+		t.Errorf("progress started at %d; want it counting from %d", first, resumed)
+	}
+	if data, _ := os.ReadFile(dst); !bytes.Equal(data, want) {
+		t.Error("resumed copy doesn't match the source")
+	}
+	if h := sha256.Sum256(want); sum != hex.EncodeToString(h[:]) {
+		t.Errorf("sum = %s; want the source's", sum)
+	}
+	if dirExists(dir) || dirExists(partialDir) {
+		t.Error("partial copy left behind after finishing")
+	}
+}
+
+func TestCopyResumingChangedSource(t *testing.T) {
+	src, want := audiobook(t)
+	partialDir := filepath.Join(t.TempDir(), partialDirName)
+	interruptedCopy(t, partialDir, src, 2*resumeOverlap)
+
+	// A source edited since is copied again from the start
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(src, later, later)
+	dst := filepath.Join(t.TempDir(), "book.m4a")
+	_, resumed, err := copyResuming(context.Background(), src, dst, partialDir, nil)
+	if err != nil || resumed != 0 {
+		t.Fatalf("copyResuming() resumed at %d, error %v; want a fresh copy", resumed, err)
+	}
+	if data, _ := os.ReadFile(dst); !bytes.Equal(data, want) {
+		t.Error("copy doesn't match the source")
+	}
+}
+
+func TestCopyResumingCancelled(t *testing.T) {
+	src, _ := audiobook(t)
+	partialDir := filepath.Join(t.TempDir(), partialDirName)
+	interruptedCopy(t, partialDir, src, 2*resumeOverlap)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dst := filepath.Join(t.TempDir(), "book.m4a")
+	if _, _, err := copyResuming(ctx, src, dst, partialDir, nil); err == nil {
+		t.Fatal("cancelled copyResuming() succeeded")
+	}
+	if fileExists(dst) || dirExists(partialDir) {
+		t.Error("a cancelled copy was kept")
+	}
+}
+
+func TestImportAudioVerifiesResumedCopy(t *testing.T) {
+	src, want := audiobook(t)
+	partialDir := filepath.Join(t.TempDir(), partialDirName)
+	dir := interruptedCopy(t, partialDir, src, 2*resumeOverlap)
+
+	// The start of the partial copy went bad on disk
+	data, _ := os.ReadFile(filepath.Join(dir, "data"))
+	copy(data, "garbled")
+	os.WriteFile(filepath.Join(dir, "data"), data, 0644)
+
+	dst := filepath.Join(t.TempDir(), "book.m4a")
+	sum, err := importAudio(context.Background(), src, dst, partialDir, true, nil)
+	if err != nil {
+		t.Fatalf("importAudio() error = %v", err)
+	}
+	if data, _ := os.ReadFile(dst); !bytes.Equal(data, want) {
+		t.Error("bad resumed copy wasn't made again")
+	}
+	if h := sha256.Sum256(want); sum != hex.EncodeToString(h[:]) {
+		t.Errorf("sum = %s; want the source's", sum)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"sort"
+)
+
+// Season sort modes, persisted per season in AppState
+const (
+	seasonSortNone     = ""
+	seasonSortNameAsc  = "name"
+	seasonSortNameDesc = "name-desc"
+)
+
+var seasonSortLabels = map[string]string{
+	seasonSortNone:     "Manual",
+	seasonSortNameAsc:  "Name (A-Z)",
+	seasonSortNameDesc: "Name (Z-A)",
+}
+
+var seasonSortLess = map[string]func(a, b AudioFile) bool{
+	seasonSortNameAsc: func(a, b AudioFile) bool {
+		return naturalLess(a.DisplayName, b.DisplayName)
+	},
+	seasonSortNameDesc: func(a, b AudioFile) bool {
+		return naturalLess(b.DisplayName, a.DisplayName)
+	},
+}
+
+// seasonIndices returns the positions in files that belong to season, in order
+func seasonIndices(files []AudioFile, season int) []int {
+	indices := []int{}
+	for i, f := range files {
+		if f.Season == season {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// seasonNumbers returns the distinct seasons present in files, ascending
+func seasonNumbers(files []AudioFile) []int {
+	seen := map[int]bool{}
+	seasons := []int{}
+	for _, f := range files {
+		if !seen[f.Season] {
+			seen[f.Season] = true
+			seasons = append(seasons, f.Season)
+		}
+	}
+	sort.Ints(seasons)
+	return seasons
+}
+
+// sortWithinSeason reorders only the files belonging to season. Members are
+// sorted among themselves and written back into the slots they already
+// occupied, so every other file keeps its position.
+func sortWithinSeason(files []AudioFile, season int, less func(a, b AudioFile) bool) {
+	indices := seasonIndices(files, season)
+	if len(indices) <= 1 {
+		return
+	}
+
+	members := make([]AudioFile, len(indices))
+	for i, idx := range indices {
+		members[i] = files[idx]
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return less(members[i], members[j])
+	})
+	for i, idx := range indices {
+		files[idx] = members[i]
+	}
+}
+
+// sortSeason sorts one season and remembers the mode so it is reapplied as
+// files are added or moved into that season.
+func (p *Podcasterator) sortSeason(season int, mode string) {
+	if p.seasonSorts == nil {
+		p.seasonSorts = map[int]string{}
+	}
+	if mode == seasonSortNone {
+		delete(p.seasonSorts, season)
+	} else {
+		p.seasonSorts[season] = mode
+	}
+
+	p.applySeasonSorts()
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+}
+
+// applySeasonSorts reapplies every persisted per-season sort
+func (p *Podcasterator) applySeasonSorts() {
+	for season, mode := range p.seasonSorts {
+		if less, ok := seasonSortLess[mode]; ok {
+			sortWithinSeason(p.files, season, less)
+		}
+	}
+}
+
+// assignSeason puts files[from..to] (inclusive) into season
+func (p *Podcasterator) assignSeason(from, to, season int) {
+	if from > to {
+		from, to = to, from
+	}
+	if from < 0 || to >= len(p.files) || season < 0 {
+		return
+	}
+
+	for i := from; i <= to; i++ {
+		p.files[i].Season = season
+	}
+
+	p.applySeasonSorts()
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// =============================================================================
+// Season Grouping Tests
+// =============================================================================
+
+func seasonTestFiles() []AudioFile {
+	return []AudioFile{
+		{ID: "1", DisplayName: "c.mp3", Season: 1},
+		{ID: "2", DisplayName: "z.mp3", Season: 2},
+		{ID: "3", DisplayName: "a.mp3", Season: 1},
+		{ID: "4", DisplayName: "y.mp3", Season: 2},
+		{ID: "5", DisplayName: "b.mp3", Season: 1},
+		{ID: "6", DisplayName: "x.mp3"},
+	}
+}
+
+func displayNames(files []AudioFile) []string {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.DisplayName
+	}
+	return names
+}
+
+func TestSortWithinSeason(t *testing.T) {
+	tests := []struct {
+		name          string
+		season        int
+		mode          string
+		expectedOrder []string
+	}{
+		{
+			name:          "sort season 1 leaves season 2 slots alone",
+			season:        1,
+			mode:          seasonSortNameAsc,
+			expectedOrder: []string{"a.mp3", "z.mp3", "b.mp3", "y.mp3", "c.mp3", "x.mp3"},
+		},
+		{
+			name:          "sort season 2 leaves season 1 slots alone",
+			season:        2,
+			mode:          seasonSortNameAsc,
+			expectedOrder: []string{"c.mp3", "y.mp3", "a.mp3", "z.mp3", "b.mp3", "x.mp3"},
+		},
+		{
+			name:          "descending sort",
+			season:        1,
+			mode:          seasonSortNameDesc,
+			expectedOrder: []string{"c.mp3", "z.mp3", "b.mp3", "y.mp3", "a.mp3", "x.mp3"},
+		},
+		{
+			name:          "season with one file (no change)",
+			season:        0,
+			mode:          seasonSortNameAsc,
+			expectedOrder: []string{"c.mp3", "z.mp3", "a.mp3", "y.mp3", "b.mp3", "x.mp3"},
+		},
+		{
+			name:          "unknown season (no change)",
+			season:        9,
+			mode:          seasonSortNameAsc,
+			expectedOrder: []string{"c.mp3", "z.mp3", "a.mp3", "y.mp3", "b.mp3", "x.mp3"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			files := seasonTestFiles()
+			sortWithinSeason(files, tc.season, seasonSortLess[tc.mode])
+
+			for i, expected := range tc.expectedOrder {
+				if files[i].DisplayName != expected {
+					t.Errorf("After sortWithinSeason(%d), files = %v; want %v",
+						tc.season, displayNames(files), tc.expectedOrder)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSeasonNumbers(t *testing.T) {
+	seasons := seasonNumbers(seasonTestFiles())
+	expected := []int{0, 1, 2}
+
+	if len(seasons) != len(expected) {
+		t.Fatalf("seasonNumbers() = %v; want %v", seasons, expected)
+	}
+	for i := range expected {
+		if seasons[i] != expected[i] {
+			t.Errorf("seasonNumbers() = %v; want %v", seasons, expected)
+		}
+	}
+}
+
+func TestSeasonSortIsReappliedOnAdd(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.files = seasonTestFiles()
+	p.sortSeason(0, seasonSortNameAsc)
+
+	// An external file lands in season 0 and should be sorted into place
+	p.addExternalFile(AudioFile{ID: "7", DisplayName: "m.mp3", ExternalURL: "https://example.com/m.mp3"})
+
+	expected := []string{"c.mp3", "z.mp3", "a.mp3", "y.mp3", "b.mp3", "m.mp3", "x.mp3"}
+	for i, name := range expected {
+		if p.files[i].DisplayName != name {
+			t.Fatalf("After add, files = %v; want %v", displayNames(p.files), expected)
+		}
+	}
+}
+
+func TestAssignSeason(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.files = []AudioFile{
+		{ID: "1", DisplayName: "a.mp3"},
+		{ID: "2", DisplayName: "b.mp3"},
+		{ID: "3", DisplayName: "c.mp3"},
+	}
+
+	p.assignSeason(2, 1, 4) // reversed range is accepted
+	if p.files[0].Season != 0 || p.files[1].Season != 4 || p.files[2].Season != 4 {
+		t.Errorf("assignSeason(2, 1, 4) seasons = [%d %d %d]; want [0 4 4]",
+			p.files[0].Season, p.files[1].Season, p.files[2].Season)
+	}
+
+	p.assignSeason(0, 10, 2) // out of range is ignored
+	if p.files[0].Season != 0 {
+		t.Error("assignSeason() with out of range index modified files")
+	}
+}
+
+func TestSeasonSortsPersist(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.files = seasonTestFiles()
+	p.sortSeason(2, seasonSortNameDesc)
+	p.flushState()
+
+	data, err := os.ReadFile(filepath.Join(p.configDir, "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to read state: %v", err)
+	}
+	var state AppState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Failed to unmarshal state: %v", err)
+	}
+
+	if state.SeasonSorts[2] != seasonSortNameDesc {
+		t.Errorf("Saved season sorts = %v; want season 2 = %q", state.SeasonSorts, seasonSortNameDesc)
+	}
+
+	p.sortSeason(2, seasonSortNone)
+	if _, ok := p.seasonSorts[2]; ok {
+		t.Error("sortSeason() with manual mode should forget the season's sort")
+	}
+}
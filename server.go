@@ -0,0 +1,496 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/feeds"
+)
+
+const (
+	minServerPort = 1024
+	maxServerPort = 65535
+
+	// portAttempts is how many consecutive ports are tried, starting with the
+	// chosen one, before giving up
+	portAttempts = 10
+)
+
+// validatePort parses a port number typed by the user. Ports below 1024
+// need admin rights on most systems, so they're not offered.
+func validatePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < minServerPort || port > maxServerPort {
+		return 0, fmt.Errorf("enter a port from %d to %d", minServerPort, maxServerPort)
+	}
+	return port, nil
+}
+
+// serverPort is the port the user chose, or defaultServerPort
+func (p *Podcasterator) serverPort() int {
+	if p.port == 0 {
+		return defaultServerPort
+	}
+	return p.port
+}
+
+// resumeServerOnLaunch starts the server if it was running when the app
+// last quit and that's turned on, trying the port it ran on so the feed URL
+// is the same. Failures are reported like any launch. With no files left
+// there's nothing to serve, so it stays stopped.
+func (p *Podcasterator) resumeServerOnLaunch() bool {
+	if !p.resumeServer || !p.serverWasRunning {
+		return false
+	}
+	if len(p.files) == 0 {
+		fmt.Println("Not restarting the server: the list is empty")
+		p.serverWasRunning = false
+		p.markDirty()
+		return false
+	}
+	port := p.lastServerPort
+	if port == 0 {
+		port = p.serverPort()
+	}
+	return p.launchServerOn(port)
+}
+
+// listenFrom binds the first free port of up to portAttempts starting at
+// port. If none can be bound it returns the error for port itself.
+func listenFrom(localOnly bool, port int) (net.Listener, error) {
+	var firstErr error
+	for i := 0; i < portAttempts && port+i <= maxServerPort; i++ {
+		ln, err := net.Listen("tcp", listenAddr(localOnly, port+i))
+		if err == nil {
+			return ln, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// listenAddr is the address the server binds to. Local-only mode binds the
+// loopback interface so the feed can't be reached from the LAN.
+func listenAddr(localOnly bool, port int) string {
+	if localOnly {
+		return fmt.Sprintf("127.0.0.1:%d", port)
+	}
+	return fmt.Sprintf("0.0.0.0:%d", port)
+}
+
+// serverHost is the host used in feed and enclosure URLs: the chosen
+// address if it's still connected, otherwise getLocalIP's pick
+func serverHost(localOnly bool, chosen string) string {
+	if localOnly {
+		return "localhost"
+	}
+	return chooseAddress(networkAddresses(), chosen)
+}
+
+// allowCORS lets web pages on any site fetch from next, for browser-based
+// podcast players. Preflight requests are answered here without reaching
+// next. Range is allowed and Content-Range exposed so players can seek.
+func allowCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", "*")
+		h.Set("Access-Control-Expose-Headers", "Content-Length, Content-Range, Accept-Ranges")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Range, Authorization")
+			h.Set("Access-Control-Max-Age", "86400")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// published is what the running server publishes, or nil while it's
+// stopped
+func (p *Podcasterator) published() *FeedServer {
+	p.serverMux.Lock()
+	defer p.serverMux.Unlock()
+	return p.live
+}
+
+// fileETag identifies the content served for file, so podcast apps can skip
+// re-downloading an episode they already have. Temp copies are identified by
+// the hash taken when they were copied, which changes whenever the audio is
+// replaced; anything else by its size and modification time.
+func fileETag(file AudioFile, info os.FileInfo) string {
+	if file.ServedPath() == file.TempPath && file.SHA256 != "" {
+		return fmt.Sprintf(`"%s-%x"`, file.SHA256, info.Size())
+	}
+	return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+}
+
+// statusAPIVersion is bumped for incompatible changes to /status. Fields may
+// be added without bumping it.
+const statusAPIVersion = 1
+
+// serverStatus is the /status response
+type serverStatus struct {
+	APIVersion    int          `json:"api_version"`
+	PodcastName   string       `json:"podcast_name"`
+	FeedURL       string       `json:"feed_url"`
+	UptimeSeconds int64        `json:"uptime_seconds"`
+	FileCount     int          `json:"file_count"`
+	Files         []statusFile `json:"files"`
+}
+
+type statusFile struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Size  int64  `json:"size"`
+}
+
+// newServerStatus describes the feed as published from files
+func newServerStatus(podcastName, feedURL string, files []AudioFile) serverStatus {
+	status := serverStatus{
+		APIVersion:  statusAPIVersion,
+		PodcastName: podcastName,
+		FeedURL:     feedURL,
+		FileCount:   len(files),
+		Files:       make([]statusFile, 0, len(files)),
+	}
+	for _, f := range files {
+		size := f.Size
+		if f.IsExternal() {
+			size = f.ExternalLength
+		}
+		status.Files = append(status.Files, statusFile{ID: f.ID, Title: f.DisplayName, Size: size})
+	}
+	return status
+}
+
+// FeedServer serves a podcast over HTTP: the feed at /feed.xml, / and
+// /status, the artwork, and the files under /files/. The window and the
+// serve command both publish into one (see publishTo) and wrap its mux
+// in whatever else they need, like auth. In the window it's rebuilt
+// whenever the podcast changes (see markDirty), so reordering, renaming or
+// adding files shows up on the next request without a restart.
+//
+// Locking: the UI goroutine owns Podcasterator and is the only one to read
+// or change its fields, p.files and p.artworkPath included; background work
+// copies what it needs first and hands results back with fyne.Do. Server
+// goroutines never touch those fields. They read what's published here,
+// under mu; the fields above mu are fixed for the life of the server, and
+// each build is new and never changed afterwards. The server itself is
+// tracked under serverMux, and the activity log has its own lock.
+type FeedServer struct {
+	baseURL string
+	feedURL string
+	tempDir string // served copies must be inside it
+
+	mu          sync.RWMutex
+	feed        *feeds.Feed
+	extras      feedExtras
+	index       indexPage
+	status      serverStatus
+	files       map[string]AudioFile // copies, by ID
+	artworkPath string
+	updatedAt   time.Time
+}
+
+// newFeedServer makes a FeedServer for a podcast served at baseURL from
+// copies in tempDir. Nothing is served until something is published to it.
+func newFeedServer(baseURL, tempDir string) *FeedServer {
+	return &FeedServer{baseURL: baseURL, feedURL: feedURLFor(baseURL), tempDir: tempDir}
+}
+
+// newServeMux routes requests to everything s serves. startedAt is when the
+// server started, for the uptime in /status. It needs nothing but what's
+// published to s, so tests can serve it with httptest.
+func newServeMux(s *FeedServer, startedAt time.Time) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/feed.xml", s.serveFeed)
+
+	mux.HandleFunc("/files/", s.serveFile)
+
+	// Browsable page for checking the feed from a browser
+	mux.HandleFunc("/", s.serveIndex)
+
+	// Machine-readable summary for scripts and dashboards
+	mux.HandleFunc("/status", s.statusHandler(startedAt))
+
+	// Artwork endpoint, named for the artwork's format
+	mux.HandleFunc("/artwork.jpg", s.serveArtwork)
+	mux.HandleFunc("/artwork.png", s.serveArtwork)
+
+	return mux
+}
+
+// publishTo builds what live publishes from the current state
+func (p *Podcasterator) publishTo(live *FeedServer) {
+	feed, extras := p.feedFor(live.baseURL)
+	index := newIndexPage(feed, live.feedURL)
+	status := newServerStatus(p.podcastName, live.feedURL, p.files)
+	// Copies, so the UI can go on editing the list
+	files := make(map[string]AudioFile, len(p.files))
+	for _, f := range p.files {
+		files[f.ID] = f
+	}
+
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	live.feed, live.extras, live.index, live.status = feed, extras, index, status
+	live.files, live.artworkPath = files, p.artworkPath
+	live.updatedAt = time.Now()
+}
+
+// file returns the published file with id. Nothing is published by a nil
+// FeedServer.
+func (s *FeedServer) file(id string) (AudioFile, bool) {
+	if s == nil {
+		return AudioFile{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.files[id]
+	return f, ok
+}
+
+// inTempDir reports whether path is somewhere inside the temp dir, judged
+// by where path leads once cleaned and relative to it. Unlike a prefix
+// test, this keeps out siblings like tempdir-evil.
+func (s *FeedServer) inTempDir(path string) bool {
+	return insideDir(path, s.tempDir)
+}
+
+// insideDir reports whether path is somewhere below dir, going by the
+// paths alone
+func insideDir(path, dir string) bool {
+	if dir == "" || path == "" {
+		return false
+	}
+	absDir, err := filepath.Abs(filepath.Clean(dir))
+	if err != nil {
+		return false
+	}
+	absFile, err := filepath.Abs(filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absFile)
+	if err != nil || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return rel != "."
+}
+
+// servableCopy reports whether the copy at path may be served. It must be
+// in the temp dir, not be a symlink, and still be in it once any symlinked
+// folders on the way there are followed; the app only ever puts real files
+// there, so a link, e.g. from a tampered state file, would expose whatever
+// it points at. Anything missing is left to the caller to answer as not
+// found.
+func (s *FeedServer) servableCopy(path string) bool {
+	if !s.inTempDir(path) {
+		return false
+	}
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		return false
+	}
+	realTemp, err := filepath.EvalSymlinks(s.tempDir)
+	if err != nil {
+		return false
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	return err == nil && insideDir(realPath, realTemp)
+}
+
+// servableOriginal reports whether the original at path may be served. It
+// must be a clean absolute path to a supported audio file, and a regular
+// file rather than a link, so an entry in a tampered state file can't
+// expose anything else on the machine. Anything missing is left to the
+// caller to answer as not found.
+func servableOriginal(path string) bool {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path || !isSupportedFile(path) {
+		return false
+	}
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	return err == nil && info.Mode().IsRegular()
+}
+
+// serveFile serves /files/<id>/<name>. The file is looked up by ID in
+// what's published and served from its temp copy, or from OriginalPath for
+// files in original mode. Path checks apply to whichever source is used.
+func (s *FeedServer) serveFile(w http.ResponseWriter, r *http.Request) {
+	urlPath := r.URL.Path
+	parts := strings.SplitN(strings.TrimPrefix(urlPath, "/files/"), "/", 2)
+
+	if len(parts) != 2 {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	id := parts[0]
+	decodedName, _ := url.PathUnescape(parts[1])
+
+	// Security checks
+	if strings.Contains(id, "..") || strings.Contains(id, "/") || strings.Contains(id, "\\") {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	if strings.Contains(decodedName, "..") || strings.HasPrefix(decodedName, "/") || strings.HasPrefix(decodedName, "\\") {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+
+	file, ok := s.file(id)
+	if ok && decodedName == episodeCoverName {
+		s.serveEpisodeCover(w, r, file)
+		return
+	}
+	if ok && decodedName == chaptersName {
+		s.serveChapters(w, r, file)
+		return
+	}
+	if ok && isTranscriptFile(decodedName) {
+		s.serveTranscript(w, r, file, decodedName)
+		return
+	}
+	if !ok || file.IsExternal() {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+
+	filePath := file.ServedPath()
+	servable := s.servableCopy
+	if file.ServeOriginal {
+		servable = servableOriginal
+	}
+	if !servable(filePath) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+
+	// ServeContent answers Range requests with 206 and the matching
+	// Content-Range and Content-Length so players can seek, and keeps the
+	// Content-Type set here. Unlike ServeFile it never redirects. Accept-Ranges
+	// is set up front so it's also sent on 416 responses. With the ETag set
+	// it answers a matching If-None-Match or If-Range itself.
+	w.Header().Set("Content-Type", mimeTypeFor(filepath.Ext(filePath)))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("ETag", fileETag(file, info))
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// serveFeed streams the current feed
+func (s *FeedServer) serveFeed(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	feed, extras := s.feed, s.extras
+	s.mu.RUnlock()
+	w.Header().Set("Content-Type", "application/rss+xml")
+	writeRSS(w, feed, s.feedURL, extras)
+}
+
+// serveIndex serves the current browsable page
+func (s *FeedServer) serveIndex(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	page := s.index
+	s.mu.RUnlock()
+	indexHandler(page)(w, r)
+}
+
+// serveArtwork serves the podcast artwork, named for its format
+func (s *FeedServer) serveArtwork(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	path := s.artworkPath
+	s.mu.RUnlock()
+	if path == "" || !fileExists(path) || r.URL.Path != "/"+artworkName(path) {
+		http.Error(w, "Artwork not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", artworkMIME(path))
+	http.ServeFile(w, r, path)
+}
+
+// statusHandler serves the current status with the uptime since startedAt
+func (s *FeedServer) statusHandler(startedAt time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		status := s.status
+		s.mu.RUnlock()
+		statusHandler(status, startedAt)(w, r)
+	}
+}
+
+// publishFeed rebuilds the running server's feed from the current state.
+// It runs on the UI goroutine, which owns the file list, and does nothing
+// while the server is stopped.
+func (p *Podcasterator) publishFeed() {
+	live := p.published()
+	if live == nil {
+		return
+	}
+	p.publishTo(live)
+	p.showLiveUpdated(live)
+}
+
+// showLiveUpdated shows that the running feed follows edits, and when it
+// last changed
+func (p *Podcasterator) showLiveUpdated(live *FeedServer) {
+	if p.liveLabel == nil || live == nil {
+		return
+	}
+	live.mu.RLock()
+	updated := live.updatedAt
+	live.mu.RUnlock()
+	p.liveLabel.SetText("● Live: edits are published as you make them (updated " + updated.Format("15:04:05") + ")")
+}
+
+// statusHandler serves status as JSON with the uptime since startedAt. It
+// exposes nothing the feed doesn't, so it has the same access as the feed.
+func statusHandler(status serverStatus, startedAt time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp := status
+		resp.UptimeSeconds = int64(time.Since(startedAt) / time.Second)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
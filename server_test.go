@@ -0,0 +1,1387 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// newFileServerFixture adds one file with distinct temp and original contents
+func newFileServerFixture(t *testing.T) (*Podcasterator, *httptest.Server, func()) {
+	p, cleanup := newTestPodcasterator(t)
+
+	originalDir := t.TempDir()
+	originalPath := filepath.Join(originalDir, "episode.mp3")
+	if err := os.WriteFile(originalPath, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tempPath := filepath.Join(p.tempDir, "abc", "episode.mp3")
+	os.MkdirAll(filepath.Dir(tempPath), 0755)
+	if err := os.WriteFile(tempPath, []byte("copy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A file next to the original that must never be reachable
+	os.WriteFile(filepath.Join(originalDir, "secret.txt"), []byte("secret"), 0644)
+
+	p.files = []AudioFile{{
+		ID:           "abc",
+		OriginalPath: originalPath,
+		TempPath:     tempPath,
+		DisplayName:  "episode.mp3",
+	}}
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+
+	// Files are served as published, so tests call markDirty after editing
+	// them as the app does
+	p.live = newFeedServer(srv.URL, p.tempDir)
+	mux.Handle("/", newServeMux(p.live, time.Now()))
+	p.publishFeed()
+
+	return p, srv, func() {
+		srv.Close()
+		cleanup()
+	}
+}
+
+func getBody(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestHandleFileRequestServeModes(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	tests := []struct {
+		name          string
+		serveOriginal bool
+		want          string
+	}{
+		{"temp copy", false, "copy"},
+		{"original", true, "original"},
+		{"back to temp copy", false, "copy"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := p.setServeOriginal("abc", tc.serveOriginal); err != nil {
+				t.Fatalf("setServeOriginal: %v", err)
+			}
+			code, body := getBody(t, srv.URL+"/files/abc/episode.mp3")
+			if code != http.StatusOK || body != tc.want {
+				t.Errorf("got %d %q; want 200 %q", code, body, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandleFileRequestRejectsTraversal(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	tests := []struct {
+		name          string
+		path          string
+		serveOriginal bool
+	}{
+		{"dotdot in name", "/files/abc/..%2Fsecret.txt", false},
+		{"dotdot in name original mode", "/files/abc/..%2Fsecret.txt", true},
+		{"dotdot id", "/files/..%2F..%2F/secret.txt", true},
+		{"backslash id", "/files/a%5Cb/episode.mp3", false},
+		{"unknown id", "/files/nope/episode.mp3", false},
+		{"missing name", "/files/abc", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p.files[0].ServeOriginal = tc.serveOriginal
+			p.markDirty()
+			code, body := getBody(t, srv.URL+tc.path)
+			if code == http.StatusOK {
+				t.Errorf("GET %s = 200 %q; want an error", tc.path, body)
+			}
+		})
+	}
+}
+
+// noRedirects is a client that reports redirects rather than following
+// them, so a request is judged by what the server first answers
+var noRedirects = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}}
+
+func TestServeMuxRejectsMaliciousPaths(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	paths := []string{
+		"/files/..%2f..%2fetc/passwd",
+		"/files/..%2F..%2F..%2F..%2Fetc%2Fpasswd",
+		"/files/abc/..%2f..%2f..%2fetc%2fpasswd",
+		"/files/abc/%2e%2e%2fsecret.txt",
+		"/files/abc/%2e%2e/%2e%2e/etc/passwd",
+		"/files/abc/..%5c..%5csecret.txt",
+		"/files/abc/%2fetc%2fpasswd",
+		"/files/%2e%2e/episode.mp3",
+		"/files/../../etc/passwd",
+		"/files/abc/../../../etc/passwd",
+		"/artwork.jpg/..%2f..%2fetc/passwd",
+		"/feed.xml/..%2f..%2fetc/passwd",
+		"/files/abc/%252e%252e%252fsecret.txt",
+		"/files/abc/.%2e%2fsecret.txt",
+		"/files/abc/%2e%2e%5csecret.txt",
+	}
+	for _, serveOriginal := range []bool{false, true} {
+		p.files[0].ServeOriginal = serveOriginal
+		p.markDirty()
+		for _, path := range paths {
+			resp, err := noRedirects.Get(srv.URL + path)
+			if err != nil {
+				t.Fatalf("GET %s: %v", path, err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK || strings.Contains(string(body), "secret") || strings.Contains(string(body), "root:") {
+				t.Errorf("GET %s (original %v) = %d %q; want it refused", path, serveOriginal, resp.StatusCode, body)
+			}
+		}
+	}
+	// Overlong UTF-8 dots aren't dots, just an odd name for the episode
+	if code, body := getBody(t, srv.URL+"/files/abc/%c0%ae%c0%ae%2fsecret.txt"); code == http.StatusOK && body != "original" {
+		t.Errorf("GET with overlong dots = %q; want the episode or nothing", body)
+	}
+}
+
+func TestServeMuxRoutes(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.artworkPath = filepath.Join(p.tempDir, "artwork.png")
+	writeTestPNG(t, p.artworkPath, 8, 8)
+	p.markDirty()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantType string
+	}{
+		{"/feed.xml", http.StatusOK, "application/rss+xml"},
+		{"/files/abc/episode.mp3", http.StatusOK, "audio/mpeg"},
+		{"/artwork.png", http.StatusOK, "image/png"},
+		{"/status", http.StatusOK, "application/json"},
+		{"/", http.StatusOK, "text/html; charset=utf-8"},
+		{"/artwork.jpg", http.StatusNotFound, ""},
+		{"/files/nope/episode.mp3", http.StatusNotFound, ""},
+		{"/nope", http.StatusNotFound, ""},
+	}
+	for _, tc := range tests {
+		resp, err := noRedirects.Get(srv.URL + tc.path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tc.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.wantCode {
+			t.Errorf("GET %s = %d; want %d", tc.path, resp.StatusCode, tc.wantCode)
+		}
+		if got := resp.Header.Get("Content-Type"); tc.wantType != "" && !strings.HasPrefix(got, tc.wantType) {
+			t.Errorf("GET %s Content-Type = %q; want %q", tc.path, got, tc.wantType)
+		}
+	}
+}
+
+func TestHandleFileRequestTempPathOutsideTempDir(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	// A tampered state file must not turn temp mode into arbitrary reads
+	p.files[0].TempPath = p.files[0].OriginalPath
+	p.markDirty()
+
+	code, _ := getBody(t, srv.URL+"/files/abc/episode.mp3")
+	if code != http.StatusForbidden {
+		t.Errorf("status = %d; want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestInTempDir(t *testing.T) {
+	parent := t.TempDir()
+	temp := filepath.Join(parent, "temp")
+	s := newFeedServer("http://host", temp)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(temp, "abc", "episode.mp3"), true},
+		{filepath.Join(temp, "abc", "..", "def", "episode.mp3"), true},
+		{temp + "/./abc//episode.mp3", true},
+		// Names starting with dots are inside; only ".." itself leads out
+		{filepath.Join(temp, "..intro.mp3"), true},
+		{filepath.Join(temp, "abc", "..intro.mp3"), true},
+		{temp, false},
+		{parent, false},
+		{temp + "-evil/episode.mp3", false},
+		{temp + "evil/episode.mp3", false},
+		{temp + "/abc/../../secret.txt", false},
+		{temp + "/../temp-evil/episode.mp3", false},
+		{filepath.Join(parent, "secret.txt"), false},
+		{"/etc/passwd", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		if got := s.inTempDir(tc.path); got != tc.want {
+			t.Errorf("inTempDir(%q) = %v; want %v", tc.path, got, tc.want)
+		}
+	}
+
+	// Relative paths are judged from where they lead
+	wd, _ := os.Getwd()
+	if rel, err := filepath.Rel(wd, filepath.Join(temp, "abc", "episode.mp3")); err == nil && !s.inTempDir(rel) {
+		t.Errorf("inTempDir(%q) = false for a relative path inside", rel)
+	}
+	if (&FeedServer{}).inTempDir(filepath.Join(wd, "main.go")) {
+		t.Error("a server without a temp dir served the working directory")
+	}
+}
+
+func TestHandleFileRequestSiblingOfTempDir(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	// A folder whose name starts with the temp dir's is still outside it
+	sibling := filepath.Join(p.tempDir+"-evil", "abc", "episode.mp3")
+	os.MkdirAll(filepath.Dir(sibling), 0755)
+	os.WriteFile(sibling, []byte("secret"), 0644)
+	p.files[0].TempPath = sibling
+	p.markDirty()
+	if code, body := getBody(t, srv.URL+"/files/abc/episode.mp3"); code != http.StatusForbidden {
+		t.Errorf("copy beside the temp dir = %d %q; want 403", code, body)
+	}
+
+	// As is one reached by climbing out through a symlinked folder
+	if err := os.Symlink(filepath.Dir(sibling), filepath.Join(p.tempDir, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	p.files[0].TempPath = filepath.Join(p.tempDir, "link", "..", "..", filepath.Base(p.tempDir)+"-evil", "abc", "episode.mp3")
+	p.markDirty()
+	if code, body := getBody(t, srv.URL+"/files/abc/episode.mp3"); code != http.StatusForbidden {
+		t.Errorf("copy reached through a symlink = %d %q; want 403", code, body)
+	}
+}
+
+func TestHandleFileRequestRejectsSymlinks(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	if !fileExists("/etc/hosts") {
+		t.Skip("no /etc/hosts to link to")
+	}
+
+	// A link in the temp dir is never followed out of it
+	link := filepath.Join(p.tempDir, "abc", "hosts.mp3")
+	if err := os.Symlink("/etc/hosts", link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	p.files[0].TempPath = link
+	p.markDirty()
+	if code, body := getBody(t, srv.URL+"/files/abc/episode.mp3"); code != http.StatusForbidden {
+		t.Errorf("symlink to /etc/hosts = %d %q; want 403", code, body)
+	}
+
+	// Nor is a linked folder
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "episode.mp3"), []byte("secret"), 0644)
+	os.Symlink(outside, filepath.Join(p.tempDir, "linked"))
+	p.files[0].TempPath = filepath.Join(p.tempDir, "linked", "episode.mp3")
+	p.markDirty()
+	if code, body := getBody(t, srv.URL+"/files/abc/episode.mp3"); code != http.StatusForbidden {
+		t.Errorf("copy in a linked folder = %d %q; want 403", code, body)
+	}
+
+	// A temp dir that is itself reached through a link still serves its copies
+	alias := filepath.Join(t.TempDir(), "alias")
+	os.Symlink(p.tempDir, alias)
+	s := newFeedServer(srv.URL, alias)
+	if !s.servableCopy(filepath.Join(alias, "abc", "episode.mp3")) {
+		t.Error("copy in a linked temp dir refused")
+	}
+	if s.servableCopy(filepath.Join(alias, "abc", "hosts.mp3")) {
+		t.Error("symlink in a linked temp dir allowed")
+	}
+}
+
+func TestHandleFileRequestOriginalMustBeAudio(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.txt")
+	os.WriteFile(secret, []byte("secret"), 0644)
+	link := filepath.Join(dir, "linked.mp3")
+	symlinks := os.Symlink(secret, link) == nil
+
+	tests := []struct {
+		name     string
+		original string
+	}{
+		{"not audio", secret},
+		{"relative", "episode.mp3"},
+		{"unclean", dir + "/../" + filepath.Base(dir) + "/episode.mp3"},
+		{"link named as audio", link},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.original == link && !symlinks {
+				t.Skip("symlinks unavailable")
+			}
+			// As a hand-edited state file could have it
+			p.files[0].OriginalPath = tc.original
+			p.files[0].ServeOriginal = true
+			p.markDirty()
+			if code, body := getBody(t, srv.URL+"/files/abc/episode.mp3"); code != http.StatusForbidden {
+				t.Errorf("original %s = %d %q; want 403", tc.original, code, body)
+			}
+		})
+	}
+}
+
+func TestHandleFileRequestRanges(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	if err := os.WriteFile(p.files[0].TempPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		rangeHeader string
+		wantStatus  int
+		wantRange   string
+		wantBody    []byte
+	}{
+		{"no range", "", http.StatusOK, "", data},
+		{"middle", "bytes=100-200", http.StatusPartialContent, "bytes 100-200/1000", data[100:201]},
+		{"open ended", "bytes=900-", http.StatusPartialContent, "bytes 900-999/1000", data[900:]},
+		{"suffix", "bytes=-50", http.StatusPartialContent, "bytes 950-999/1000", data[950:]},
+		{"unsatisfiable", "bytes=2000-", http.StatusRequestedRangeNotSatisfiable, "bytes */1000", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/files/abc/episode.mp3", nil)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tc.wantStatus {
+				t.Fatalf("status = %d; want %d", resp.StatusCode, tc.wantStatus)
+			}
+			if got := resp.Header.Get("Accept-Ranges"); got != "bytes" {
+				t.Errorf("Accept-Ranges = %q; want %q", got, "bytes")
+			}
+			if got := resp.Header.Get("Content-Range"); got != tc.wantRange {
+				t.Errorf("Content-Range = %q; want %q", got, tc.wantRange)
+			}
+			if tc.wantBody == nil {
+				return
+			}
+			if got := resp.Header.Get("Content-Type"); got != "audio/mpeg" {
+				t.Errorf("Content-Type = %q; want %q", got, "audio/mpeg")
+			}
+			if resp.ContentLength != int64(len(tc.wantBody)) {
+				t.Errorf("Content-Length = %d; want %d", resp.ContentLength, len(tc.wantBody))
+			}
+			if !bytes.Equal(body, tc.wantBody) {
+				t.Errorf("body = %d bytes not matching the requested slice", len(body))
+			}
+		})
+	}
+}
+
+func TestSetServeOriginalRequiresOriginal(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	os.Remove(p.files[0].OriginalPath)
+	if err := p.setServeOriginal("abc", true); err == nil {
+		t.Fatal("setServeOriginal with missing original succeeded")
+	}
+	if p.files[0].ServeOriginal {
+		t.Error("ServeOriginal set despite error")
+	}
+
+	code, body := getBody(t, srv.URL+"/files/abc/episode.mp3")
+	if code != http.StatusOK || body != "copy" {
+		t.Errorf("got %d %q; want 200 %q", code, body, "copy")
+	}
+}
+
+func TestLaunchLeavesFileDatesAlone(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+
+	old := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	os.Chtimes(p.files[0].TempPath, old, old)
+	os.Chtimes(p.files[0].OriginalPath, old, old)
+	p.files[0].AddedAt = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	dates := feedPubDates(t, p)
+	if dates["abc"] != "Mon, 01 Jan 2024 12:00:00 +0000" {
+		t.Errorf("pubDate = %q; want it from list order, not the file's mtime", dates["abc"])
+	}
+	for _, path := range []string{p.files[0].TempPath, p.files[0].OriginalPath} {
+		if info, _ := os.Stat(path); !info.ModTime().Equal(old) {
+			t.Errorf("%s mtime changed to %v", path, info.ModTime())
+		}
+	}
+}
+
+func TestHandleFileRequestETag(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	url := srv.URL + "/files/abc/episode.mp3"
+
+	get := func(etag string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", url, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	tests := []struct {
+		name  string
+		setup func()
+		edit  func()
+	}{
+		{
+			name:  "copy without hash",
+			setup: func() {},
+			edit:  func() { os.WriteFile(p.files[0].TempPath, []byte("copy, edited"), 0644) },
+		},
+		{
+			name: "copy with hash",
+			setup: func() {
+				p.files[0].SHA256 = "aaaa"
+				p.markDirty()
+			},
+			edit: func() {
+				p.files[0].SHA256 = "bbbb"
+				p.markDirty()
+			},
+		},
+		{
+			name: "original",
+			setup: func() {
+				p.files[0].ServeOriginal = true
+				p.markDirty()
+			},
+			edit: func() {
+				os.WriteFile(p.files[0].OriginalPath, []byte("original, edited"), 0644)
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setup()
+			first := get("")
+			etag := first.Header.Get("ETag")
+			if etag == "" || first.Header.Get("Last-Modified") == "" {
+				t.Fatalf("headers = %v; want an ETag and Last-Modified", first.Header)
+			}
+			if again := get(""); again.Header.Get("ETag") != etag {
+				t.Errorf("ETag changed between requests: %s then %s", etag, again.Header.Get("ETag"))
+			}
+			if cached := get(etag); cached.StatusCode != http.StatusNotModified {
+				t.Errorf("matching If-None-Match status = %d; want %d", cached.StatusCode, http.StatusNotModified)
+			}
+
+			tc.edit()
+			if changed := get(etag); changed.StatusCode != http.StatusOK || changed.Header.Get("ETag") == etag {
+				t.Errorf("after the audio changed status = %d, ETag %s; want %d and a new ETag", changed.StatusCode, changed.Header.Get("ETag"), http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		localOnly bool
+		want      string
+	}{
+		{true, "127.0.0.1:8080"},
+		{false, "0.0.0.0:8080"},
+	}
+
+	for _, tc := range tests {
+		if got := listenAddr(tc.localOnly, 8080); got != tc.want {
+			t.Errorf("listenAddr(%v, 8080) = %q; want %q", tc.localOnly, got, tc.want)
+		}
+	}
+
+	if got := serverHost(true, "192.0.2.1"); got != "localhost" {
+		t.Errorf("serverHost(true) = %q; want %q", got, "localhost")
+	}
+}
+
+func TestValidatePort(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"8080", 8080, false},
+		{" 1024 ", 1024, false},
+		{"65535", 65535, false},
+		{"80", 0, true},
+		{"65536", 0, true},
+		{"http", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tc := range tests {
+		got, err := validatePort(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("validatePort(%q) = %d, %v; want %d, error %v", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+// busyPort holds a loopback port open for the rest of the test
+func busyPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestListenFromSkipsBusyPort(t *testing.T) {
+	taken := busyPort(t)
+
+	ln, err := listenFrom(true, taken)
+	if err != nil {
+		t.Fatalf("listenFrom(%d) error = %v", taken, err)
+	}
+	defer ln.Close()
+
+	got := ln.Addr().(*net.TCPAddr).Port
+	if got <= taken || got >= taken+portAttempts {
+		t.Errorf("listenFrom(%d) bound port %d; want one of the next %d", taken, got, portAttempts-1)
+	}
+}
+
+func TestLaunchServerUsesFallbackPort(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = busyPort(t)
+
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed with a busy port")
+	}
+	defer p.shutdownServer()
+
+	_, portStr, _ := net.SplitHostPort(strings.TrimPrefix(strings.TrimSuffix(p.serverURL, "/feed.xml"), "http://"))
+	if port, _ := strconv.Atoi(portStr); port == p.port || port == 0 {
+		t.Errorf("feed URL %s doesn't use a fallback port (busy: %d)", p.serverURL, p.port)
+	}
+
+	code, _ := getBody(t, p.serverURL)
+	if code != http.StatusOK {
+		t.Errorf("GET %s = %d; want 200", p.serverURL, code)
+	}
+}
+
+func TestLaunchServerBindFailure(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+
+	// At the top of the range there are no further ports to fall back to
+	ln, err := net.Listen("tcp", listenAddr(true, maxServerPort))
+	if err != nil {
+		t.Skipf("port %d unavailable: %v", maxServerPort, err)
+	}
+	defer ln.Close()
+	p.port = maxServerPort
+
+	if p.launchServer() {
+		defer p.shutdownServer()
+		t.Fatal("launchServer() succeeded on a busy port")
+	}
+	if p.serverRunning || p.server != nil || p.launching || p.serverURL != "" {
+		t.Errorf("after failed launch: running=%v server=%v launching=%v url=%q",
+			p.serverRunning, p.server != nil, p.launching, p.serverURL)
+	}
+
+	// A later launch on a free port still works
+	p.port = 0
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed after an earlier bind failure")
+	}
+	p.shutdownServer()
+}
+
+func TestLaunchButtonNeedsFiles(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.launchBtn = p.newTipButton("Launch", nil, launchTip, nil)
+	p.launchHint = widget.NewLabel("")
+	p.updateLaunchButton()
+
+	check := func(when string, enabled bool) {
+		t.Helper()
+		if p.launchBtn.Disabled() == enabled || p.launchHint.Visible() == enabled {
+			t.Errorf("%s: launch disabled=%v, hint shown=%v; want enabled=%v",
+				when, p.launchBtn.Disabled(), p.launchHint.Visible(), enabled)
+		}
+		want := launchNoFilesTip
+		if enabled {
+			want = launchTip
+		}
+		if p.launchBtn.tip != want {
+			t.Errorf("%s: tip = %q; want %q", when, p.launchBtn.tip, want)
+		}
+	}
+	check("no files", false)
+
+	addNamedFiles(t, p, "a.mp3", "b.mp3")
+	check("after adding", true)
+	p.deleteFile(0)
+	check("after deleting one of two", true)
+	p.deleteFile(0)
+	check("after deleting the last", false)
+
+	p.undoRemove()
+	check("after undo", true)
+	p.clearAll()
+	check("after clear all", false)
+}
+
+func TestClearServer(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	server := p.server
+
+	if p.clearServer(&http.Server{}) {
+		t.Error("clearServer() cleared a server that isn't running")
+	}
+	if !p.clearServer(server) {
+		t.Fatal("clearServer() didn't clear the running server")
+	}
+	server.Close()
+	if p.serverRunning || p.server != nil || p.serverURL != "" {
+		t.Errorf("after clear: running=%v server=%v url=%q", p.serverRunning, p.server != nil, p.serverURL)
+	}
+	if p.clearServer(server) {
+		t.Error("clearServer() cleared the same server twice")
+	}
+}
+
+func TestLocalOnlyNotReachableFromLAN(t *testing.T) {
+	lanIP := getLocalIP()
+	if lanIP == "localhost" {
+		t.Skip("no LAN address on this machine")
+	}
+
+	ln, err := net.Listen("tcp", listenAddr(true, 0))
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	if host, _, _ := net.SplitHostPort(ln.Addr().String()); !net.ParseIP(host).IsLoopback() {
+		t.Fatalf("bound to %s; want a loopback address", host)
+	}
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(lanIP, port), time.Second)
+	if err == nil {
+		conn.Close()
+		t.Errorf("local-only listener reachable at %s", net.JoinHostPort(lanIP, port))
+	}
+}
+
+func TestNumberedEnclosureRoundTrip(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	tests := []struct {
+		index, count int
+		want         string
+	}{
+		{0, 3, "01-episode.mp3"},
+		{8, 12, "09-episode.mp3"},
+		{41, 150, "042-episode.mp3"},
+	}
+
+	for _, tc := range tests {
+		name := enclosureName("episode.mp3", tc.index, tc.count, true)
+		if name != tc.want {
+			t.Errorf("enclosureName(%d, %d) = %q; want %q", tc.index, tc.count, name, tc.want)
+		}
+
+		item, ok := feedItemNamed(p.files[0], name, srv.URL, time.Now())
+		if !ok {
+			t.Fatal("feedItemNamed() skipped the file")
+		}
+		if item.Title != "episode.mp3" {
+			t.Errorf("Title = %q; want the unprefixed name", item.Title)
+		}
+
+		// The handler resolves the prefixed name back to the same file
+		code, body := getBody(t, item.Enclosure.Url)
+		if code != http.StatusOK || body != "copy" {
+			t.Errorf("GET %s = %d %q; want 200 %q", item.Enclosure.Url, code, body, "copy")
+		}
+	}
+
+	if name := enclosureName("episode.mp3", 0, 3, false); name != "episode.mp3" {
+		t.Errorf("enclosureName() unnumbered = %q; want %q", name, "episode.mp3")
+	}
+	if !fileExists(p.files[0].TempPath) {
+		t.Error("temp file was renamed")
+	}
+}
+
+func TestASCIISlug(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"episode.mp3", "episode.mp3"},
+		{"Café Ünïcode.mp3", "Cafe-Unicode.mp3"},
+		{"Crème brûlée – Part 2.M4A", "Creme-brulee-Part-2.m4a"},
+		{"Straße & Ærø.mp3", "Strasse-AEro.mp3"},
+		{"🎧 Late Night 🌙.mp3", "Late-Night.mp3"},
+		{"🎧🌙.mp3", "episode.mp3"},
+		{"日本語.m4b", "episode.m4b"},
+		{"a  --  b__c.mp3", "a-b__c.mp3"},
+		{"v1.2 final.mp3", "v1.2-final.mp3"},
+		{"no extension", "no-extension"},
+	}
+	for _, tc := range tests {
+		if got := asciiSlug(tc.in); got != tc.want {
+			t.Errorf("asciiSlug(%q) = %q; want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestASCIIFileNamesRoundTrip(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.files[0].DisplayName = "Épisode 1 🎧.mp3"
+	p.asciiFileNames = true
+	p.numberEnclosures = true
+
+	name := p.servedName(0)
+	if name != "01-Episode-1.mp3" {
+		t.Errorf("servedName() = %q; want the numbered slug", name)
+	}
+	item, ok := feedItemNamed(p.files[0], name, srv.URL, time.Now())
+	if !ok {
+		t.Fatal("feedItemNamed() skipped the file")
+	}
+	if item.Title != "Épisode 1 🎧.mp3" {
+		t.Errorf("Title = %q; want the real name", item.Title)
+	}
+	if !strings.HasSuffix(item.Enclosure.Url, "/files/abc/01-Episode-1.mp3") {
+		t.Errorf("enclosure URL = %q; want the slug unescaped", item.Enclosure.Url)
+	}
+	if code, body := getBody(t, item.Enclosure.Url); code != http.StatusOK || body != "copy" {
+		t.Errorf("GET %s = %d %q; want the file", item.Enclosure.Url, code, body)
+	}
+
+	p.asciiFileNames = false
+	p.numberEnclosures = false
+	if name := p.servedName(0); name != "Épisode 1 🎧.mp3" {
+		t.Errorf("servedName() with the option off = %q", name)
+	}
+}
+
+func TestAllowCORS(t *testing.T) {
+	reached := false
+	srv := httptest.NewServer(allowCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.Write([]byte("feed"))
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/feed.xml")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q; want *", got)
+	}
+	if !strings.Contains(resp.Header.Get("Access-Control-Expose-Headers"), "Content-Range") {
+		t.Error("Content-Range isn't exposed to scripts")
+	}
+
+	reached = false
+	req, _ := http.NewRequest(http.MethodOptions, srv.URL+"/files/a/one.mp3", nil)
+	req.Header.Set("Origin", "https://player.example")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "range")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("OPTIONS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || reached {
+		t.Errorf("preflight status = %d, reached handler %v; want %d answered directly", resp.StatusCode, reached, http.StatusNoContent)
+	}
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("preflight Access-Control-Allow-Origin = %q; want *", got)
+	}
+	if !strings.Contains(resp.Header.Get("Access-Control-Allow-Headers"), "Range") {
+		t.Error("preflight doesn't allow the Range header")
+	}
+}
+
+func TestLaunchServerCORS(t *testing.T) {
+	for _, allow := range []bool{false, true} {
+		t.Run(strconv.FormatBool(allow), func(t *testing.T) {
+			p, _, cleanup := newFileServerFixture(t)
+			defer cleanup()
+			p.localOnly = true
+			p.port = 0
+			p.allowCORS = allow
+			// Preflights carry no credentials, so they must pass protection
+			p.auth = feedAuth{Enabled: true, User: "office"}
+			p.auth.setPassword("s3cret")
+
+			if !p.launchServer() {
+				t.Fatal("launchServer() failed")
+			}
+			defer p.shutdownServer()
+			base := strings.TrimSuffix(p.serverURL, "/feed.xml")
+
+			for _, path := range []string{"/feed.xml", "/files/abc/episode.mp3"} {
+				req, _ := http.NewRequest(http.MethodGet, base+path, nil)
+				req.SetBasicAuth("office", "s3cret")
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatalf("GET %s: %v", path, err)
+				}
+				resp.Body.Close()
+				want := ""
+				if allow {
+					want = "*"
+				}
+				if got := resp.Header.Get("Access-Control-Allow-Origin"); got != want {
+					t.Errorf("GET %s Access-Control-Allow-Origin = %q; want %q", path, got, want)
+				}
+			}
+
+			req, _ := http.NewRequest(http.MethodOptions, p.serverURL, nil)
+			req.Header.Set("Origin", "https://player.example")
+			req.Header.Set("Access-Control-Request-Method", "GET")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("OPTIONS: %v", err)
+			}
+			resp.Body.Close()
+			if allow && resp.StatusCode != http.StatusNoContent {
+				t.Errorf("preflight status = %d; want %d", resp.StatusCode, http.StatusNoContent)
+			}
+			if !allow && resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("preflight with CORS off = %d; want %d", resp.StatusCode, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	files := []AudioFile{
+		{ID: "a", DisplayName: "one.mp3", Size: 100},
+		{ID: "b", DisplayName: "Hosted", ExternalURL: "https://example.com/b.mp3", ExternalLength: 200},
+	}
+	status := newServerStatus("My Show", "http://host:8080/feed.xml", files)
+	srv := httptest.NewServer(statusHandler(status, time.Now().Add(-90*time.Second)))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET /status: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q; want application/json", ct)
+	}
+
+	// Decode generically so renamed or retyped fields are caught
+	var got map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	wantKeys := []string{"api_version", "podcast_name", "feed_url", "uptime_seconds", "file_count", "files"}
+	for _, k := range wantKeys {
+		if _, ok := got[k]; !ok {
+			t.Errorf("response missing %q: %v", k, got)
+		}
+	}
+	if len(got) != len(wantKeys) {
+		t.Errorf("response has %d keys; want %d: %v", len(got), len(wantKeys), got)
+	}
+
+	if got["api_version"] != float64(statusAPIVersion) {
+		t.Errorf("api_version = %v; want %d", got["api_version"], statusAPIVersion)
+	}
+	if got["podcast_name"] != "My Show" || got["feed_url"] != "http://host:8080/feed.xml" {
+		t.Errorf("name/feed = %v, %v", got["podcast_name"], got["feed_url"])
+	}
+	if got["file_count"] != float64(2) {
+		t.Errorf("file_count = %v; want 2", got["file_count"])
+	}
+	if up, _ := got["uptime_seconds"].(float64); up < 90 {
+		t.Errorf("uptime_seconds = %v; want >= 90", got["uptime_seconds"])
+	}
+
+	wantFiles := []any{
+		map[string]any{"id": "a", "title": "one.mp3", "size": float64(100)},
+		map[string]any{"id": "b", "title": "Hosted", "size": float64(200)},
+	}
+	if !reflect.DeepEqual(got["files"], wantFiles) {
+		t.Errorf("files = %v; want %v", got["files"], wantFiles)
+	}
+
+	post, err := http.Post(srv.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST /status: %v", err)
+	}
+	post.Body.Close()
+	if post.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d; want %d", post.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestLaunchServerConcurrentCalls(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+
+	const callers = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started := 0
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if p.launchServer() {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	defer p.shutdownServer()
+
+	if started != 1 {
+		t.Errorf("%d launches started a server; want exactly 1", started)
+	}
+	if !p.serverRunning || p.server == nil || p.launching {
+		t.Errorf("after launch: running=%v server=%v launching=%v", p.serverRunning, p.server != nil, p.launching)
+	}
+
+	// Launching again while running is a no-op that keeps the same server
+	server := p.server
+	if p.launchServer() || p.server != server {
+		t.Error("launchServer() while running replaced the server")
+	}
+}
+
+func TestFeedEpoch(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		files []AudioFile
+		want  time.Time
+	}{
+		{"no files", nil, now},
+		{"no AddedAt", []AudioFile{{ID: "a"}}, now},
+		{"earliest wins", []AudioFile{{AddedAt: late}, {}, {AddedAt: early}}, early},
+	}
+
+	for _, tc := range tests {
+		if got := feedEpoch(tc.files, now); !got.Equal(tc.want) {
+			t.Errorf("%s: feedEpoch() = %v; want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// feedPubDates launches the server, returns each item's pubDate by guid,
+// and stops it again
+func feedPubDates(t *testing.T, p *Podcasterator) map[string]string {
+	t.Helper()
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+
+	_, body := getBody(t, p.serverURL)
+	var doc struct {
+		Items []struct {
+			GUID    string `xml:"guid"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("invalid feed: %v", err)
+	}
+	dates := map[string]string{}
+	for _, item := range doc.Items {
+		dates[item.GUID] = item.PubDate
+	}
+	return dates
+}
+
+func TestFeedPubDatesStableAcrossLaunches(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+
+	second := filepath.Join(p.tempDir, "def", "second.mp3")
+	os.MkdirAll(filepath.Dir(second), 0755)
+	os.WriteFile(second, []byte("second"), 0644)
+	p.files[0].AddedAt = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p.files = append(p.files, AudioFile{
+		ID: "def", TempPath: second, DisplayName: "second.mp3",
+		AddedAt: time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC),
+	})
+
+	first := feedPubDates(t, p)
+	if first["abc"] != "Mon, 01 Jan 2024 12:00:00 +0000" || first["def"] != "Mon, 01 Jan 2024 11:59:59 +0000" {
+		t.Fatalf("pubDates = %v; want the first file newest, anchored at the earliest AddedAt", first)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if again := feedPubDates(t, p); !reflect.DeepEqual(again, first) {
+		t.Errorf("pubDates changed between launches: %v then %v", first, again)
+	}
+
+	// Appending a file leaves the existing dates alone
+	p.files = append(p.files, AudioFile{ID: "ghi", ExternalURL: "https://example.com/c.mp3", ExternalLength: 1, ExternalType: "audio/mpeg", AddedAt: time.Now()})
+	appended := feedPubDates(t, p)
+	if appended["abc"] != first["abc"] || appended["def"] != first["def"] {
+		t.Errorf("pubDates changed after appending: %v then %v", first, appended)
+	}
+
+	// Removing the oldest file keeps the epoch, so the next one only moves
+	// up a place instead of to its own AddedAt, and so does a restart
+	p.files = p.files[1:]
+	p.saveState()
+	next := &Podcasterator{tempDir: p.tempDir, configDir: p.configDir, localOnly: true}
+	next.loadState()
+	for _, q := range []*Podcasterator{p, next} {
+		if got := feedPubDates(t, q)["def"]; got != first["abc"] {
+			t.Errorf("pubDate of the new first file = %q; want %q", got, first["abc"])
+		}
+	}
+}
+
+func TestFeedPubDatesDescendWithListOrder(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		p.files = append(p.files, AudioFile{ID: id, DisplayName: id + ".mp3",
+			ExternalURL: "https://example.com/" + id + ".mp3", ExternalLength: 1, ExternalType: "audio/mpeg",
+			AddedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)})
+	}
+
+	order := func() (guids []string) {
+		t.Helper()
+		rss, _, err := p.siteFeed("http://host")
+		if err != nil {
+			t.Fatalf("siteFeed() error = %v", err)
+		}
+		var doc struct {
+			Items []struct {
+				GUID    string `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"channel>item"`
+		}
+		if err := xml.Unmarshal([]byte(rss), &doc); err != nil {
+			t.Fatalf("invalid feed: %v", err)
+		}
+		var last time.Time
+		for i, item := range doc.Items {
+			date, err := time.Parse(time.RFC1123Z, item.PubDate)
+			if err != nil {
+				t.Fatalf("item %s pubDate %q: %v", item.GUID, item.PubDate, err)
+			}
+			if i > 0 && !date.Before(last) {
+				t.Errorf("item %d (%s) published %v, not before the item above it at %v", i, item.GUID, date, last)
+			}
+			last = date
+			guids = append(guids, item.GUID)
+		}
+		return guids
+	}
+
+	if got := strings.Join(order(), ","); got != "a,b,c,d" {
+		t.Errorf("items = %s; want list order", got)
+	}
+	// The dates follow the list as it is reordered
+	p.reverse()
+	p.moveUp(2)
+	if got := strings.Join(order(), ","); got != "d,b,c,a" {
+		t.Errorf("items after reordering = %s; want d,b,c,a", got)
+	}
+}
+
+func TestLiveFeedFollowsEdits(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+	root := strings.TrimSuffix(p.serverURL, "/feed.xml")
+
+	titles := func() string {
+		t.Helper()
+		_, body := getBody(t, p.serverURL)
+		var doc struct {
+			Title string `xml:"channel>title"`
+			Items []struct {
+				Titles []string `xml:"title"` // <title> and <itunes:title>
+			} `xml:"channel>item"`
+		}
+		if err := xml.Unmarshal([]byte(body), &doc); err != nil {
+			t.Fatalf("invalid feed: %v", err)
+		}
+		var items []string
+		for _, item := range doc.Items {
+			items = append(items, item.Titles[0])
+		}
+		return doc.Title + ": " + strings.Join(items, ",")
+	}
+	if got := titles(); got != "Test Podcast: episode.mp3" {
+		t.Fatalf("feed at launch = %q", got)
+	}
+
+	addNamedFiles(t, p, "second.mp3")
+	p.reverse()
+	p.podcastName = "Renamed"
+	p.markDirty()
+	if got := titles(); got != "Renamed: second.mp3,episode.mp3" {
+		t.Errorf("feed after editing = %q; want the edits published", got)
+	}
+	if _, body := getBody(t, root+"/"); !strings.Contains(body, "Renamed") || !strings.Contains(body, "second.mp3") {
+		t.Errorf("index page after editing:\n%s", body)
+	}
+	var status serverStatus
+	_, body := getBody(t, root+"/status")
+	if err := json.Unmarshal([]byte(body), &status); err != nil || status.PodcastName != "Renamed" || status.FileCount != 2 {
+		t.Errorf("status after editing = %s", body)
+	}
+
+	// Nothing is published once the server stops
+	p.shutdownServer()
+	if p.live != nil {
+		t.Error("live feed kept after the server stopped")
+	}
+	p.markDirty()
+}
+
+func TestLiveFeedWaitsForBurstOfEdits(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+
+	// A title typed a letter at a time restarts the wait each time and
+	// leaves the feed alone until it's over
+	p.publishDelay = time.Hour
+	var timers []*time.Timer
+	for _, name := range []string{"R", "Re", "Ren"} {
+		p.podcastName = name
+		p.markDirty()
+		timers = append(timers, p.publishTimer)
+	}
+	defer p.publishTimer.Stop()
+	if timers[0] == nil || timers[0] == timers[1] || timers[1] == timers[2] {
+		t.Error("each edit didn't restart the pending publish")
+	}
+	if _, body := getBody(t, p.serverURL); !strings.Contains(body, "<title>Test Podcast</title>") {
+		t.Errorf("feed changed during a burst of edits:\n%s", body)
+	}
+}
+
+func TestFilesServedLiveWhileRunning(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+	root := strings.TrimSuffix(p.serverURL, "/feed.xml")
+
+	addNamedFiles(t, p, "new.mp3")
+	added := p.files[1]
+	url := root + "/files/" + added.ID + "/new.mp3"
+	if code, body := getBody(t, url); code != http.StatusOK || body != "new.mp3" {
+		t.Errorf("GET file added while running = %d %q; want it served", code, body)
+	}
+
+	p.deleteFile(1)
+	if code, _ := getBody(t, url); code != http.StatusNotFound {
+		t.Errorf("GET file deleted while running = %d; want 404", code)
+	}
+}
+
+// TestEditWhileServing edits the list while requests are answered, for
+// go test -race
+func TestEditWhileServing(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+	addNamedFiles(t, p, "a.mp3", "b.mp3")
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+	root := strings.TrimSuffix(p.serverURL, "/feed.xml")
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, path := range []string{"/feed.xml", "/", "/status", "/files/abc/episode.mp3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if resp, err := http.Get(root + path); err == nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		p.reverse()
+		p.moveUp(2)
+		p.applyRename(&p.files[0], fmt.Sprintf("renamed %d.mp3", i))
+		p.podcastName = fmt.Sprintf("Podcast %d", i)
+		p.markDirty()
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestResumeServerOnLaunch(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+
+	// The port the server last ran on, free again
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastPort := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	p.serverWasRunning = true
+	p.lastServerPort = lastPort
+	if p.resumeServerOnLaunch() || p.serverRunning {
+		t.Fatal("server resumed with the option off")
+	}
+
+	p.resumeServer = true
+	if !p.resumeServerOnLaunch() {
+		t.Fatal("resumeServerOnLaunch() didn't start the server")
+	}
+	if want := fmt.Sprintf(":%d/feed.xml", lastPort); !strings.HasSuffix(p.serverURL, want) {
+		t.Errorf("serverURL = %q; want it on the last port %d", p.serverURL, lastPort)
+	}
+
+	// Quitting keeps it marked as running; stopping it forgets that
+	p.shutdownServer()
+	if !p.serverWasRunning {
+		t.Error("shutting down on exit cleared serverWasRunning")
+	}
+	p.launchServer()
+	p.stopServer()
+	if p.serverWasRunning {
+		t.Error("stopServer() left serverWasRunning set")
+	}
+
+	// An emptied list has nothing to serve
+	p.serverWasRunning = true
+	p.files = nil
+	if p.resumeServerOnLaunch() || p.serverRunning || p.serverWasRunning {
+		t.Error("server resumed with no files")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// registerShortcuts wires the window's keyboard shortcuts. Alt+Up/Down move
+// the selected file, Delete removes it and F2 renames it; Ctrl+Z (Cmd+Z on
+// macOS) undoes the last delete or clear. Entries handle their own keys
+// while they have focus.
+func (p *Podcasterator) registerShortcuts() {
+	c := p.window.Canvas()
+	c.AddShortcut(&fyne.ShortcutUndo{}, func(fyne.Shortcut) { p.undoRemove() })
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyUp, Modifier: fyne.KeyModifierAlt}, func(fyne.Shortcut) {
+		if !p.dialogOpen() {
+			p.moveSelected(-1)
+		}
+	})
+	c.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyDown, Modifier: fyne.KeyModifierAlt}, func(fyne.Shortcut) {
+		if !p.dialogOpen() {
+			p.moveSelected(1)
+		}
+	})
+	// Keys without a modifier never arrive as shortcuts
+	c.SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		if !p.dialogOpen() {
+			p.typedKey(ev.Name)
+		}
+	})
+}
+
+// dialogOpen reports whether a dialog is showing, so keys meant for it
+// don't act on the list behind it
+func (p *Podcasterator) dialogOpen() bool {
+	return p.window != nil && p.window.Canvas().Overlays().Top() != nil
+}
+
+// typedKey handles an unmodified key press that no widget took
+func (p *Podcasterator) typedKey(key fyne.KeyName) {
+	switch key {
+	case fyne.KeyDelete:
+		p.deleteSelected()
+	case fyne.KeyF2:
+		p.renameSelected()
+	}
+}
+
+// selectedIndex is the list position of the selected file, or -1 if none is
+// selected. The selection follows the file, not the row it was on.
+func (p *Podcasterator) selectedIndex() int {
+	if p.selectedID == "" {
+		return -1
+	}
+	for i, file := range p.files {
+		if file.ID == p.selectedID {
+			return i
+		}
+	}
+	return -1
+}
+
+// selectFile selects the file at index and scrolls it into view
+func (p *Podcasterator) selectFile(index int) {
+	if index < 0 || index >= len(p.files) {
+		return
+	}
+	p.selectedID = p.files[index].ID
+	if p.fileList != nil {
+		p.fileList.Select(widget.ListItemID(index))
+		p.fileList.ScrollTo(widget.ListItemID(index))
+	}
+}
+
+// moveSelected moves the selected file delta places (-1 up, 1 down) and
+// keeps it selected
+func (p *Podcasterator) moveSelected(delta int) {
+	index := p.selectedIndex()
+	if index < 0 {
+		return
+	}
+	switch {
+	case delta < 0 && index > 0:
+		p.moveUp(index)
+		p.selectFile(index - 1)
+	case delta > 0 && index < len(p.files)-1:
+		p.moveDown(index)
+		p.selectFile(index + 1)
+	}
+}
+
+// deleteSelected deletes the selected file, as the row's × button does, and
+// selects the file that took its place so Delete can be pressed again
+func (p *Podcasterator) deleteSelected() {
+	index := p.selectedIndex()
+	if index < 0 {
+		return
+	}
+	p.deleteFile(index)
+	p.selectedID = ""
+	if p.fileList != nil {
+		p.fileList.UnselectAll()
+	}
+	p.selectFile(min(index, len(p.files)-1))
+}
+
+// renameSelected opens the rename dialog for the selected file
+func (p *Podcasterator) renameSelected() {
+	if index := p.selectedIndex(); index >= 0 {
+		p.renameFile(index)
+	}
+}
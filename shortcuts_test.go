@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// =============================================================================
+// Keyboard Shortcut Tests
+// =============================================================================
+
+func TestMoveSelected(t *testing.T) {
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+
+	p.moveSelected(1) // nothing selected
+	p.selectFile(0)
+	p.moveSelected(-1) // already at the top
+	p.moveSelected(1)
+	p.moveSelected(1)
+	p.moveSelected(1) // already at the bottom
+
+	if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,c.mp3,a.mp3" {
+		t.Errorf("order = %s; want b.mp3,c.mp3,a.mp3", got)
+	}
+	if got := p.selectedIndex(); got != 2 {
+		t.Errorf("selectedIndex() = %d; want 2, following the moved file", got)
+	}
+}
+
+func TestDeleteSelected(t *testing.T) {
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+
+	p.deleteSelected() // nothing selected
+	if len(p.files) != 3 {
+		t.Fatalf("deleteSelected() with no selection removed a file")
+	}
+
+	p.selectFile(1)
+	p.typedKey(fyne.KeyDelete)
+	if got := strings.Join(displayNames(p.files), ","); got != "a.mp3,c.mp3" {
+		t.Errorf("after Delete = %s; want a.mp3,c.mp3", got)
+	}
+	if p.selectedIndex() != 1 {
+		t.Errorf("selectedIndex() = %d; want 1, the file that took its place", p.selectedIndex())
+	}
+
+	// Deleting the last file selects the one before it
+	p.typedKey(fyne.KeyDelete)
+	if got := strings.Join(displayNames(p.files), ","); got != "a.mp3" || p.selectedIndex() != 0 {
+		t.Errorf("after second Delete = %s selecting %d; want a.mp3 selected", got, p.selectedIndex())
+	}
+
+	p.undoRemove()
+	p.undoRemove()
+	if got := strings.Join(displayNames(p.files), ","); got != "a.mp3,b.mp3,c.mp3" {
+		t.Errorf("after undoing both = %s; want a.mp3,b.mp3,c.mp3", got)
+	}
+}
+
+func TestRegisteredShortcuts(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.fileList = p.newFileList()
+	p.window = test.NewTempWindow(t, p.fileList)
+	p.registerShortcuts()
+	c := p.window.Canvas()
+
+	// Selecting a row in the list selects its file
+	p.fileList.Select(widget.ListItemID(0))
+	if p.selectedIndex() != 0 {
+		t.Fatalf("selectedIndex() = %d after selecting row 0", p.selectedIndex())
+	}
+
+	c.(fyne.Shortcutable).TypedShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyDown, Modifier: fyne.KeyModifierAlt})
+	if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,a.mp3,c.mp3" {
+		t.Errorf("after Alt+Down = %s; want b.mp3,a.mp3,c.mp3", got)
+	}
+
+	c.OnTypedKey()(&fyne.KeyEvent{Name: fyne.KeyDelete})
+	if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,c.mp3" {
+		t.Errorf("after Delete = %s; want b.mp3,c.mp3", got)
+	}
+
+	c.(fyne.Shortcutable).TypedShortcut(&fyne.ShortcutUndo{})
+	if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,a.mp3,c.mp3" {
+		t.Errorf("after Undo = %s; want b.mp3,a.mp3,c.mp3", got)
+	}
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// dirSize totals the sizes of the files under dir
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// checkStorageDir makes sure dir can take need more bytes: it's created if
+// missing, must be empty so nothing else there is mixed in with the copies,
+// and must be writable with room to spare (see checkFreeSpace).
+func checkStorageDir(dir string, need int64) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("couldn't create %s: %w", dir, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s isn't empty; choose an empty folder", dir)
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("%s isn't writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return checkFreeSpace(dir, need)
+}
+
+// moveDir moves everything in src into dst, which must exist and be empty.
+// A rename is tried first; across drives the files are copied with their
+// modification times, then src is removed. src may be missing. If a copy
+// fails, what was already copied is removed again, leaving dst empty.
+func moveDir(src, dst string) error {
+	if !dirExists(src) {
+		return nil
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	var copied []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := rebasePath(path, src, dst)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if !dirExists(target) {
+				copied = append(copied, target)
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		// The originals are deleted once moved, so every copy is checked
+		copied = append(copied, target)
+		if _, err := copyVerified(context.Background(), path, target, nil); err != nil {
+			return err
+		}
+		// Served files are identified partly by their modification time
+		return os.Chtimes(target, info.ModTime(), info.ModTime())
+	})
+	if err != nil {
+		// Deepest first, so each folder is empty by the time it's removed
+		for i := len(copied) - 1; i >= 0; i-- {
+			os.Remove(copied[i])
+		}
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// rebasePath is path moved from under oldDir to the same place under
+// newDir. Paths outside oldDir are returned unchanged.
+func rebasePath(path, oldDir, newDir string) string {
+	if path == "" || (filepath.Clean(path) != filepath.Clean(oldDir) && !insideDir(path, oldDir)) {
+		return path
+	}
+	rel, _ := filepath.Rel(oldDir, path)
+	return filepath.Join(newDir, rel)
+}
+
+// rebaseFiles points the temp paths of files from oldDir to newDir
+func rebaseFiles(files []AudioFile, oldDir, newDir string) {
+	for i := range files {
+		files[i].TempPath = rebasePath(files[i].TempPath, oldDir, newDir)
+		files[i].ArtworkPath = rebasePath(files[i].ArtworkPath, oldDir, newDir)
+		files[i].TrimmedPath = rebasePath(files[i].TrimmedPath, oldDir, newDir)
+		files[i].ChaptersPath = rebasePath(files[i].ChaptersPath, oldDir, newDir)
+		files[i].TranscriptPath = rebasePath(files[i].TranscriptPath, oldDir, newDir)
+	}
+}
+
+// checkTempDirChange reports why the temp dir can't be moved to dir now
+func (p *Podcasterator) checkTempDirChange(dir string) error {
+	if p.serverRunning {
+		return fmt.Errorf("stop the server before moving the temp folder")
+	}
+	if err := p.tempDirErr(); err != nil {
+		return err
+	}
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("choose a full folder path")
+	}
+	dir = filepath.Clean(dir)
+	old := filepath.Clean(p.tempDir)
+	if dir == old {
+		return fmt.Errorf("the temp folder is already %s", dir)
+	}
+	if insideDir(dir, old) || insideDir(old, dir) {
+		return fmt.Errorf("the new temp folder can't be inside the current one, or hold it")
+	}
+	return checkStorageDir(dir, dirSize(old))
+}
+
+// useTempDir points everything at dir once the copies have been moved
+// there. custom is what's saved: dir, or empty for the platform default.
+func (p *Podcasterator) useTempDir(dir, custom string) {
+	old := p.tempDir
+	rebaseFiles(p.files, old, dir)
+	p.artworkPath = rebasePath(p.artworkPath, old, dir)
+	for i := range p.projects {
+		rebaseFiles(p.projects[i].Files, old, dir)
+		p.projects[i].ArtworkPath = rebasePath(p.projects[i].ArtworkPath, old, dir)
+	}
+	p.tempDir = dir
+	p.customTempDir = custom
+	p.saveState()
+}
+
+// tempDirMove is a move of the temp dir under way
+type tempDirMove struct {
+	dst, custom string
+	moved       chan struct{} // closed once the files are moved
+	err         error         // why they couldn't be, set before moved closes
+}
+
+// moveTempDirFiles moves the files of the temp dir; tests replace it to
+// look at the app while a move is under way
+var moveTempDirFiles = moveDir
+
+// moveTempDir moves the temp dir and everything in it to dir, custom being
+// what's saved: dir, or empty for the platform default. Undo history is
+// purged first, since the trash moves too. The files are moved off the UI
+// thread, and done is called back on it once they're in place.
+func (p *Podcasterator) moveTempDir(dir, custom string, done func(error)) {
+	// Checked again here, since the server may have been started or the
+	// folder unplugged while the move was being confirmed
+	if err := p.checkTempDirChange(dir); err != nil {
+		done(err)
+		return
+	}
+
+	p.purgeTrash()
+	// Nothing may write to the old folder while it moves: imports, drops
+	// and replacing audio are refused, and saves wait for the move
+	m := &tempDirMove{dst: filepath.Clean(dir), custom: custom, moved: make(chan struct{})}
+	p.movingTempDir = m
+	src := p.tempDir
+	go func() {
+		m.err = moveTempDirFiles(src, m.dst)
+		close(m.moved)
+		fyne.Do(func() {
+			// Unless quitting already finished it
+			if p.movingTempDir == m {
+				done(p.finishTempDirMove())
+			}
+		})
+	}()
+}
+
+// finishTempDirMove points everything at the new temp dir once the files
+// are moved, or stays with the old one if they couldn't be, and writes the
+// saves queued during the move
+func (p *Podcasterator) finishTempDirMove() error {
+	m := p.movingTempDir
+	p.movingTempDir = nil
+	if m.err != nil {
+		p.flushState()
+		return fmt.Errorf("couldn't move the temp folder: %w", m.err)
+	}
+	p.useTempDir(m.dst, m.custom)
+	return nil
+}
+
+// askChangeTempDir confirms moving the temp dir to dir, then moves it with
+// a progress dialog. An empty dir is the platform default.
+func (p *Podcasterator) askChangeTempDir(dir string, onDone func()) {
+	target := dir
+	if target == "" {
+		target = p.defaultTempDir
+	}
+	if err := p.checkTempDirChange(target); err != nil {
+		dialog.ShowError(err, p.window)
+		return
+	}
+
+	message := fmt.Sprintf("Move the copies of your files (%s) to\n%s?\n\nUndo history is cleared.",
+		formatBytes(dirSize(p.tempDir)), target)
+	dialog.ShowConfirm("Move Temp Folder", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		bar := widget.NewProgressBarInfinite()
+		progress := dialog.NewCustomWithoutButtons("Moving Files", container.NewVBox(widget.NewLabel("Moving to "+target), bar), p.window)
+		progress.Show()
+		p.moveTempDir(target, dir, func(err error) {
+			progress.Hide()
+			if err != nil {
+				dialog.ShowError(err, p.window)
+				return
+			}
+			onDone()
+		})
+	}, p.window)
+}
+
+// cleanupGrace keeps files written this recently out of a clean up, since
+// they may be copies still being made
+const cleanupGrace = 10 * time.Minute
+
+// filePaths is every file kept for file in the temp dir
+func filePaths(file AudioFile) []string {
+	return []string{file.TempPath, file.ArtworkPath, file.TrimmedPath, file.ChaptersPath, file.TranscriptPath}
+}
+
+// referencedPaths is every file the list, the other projects or the undo
+// history still use
+func (p *Podcasterator) referencedPaths() map[string]bool {
+	used := map[string]bool{}
+	add := func(paths ...string) {
+		for _, path := range paths {
+			if path != "" {
+				used[filepath.Clean(path)] = true
+			}
+		}
+	}
+	add(p.artworkPath)
+	for _, file := range p.files {
+		add(filePaths(file)...)
+	}
+	for _, pr := range p.projects {
+		add(pr.ArtworkPath)
+		for _, file := range pr.Files {
+			add(filePaths(file)...)
+		}
+	}
+	for _, record := range p.undoStack {
+		for _, t := range record.Files {
+			add(t.TrashPath)
+			add(filePaths(t.File)...)
+		}
+	}
+	return used
+}
+
+// unusedFiles lists the files under the temp dir nothing uses any more,
+// e.g. left by a crash mid-import, and their total size. Partial copies
+// are kept to be resumed (see copyResuming), so they're never listed.
+func (p *Podcasterator) unusedFiles() ([]string, int64) {
+	used := p.referencedPaths()
+	cutoff := time.Now().Add(-cleanupGrace)
+	var unused []string
+	var total int64
+	filepath.WalkDir(p.tempDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && d.Name() == partialDirName {
+			return fs.SkipDir
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || used[filepath.Clean(path)] || info.ModTime().After(cutoff) {
+			return nil
+		}
+		unused = append(unused, path)
+		total += info.Size()
+		return nil
+	})
+	return unused, total
+}
+
+// removeEmptyDirs removes the folders under dir left empty, deepest first;
+// dir itself and the folders of partial copies are kept
+func removeEmptyDirs(dir string) {
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && d.Name() == partialDirName {
+			return fs.SkipDir
+		}
+		if err == nil && d.IsDir() && path != dir {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i]) // fails unless empty
+	}
+}
+
+// cleanUpTempDir deletes the files in the temp dir nothing uses and returns
+// how much space that freed
+func (p *Podcasterator) cleanUpTempDir() (int64, error) {
+	if err := p.tempDirErr(); err != nil {
+		return 0, err
+	}
+	unused, _ := p.unusedFiles()
+	var freed int64
+	var failed []string
+	for _, path := range unused {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			failed = append(failed, filepath.Base(path))
+			continue
+		}
+		freed += info.Size()
+	}
+	removeEmptyDirs(p.tempDir)
+	if len(failed) > 0 {
+		return freed, fmt.Errorf("couldn't delete %d files: %v", len(failed), failed)
+	}
+	return freed, nil
+}
+
+// askCleanUpTempDir confirms deleting what nothing uses in the temp dir,
+// then deletes it
+func (p *Podcasterator) askCleanUpTempDir(onDone func()) {
+	if !p.requireTempDir() {
+		return
+	}
+	unused, size := p.unusedFiles()
+	if len(unused) == 0 {
+		dialog.ShowInformation("Clean Up", "Every file in the temp folder is in use.", p.window)
+		return
+	}
+
+	message := fmt.Sprintf("Delete %d files (%s) in the temp folder that no episode,\nproject or undo uses?", len(unused), formatBytes(size))
+	dialog.ShowConfirm("Clean Up Temp Folder", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		_, err := p.cleanUpTempDir()
+		onDone()
+		if err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	}, p.window)
+}
+
+// storageSection is the settings section for where copies are kept
+func (p *Podcasterator) storageSection() fyne.CanvasObject {
+	folderLabel := widget.NewLabel("")
+	folderLabel.Wrapping = fyne.TextWrapBreak
+	sizeLabel := widget.NewLabel("")
+	var defaultBtn *widget.Button
+	update := func() {
+		folderLabel.SetText(p.tempDir)
+		sizeLabel.SetText("Using " + formatBytes(dirSize(p.tempDir)))
+		if p.customTempDir == "" {
+			defaultBtn.Disable()
+		} else {
+			defaultBtn.Enable()
+		}
+	}
+
+	chooseBtn := widget.NewButton("Move…", func() {
+		dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+			if err != nil || folder == nil {
+				return
+			}
+			p.askChangeTempDir(folder.Path(), update)
+		}, p.window)
+	})
+	defaultBtn = widget.NewButton("Use Default", func() {
+		p.askChangeTempDir("", update)
+	})
+	cleanUpBtn := widget.NewButton("Clean Up…", func() {
+		p.askCleanUpTempDir(update)
+	})
+	update()
+
+	note := widget.NewLabel("Where copies of your files and artwork are kept. Choose an empty\nfolder, e.g. on a larger drive; the copies are moved there. Clean Up\ndeletes anything left there that's no longer used.")
+	note.Importance = widget.LowImportance
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("Temp Folder", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		folderLabel,
+		sizeLabel,
+		container.NewHBox(chooseBtn, defaultBtn, cleanUpBtn),
+		note,
+	)
+}
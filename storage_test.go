@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// =============================================================================
+// Temp Folder Tests
+// =============================================================================
+
+func TestRebasePath(t *testing.T) {
+	old, dst := filepath.FromSlash("/cache/podcasterator"), filepath.FromSlash("/mnt/big/pods")
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"", ""},
+		{"/cache/podcasterator/abc/one.mp3", "/mnt/big/pods/abc/one.mp3"},
+		{"/cache/podcasterator", "/mnt/big/pods"},
+		{"/cache/podcasterator-old/abc/one.mp3", "/cache/podcasterator-old/abc/one.mp3"},
+		{"/home/me/one.mp3", "/home/me/one.mp3"},
+	}
+	for _, tc := range tests {
+		path, want := filepath.FromSlash(tc.path), filepath.FromSlash(tc.want)
+		if got := rebasePath(path, old, dst); got != want {
+			t.Errorf("rebasePath(%q) = %q; want %q", path, got, want)
+		}
+	}
+}
+
+func TestCheckStorageDir(t *testing.T) {
+	root := t.TempDir()
+
+	missing := filepath.Join(root, "new", "storage")
+	if err := checkStorageDir(missing, 1000); err != nil {
+		t.Errorf("checkStorageDir() of a missing folder error = %v", err)
+	}
+	if !dirExists(missing) {
+		t.Error("checkStorageDir() didn't create the folder")
+	}
+	if entries, _ := os.ReadDir(missing); len(entries) != 0 {
+		t.Errorf("checkStorageDir() left %d files behind", len(entries))
+	}
+
+	busy := filepath.Join(root, "busy")
+	os.MkdirAll(busy, 0755)
+	os.WriteFile(filepath.Join(busy, "notes.txt"), []byte("mine"), 0644)
+	if err := checkStorageDir(busy, 0); err == nil {
+		t.Error("checkStorageDir() accepted a folder with files in it")
+	}
+
+	if err := checkStorageDir(missing, 1<<62); err == nil || !strings.Contains(err.Error(), "free") {
+		t.Errorf("checkStorageDir() needing more than the drive holds error = %v", err)
+	}
+}
+
+func TestMoveDir(t *testing.T) {
+	for _, merge := range []bool{false, true} {
+		name := "rename"
+		if merge {
+			name = "copy"
+		}
+		t.Run(name, func(t *testing.T) {
+			src, dst := filepath.Join(t.TempDir(), "src"), filepath.Join(t.TempDir(), "dst")
+			os.MkdirAll(filepath.Join(src, "abc"), 0755)
+			os.WriteFile(filepath.Join(src, "abc", "one.mp3"), []byte("audio"), 0644)
+			old := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+			os.Chtimes(filepath.Join(src, "abc", "one.mp3"), old, old)
+			os.MkdirAll(dst, 0755)
+			if merge {
+				// A rename can't replace a folder with files, so it's copied
+				os.WriteFile(filepath.Join(dst, "keep.txt"), []byte("keep"), 0644)
+			}
+
+			if err := moveDir(src, dst); err != nil {
+				t.Fatalf("moveDir() error = %v", err)
+			}
+			moved := filepath.Join(dst, "abc", "one.mp3")
+			if data, _ := os.ReadFile(moved); string(data) != "audio" {
+				t.Errorf("moved file holds %q", data)
+			}
+			if info, err := os.Stat(moved); err != nil || !info.ModTime().Equal(old) {
+				t.Errorf("moved file's mtime wasn't kept: %v", err)
+			}
+			if dirExists(src) {
+				t.Error("moveDir() left the source folder")
+			}
+		})
+	}
+
+	if err := moveDir(filepath.Join(t.TempDir(), "missing"), t.TempDir()); err != nil {
+		t.Errorf("moveDir() of a missing folder error = %v", err)
+	}
+
+	t.Run("failed copy", func(t *testing.T) {
+		src, dst := filepath.Join(t.TempDir(), "src"), t.TempDir()
+		os.MkdirAll(filepath.Join(src, "abc"), 0755)
+		os.WriteFile(filepath.Join(src, "abc", "one.mp3"), []byte("audio"), 0644)
+		os.WriteFile(filepath.Join(src, "two.mp3"), []byte("audio"), 0644)
+		// A folder in the way of two.mp3 fails its copy after abc's
+		os.MkdirAll(filepath.Join(dst, "two.mp3"), 0755)
+
+		if err := moveDir(src, dst); err == nil {
+			t.Fatal("moveDir() succeeded")
+		}
+		if dirExists(filepath.Join(dst, "abc")) {
+			t.Error("moveDir() left the files it had copied")
+		}
+		if !fileExists(filepath.Join(src, "abc", "one.mp3")) || !fileExists(filepath.Join(src, "two.mp3")) {
+			t.Error("moveDir() lost source files")
+		}
+	})
+}
+
+// moveTempDirAndWait moves the temp dir to dir and waits for the move to
+// finish
+func moveTempDirAndWait(t *testing.T, p *Podcasterator, dir, custom string) error {
+	t.Helper()
+	result := make(chan error, 1)
+	p.moveTempDir(dir, custom, func(err error) { result <- err })
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("the temp folder never finished moving")
+		return nil
+	}
+}
+
+func TestMoveTempDir(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.defaultTempDir = p.tempDir
+	p.artworkPath = filepath.Join(p.projectTempDir(), "artwork.jpg")
+	os.WriteFile(p.artworkPath, []byte("jpeg"), 0644)
+	other := filepath.Join(p.tempDir, "projects", "other", "x", "x.mp3")
+	os.MkdirAll(filepath.Dir(other), 0755)
+	os.WriteFile(other, []byte("other"), 0644)
+	p.projects = []Project{{ID: "other", Files: []AudioFile{{ID: "x", TempPath: other, DisplayName: "x.mp3"}}}}
+	p.deleteFile(0) // something in the trash
+
+	dir := filepath.Join(t.TempDir(), "storage")
+	if err := moveTempDirAndWait(t, p, dir, dir); err != nil {
+		t.Fatalf("moveTempDir() error = %v", err)
+	}
+	if p.tempDir != dir || p.customTempDir != dir {
+		t.Errorf("tempDir = %q, custom %q; want %q", p.tempDir, p.customTempDir, dir)
+	}
+	for _, path := range []string{p.files[0].TempPath, p.files[1].TempPath, p.artworkPath, p.projects[0].Files[0].TempPath} {
+		if !strings.HasPrefix(path, dir) || !fileExists(path) {
+			t.Errorf("%s wasn't moved into the new temp folder", path)
+		}
+	}
+	if dirExists(p.defaultTempDir) {
+		t.Error("the old temp folder is still there")
+	}
+	if len(p.undoStack) != 0 {
+		t.Error("undo history survived the move")
+	}
+
+	// The choice is saved and used on the next start
+	data, _ := os.ReadFile(filepath.Join(p.configDir, "state.json"))
+	var state AppState
+	json.Unmarshal(data, &state)
+	if state.TempDir != dir {
+		t.Errorf("saved temp_dir = %q; want %q", state.TempDir, dir)
+	}
+	next := &Podcasterator{tempDir: p.defaultTempDir, defaultTempDir: p.defaultTempDir, configDir: p.configDir}
+	next.loadState()
+	if next.tempDir != dir || next.tempDirUnavailable || len(next.files) != 2 {
+		t.Errorf("after restart tempDir = %q, unavailable %v, %d files", next.tempDir, next.tempDirUnavailable, len(next.files))
+	}
+
+	if err := moveTempDirAndWait(t, p, p.defaultTempDir, ""); err != nil {
+		t.Fatalf("moveTempDir() back to the default error = %v", err)
+	}
+	if p.tempDir != p.defaultTempDir || p.customTempDir != "" || !fileExists(p.files[0].TempPath) {
+		t.Errorf("after going back tempDir = %q, custom %q", p.tempDir, p.customTempDir)
+	}
+}
+
+func TestMoveTempDirQueuesSaves(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.saveState()
+	statePath := filepath.Join(p.configDir, "state.json")
+	before, _ := os.ReadFile(statePath)
+
+	release := make(chan struct{})
+	defer func() { moveTempDirFiles = moveDir }()
+	moveTempDirFiles = func(src, dst string) error {
+		<-release
+		return moveDir(src, dst)
+	}
+	result := make(chan error, 1)
+	dir := filepath.Join(t.TempDir(), "storage")
+	p.moveTempDir(dir, dir, func(err error) { result <- err })
+
+	if err := p.tempDirErr(); err == nil || !strings.Contains(err.Error(), "being moved") {
+		t.Errorf("tempDirErr() during the move = %v", err)
+	}
+	if _, err := p.prepareReplace(0, p.files[1].TempPath); err == nil {
+		t.Error("prepareReplace() wrote into a temp folder being moved")
+	}
+	p.podcastName = "Renamed mid-move"
+	p.markDirty()
+	p.saveState()
+	if data, _ := os.ReadFile(statePath); string(data) != string(before) {
+		t.Error("state was saved into a temp folder being moved")
+	}
+
+	close(release)
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("moveTempDir() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the temp folder never finished moving")
+	}
+	if p.movingTempDir != nil || p.tempDirErr() != nil {
+		t.Error("the temp folder is still flagged as moving")
+	}
+	data, _ := os.ReadFile(statePath)
+	var state AppState
+	json.Unmarshal(data, &state)
+	if state.PodcastName != "Renamed mid-move" || state.TempDir != dir {
+		t.Errorf("saved podcast name %q, temp_dir %q; want the edit made during the move", state.PodcastName, state.TempDir)
+	}
+}
+
+func TestMoveTempDirRefused(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+
+	tests := []struct {
+		name  string
+		dir   string
+		setup func()
+	}{
+		{"same folder", p.tempDir, func() {}},
+		{"inside the current one", filepath.Join(p.tempDir, "inner"), func() {}},
+		{"holding the current one", filepath.Dir(p.tempDir), func() {}},
+		{"relative", "storage", func() {}},
+		{"folder unavailable", t.TempDir(), func() { p.tempDirUnavailable = true }},
+		{"already moving", t.TempDir(), func() { p.tempDirUnavailable, p.movingTempDir = false, &tempDirMove{} }},
+		{"server running", t.TempDir(), func() { p.movingTempDir, p.serverRunning = nil, true }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setup()
+			before := p.files[0].TempPath
+			if err := moveTempDirAndWait(t, p, tc.dir, tc.dir); err == nil {
+				t.Error("moveTempDir() succeeded")
+			}
+			if p.files[0].TempPath != before || !fileExists(before) {
+				t.Error("a refused change moved files")
+			}
+		})
+	}
+}
+
+func TestCleanUpTempDir(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3", "c.mp3")
+
+	old := time.Now().Add(-2 * cleanupGrace)
+	write := func(path string) string {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("data"), 0644)
+		os.Chtimes(path, old, old)
+		return path
+	}
+	for _, f := range p.files {
+		os.Chtimes(f.TempPath, old, old)
+	}
+	p.files[0].TranscriptPath = write(filepath.Join(filepath.Dir(p.files[0].TempPath), "transcript.vtt"))
+	p.artworkPath = write(filepath.Join(p.tempDir, "artwork.jpg"))
+	other := write(filepath.Join(p.tempDir, "projects", "other", "x", "x.mp3"))
+	p.projects = []Project{{ID: "other", Files: []AudioFile{{ID: "x", TempPath: other}}}}
+	p.deleteFile(2)
+	trashed := p.undoStack[0].Files[0].TrashPath
+	os.Chtimes(trashed, old, old)
+
+	orphan := write(filepath.Join(p.tempDir, "gone", "episode.mp3"))
+	stale := write(filepath.Join(p.tempDir, "covers", "gone.jpg"))
+	copying := filepath.Join(p.tempDir, "new", "episode.mp3")
+	os.MkdirAll(filepath.Dir(copying), 0755)
+	os.WriteFile(copying, []byte("partial"), 0644)
+	// Copies cut short are kept to resume, however old
+	resumable := write(filepath.Join(p.projectTempDir(), partialDirName, "abc", "data"))
+
+	unused, size := p.unusedFiles()
+	if len(unused) != 2 || size != 8 {
+		t.Fatalf("unusedFiles() = %v, %d bytes; want the two left over", unused, size)
+	}
+	freed, err := p.cleanUpTempDir()
+	if err != nil || freed != 8 {
+		t.Fatalf("cleanUpTempDir() = %d, %v", freed, err)
+	}
+	if fileExists(orphan) || fileExists(stale) || dirExists(filepath.Dir(orphan)) {
+		t.Error("files nothing uses, or their folders, were left behind")
+	}
+	for _, path := range []string{p.files[0].TempPath, p.files[1].TempPath, p.files[0].TranscriptPath, p.artworkPath, other, trashed, copying, resumable} {
+		if !fileExists(path) {
+			t.Errorf("%s was deleted while in use", path)
+		}
+	}
+	if err := p.undo(); err != nil {
+		t.Errorf("undo() after a clean up error = %v", err)
+	}
+}
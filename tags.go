@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dhowden/tag"
+)
+
+// maxTagNameLength keeps names built from tag titles within file system limits
+const maxTagNameLength = 200
+
+// audioTags is the embedded ID3 or MP4 metadata of an audio file
+type audioTags struct {
+	Title  string
+	Artist string
+	Album  string
+	Track  int
+}
+
+// readTags reads the embedded metadata of the audio file at path
+func readTags(path string) (audioTags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return audioTags{}, err
+	}
+	defer f.Close()
+
+	m, err := tag.ReadFrom(f)
+	if err != nil {
+		return audioTags{}, err
+	}
+	track, _ := m.Track()
+	return audioTags{
+		Title:  strings.TrimSpace(m.Title()),
+		Artist: strings.TrimSpace(m.Artist()),
+		Album:  strings.TrimSpace(m.Album()),
+		Track:  track,
+	}, nil
+}
+
+// apply copies the tags that are stored on the file
+func (t audioTags) apply(f *AudioFile) {
+	f.Artist = t.Artist
+	f.Album = t.Album
+	f.Track = t.Track
+}
+
+// tagFileName turns a tag title into a file name with ext. Characters that
+// aren't allowed in file names on some systems are replaced. It returns ""
+// when nothing usable is left.
+func tagFileName(title, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, title)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if len(name) > maxTagNameLength {
+		name = strings.ToValidUTF8(name[:maxTagNameLength], "")
+	}
+	if strings.Trim(name, "_ ") == "" {
+		return ""
+	}
+	return name + ext
+}
+
+// sortByTrack orders files by their track number tag. Files without one
+// keep their relative order after the numbered files.
+func (p *Podcasterator) sortByTrack() {
+	if len(p.files) <= 1 {
+		return
+	}
+
+	sort.SliceStable(p.files, func(i, j int) bool {
+		a, b := p.files[i].Track, p.files[j].Track
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+
+	if p.fileList != nil {
+		p.fileList.Refresh()
+	}
+	p.markDirty()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Embedded Tag Tests
+// =============================================================================
+
+// id3v23 builds an ID3v2.3 tag of Latin-1 text frames
+func id3v23(frames map[string]string) []byte {
+	var body []byte
+	for _, id := range []string{"TIT2", "TPE1", "TALB", "TRCK"} {
+		value, ok := frames[id]
+		if !ok {
+			continue
+		}
+		body = append(body, id...)
+		body = binary.BigEndian.AppendUint32(body, uint32(1+len(value)))
+		body = append(body, 0, 0, 0) // flags, then ISO-8859-1 encoding
+		body = append(body, value...)
+	}
+
+	size := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(header, body...)
+}
+
+// mp4TextItem builds an ilst item holding a UTF-8 data atom
+func mp4TextItem(name, value string) []byte {
+	data := append([]byte{0, 0, 0, 1, 0, 0, 0, 0}, value...)
+	return mp4Atom(name, mp4Atom("data", data))
+}
+
+func TestReadTags(t *testing.T) {
+	trkn := mp4Atom("trkn", mp4Atom("data", []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 7, 0, 9, 0, 0}))
+	mp4 := bytes.Join([][]byte{
+		mp4Atom("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		mp4Atom("moov", mp4Atom("udta", mp4Atom("meta", []byte{0, 0, 0, 0},
+			mp4Atom("ilst",
+				mp4TextItem("\xa9nam", "Chapter Seven"),
+				mp4TextItem("\xa9ART", "A. Reader"),
+				mp4TextItem("\xa9alb", "The Book"),
+				trkn,
+			),
+		))),
+	}, nil)
+
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want audioTags
+	}{
+		{
+			"id3", "a.mp3",
+			append(id3v23(map[string]string{"TIT2": "Lecture 3", "TPE1": "Prof", "TALB": "Course", "TRCK": "3/12"}), mp3Frames(5)...),
+			audioTags{Title: "Lecture 3", Artist: "Prof", Album: "Course", Track: 3},
+		},
+		{
+			"id3 title only", "a.mp3",
+			append(id3v23(map[string]string{"TIT2": "  Padded  "}), mp3Frames(5)...),
+			audioTags{Title: "Padded"},
+		},
+		{"mp4", "a.m4a", mp4, audioTags{Title: "Chapter Seven", Artist: "A. Reader", Album: "The Book", Track: 7}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readTags(writeAudio(t, tc.file, tc.data))
+			if err != nil {
+				t.Fatalf("readTags() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("readTags() = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadTagsUntagged(t *testing.T) {
+	if tags, err := readTags(writeAudio(t, "a.mp3", mp3Frames(5))); err == nil && tags.Title != "" {
+		t.Errorf("readTags() on an untagged file = %+v", tags)
+	}
+}
+
+func TestTagFileName(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Lecture 3", "Lecture 3.mp3"},
+		{"AC/DC: Live?", "AC_DC_ Live_.mp3"},
+		{"  ..Hidden.. ", "Hidden.mp3"},
+		{"", ""},
+		{"///", ""},
+		{strings.Repeat("x", 300), strings.Repeat("x", maxTagNameLength) + ".mp3"},
+	}
+
+	for _, tc := range tests {
+		if got := tagFileName(tc.title, ".mp3"); got != tc.want {
+			t.Errorf("tagFileName(%q) = %q; want %q", tc.title, got, tc.want)
+		}
+	}
+}
+
+func TestAddFileUsesTagTitle(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	tagged := filepath.Join(dir, "track01.mp3")
+	os.WriteFile(tagged, append(id3v23(map[string]string{"TIT2": "Opening", "TPE1": "Band", "TALB": "Album", "TRCK": "1"}), mp3Frames(5)...), 0644)
+	untagged := filepath.Join(dir, "track02.mp3")
+	os.WriteFile(untagged, mp3Frames(5), 0644)
+
+	p.addFile(tagged)
+	p.addFile(untagged)
+
+	if len(p.files) != 2 {
+		t.Fatalf("got %d files; want 2", len(p.files))
+	}
+	if got := p.files[0]; got.DisplayName != "Opening.mp3" || got.Artist != "Band" || got.Album != "Album" || got.Track != 1 {
+		t.Errorf("tagged file = %+v", got)
+	}
+	if filepath.Base(p.files[0].TempPath) != "Opening.mp3" {
+		t.Errorf("tagged temp file = %s; want it named after the title", p.files[0].TempPath)
+	}
+	if got := p.files[1].DisplayName; got != "track02.mp3" {
+		t.Errorf("untagged DisplayName = %q; want the file name", got)
+	}
+}
+
+func TestSortByTrack(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.files = []AudioFile{
+		{ID: "a", DisplayName: "a", Track: 3},
+		{ID: "b", DisplayName: "b"},
+		{ID: "c", DisplayName: "c", Track: 1},
+		{ID: "d", DisplayName: "d"},
+		{ID: "e", DisplayName: "e", Track: 2},
+	}
+	p.sortByTrack()
+
+	if got := strings.Join(displayNames(p.files), ","); got != "c,e,a,b,d" {
+		t.Errorf("order = %s; want c,e,a,b,d", got)
+	}
+	if !p.dirty {
+		t.Error("sortByTrack() didn't mark the state dirty")
+	}
+}
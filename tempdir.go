@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// tempDirMissingFor reports whether saved files have copies in the temp dir
+// but the temp dir itself is gone, e.g. because its drive is unplugged. A
+// missing dir with nothing in it is just a first run.
+func (p *Podcasterator) tempDirMissingFor(files []AudioFile) bool {
+	if dirExists(p.tempDir) {
+		return false
+	}
+	for _, f := range files {
+		if !f.IsExternal() && f.TempPath != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureTempDir creates the temp dir unless it's flagged unavailable, so an
+// unmounted drive's mount point isn't filled with an empty folder.
+func (p *Podcasterator) ensureTempDir() {
+	if !p.tempDirUnavailable {
+		os.MkdirAll(p.tempDir, 0755)
+	}
+}
+
+// tempDirErr says why nothing can be written into the temp dir right now:
+// it's missing, or it's being moved. It's nil otherwise.
+func (p *Podcasterator) tempDirErr() error {
+	switch {
+	case p.tempDirUnavailable:
+		return fmt.Errorf("the temp folder %s is not available", p.tempDir)
+	case p.movingTempDir != nil:
+		return fmt.Errorf("the temp folder is being moved; try again once it's done")
+	}
+	return nil
+}
+
+// requireTempDir returns false, telling the user why, while the temp dir is
+// unavailable or being moved. Anything that writes into the temp dir checks
+// it first.
+func (p *Podcasterator) requireTempDir() bool {
+	err := p.tempDirErr()
+	if err == nil {
+		return true
+	}
+	if p.window != nil {
+		dialog.ShowError(err, p.window)
+	}
+	return false
+}
+
+// retryTempDir reloads the saved state if the temp dir has come back. The
+// state file wasn't written while it was missing, so nothing was lost.
+func (p *Podcasterator) retryTempDir() bool {
+	if !dirExists(p.tempDir) {
+		return false
+	}
+	p.tempDirUnavailable = false
+	p.loadState()
+	p.refreshFileViews()
+	p.startWatching()
+	return true
+}
+
+// discardMissingTempFiles gives up on the missing temp dir: files whose
+// copies lived there are dropped and a fresh temp dir is created.
+func (p *Podcasterator) discardMissingTempFiles() {
+	p.tempDirUnavailable = false
+	kept := []AudioFile{}
+	for _, f := range p.files {
+		if f.IsExternal() || f.refreshStat() == nil {
+			kept = append(kept, f)
+		}
+	}
+	p.files = kept
+	p.ensureTempDir()
+	p.refreshFileViews()
+	p.startWatching()
+	p.markDirty()
+}
+
+// refreshFileViews redraws everything that shows the file list
+func (p *Podcasterator) refreshFileViews() {
+	if p.fileList != nil {
+		p.fileList.UnselectAll()
+		p.fileList.Refresh()
+	}
+	p.hideEpisodeDetail()
+	p.updateFileCount()
+	p.updateBulkBar()
+}
+
+// showTempDirMissing explains that the temp dir is gone and offers to wait
+// for it (e.g. plug the drive back in) or carry on without those files.
+func (p *Podcasterator) showTempDirMissing() {
+	if p.window == nil {
+		return
+	}
+
+	message := fmt.Sprintf(
+		"The temp folder holding your %d files can't be found:\n%s\n\n"+
+			"If it's on a drive that's unplugged, reconnect it and choose Retry.\n"+
+			"Your saved list is left untouched until then.\n\n"+
+			"Continue drops those files from the list.",
+		len(p.files), p.tempDir)
+
+	dialog.ShowCustomConfirm("Temp Folder Missing", "Retry", "Continue Without Them",
+		widget.NewLabel(message), func(retry bool) {
+			if !retry {
+				p.discardMissingTempFiles()
+				return
+			}
+			if !p.retryTempDir() {
+				p.showTempDirMissing()
+			}
+		}, p.window)
+}
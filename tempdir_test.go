@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// =============================================================================
+// Missing Temp Dir Tests
+// =============================================================================
+
+func TestMissingTempDirKeepsState(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	src := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := os.WriteFile(src, []byte("audio"), 0644); err != nil {
+		t.Fatalf("Failed to create source file: %v", err)
+	}
+	p.addFile(src)
+	p.saveState()
+
+	statePath := filepath.Join(p.configDir, "state.json")
+	goodState, _ := os.ReadFile(statePath)
+
+	// Simulate the drive being unplugged
+	unplugged := filepath.Join(t.TempDir(), "unplugged")
+	if err := os.Rename(p.tempDir, unplugged); err != nil {
+		t.Fatalf("Failed to move temp dir: %v", err)
+	}
+
+	p2 := &Podcasterator{tempDir: p.tempDir, configDir: p.configDir}
+	p2.loadState()
+	p2.ensureTempDir()
+
+	if !p2.tempDirUnavailable {
+		t.Fatal("loadState() did not flag the missing temp dir")
+	}
+	if len(p2.files) != 1 {
+		t.Errorf("loadState() kept %d files; want 1", len(p2.files))
+	}
+	if dirExists(p2.tempDir) {
+		t.Error("ensureTempDir() recreated the missing temp dir")
+	}
+
+	p2.podcastName = "Changed while missing"
+	p2.saveState()
+	if data, _ := os.ReadFile(statePath); !bytes.Equal(data, goodState) {
+		t.Error("saveState() overwrote the state file while the temp dir was missing")
+	}
+	if p2.launchServer() {
+		p2.shutdownServer()
+		t.Error("launchServer() started while the temp dir was missing")
+	}
+	if p2.retryTempDir() {
+		t.Error("retryTempDir() succeeded while the temp dir was still missing")
+	}
+
+	// Plug the drive back in
+	if err := os.Rename(unplugged, p.tempDir); err != nil {
+		t.Fatalf("Failed to restore temp dir: %v", err)
+	}
+	if !p2.retryTempDir() {
+		t.Fatal("retryTempDir() failed after the temp dir came back")
+	}
+	if p2.tempDirUnavailable || len(p2.files) != 1 || !fileExists(p2.files[0].TempPath) {
+		t.Errorf("after retry: unavailable=%v files=%+v", p2.tempDirUnavailable, p2.files)
+	}
+}
+
+func TestDiscardMissingTempFiles(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.tempDirUnavailable = true
+	p.files = []AudioFile{
+		{ID: "local", TempPath: filepath.Join(p.tempDir, "gone", "a.mp3")},
+		{ID: "ext", ExternalURL: "https://example.com/b.mp3"},
+	}
+	os.RemoveAll(p.tempDir)
+
+	p.discardMissingTempFiles()
+
+	if p.tempDirUnavailable {
+		t.Error("discardMissingTempFiles() left the temp dir flagged")
+	}
+	if len(p.files) != 1 || p.files[0].ID != "ext" {
+		t.Errorf("files = %+v; want only the external file", p.files)
+	}
+	if !dirExists(p.tempDir) {
+		t.Error("discardMissingTempFiles() did not create a fresh temp dir")
+	}
+}
+
+func TestFirstRunIsNotMissingTempDir(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	os.RemoveAll(p.tempDir)
+	if p.tempDirMissingFor(nil) {
+		t.Error("tempDirMissingFor() with no files reported a missing temp dir")
+	}
+	ext := []AudioFile{{ExternalURL: "https://example.com/a.mp3"}}
+	if p.tempDirMissingFor(ext) {
+		t.Error("tempDirMissingFor() with only external files reported a missing temp dir")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// certValidity is how long a generated certificate lasts. Apple platforms
+// reject server certificates valid for more than 825 days.
+const certValidity = 825 * 24 * time.Hour
+
+// certRenewBefore is how close to expiry a certificate is replaced
+const certRenewBefore = 30 * 24 * time.Hour
+
+// certDir is where the self-signed certificate and its key are kept. It's in
+// the config dir so the certificate users have trusted survives restarts.
+func (p *Podcasterator) certDir() string {
+	return filepath.Join(p.configDir, "tls")
+}
+
+// certPaths are the certificate and key files in dir
+func certPaths(dir string) (certPath, keyPath string) {
+	return filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+}
+
+// serverScheme is the URL scheme the server is reached with
+func (p *Podcasterator) serverScheme() string {
+	if p.useHTTPS {
+		return "https"
+	}
+	return "http"
+}
+
+// serverTLSConfig is the TLS setup for the server, or nil when serving plain
+// HTTP. The certificate covers the address the feed is advertised at.
+func (p *Podcasterator) serverTLSConfig() (*tls.Config, error) {
+	if !p.useHTTPS {
+		return nil, nil
+	}
+	cert, err := loadOrCreateCertificate(p.certDir(), []string{p.advertisedHost(), mdnsHost()}, certHosts(p.advertisedHost()))
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
+
+// certHosts are the names and addresses a new certificate is issued for:
+// this computer under every name the server can be reached at, so changing
+// the feed address doesn't mean trusting a new certificate
+func certHosts(advertised string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1", mdnsHost()}
+	for _, a := range networkAddresses() {
+		hosts = append(hosts, a.IP)
+	}
+	return append(hosts, advertised)
+}
+
+// loadOrCreateCertificate loads the certificate in dir, replacing it with a
+// new self-signed one for hosts if it's missing, unreadable, close to
+// expiry or doesn't cover every name in need
+func loadOrCreateCertificate(dir string, need, hosts []string) (tls.Certificate, error) {
+	certPath, keyPath := certPaths(dir)
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil && certCovers(cert, need, time.Now()) {
+		return cert, nil
+	}
+
+	certPEM, keyPEM, err := generateCertificate(hosts, time.Now())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writeFileAtomic(keyPath, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := writeFileAtomic(certPath, certPEM, 0644); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// certCovers reports whether cert is valid at now, for a while yet, for
+// every name in need
+func certCovers(cert tls.Certificate, need []string, now time.Time) bool {
+	if len(cert.Certificate) == 0 {
+		return false
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return false
+	}
+	if now.Before(leaf.NotBefore) || now.Add(certRenewBefore).After(leaf.NotAfter) {
+		return false
+	}
+	for _, host := range need {
+		if leaf.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// generateCertificate makes a self-signed ECDSA certificate for hosts, each
+// either an IP address or a DNS name, and returns it and its key as PEM
+func generateCertificate(hosts []string, now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "Podcasterator", Organization: []string{"Podcasterator"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+
+		BasicConstraintsValid: true,
+	}
+	seen := map[string]bool{}
+	for _, h := range hosts {
+		if h == "" || seen[h] {
+			continue
+		}
+		seen[h] = true
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
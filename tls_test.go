@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// HTTPS Tests
+// =============================================================================
+
+func TestGenerateCertificate(t *testing.T) {
+	now := time.Now()
+	certPEM, keyPEM, err := generateCertificate([]string{"localhost", "192.168.1.20", "", "localhost", "::1"}, now)
+	if err != nil {
+		t.Fatalf("generateCertificate() error = %v", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("generated pair doesn't load: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+
+	if strings.Join(leaf.DNSNames, ",") != "localhost" || len(leaf.IPAddresses) != 2 {
+		t.Errorf("SANs = %v %v; want localhost and two IPs", leaf.DNSNames, leaf.IPAddresses)
+	}
+	if !certCovers(cert, []string{"localhost", "192.168.1.20", "::1"}, now) {
+		t.Error("certCovers() = false for the names it was issued for")
+	}
+	if certCovers(cert, []string{"10.0.0.5"}, now) {
+		t.Error("certCovers() = true for an address it wasn't issued for")
+	}
+	if certCovers(cert, []string{"localhost"}, now.Add(certValidity-certRenewBefore/2)) {
+		t.Error("certCovers() = true close to expiry")
+	}
+}
+
+func TestLoadOrCreateCertificate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tls")
+
+	first, err := loadOrCreateCertificate(dir, []string{"localhost"}, []string{"localhost", "192.168.1.20"})
+	if err != nil {
+		t.Fatalf("loadOrCreateCertificate() error = %v", err)
+	}
+	_, keyPath := certPaths(dir)
+	if info, err := os.Stat(keyPath); err != nil {
+		t.Fatalf("key not saved: %v", err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v; want 0600", info.Mode().Perm())
+	}
+
+	// An existing certificate that covers what's needed is kept, so users
+	// don't have to trust a new one
+	again, _ := loadOrCreateCertificate(dir, []string{"192.168.1.20"}, []string{"localhost", "10.0.0.5"})
+	if string(again.Certificate[0]) != string(first.Certificate[0]) {
+		t.Error("certificate replaced though it still covers the address")
+	}
+
+	// A new address it doesn't cover gets a new certificate
+	moved, _ := loadOrCreateCertificate(dir, []string{"10.0.0.5"}, []string{"localhost", "10.0.0.5"})
+	if string(moved.Certificate[0]) == string(first.Certificate[0]) || !certCovers(moved, []string{"10.0.0.5"}, time.Now()) {
+		t.Error("certificate not replaced for an address it doesn't cover")
+	}
+}
+
+func TestLaunchServerHTTPS(t *testing.T) {
+	p, _, cleanup := newFileServerFixture(t)
+	defer cleanup()
+	p.localOnly = true
+	p.port = 0
+	p.useHTTPS = true
+
+	if !p.launchServer() {
+		t.Fatal("launchServer() failed")
+	}
+	defer p.shutdownServer()
+
+	if !strings.HasPrefix(p.serverURL, "https://localhost:") {
+		t.Errorf("serverURL = %s; want https://localhost", p.serverURL)
+	}
+
+	// A client that trusts the saved certificate validates the server
+	certPath, _ := certPaths(p.certDir())
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatalf("certificate not saved: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	resp, err := client.Get(p.serverURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", p.serverURL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET %s = %d; want 200", p.serverURL, resp.StatusCode)
+	}
+}
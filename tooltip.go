@@ -0,0 +1,154 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// tooltipDelay is how long the pointer rests on a button before its tip
+// shows
+const tooltipDelay = 600 * time.Millisecond
+
+// tooltipAfter schedules a tip to show; tests replace it to show tips
+// without waiting on a timer
+var tooltipAfter = time.AfterFunc
+
+// tooltipLayer floats button tips over the window content. Nothing in it
+// is hoverable or tappable, so the pointer still reaches the button under a
+// tip.
+type tooltipLayer struct {
+	layer *fyne.Container
+	tip   *fyne.Container
+	bg    *canvas.Rectangle
+	text  *canvas.Text
+	owner fyne.CanvasObject // button whose tip is showing
+}
+
+func newTooltipLayer() *tooltipLayer {
+	l := &tooltipLayer{bg: canvas.NewRectangle(nil), text: canvas.NewText("", nil)}
+	l.text.TextSize = theme.CaptionTextSize()
+	l.bg.CornerRadius = theme.InputRadiusSize()
+	l.tip = container.NewStack(l.bg, container.NewPadded(l.text))
+	l.tip.Hide()
+	l.layer = container.NewWithoutLayout(l.tip)
+	return l
+}
+
+// wrap stacks the layer over the window content
+func (l *tooltipLayer) wrap(content fyne.CanvasObject) fyne.CanvasObject {
+	return container.NewStack(content, l.layer)
+}
+
+// show puts text just below owner, or above it near the bottom of the
+// window, kept inside the window's width
+func (l *tooltipLayer) show(owner fyne.CanvasObject, text string) {
+	l.bg.FillColor = theme.Color(theme.ColorNameOverlayBackground)
+	l.bg.StrokeColor = theme.Color(theme.ColorNameShadow)
+	l.bg.StrokeWidth = 1
+	l.text.Color = theme.Color(theme.ColorNameForeground)
+	l.text.Text = text
+	size := l.tip.MinSize()
+	l.tip.Resize(size)
+
+	d := fyne.CurrentApp().Driver()
+	pos := d.AbsolutePositionForObject(owner).Subtract(d.AbsolutePositionForObject(l.layer))
+	bounds := l.layer.Size()
+	below := pos.Y + owner.Size().Height + theme.Padding()
+	if below+size.Height > bounds.Height {
+		pos.Y -= size.Height + theme.Padding()
+	} else {
+		pos.Y = below
+	}
+	pos.X = max(0, min(pos.X, bounds.Width-size.Width))
+	l.tip.Move(pos)
+
+	l.owner = owner
+	l.tip.Show()
+	l.tip.Refresh()
+}
+
+// hide takes down owner's tip; another button's is left alone
+func (l *tooltipLayer) hide(owner fyne.CanvasObject) {
+	if l.owner != owner {
+		return
+	}
+	l.owner = nil
+	l.tip.Hide()
+}
+
+// tipButton is a button that describes its action in a tip when the
+// pointer rests on it, including while it's disabled
+type tipButton struct {
+	widget.Button
+	tip     string
+	tips    *tooltipLayer
+	hovered bool
+	timer   *time.Timer
+}
+
+// newTipButton makes a button with tip shown on the window's tooltip
+// layer. Without one, e.g. in tests, it's a plain button.
+func (p *Podcasterator) newTipButton(label string, icon fyne.Resource, tip string, tapped func()) *tipButton {
+	b := &tipButton{tip: tip, tips: p.tooltips}
+	b.Text = label
+	b.Icon = icon
+	b.OnTapped = tapped
+	b.ExtendBaseWidget(b)
+	return b
+}
+
+// SetTip changes the tip, updating it if it's showing
+func (b *tipButton) SetTip(tip string) {
+	b.tip = tip
+	if b.tips != nil && b.tips.owner == b {
+		b.tips.show(b, tip)
+	}
+}
+
+// MouseIn implements desktop.Hoverable
+func (b *tipButton) MouseIn(e *desktop.MouseEvent) {
+	b.Button.MouseIn(e)
+	b.hovered = true
+	if b.tips == nil || b.tip == "" {
+		return
+	}
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.timer = tooltipAfter(tooltipDelay, func() {
+		fyne.Do(func() {
+			if b.hovered && b.Visible() {
+				b.tips.show(b, b.tip)
+			}
+		})
+	})
+}
+
+// MouseOut implements desktop.Hoverable
+func (b *tipButton) MouseOut() {
+	b.Button.MouseOut()
+	b.hideTip()
+}
+
+// Tapped implements fyne.Tappable; the tip goes once the button is used
+func (b *tipButton) Tapped(e *fyne.PointEvent) {
+	b.hideTip()
+	b.Button.Tapped(e)
+}
+
+func (b *tipButton) hideTip() {
+	b.hovered = false
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if b.tips != nil {
+		b.tips.hide(b)
+	}
+}
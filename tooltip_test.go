@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+)
+
+// =============================================================================
+// Tooltip Tests
+// =============================================================================
+
+// pendingTips holds tips scheduled by tipButtons instead of starting
+// timers; call the returned func to show the latest one as its delay runs out
+func pendingTips(t *testing.T) func() {
+	var pending func()
+	tooltipAfter = func(_ time.Duration, f func()) *time.Timer {
+		pending = f
+		return time.NewTimer(time.Hour)
+	}
+	t.Cleanup(func() { tooltipAfter = time.AfterFunc })
+	return func() {
+		if pending != nil {
+			pending()
+		}
+	}
+}
+
+func TestTipButton(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.tooltips = newTooltipLayer()
+	elapse := pendingTips(t)
+
+	tapped := 0
+	btn := p.newTipButton("Go", nil, "Does the thing", func() { tapped++ })
+	w := test.NewTempWindow(t, p.tooltips.wrap(container.NewVBox(btn)))
+	w.Resize(fyne.NewSize(400, 300))
+
+	btn.MouseIn(&desktop.MouseEvent{})
+	if p.tooltips.tip.Visible() {
+		t.Fatal("tip showed before the delay")
+	}
+	elapse()
+	if !p.tooltips.tip.Visible() {
+		t.Fatal("tip didn't show while hovering")
+	}
+	if p.tooltips.text.Text != "Does the thing" {
+		t.Errorf("tip = %q", p.tooltips.text.Text)
+	}
+	if tip, below := p.tooltips.tip.Position(), btn.Position().Y+btn.Size().Height; tip.Y < below {
+		t.Errorf("tip at %v; want it below the button (y >= %v)", tip, below)
+	}
+
+	btn.MouseOut()
+	if p.tooltips.tip.Visible() {
+		t.Error("tip still showing after the pointer left")
+	}
+
+	// Tapping takes the tip down before it shows
+	btn.MouseIn(&desktop.MouseEvent{})
+	test.Tap(btn)
+	elapse()
+	if visible := p.tooltips.tip.Visible(); tapped != 1 || visible {
+		t.Errorf("tapped %d times, tip showing %v; want tapped once and no tip", tapped, visible)
+	}
+
+	// Disabled buttons still explain themselves
+	btn.Disable()
+	btn.MouseIn(&desktop.MouseEvent{})
+	elapse()
+	if !p.tooltips.tip.Visible() {
+		t.Error("disabled button showed no tip")
+	}
+	btn.SetTip("Can't do the thing yet")
+	if p.tooltips.text.Text != "Can't do the thing yet" {
+		t.Errorf("tip after SetTip = %q", p.tooltips.text.Text)
+	}
+	btn.MouseOut()
+}
+
+func TestTooltipLayerNearBottom(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.tooltips = newTooltipLayer()
+
+	btn := p.newTipButton("Go", nil, "Does the thing", nil)
+	w := test.NewTempWindow(t, p.tooltips.wrap(container.NewBorder(nil, btn, nil, nil)))
+	w.Resize(fyne.NewSize(400, 300))
+
+	p.tooltips.show(btn, btn.tip)
+	if tip := p.tooltips.tip; tip.Position().Y+tip.Size().Height > btn.Position().Y {
+		t.Errorf("tip at %v over a button at %v; want it above", tip.Position(), btn.Position())
+	}
+}
+
+func TestTipButtonWithoutLayer(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	tapped := false
+	btn := p.newTipButton("Go", nil, "Does the thing", func() { tapped = true })
+	btn.MouseIn(&desktop.MouseEvent{})
+	btn.Tapped(&fyne.PointEvent{})
+	btn.MouseOut()
+	if !tapped {
+		t.Error("button without a tooltip layer didn't tap")
+	}
+}
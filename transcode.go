@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// transcodedExtensions are accepted as sources but converted to AAC in an
+// M4A container on import, since podcast apps can't be relied on to play them
+var transcodedExtensions = []string{".wav", ".flac", ".ogg"}
+
+// transcodeExtension is the extension of transcoded files, served as audio/mp4
+const transcodeExtension = ".m4a"
+
+// transcodeBitrate is the AAC bitrate used for transcoded files
+const transcodeBitrate = "192k"
+
+var errFFmpegMissing = errors.New("ffmpeg is required to add WAV, FLAC and OGG files")
+
+// lookFFmpeg finds the ffmpeg binary. Tests replace it.
+var lookFFmpeg = func() (string, error) {
+	return exec.LookPath("ffmpeg")
+}
+
+// needsTranscode reports whether the file at path is converted on import
+func needsTranscode(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, t := range transcodedExtensions {
+		if ext == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ffmpegAvailable reports whether files can be transcoded
+func ffmpegAvailable() bool {
+	_, err := lookFFmpeg()
+	return err == nil
+}
+
+// transcodeWithProgress converts src to AAC at dst with ffmpeg. The source is
+// streamed to ffmpeg through a pipe so progress is reported against its size
+// and it's hashed on the way, as copyWithProgress does. Cancelling ctx stops
+// ffmpeg; dst is removed on any error.
+func transcodeWithProgress(ctx context.Context, src, dst string, onProgress func(written, total int64)) (string, error) {
+	ffmpeg, err := lookFFmpeg()
+	if err != nil {
+		return "", errFFmpegMissing
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	counter := &progressWriter{total: info.Size(), onProgress: onProgress}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-nostdin", "-hide_banner", "-loglevel", "error", "-y",
+		"-i", "pipe:0",
+		"-vn", "-c:a", "aac", "-b:a", transcodeBitrate,
+		"-f", "ipod", dst)
+	cmd.Stdin = io.TeeReader(contextReader{ctx: ctx, r: in}, io.MultiWriter(h, counter))
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(dst)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("ffmpeg: %s", msg)
+		}
+		return "", fmt.Errorf("ffmpeg: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// importAudio puts the audio of src at dst, transcoding formats podcast apps
+// don't play and copying the rest. Copies cut short are picked up again
+// from partialDir, unless it's empty (see copyResuming). With verify,
+// copies are read back and checked against src (see copyVerified);
+// transcodes can't be. It returns the SHA-256 of src.
+func importAudio(ctx context.Context, src, dst, partialDir string, verify bool, onProgress func(written, total int64)) (string, error) {
+	if needsTranscode(src) {
+		return transcodeWithProgress(ctx, src, dst, onProgress)
+	}
+	if partialDir == "" {
+		if verify {
+			return copyVerified(ctx, src, dst, onProgress)
+		}
+		return copyWithProgress(ctx, src, dst, onProgress)
+	}
+
+	sum, resumed, err := copyResuming(ctx, src, dst, partialDir, onProgress)
+	if err != nil || !verify {
+		return sum, err
+	}
+	// The part copied before the copy was resumed wasn't hashed from src,
+	// so src is read again in full to check it
+	if resumed > 0 {
+		err = verifySum(src, sum, "source")
+	} else {
+		err = verifySum(dst, sum, "copy")
+	}
+	if err == nil {
+		return sum, nil
+	}
+	os.Remove(dst)
+	return copyVerified(ctx, src, dst, onProgress)
+}
+
+// transcodablePaths drops the paths that need ffmpeg when it isn't
+// installed, telling the user why they were skipped
+func (p *Podcasterator) transcodablePaths(paths []string) []string {
+	available := ffmpegAvailable()
+	var kept, skipped []string
+	for _, path := range paths {
+		if needsTranscode(path) && !available {
+			skipped = append(skipped, filepath.Base(path))
+			continue
+		}
+		kept = append(kept, path)
+	}
+	if len(skipped) > 0 && p.window != nil {
+		p.showFFmpegRequired(skipped)
+	}
+	return kept
+}
+
+// showFFmpegRequired explains that names couldn't be added without ffmpeg
+func (p *Podcasterator) showFFmpegRequired(names []string) {
+	list := strings.Join(names, "\n")
+	if len(names) > 5 {
+		list = strings.Join(names[:5], "\n") + fmt.Sprintf("\n… and %d more", len(names)-5)
+	}
+	dialog.ShowInformation("ffmpeg Required",
+		"WAV, FLAC and OGG files are converted to M4A when added, which\n"+
+			"needs ffmpeg. Install it from https://ffmpeg.org (or your package\n"+
+			"manager), make sure it's on your PATH, and add the files again.\n\n"+
+			"Skipped:\n"+list, p.window)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Transcoding Tests
+// =============================================================================
+
+// fakeFFmpeg points lookFFmpeg at a shell script with the given body for the
+// rest of the test
+func fakeFFmpeg(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ffmpeg is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := lookFFmpeg
+	lookFFmpeg = func() (string, error) { return path, nil }
+	t.Cleanup(func() { lookFFmpeg = old })
+}
+
+// copyingFFmpeg writes its stdin to the output file, the last argument
+const copyingFFmpeg = `for last; do :; done
+cat > "$last"`
+
+func TestNeedsTranscode(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"take.wav", true},
+		{"album/track.FLAC", true},
+		{"voice.ogg", true},
+		{"song.mp3", false},
+		{"book.m4b", false},
+		{"wav", false},
+	}
+
+	for _, tc := range tests {
+		if got := needsTranscode(tc.path); got != tc.want {
+			t.Errorf("needsTranscode(%q) = %v; want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestTranscodeWithProgress(t *testing.T) {
+	fakeFFmpeg(t, copyingFFmpeg)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "take.wav")
+	os.WriteFile(src, []byte("RIFF wave data"), 0644)
+	dst := filepath.Join(dir, "take.m4a")
+
+	var written, total int64
+	sum, err := transcodeWithProgress(context.Background(), src, dst, func(w, tot int64) {
+		written, total = w, tot
+	})
+	if err != nil {
+		t.Fatalf("transcodeWithProgress() error = %v", err)
+	}
+	if got, _ := os.ReadFile(dst); string(got) != "RIFF wave data" {
+		t.Errorf("ffmpeg output = %q; want the piped source", got)
+	}
+	if written != 14 || total != 14 {
+		t.Errorf("progress ended at %d/%d; want 14/14", written, total)
+	}
+	if want, _ := copyWithProgress(context.Background(), src, filepath.Join(dir, "copy.wav"), nil); sum != want {
+		t.Errorf("hash = %s; want the source hash %s", sum, want)
+	}
+}
+
+func TestTranscodeFailure(t *testing.T) {
+	fakeFFmpeg(t, `for last; do :; done
+echo partial > "$last"
+echo "pipe:0: Invalid data found when processing input" >&2
+exit 1`)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "broken.flac")
+	os.WriteFile(src, []byte("not flac"), 0644)
+	dst := filepath.Join(dir, "broken.m4a")
+
+	_, err := transcodeWithProgress(context.Background(), src, dst, nil)
+	if err == nil || !strings.Contains(err.Error(), "Invalid data found") {
+		t.Errorf("transcodeWithProgress() error = %v; want ffmpeg's message", err)
+	}
+	if fileExists(dst) {
+		t.Error("failed transcode left its output behind")
+	}
+}
+
+func TestTranscodeFFmpegMissing(t *testing.T) {
+	old := lookFFmpeg
+	lookFFmpeg = func() (string, error) { return "", errors.New("not found") }
+	defer func() { lookFFmpeg = old }()
+
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	src := filepath.Join(t.TempDir(), "take.wav")
+	os.WriteFile(src, []byte("wave"), 0644)
+
+	if _, err := importAudio(context.Background(), src, filepath.Join(t.TempDir(), "take.m4a"), "", false, nil); !errors.Is(err, errFFmpegMissing) {
+		t.Errorf("importAudio() error = %v; want errFFmpegMissing", err)
+	}
+	if got := p.transcodablePaths([]string{"/a.mp3", src, "/b.m4a"}); strings.Join(got, ",") != "/a.mp3,/b.m4a" {
+		t.Errorf("transcodablePaths() = %v; want the files that don't need ffmpeg", got)
+	}
+}
+
+func TestAddFileTranscodes(t *testing.T) {
+	fakeFFmpeg(t, copyingFFmpeg)
+
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.keepExtension = true
+
+	src := filepath.Join(t.TempDir(), "Session.flac")
+	os.WriteFile(src, []byte("flac"), 0644)
+	p.addFile(src)
+
+	if len(p.files) != 1 {
+		t.Fatalf("got %d files; want 1", len(p.files))
+	}
+	file := p.files[0]
+	if file.DisplayName != "Session.m4a" || filepath.Ext(file.TempPath) != ".m4a" {
+		t.Errorf("transcoded file = %q at %s; want an .m4a", file.DisplayName, file.TempPath)
+	}
+	if file.OriginalPath != src || !fileExists(file.TempPath) {
+		t.Errorf("transcoded file = %+v", file)
+	}
+	if err := p.setServeOriginal(file.ID, true); err == nil {
+		t.Error("setServeOriginal() allowed serving the untranscoded original")
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// transcriptTypes maps the transcript formats that can be attached to the
+// type <podcast:transcript> declares for them
+var transcriptTypes = map[string]string{
+	".vtt": "text/vtt",
+	".srt": "application/x-subrip",
+}
+
+// transcriptExtensions is the order sidecar transcripts are looked for in
+var transcriptExtensions = []string{".vtt", ".srt"}
+
+func isTranscriptFile(path string) bool {
+	_, ok := transcriptTypes[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// transcriptType is the MIME type of the transcript at path
+func transcriptType(path string) string {
+	return transcriptTypes[strings.ToLower(filepath.Ext(path))]
+}
+
+// sidecarTranscript returns the transcript kept next to the audio at path
+// with the same base name, e.g. episode.vtt for episode.mp3, or "" if there
+// is none. WebVTT is preferred when both exist.
+func sidecarTranscript(path string) string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range transcriptExtensions {
+		for _, candidate := range []string{base + ext, base + strings.ToUpper(ext)} {
+			if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// transcriptName is the file name a transcript is served under, next to
+// its audio at /files/<id>/
+func transcriptName(path string) string {
+	return "transcript" + strings.ToLower(filepath.Ext(path))
+}
+
+// copyTranscript copies the transcript at src beside the temp copy at
+// tempPath and returns where it went
+func copyTranscript(src, tempPath string) (string, error) {
+	dst := filepath.Join(filepath.Dir(tempPath), transcriptName(src))
+	if _, err := copyWithProgress(context.Background(), src, dst, nil); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// setTranscript copies the transcript at path for the episode with id,
+// replacing any it had
+func (p *Podcasterator) setTranscript(id, path string) error {
+	if err := p.tempDirErr(); err != nil {
+		return err
+	}
+	if !isTranscriptFile(path) {
+		return fmt.Errorf("unsupported transcript type: %s; use .vtt or .srt", filepath.Ext(path))
+	}
+	index := p.fileIndex(id)
+	if index < 0 {
+		return fmt.Errorf("episode no longer exists")
+	}
+	file := &p.files[index]
+	if file.IsExternal() {
+		return fmt.Errorf("hosted episodes can't have a transcript attached")
+	}
+
+	dst, err := copyTranscript(path, file.TempPath)
+	if err != nil {
+		return fmt.Errorf("couldn't copy %s: %w", filepath.Base(path), err)
+	}
+	if file.TranscriptPath != "" && file.TranscriptPath != dst {
+		os.Remove(file.TranscriptPath)
+	}
+	file.TranscriptPath = dst
+	p.refreshEpisodeRow(index)
+	p.markDirty()
+	return nil
+}
+
+// removeTranscript deletes the transcript of the episode with id
+func (p *Podcasterator) removeTranscript(id string) error {
+	index := p.fileIndex(id)
+	if index < 0 {
+		return fmt.Errorf("episode no longer exists")
+	}
+	if p.files[index].TranscriptPath != "" {
+		os.Remove(p.files[index].TranscriptPath)
+	}
+	p.files[index].TranscriptPath = ""
+	p.refreshEpisodeRow(index)
+	p.markDirty()
+	return nil
+}
+
+// editTranscript lets the user attach a transcript to the episode at index,
+// or, when it has one, replace or remove it
+func (p *Podcasterator) editTranscript(index int) {
+	if index < 0 || index >= len(p.files) || p.window == nil {
+		return
+	}
+	file := p.files[index]
+	if file.TranscriptPath == "" {
+		p.chooseTranscript(file.ID)
+		return
+	}
+
+	var d dialog.Dialog
+	replaceBtn := widget.NewButton("Replace…", func() {
+		d.Hide()
+		p.chooseTranscript(file.ID)
+	})
+	removeBtn := widget.NewButton("Remove", func() {
+		d.Hide()
+		if err := p.removeTranscript(file.ID); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	})
+	note := widget.NewLabel("Published as the episode's <podcast:transcript>.")
+	note.Importance = widget.LowImportance
+
+	d = dialog.NewCustom("Transcript: "+truncateFilename(file.DisplayName), "Close",
+		container.NewVBox(widget.NewLabel(transcriptName(file.TranscriptPath)), note,
+			container.NewHBox(replaceBtn, removeBtn)), p.window)
+	d.Show()
+}
+
+// chooseTranscript opens a file picker for the transcript of the episode
+// with id
+func (p *Podcasterator) chooseTranscript(id string) {
+	if !p.requireTempDir() {
+		return
+	}
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+		if err := p.setTranscript(id, reader.URI().Path()); err != nil {
+			dialog.ShowError(err, p.window)
+		}
+	}, p.window)
+	d.SetFilter(storage.NewExtensionFileFilter(transcriptExtensions))
+	d.Show()
+}
+
+// transcriptURL is the URL of an episode's transcript under baseURL
+func transcriptURL(baseURL string, file AudioFile) string {
+	return fmt.Sprintf("%s/files/%s/%s", strings.TrimRight(baseURL, "/"), file.ID, transcriptName(file.TranscriptPath))
+}
+
+// itemTranscriptElements is the <podcast:transcript> for an item, or
+// nothing when the file has no transcript
+func itemTranscriptElements(file AudioFile, baseURL string) []channelElement {
+	if file.TranscriptPath == "" || !fileExists(file.TranscriptPath) {
+		return nil
+	}
+	return []channelElement{{Name: "podcast:transcript", Attrs: map[string]string{
+		"url":  transcriptURL(baseURL, file),
+		"type": transcriptType(file.TranscriptPath),
+	}}}
+}
+
+// serveTranscript serves the transcript of an episode requested as name,
+// which must match its format
+func (s *FeedServer) serveTranscript(w http.ResponseWriter, r *http.Request, file AudioFile, name string) {
+	if file.TranscriptPath == "" || name != transcriptName(file.TranscriptPath) || !fileExists(file.TranscriptPath) {
+		http.Error(w, "Transcript not found", http.StatusNotFound)
+		return
+	}
+	if !s.servableCopy(file.TranscriptPath) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", transcriptType(file.TranscriptPath)+"; charset=utf-8")
+	http.ServeFile(w, r, file.TranscriptPath)
+}
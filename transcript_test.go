@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Transcript Tests
+// =============================================================================
+
+const testVTT = "WEBVTT\n\n00:00.000 --> 00:02.000\nHello\n"
+
+func TestSidecarTranscript(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "episode.mp3")
+	os.WriteFile(audio, []byte("audio"), 0644)
+
+	if got := sidecarTranscript(audio); got != "" {
+		t.Errorf("sidecarTranscript() = %q with no transcript; want none", got)
+	}
+
+	srt := filepath.Join(dir, "episode.SRT")
+	os.WriteFile(srt, []byte("1\n"), 0644)
+	if got := sidecarTranscript(audio); got != srt {
+		t.Errorf("sidecarTranscript() = %q; want %q", got, srt)
+	}
+
+	vtt := filepath.Join(dir, "episode.vtt")
+	os.WriteFile(vtt, []byte(testVTT), 0644)
+	if got := sidecarTranscript(audio); got != vtt {
+		t.Errorf("sidecarTranscript() = %q; want WebVTT preferred", got)
+	}
+
+	// Another episode's transcript isn't picked up
+	other := filepath.Join(dir, "other.mp3")
+	os.WriteFile(other, []byte("audio"), 0644)
+	if got := sidecarTranscript(other); got != "" {
+		t.Errorf("sidecarTranscript() = %q for other.mp3; want none", got)
+	}
+}
+
+func TestTranscriptType(t *testing.T) {
+	tests := map[string]string{
+		"a.vtt": "text/vtt",
+		"a.VTT": "text/vtt",
+		"a.srt": "application/x-subrip",
+		"a.txt": "",
+	}
+	for path, want := range tests {
+		if got := transcriptType(path); got != want {
+			t.Errorf("transcriptType(%q) = %q; want %q", path, got, want)
+		}
+	}
+}
+
+func TestImportFileCopiesTranscript(t *testing.T) {
+	src := t.TempDir()
+	audio := filepath.Join(src, "episode.mp3")
+	os.WriteFile(audio, []byte("audio"), 0644)
+	os.WriteFile(filepath.Join(src, "episode.vtt"), []byte(testVTT), 0644)
+
+	file, err := importFile(context.Background(), audio, t.TempDir(), false, false, nil)
+	if err != nil {
+		t.Fatalf("importFile() error = %v", err)
+	}
+	if file.TranscriptPath != filepath.Join(filepath.Dir(file.TempPath), "transcript.vtt") {
+		t.Fatalf("TranscriptPath = %q; want it beside the temp copy", file.TranscriptPath)
+	}
+	if data, _ := os.ReadFile(file.TranscriptPath); string(data) != testVTT {
+		t.Errorf("copied transcript = %q", data)
+	}
+}
+
+func TestSetTranscript(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3")
+	id := p.files[1].ID
+	dir := t.TempDir()
+
+	txt := filepath.Join(dir, "notes.txt")
+	os.WriteFile(txt, []byte("text"), 0644)
+	if err := p.setTranscript(id, txt); err == nil {
+		t.Error("setTranscript() accepted a .txt file")
+	}
+
+	srt := filepath.Join(dir, "b.srt")
+	os.WriteFile(srt, []byte("1\n00:00:00,000 --> 00:00:02,000\nHello\n"), 0644)
+	if err := p.setTranscript(id, srt); err != nil {
+		t.Fatalf("setTranscript() error = %v", err)
+	}
+	first := p.files[1].TranscriptPath
+	if !fileExists(first) || !strings.Contains(fileRowDetails(p.files[1]), "💬 transcript") {
+		t.Errorf("transcript at %q isn't attached", first)
+	}
+
+	// Replacing it with another format removes the old copy
+	vtt := filepath.Join(dir, "b.vtt")
+	os.WriteFile(vtt, []byte(testVTT), 0644)
+	if err := p.setTranscript(id, vtt); err != nil {
+		t.Fatal(err)
+	}
+	if fileExists(first) || filepath.Base(p.files[1].TranscriptPath) != "transcript.vtt" {
+		t.Errorf("TranscriptPath = %q; want the .srt replaced by the .vtt", p.files[1].TranscriptPath)
+	}
+
+	rss, files, err := p.siteFeed("http://host")
+	if err != nil {
+		t.Fatalf("siteFeed() error = %v", err)
+	}
+	want := `<podcast:transcript type="text/vtt" url="http://host/files/` + id + `/transcript.vtt">`
+	if !strings.Contains(rss, want) || strings.Count(rss, "<podcast:transcript") != 1 {
+		t.Errorf("want the transcript on its episode only:\n%s", rss)
+	}
+	if last := files[len(files)-1]; last.ID != id || last.Name != "transcript.vtt" {
+		t.Errorf("static export files end with %+v; want the transcript", last)
+	}
+
+	transcript := p.files[1].TranscriptPath
+	if err := p.removeTranscript(id); err != nil {
+		t.Fatal(err)
+	}
+	if p.files[1].TranscriptPath != "" || fileExists(transcript) {
+		t.Error("removeTranscript() left the transcript behind")
+	}
+}
+
+func TestServeTranscript(t *testing.T) {
+	p, srv, cleanup := newFileServerFixture(t)
+	defer cleanup()
+
+	if code, _ := getBody(t, srv.URL+"/files/abc/transcript.vtt"); code != http.StatusNotFound {
+		t.Errorf("GET transcript of an episode without one = %d; want 404", code)
+	}
+
+	vtt := filepath.Join(t.TempDir(), "episode.vtt")
+	os.WriteFile(vtt, []byte(testVTT), 0644)
+	if err := p.setTranscript("abc", vtt); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(srv.URL + "/files/abc/transcript.vtt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/vtt") {
+		t.Errorf("GET transcript = %d %s; want 200 text/vtt", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if code, _ := getBody(t, srv.URL+"/files/abc/transcript.srt"); code != http.StatusNotFound {
+		t.Errorf("GET transcript in another format = %d; want 404", code)
+	}
+
+	// A transcript outside the temp dir is never served
+	p.files[0].TranscriptPath = vtt
+	p.markDirty()
+	if code, _ := getBody(t, srv.URL+"/files/abc/transcript.vtt"); code != http.StatusForbidden {
+		t.Errorf("transcript outside the temp dir = %d; want 403", code)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+var errTrimNeedsFFmpeg = errors.New("ffmpeg is required to cut the start of an episode")
+
+// parseStartOffset accepts a blank (start at the beginning), seconds such as
+// "90" or "12.5", or minutes and seconds such as "1:30" or "1:02:03"
+func parseStartOffset(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	invalid := fmt.Errorf("start at must be seconds (90) or minutes and seconds (1:30)")
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, invalid
+	}
+	var total time.Duration
+	for i, part := range parts {
+		last := i == len(parts)-1
+		var n float64
+		var err error
+		if last {
+			n, err = strconv.ParseFloat(part, 64)
+		} else {
+			var whole int
+			whole, err = strconv.Atoi(part)
+			n = float64(whole)
+		}
+		// Minutes and seconds after the first field are 0-59
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, invalid
+		}
+		total = total*60 + time.Duration(n*float64(time.Second))
+	}
+	return total.Round(time.Millisecond), nil
+}
+
+// formatStartOffset shows an offset the way parseStartOffset reads it back,
+// e.g. "1:30" or "1:02:03.5". Zero is blank.
+func formatStartOffset(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	ms := d.Milliseconds()
+	h, m, s, frac := ms/3600000, ms/60000%60, ms/1000%60, ms%1000
+	text := fmt.Sprintf("%d:%02d", m, s)
+	if h > 0 {
+		text = fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	if frac > 0 {
+		text += strings.TrimRight(fmt.Sprintf(".%03d", frac), "0")
+	}
+	return text
+}
+
+// trimmedPath is where the copy of file starting at offset is kept, beside
+// its temp copy. Each offset gets its own name so a new cut never
+// overwrites the one being served.
+func trimmedPath(file AudioFile, offset time.Duration) string {
+	return filepath.Join(filepath.Dir(file.TempPath),
+		fmt.Sprintf("trimmed-%d%s", offset.Milliseconds(), filepath.Ext(file.TempPath)))
+}
+
+// trimAudio writes src without its first offset to dst with ffmpeg. The
+// audio isn't re-encoded, so it's quick and lossless and the cut lands on
+// the nearest frame. dst is removed on any error.
+func trimAudio(ctx context.Context, src, dst string, offset time.Duration) error {
+	ffmpeg, err := lookFFmpeg()
+	if err != nil {
+		return errTrimNeedsFFmpeg
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-nostdin", "-hide_banner", "-loglevel", "error", "-y",
+		"-ss", strconv.FormatFloat(offset.Seconds(), 'f', 3, 64), "-i", src,
+		"-map", "0:a", "-map_metadata", "0", "-c", "copy", dst)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(dst)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("ffmpeg: %s", msg)
+		}
+		return fmt.Errorf("ffmpeg: %w", err)
+	}
+	if info, err := os.Stat(dst); err != nil || info.Size() == 0 {
+		os.Remove(dst)
+		return fmt.Errorf("ffmpeg didn't write any audio")
+	}
+	return nil
+}
+
+// checkStartOffset reports why file can't start at offset
+func checkStartOffset(file AudioFile, offset time.Duration) error {
+	switch {
+	case file.IsExternal():
+		return fmt.Errorf("hosted episodes can't be cut")
+	case offset < 0:
+		return fmt.Errorf("start at can't be negative")
+	case offset > 0 && file.ServeOriginal:
+		return fmt.Errorf("turn off serving the original file first; the original itself is never changed")
+	}
+	if offset > 0 {
+		if length, err := audioDuration(file.TempPath); err == nil && offset >= length {
+			return fmt.Errorf("the episode is only %s long", formatDuration(length))
+		}
+	}
+	return nil
+}
+
+// fileIndex returns the position of the file with id, or -1
+func (p *Podcasterator) fileIndex(id string) int {
+	for i := range p.files {
+		if p.files[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyStartOffset points the file with id at trimmed, its copy cut to
+// start at offset, or back at its whole temp copy when offset is 0. The
+// previous cut is removed. The length and chapters are read again from
+// what's served.
+func (p *Podcasterator) applyStartOffset(id string, offset time.Duration, trimmed string) error {
+	index := p.fileIndex(id)
+	if index < 0 {
+		os.Remove(trimmed)
+		return fmt.Errorf("episode no longer exists")
+	}
+	file := &p.files[index]
+	if file.TrimmedPath != "" && file.TrimmedPath != trimmed {
+		os.Remove(file.TrimmedPath)
+	}
+	file.StartOffset = offset
+	file.TrimmedPath = trimmed
+	file.Duration = 0
+	file.refreshStat()
+	p.ensureDuration(index)
+	p.refreshChapters(index)
+
+	if p.fileList != nil {
+		p.fileList.RefreshItem(index)
+	}
+	if p.detail != nil && p.detail.fileID == id {
+		p.showEpisodeDetail(id)
+	}
+	p.markDirty()
+	return nil
+}
+
+// setStartOffset cuts the file with id to start at offset, blocking until
+// ffmpeg is done. An offset of 0 serves the whole file again.
+func (p *Podcasterator) setStartOffset(id string, offset time.Duration) error {
+	index := p.fileIndex(id)
+	if index < 0 {
+		return fmt.Errorf("episode no longer exists")
+	}
+	file := p.files[index]
+	if err := checkStartOffset(file, offset); err != nil {
+		return err
+	}
+	if offset == file.StartOffset && (offset == 0 || fileExists(file.TrimmedPath)) {
+		return nil
+	}
+
+	trimmed := ""
+	if offset > 0 {
+		trimmed = trimmedPath(file, offset)
+		if err := trimAudio(context.Background(), file.TempPath, trimmed, offset); err != nil {
+			return err
+		}
+	}
+	return p.applyStartOffset(id, offset, trimmed)
+}
+
+// editStartOffset opens a dialog for where the episode at index starts
+func (p *Podcasterator) editStartOffset(index int) {
+	if index < 0 || index >= len(p.files) || p.window == nil {
+		return
+	}
+	file := p.files[index]
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("0:00")
+	entry.SetText(formatStartOffset(file.StartOffset))
+	entry.Validator = func(s string) error {
+		_, err := parseStartOffset(s)
+		return err
+	}
+
+	note := widget.NewLabel("Listeners start this far in, e.g. 1:30 to skip a 90-second intro.\n" +
+		"The audio before it is cut from the served copy (with ffmpeg) and the\n" +
+		"feed shows the shorter length. Your original file is never changed.\n" +
+		"Leave blank to serve the whole file.")
+	note.Importance = widget.LowImportance
+
+	d := dialog.NewCustomConfirm("Start At: "+truncateFilename(file.DisplayName), "Save", "Cancel",
+		container.NewVBox(widget.NewForm(widget.NewFormItem("Start at", entry)), note),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			offset, err := parseStartOffset(entry.Text)
+			if err == nil {
+				err = checkStartOffset(file, offset)
+			}
+			if err != nil {
+				dialog.ShowError(err, p.window)
+				return
+			}
+			if offset == file.StartOffset && (offset == 0 || fileExists(file.TrimmedPath)) {
+				return
+			}
+			if offset == 0 {
+				if err := p.applyStartOffset(file.ID, 0, ""); err != nil {
+					dialog.ShowError(err, p.window)
+				}
+				return
+			}
+			p.trimInBackground(file, offset)
+		},
+		p.window,
+	)
+	d.Show()
+	p.window.Canvas().Focus(entry)
+}
+
+// trimInBackground cuts file to start at offset off the UI thread
+func (p *Podcasterator) trimInBackground(file AudioFile, offset time.Duration) {
+	if !p.requireTempDir() {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := dialog.NewCustom("Cutting", "Cancel",
+		container.NewVBox(widget.NewLabel("Cutting the start of "+truncateFilename(file.DisplayName)), widget.NewProgressBarInfinite()),
+		p.window)
+	progress.SetOnClosed(cancel)
+	progress.Show()
+
+	trimmed := trimmedPath(file, offset)
+	go func() {
+		err := trimAudio(ctx, file.TempPath, trimmed, offset)
+		cancelled := ctx.Err() != nil
+		cancel()
+		fyne.Do(func() {
+			progress.Hide()
+			if cancelled {
+				os.Remove(trimmed)
+				return
+			}
+			if err == nil {
+				err = p.applyStartOffset(file.ID, offset, trimmed)
+			}
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("couldn't cut the start: %w", err), p.window)
+			}
+		})
+	}()
+}
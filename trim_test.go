@@ -0,0 +1,240 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// =============================================================================
+// Start Offset Tests
+// =============================================================================
+
+// cuttingFFmpeg writes "cut at <-ss>" and then its input to the output file,
+// the last argument
+const cuttingFFmpeg = `while [ $# -gt 0 ]; do
+	case "$1" in
+	-ss) ss="$2"; shift ;;
+	-i) src="$2"; shift ;;
+	esac
+	last="$1"
+	shift
+done
+printf 'cut at %s\n' "$ss" > "$last"
+cat "$src" >> "$last"`
+
+func TestParseStartOffset(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"  ", 0, false},
+		{"90", 90 * time.Second, false},
+		{"12.5", 12500 * time.Millisecond, false},
+		{"1:30", 90 * time.Second, false},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, false},
+		{"0:05.25", 5250 * time.Millisecond, false},
+		{"1:60", 0, true},
+		{"1:2:3:4", 0, true},
+		{"-5", 0, true},
+		{"1.5:00", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tc := range tests {
+		got, err := parseStartOffset(tc.input)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("parseStartOffset(%q) = %v, %v; want %v, error %v", tc.input, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestFormatStartOffset(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, ""},
+		{90 * time.Second, "1:30"},
+		{5 * time.Second, "0:05"},
+		{time.Hour + 2*time.Minute + 3500*time.Millisecond, "1:02:03.5"},
+	}
+	for _, tc := range tests {
+		got := formatStartOffset(tc.d)
+		if got != tc.want {
+			t.Errorf("formatStartOffset(%v) = %q; want %q", tc.d, got, tc.want)
+		}
+		if back, err := parseStartOffset(got); err != nil || back != tc.d {
+			t.Errorf("parseStartOffset(%q) = %v, %v; want %v back", got, back, err, tc.d)
+		}
+	}
+}
+
+func TestSetStartOffset(t *testing.T) {
+	fakeFFmpeg(t, cuttingFFmpeg)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	id := p.files[0].ID
+
+	p.dirty = false
+	if err := p.setStartOffset(id, 90*time.Second); err != nil {
+		t.Fatalf("setStartOffset() error = %v", err)
+	}
+	file := p.files[0]
+	if file.StartOffset != 90*time.Second || file.ServedPath() != file.TrimmedPath {
+		t.Fatalf("after setStartOffset() = %+v; want the cut copy served", file)
+	}
+	data, _ := os.ReadFile(file.TrimmedPath)
+	if string(data) != "cut at 90.000\naudio" {
+		t.Errorf("cut copy holds %q", data)
+	}
+	if file.Size != int64(len(data)) {
+		t.Errorf("Size = %d; want the cut copy's %d", file.Size, len(data))
+	}
+	if data, _ := os.ReadFile(file.TempPath); string(data) != "audio" {
+		t.Error("the temp copy was changed")
+	}
+	if !p.dirty {
+		t.Error("setStartOffset() didn't mark the state dirty")
+	}
+
+	// A new offset replaces the old cut
+	first := file.TrimmedPath
+	if err := p.setStartOffset(id, 30*time.Second); err != nil {
+		t.Fatalf("setStartOffset() error = %v", err)
+	}
+	if fileExists(first) || !fileExists(p.files[0].TrimmedPath) {
+		t.Error("changing the offset didn't replace the old cut")
+	}
+
+	// Zero serves the whole file again
+	second := p.files[0].TrimmedPath
+	if err := p.setStartOffset(id, 0); err != nil {
+		t.Fatalf("setStartOffset(0) error = %v", err)
+	}
+	if fileExists(second) || p.files[0].TrimmedPath != "" || p.files[0].ServedPath() != p.files[0].TempPath {
+		t.Errorf("after clearing the offset = %+v", p.files[0])
+	}
+	if p.files[0].Size != int64(len("audio")) {
+		t.Errorf("Size = %d after clearing the offset; want the whole file", p.files[0].Size)
+	}
+}
+
+func TestSetStartOffsetRefused(t *testing.T) {
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.files = append(p.files, AudioFile{ID: "x", DisplayName: "x.mp3", ExternalURL: "https://example.com/x.mp3"})
+
+	if err := p.setStartOffset("x", time.Minute); err == nil {
+		t.Error("setStartOffset() of a hosted episode succeeded")
+	}
+	if err := p.setStartOffset("gone", time.Minute); err == nil {
+		t.Error("setStartOffset() of a missing episode succeeded")
+	}
+	if err := p.setStartOffset(p.files[0].ID, -time.Second); err == nil {
+		t.Error("setStartOffset() with a negative offset succeeded")
+	}
+
+	p.files[1].ServeOriginal = true
+	if err := p.setStartOffset(p.files[1].ID, time.Minute); err == nil {
+		t.Error("setStartOffset() while serving the original succeeded")
+	}
+
+	old := lookFFmpeg
+	lookFFmpeg = func() (string, error) { return "", errors.New("not found") }
+	err := p.setStartOffset(p.files[0].ID, time.Minute)
+	lookFFmpeg = old
+	if !errors.Is(err, errTrimNeedsFFmpeg) {
+		t.Errorf("setStartOffset() without ffmpeg error = %v; want errTrimNeedsFFmpeg", err)
+	}
+
+	fakeFFmpeg(t, `echo "Invalid data found when processing input" >&2; exit 1`)
+	err = p.setStartOffset(p.files[0].ID, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "Invalid data") {
+		t.Errorf("setStartOffset() with ffmpeg failing error = %v", err)
+	}
+	if p.files[0].StartOffset != 0 || p.files[0].TrimmedPath != "" {
+		t.Errorf("a failed cut changed the file: %+v", p.files[0])
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(p.files[0].TempPath)); len(entries) != 1 {
+		t.Errorf("a failed cut left %d files beside the copy", len(entries)-1)
+	}
+}
+
+func TestStartOffsetKeepsOriginalOff(t *testing.T) {
+	fakeFFmpeg(t, cuttingFFmpeg)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.setStartOffset(p.files[0].ID, time.Minute)
+
+	if err := p.setServeOriginal(p.files[0].ID, true); err == nil {
+		t.Error("setServeOriginal() of a cut episode succeeded")
+	}
+	if etag := fileETag(p.files[0], mustStat(t, p.files[0].TrimmedPath)); strings.Contains(etag, p.files[0].SHA256) {
+		t.Errorf("cut copy's ETag %s uses the source's hash", etag)
+	}
+}
+
+func mustStat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestReplaceAudioKeepsStartOffset(t *testing.T) {
+	fakeFFmpeg(t, cuttingFFmpeg)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.setStartOffset(p.files[0].ID, time.Minute)
+
+	src := filepath.Join(t.TempDir(), "take2.mp3")
+	os.WriteFile(src, []byte("take two"), 0644)
+	if err := replaceAndFinish(p, 0, src); err != nil {
+		t.Fatalf("replacing the audio error = %v", err)
+	}
+	if data, _ := os.ReadFile(p.files[0].ServedPath()); string(data) != "cut at 60.000\ntake two" {
+		t.Errorf("served after replace = %q; want the new audio cut", data)
+	}
+}
+
+func TestApplyProjectDropsLostCut(t *testing.T) {
+	fakeFFmpeg(t, cuttingFFmpeg)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.setStartOffset(p.files[0].ID, time.Minute)
+	os.Remove(p.files[0].TrimmedPath)
+
+	p.applyProject(p.currentProject())
+	if len(p.files) != 3 {
+		t.Fatalf("applyProject() kept %d files; want the one with a lost cut kept too", len(p.files))
+	}
+	if p.files[0].StartOffset != 0 || p.files[0].ServedPath() != p.files[0].TempPath {
+		t.Errorf("after losing the cut = %+v; want the whole file served", p.files[0])
+	}
+}
+
+func TestExportCutEpisode(t *testing.T) {
+	fakeFFmpeg(t, cuttingFFmpeg)
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	p.setStartOffset(p.files[0].ID, 90*time.Second)
+
+	dir := t.TempDir()
+	manifest, err := exportFiles(p.files, dir)
+	if err != nil {
+		t.Fatalf("exportFiles() error = %v", err)
+	}
+	if manifest.Files[0].StartOffset != 90 || manifest.Files[1].StartOffset != 0 {
+		t.Errorf("manifest start offsets = %v, %v; want 90, 0", manifest.Files[0].StartOffset, manifest.Files[1].StartOffset)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, manifest.Files[0].Name)); !strings.HasPrefix(string(data), "cut at 90") {
+		t.Errorf("exported %q; want the cut audio", data)
+	}
+}
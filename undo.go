@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"fyne.io/fyne/v2/dialog"
+	"github.com/google/uuid"
+)
+
+// maxUndoSteps bounds how many deletes can be undone. Older ones are purged
+// from the trash.
+const maxUndoSteps = 10
+
+// trashDirName is the folder under the temp dir where removed files wait
+// until they're restored or purged
+const trashDirName = ".trash"
+
+// trashedFile is a removed file and where it was in the list
+type trashedFile struct {
+	Index     int
+	File      AudioFile
+	TrashPath string // empty for external files, which have no copy
+}
+
+// undoRecord is one delete or clear that can be undone
+type undoRecord struct {
+	Files []trashedFile
+}
+
+// trashDir is where removed temp copies are moved
+func (p *Podcasterator) trashDir() string {
+	return filepath.Join(p.tempDir, trashDirName)
+}
+
+// trashFile moves the temp copy of file into the trash and returns where it
+// went. If it can't be moved it's left in place and purged from there.
+func (p *Podcasterator) trashFile(file AudioFile) string {
+	if file.IsExternal() || file.TempPath == "" {
+		return ""
+	}
+	dir := filepath.Join(p.trashDir(), uuid.New().String())
+	dest := filepath.Join(dir, filepath.Base(file.TempPath))
+	if err := os.MkdirAll(dir, 0755); err == nil {
+		if err := os.Rename(file.TempPath, dest); err == nil {
+			return dest
+		}
+		os.Remove(dir)
+	}
+	return file.TempPath
+}
+
+// removeFiles takes the files at indices out of the list, moving their temp
+// copies to the trash, and records the removal so it can be undone
+func (p *Podcasterator) removeFiles(indices []int) {
+	sort.Ints(indices)
+	removing := map[int]bool{}
+	var record undoRecord
+	for _, i := range indices {
+		if i < 0 || i >= len(p.files) || removing[i] {
+			continue
+		}
+		removing[i] = true
+		record.Files = append(record.Files, trashedFile{
+			Index:     i,
+			File:      p.files[i],
+			TrashPath: p.trashFile(p.files[i]),
+		})
+	}
+	if len(record.Files) == 0 {
+		return
+	}
+
+	kept := make([]AudioFile, 0, len(p.files)-len(record.Files))
+	for i, f := range p.files {
+		if !removing[i] {
+			kept = append(kept, f)
+		}
+	}
+	p.files = kept
+	removed := make([]string, len(record.Files))
+	for i, t := range record.Files {
+		removed[i] = t.File.OriginalPath
+	}
+	p.noteWatchedRemoved(removed)
+	p.pushUndo(record)
+	p.updateBulkBar()
+}
+
+// pushUndo adds record to the undo stack, purging the oldest record's files
+// once there are more than maxUndoSteps
+func (p *Podcasterator) pushUndo(record undoRecord) {
+	p.undoStack = append(p.undoStack, record)
+	for len(p.undoStack) > maxUndoSteps {
+		purgeRecord(p.undoStack[0])
+		p.undoStack = p.undoStack[1:]
+	}
+	p.updateUndoButton()
+}
+
+// undo puts back the files removed by the most recent delete or clear, at
+// the positions they had
+func (p *Podcasterator) undo() error {
+	if len(p.undoStack) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	record := p.undoStack[len(p.undoStack)-1]
+	p.undoStack = p.undoStack[:len(p.undoStack)-1]
+	p.updateUndoButton()
+
+	var failed, restored []string
+	for _, t := range record.Files {
+		file := t.File
+		if t.TrashPath != "" && t.TrashPath != file.TempPath {
+			os.MkdirAll(filepath.Dir(file.TempPath), 0755)
+			if err := os.Rename(t.TrashPath, file.TempPath); err != nil {
+				failed = append(failed, file.DisplayName)
+				continue
+			}
+			os.Remove(filepath.Dir(t.TrashPath))
+		}
+		if !file.IsExternal() {
+			file.refreshStat()
+		}
+
+		// Records are in ascending index order, so earlier files are
+		// already back in place when later ones are inserted
+		index := min(t.Index, len(p.files))
+		p.files = append(p.files[:index], append([]AudioFile{file}, p.files[index:]...)...)
+		restored = append(restored, file.OriginalPath)
+	}
+
+	p.forgetWatchedRemoved(restored)
+	p.refreshFileViews()
+	p.markDirty()
+	if len(failed) > 0 {
+		return fmt.Errorf("couldn't restore %d files: %v", len(failed), failed)
+	}
+	return nil
+}
+
+// undoRemove undoes the last delete or clear from the Undo button or Ctrl+Z
+func (p *Podcasterator) undoRemove() {
+	if len(p.undoStack) == 0 {
+		return
+	}
+	if err := p.undo(); err != nil && p.window != nil {
+		dialog.ShowError(err, p.window)
+	}
+}
+
+// updateUndoButton enables the Undo button while there's something to undo
+func (p *Podcasterator) updateUndoButton() {
+	if p.undoBtn == nil {
+		return
+	}
+	if len(p.undoStack) > 0 {
+		p.undoBtn.Enable()
+	} else {
+		p.undoBtn.Disable()
+	}
+}
+
+// purgeRecord deletes the trashed copies of a record that can no longer be
+// undone, along with their episode artwork, cut copies, chapters and
+// transcripts
+func purgeRecord(record undoRecord) {
+	for _, t := range record.Files {
+		if t.File.ArtworkPath != "" {
+			os.Remove(t.File.ArtworkPath)
+		}
+		if t.File.TrimmedPath != "" {
+			os.Remove(t.File.TrimmedPath)
+		}
+		if t.File.ChaptersPath != "" {
+			os.Remove(t.File.ChaptersPath)
+		}
+		if t.File.TranscriptPath != "" {
+			os.Remove(t.File.TranscriptPath)
+		}
+		if t.TrashPath == "" {
+			continue
+		}
+		os.Remove(t.TrashPath)
+		if t.TrashPath != t.File.TempPath {
+			os.Remove(filepath.Dir(t.TrashPath))
+		}
+		// The file's own folder is kept for an undo until now
+		os.Remove(filepath.Dir(t.File.TempPath))
+	}
+}
+
+// purgeTrash forgets every undo record and empties the trash
+func (p *Podcasterator) purgeTrash() {
+	for _, record := range p.undoStack {
+		purgeRecord(record)
+	}
+	p.undoStack = nil
+	os.RemoveAll(p.trashDir())
+	p.updateUndoButton()
+}
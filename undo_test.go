@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// Undo Tests
+// =============================================================================
+
+// addNamedFiles adds a local file for each name, in order
+func addNamedFiles(t *testing.T, p *Podcasterator, names ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	before := len(p.files)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(name), 0644)
+		p.addFile(path)
+	}
+	if added := len(p.files) - before; added != len(names) {
+		t.Fatalf("added %d files; want %d", added, len(names))
+	}
+}
+
+func TestDeleteFileUndo(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3", "c.mp3")
+	deleted := p.files[1]
+
+	p.deleteFile(1)
+	if fileExists(deleted.TempPath) {
+		t.Error("deleted file's temp copy is still in place")
+	}
+	if entries, _ := os.ReadDir(p.trashDir()); len(entries) != 1 {
+		t.Errorf("trash has %d entries; want 1", len(entries))
+	}
+
+	p.dirty = false
+	if err := p.undo(); err != nil {
+		t.Fatalf("undo() error = %v", err)
+	}
+	if got := strings.Join(displayNames(p.files), ","); got != "a.mp3,b.mp3,c.mp3" {
+		t.Errorf("files after undo = %s; want a.mp3,b.mp3,c.mp3", got)
+	}
+	if data, err := os.ReadFile(p.files[1].TempPath); err != nil || string(data) != "b.mp3" {
+		t.Errorf("restored temp copy = %q, %v", data, err)
+	}
+	if !p.dirty {
+		t.Error("undo() didn't mark the state dirty")
+	}
+	if err := p.undo(); err == nil {
+		t.Error("second undo() succeeded with nothing left to undo")
+	}
+}
+
+func TestClearAllUndo(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3")
+	p.files = append(p.files, AudioFile{ID: "ext", DisplayName: "hosted", ExternalURL: "https://example.com/x.mp3"})
+	addNamedFiles(t, p, "c.mp3")
+
+	p.deleteFile(0)
+	p.clearAll()
+	if len(p.files) != 0 {
+		t.Fatalf("clearAll() left %d files", len(p.files))
+	}
+
+	if err := p.undo(); err != nil {
+		t.Fatalf("undo() error = %v", err)
+	}
+	if got := strings.Join(displayNames(p.files), ","); got != "b.mp3,hosted,c.mp3" {
+		t.Errorf("files after undoing the clear = %s; want b.mp3,hosted,c.mp3", got)
+	}
+	if err := p.undo(); err != nil {
+		t.Fatalf("undo() error = %v", err)
+	}
+	if got := strings.Join(displayNames(p.files), ","); got != "a.mp3,b.mp3,hosted,c.mp3" {
+		t.Errorf("files after undoing the delete = %s; want a.mp3,b.mp3,hosted,c.mp3", got)
+	}
+	for _, f := range p.files {
+		if !f.IsExternal() && !fileExists(f.TempPath) {
+			t.Errorf("%s has no temp copy after undo", f.DisplayName)
+		}
+	}
+}
+
+func TestUndoStackBounded(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	names := make([]string, maxUndoSteps+1)
+	for i := range names {
+		names[i] = string(rune('a'+i)) + ".mp3"
+	}
+	addNamedFiles(t, p, names...)
+	first := p.files[0]
+
+	for len(p.files) > 0 {
+		p.deleteFile(0)
+	}
+	if len(p.undoStack) != maxUndoSteps {
+		t.Errorf("undo stack holds %d records; want %d", len(p.undoStack), maxUndoSteps)
+	}
+	if entries, _ := os.ReadDir(p.trashDir()); len(entries) != maxUndoSteps {
+		t.Errorf("trash has %d entries; want the oldest purged, leaving %d", len(entries), maxUndoSteps)
+	}
+	if dirExists(filepath.Dir(first.TempPath)) {
+		t.Error("purged file's folder was left behind")
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	addNamedFiles(t, p, "a.mp3", "b.mp3")
+
+	p.deleteFile(0)
+	p.shutdown()
+
+	if len(p.undoStack) != 0 || dirExists(p.trashDir()) {
+		t.Errorf("after shutdown the undo stack has %d records and trash exists = %v", len(p.undoStack), dirExists(p.trashDir()))
+	}
+	if !fileExists(p.files[0].TempPath) {
+		t.Error("purging the trash removed a file still in the list")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// copyAttempts is how many times a copy that doesn't match its source is
+// made before giving up
+const copyAttempts = 2
+
+// errCopyMismatch is returned when a copy reads back differently from its
+// source, e.g. because the drive is failing
+var errCopyMismatch = errors.New("the copy doesn't match the original")
+
+// hashFile returns the SHA-256 of the file at path
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifySum reads the file at path and checks it hashes to sum: a copy
+// against the hash of its source taken while it was copied, or a source
+// against the hash of its copy. what names the file in a read error.
+func verifySum(path, sum, what string) error {
+	got, err := hashFile(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read back the %s: %w", what, err)
+	}
+	if got != sum {
+		return errCopyMismatch
+	}
+	return nil
+}
+
+// copyVerified is copyWithProgress followed by reading the copy back. The
+// source is only read once, since its hash is taken during the copy. A copy
+// that doesn't match is removed and made again, up to copyAttempts times.
+func copyVerified(ctx context.Context, src, dst string, onProgress func(written, total int64)) (string, error) {
+	for attempt := 1; ; attempt++ {
+		sum, err := copyWithProgress(ctx, src, dst, onProgress)
+		if err != nil {
+			return "", err
+		}
+		err = verifySum(dst, sum, "copy")
+		if err == nil {
+			return sum, nil
+		}
+		os.Remove(dst)
+		if !errors.Is(err, errCopyMismatch) {
+			return "", fmt.Errorf("%s: %w", filepath.Base(src), err)
+		}
+		if attempt == copyAttempts {
+			return "", fmt.Errorf("%s: %w after %d tries", filepath.Base(src), err, attempt)
+		}
+	}
+}
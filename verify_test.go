@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// =============================================================================
+// Copy Verification Tests
+// =============================================================================
+
+func TestVerifyCopy(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "book.m4a")
+	data := bytes.Repeat([]byte("chapter"), 50000)
+	os.WriteFile(src, data, 0644)
+	dst := filepath.Join(dir, "copy.m4a")
+	sum, err := copyWithProgress(context.Background(), src, dst, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifySum(dst, sum, "copy"); err != nil {
+		t.Errorf("verifySum() of a good copy error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func()
+	}{
+		{"truncated", func() { os.Truncate(dst, int64(len(data)/2)) }},
+		{"flipped byte", func() {
+			bad := bytes.Clone(data)
+			bad[len(bad)/3] ^= 0xff
+			os.WriteFile(dst, bad, 0644)
+		}},
+		{"empty", func() { os.WriteFile(dst, nil, 0644) }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.corrupt()
+			if err := verifySum(dst, sum, "copy"); !errors.Is(err, errCopyMismatch) {
+				t.Errorf("verifySum() error = %v; want errCopyMismatch", err)
+			}
+		})
+	}
+
+	if err := verifySum(filepath.Join(dir, "missing.m4a"), sum, "copy"); err == nil || errors.Is(err, errCopyMismatch) {
+		t.Errorf("verifySum() of a missing copy error = %v; want a read error", err)
+	}
+}
+
+func TestCopyVerified(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "book.m4a")
+	os.WriteFile(src, bytes.Repeat([]byte("chapter"), 1000), 0644)
+
+	dst := filepath.Join(dir, "copy.m4a")
+	sum, err := copyVerified(context.Background(), src, dst, nil)
+	if err != nil {
+		t.Fatalf("copyVerified() error = %v", err)
+	}
+	if want, _ := hashFile(src); sum != want {
+		t.Errorf("copyVerified() hash = %s; want the source's %s", sum, want)
+	}
+
+	if _, err := copyVerified(context.Background(), filepath.Join(dir, "missing.m4a"), filepath.Join(dir, "none.m4a"), nil); err == nil {
+		t.Error("copyVerified() of a missing source succeeded")
+	}
+}
+
+func TestCopyVerifiedRetriesTruncatedDestination(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs /dev/null")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "book.m4a")
+	os.WriteFile(src, []byte("chapter one"), 0644)
+
+	// Everything written the first time is lost, so that copy reads back
+	// empty. It's removed, and the second copy is a real file.
+	dst := filepath.Join(dir, "copy.m4a")
+	if err := os.Symlink(os.DevNull, dst); err != nil {
+		t.Skipf("can't make a symlink: %v", err)
+	}
+	if _, err := copyVerified(context.Background(), src, dst, nil); err != nil {
+		t.Fatalf("copyVerified() error = %v; want the retry to succeed", err)
+	}
+	if info, err := os.Lstat(dst); err != nil || !info.Mode().IsRegular() {
+		t.Fatal("the bad copy wasn't replaced by a real one")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "chapter one" {
+		t.Errorf("retried copy holds %q", data)
+	}
+
+	// Unverified, the same bad copy goes unnoticed
+	os.Remove(dst)
+	os.Symlink(os.DevNull, dst)
+	if _, err := importAudio(context.Background(), src, dst, "", false, nil); err != nil {
+		t.Errorf("unverified importAudio() error = %v", err)
+	}
+	if info, _ := os.Lstat(dst); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("unverified import replaced the bad copy")
+	}
+}
+
+func TestAddFilesVerified(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	p.verifyCopies = true
+	src := filepath.Join(t.TempDir(), "one.mp3")
+	os.WriteFile(src, []byte("audio"), 0644)
+
+	if err := p.addFiles([]string{src}); err != nil {
+		t.Fatalf("addFiles() error = %v", err)
+	}
+	if len(p.files) != 1 || p.files[0].SHA256 == "" {
+		t.Fatalf("files = %+v; want the verified copy added with its hash", p.files)
+	}
+	if err := verifySum(p.files[0].TempPath, p.files[0].SHA256, "copy"); err != nil {
+		t.Errorf("added copy doesn't match: %v", err)
+	}
+}
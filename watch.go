@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long a new file in the watched folder must go without
+// changing before it's imported, so files still being copied in aren't
+// picked up half-written
+const watchSettle = 2 * time.Second
+
+// folderWatcher reports supported audio files appearing in or disappearing
+// from a folder and its subfolders
+type folderWatcher struct {
+	w         *fsnotify.Watcher
+	settle    time.Duration
+	onAdded   func(paths []string)
+	onRemoved func(paths []string)
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newFolderWatcher starts watching dir. onAdded gets new files once they've
+// settled, in natural order; onRemoved gets paths as they're removed or
+// renamed away, which may be folders. Both are called from the watcher's
+// goroutine.
+func newFolderWatcher(dir string, settle time.Duration, onAdded, onRemoved func(paths []string)) (*folderWatcher, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a folder", dir)
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	fw := &folderWatcher{w: w, settle: settle, onAdded: onAdded, onRemoved: onRemoved, done: make(chan struct{})}
+	if err := w.Add(dir); err != nil {
+		w.Close()
+		return nil, err
+	}
+	fw.addTree(dir, nil)
+	go fw.run()
+	return fw, nil
+}
+
+// addTree watches the folders under dir. With pending set, the supported
+// files found are marked as new, for a folder moved in whole.
+func (fw *folderWatcher) addTree(dir string, pending map[string]time.Time) {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			fw.w.Add(path)
+		} else if pending != nil && isSupportedFile(path) {
+			pending[path] = time.Now()
+		}
+		return nil
+	})
+}
+
+func (fw *folderWatcher) run() {
+	pending := map[string]time.Time{}
+	tick := time.NewTicker(max(fw.settle/4, 10*time.Millisecond))
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-fw.done:
+			return
+		case ev, ok := <-fw.w.Events:
+			if !ok {
+				return
+			}
+			switch {
+			case ev.Has(fsnotify.Create) || ev.Has(fsnotify.Write):
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if ev.Has(fsnotify.Create) {
+						fw.addTree(ev.Name, pending)
+					}
+				} else if isSupportedFile(ev.Name) {
+					pending[ev.Name] = time.Now()
+				}
+			case ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename):
+				delete(pending, ev.Name)
+				fw.onRemoved([]string{ev.Name})
+			}
+		case err, ok := <-fw.w.Errors:
+			if !ok {
+				return
+			}
+			fmt.Println("Watched folder error:", err)
+		case now := <-tick.C:
+			var ready []string
+			for path, changed := range pending {
+				if now.Sub(changed) >= fw.settle {
+					ready = append(ready, path)
+					delete(pending, path)
+				}
+			}
+			if len(ready) > 0 {
+				sort.Slice(ready, func(i, j int) bool { return naturalPathLess(ready[i], ready[j]) })
+				fw.onAdded(ready)
+			}
+		}
+	}
+}
+
+// stop ends watching. It's safe to call more than once.
+func (fw *folderWatcher) stop() {
+	fw.stopOnce.Do(func() {
+		close(fw.done)
+		fw.w.Close()
+	})
+}
+
+// startWatching (re)starts watching the active project's folder, then
+// catches up on the files that landed there while it wasn't watched
+func (p *Podcasterator) startWatching() {
+	p.stopWatching()
+	if p.watchFolder == "" || p.tempDirUnavailable {
+		return
+	}
+
+	var fw *folderWatcher
+	fw, err := newFolderWatcher(p.watchFolder, watchSettle,
+		func(paths []string) {
+			fyne.Do(func() {
+				if p.watcher == fw {
+					p.addFilesInBackground(paths)
+				}
+			})
+		},
+		func(paths []string) {
+			fyne.Do(func() {
+				if p.watcher == fw && p.watchRemovals {
+					p.removeWatched(paths)
+				}
+			})
+		})
+	if err != nil {
+		fmt.Printf("Couldn't watch %s: %v\n", p.watchFolder, err)
+		return
+	}
+	p.watcher = fw
+	p.catchUpWatched()
+}
+
+// catchUpWatched adds the audio files in the watched folder that aren't in
+// the list, e.g. those saved there while the app was closed
+func (p *Podcasterator) catchUpWatched() {
+	p.addFilesInBackground(p.watchedToAdd())
+}
+
+// watchedToAdd lists the audio files in the watched folder to catch up on.
+// Files removed from the list are tracked in watchRemoved and left out, so
+// they stay removed; those no longer in the folder are forgotten. Files
+// already in the list are left to addFilesInBackground to skip.
+func (p *Podcasterator) watchedToAdd() []string {
+	paths := folderFiles(p.watchFolder, p.walkFolderOrder)
+	present := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		present[path] = true
+	}
+	removed := map[string]bool{}
+	var kept []string
+	for _, path := range p.watchRemoved {
+		if present[path] {
+			removed[path] = true
+			kept = append(kept, path)
+		}
+	}
+	if len(kept) != len(p.watchRemoved) {
+		p.watchRemoved = kept
+		p.markDirty()
+	}
+
+	var pending []string
+	for _, path := range paths {
+		if !removed[path] {
+			pending = append(pending, path)
+		}
+	}
+	return pending
+}
+
+// noteWatchedRemoved records which of the originals at paths, of files just
+// removed from the list, are in the watched folder, so catching up doesn't
+// add them back
+func (p *Podcasterator) noteWatchedRemoved(paths []string) {
+	if p.watchFolder == "" {
+		return
+	}
+	for _, path := range paths {
+		if insideDir(path, p.watchFolder) && !slices.Contains(p.watchRemoved, path) {
+			p.watchRemoved = append(p.watchRemoved, path)
+		}
+	}
+}
+
+// forgetWatchedRemoved stops tracking the removed files at paths, or inside
+// them when they're folders: they're back in the list, or gone from the
+// folder
+func (p *Podcasterator) forgetWatchedRemoved(paths []string) {
+	p.watchRemoved = slices.DeleteFunc(p.watchRemoved, func(removed string) bool {
+		for _, path := range paths {
+			if removed == path || insideDir(removed, path) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// stopWatching stops watching the folder, if one is being watched
+func (p *Podcasterator) stopWatching() {
+	if p.watcher != nil {
+		p.watcher.stop()
+		p.watcher = nil
+	}
+}
+
+// removeWatched removes the files whose originals were at paths, or inside
+// them when they're folders. It can be undone like any delete.
+func (p *Podcasterator) removeWatched(paths []string) {
+	var indices []int
+	for i, f := range p.files {
+		for _, path := range paths {
+			if f.OriginalPath == path || strings.HasPrefix(f.OriginalPath, path+string(filepath.Separator)) {
+				indices = append(indices, i)
+				break
+			}
+		}
+	}
+	if len(indices) == 0 {
+		return
+	}
+	for _, i := range indices {
+		if p.detail != nil && p.detail.fileID == p.files[i].ID {
+			p.hideEpisodeDetail()
+		}
+	}
+	p.removeFiles(indices)
+	// Deleted from the folder, so a file saved there again is added
+	p.forgetWatchedRemoved(paths)
+	if p.fileList != nil {
+		p.fileList.UnselectAll()
+		p.fileList.Refresh()
+	}
+	p.updateFileCount()
+	p.saveNow()
+}
+
+// setWatchFolder watches dir for the active project, adding the files
+// already in it, or stops watching when it's empty
+func (p *Podcasterator) setWatchFolder(dir string) {
+	if dir == p.watchFolder {
+		return
+	}
+	p.watchFolder = dir
+	p.watchRemoved = nil
+	p.markDirty()
+	p.startWatching()
+}
+
+// watchSection is the settings section for the watched folder
+func (p *Podcasterator) watchSection() fyne.CanvasObject {
+	folderLabel := widget.NewLabel("")
+	folderLabel.Wrapping = fyne.TextWrapBreak
+	var stopBtn *widget.Button
+	update := func() {
+		if p.watchFolder == "" {
+			folderLabel.SetText("Not watching a folder")
+			stopBtn.Disable()
+		} else {
+			folderLabel.SetText(p.watchFolder)
+			stopBtn.Enable()
+		}
+	}
+
+	chooseBtn := widget.NewButton("Choose Folder…", func() {
+		if !p.requireTempDir() {
+			return
+		}
+		dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+			if err != nil || folder == nil {
+				return
+			}
+			p.setWatchFolder(folder.Path())
+			update()
+		}, p.window)
+	})
+	stopBtn = widget.NewButton("Stop Watching", func() {
+		p.setWatchFolder("")
+		update()
+	})
+	update()
+
+	removalsCheck := widget.NewCheck("Remove files from the list when they're deleted from the folder", func(checked bool) {
+		p.watchRemovals = checked
+		p.markDirty()
+	})
+	removalsCheck.SetChecked(p.watchRemovals)
+
+	note := widget.NewLabel("Audio files already in the folder are added when you choose it, new\nones as they appear, and those saved there while the app was closed\nwhen it's opened again. Files you remove from the list stay removed.")
+	note.Importance = widget.LowImportance
+
+	return container.NewVBox(
+		widget.NewLabelWithStyle("Watched Folder", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		folderLabel,
+		container.NewHBox(chooseBtn, stopBtn),
+		removalsCheck,
+		note,
+	)
+}
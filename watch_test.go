@@ -0,0 +1,268 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// =============================================================================
+// Watched Folder Tests
+// =============================================================================
+
+// waitForPaths waits for the next batch of paths from ch
+func waitForPaths(t *testing.T, ch chan []string, what string) []string {
+	t.Helper()
+	select {
+	case paths := <-ch:
+		return paths
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+		return nil
+	}
+}
+
+func TestFolderWatcher(t *testing.T) {
+	dir := t.TempDir()
+	added := make(chan []string, 10)
+	removed := make(chan []string, 10)
+	fw, err := newFolderWatcher(dir, 50*time.Millisecond,
+		func(paths []string) { added <- paths },
+		func(paths []string) { removed <- paths })
+	if err != nil {
+		t.Fatalf("newFolderWatcher() error = %v", err)
+	}
+	defer fw.stop()
+
+	// Unsupported files are ignored; the rest come in natural order
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0644)
+	os.WriteFile(filepath.Join(dir, "Episode 10.mp3"), []byte("ten"), 0644)
+	os.WriteFile(filepath.Join(dir, "Episode 2.mp3"), []byte("two"), 0644)
+	got := waitForPaths(t, added, "new files")
+	want := []string{filepath.Join(dir, "Episode 2.mp3"), filepath.Join(dir, "Episode 10.mp3")}
+	if !slices.Equal(got, want) {
+		t.Errorf("added = %v; want %v", got, want)
+	}
+
+	// A folder moved in whole is watched along with its files
+	staging := t.TempDir()
+	os.WriteFile(filepath.Join(staging, "bonus.m4a"), []byte("bonus"), 0644)
+	sub := filepath.Join(dir, "Extras")
+	if err := os.Rename(staging, sub); err != nil {
+		t.Skipf("can't move a folder in: %v", err)
+	}
+	if got := waitForPaths(t, added, "a moved-in folder"); !slices.Equal(got, []string{filepath.Join(sub, "bonus.m4a")}) {
+		t.Errorf("added = %v; want the moved-in file", got)
+	}
+	os.WriteFile(filepath.Join(sub, "later.mp3"), []byte("later"), 0644)
+	if got := waitForPaths(t, added, "a file in a subfolder"); !slices.Equal(got, []string{filepath.Join(sub, "later.mp3")}) {
+		t.Errorf("added = %v; want the subfolder's new file", got)
+	}
+
+	os.Remove(want[0])
+	if got := waitForPaths(t, removed, "a removal"); !slices.Contains(got, want[0]) {
+		t.Errorf("removed = %v; want %s", got, want[0])
+	}
+}
+
+func TestFolderWatcherStop(t *testing.T) {
+	if _, err := newFolderWatcher(filepath.Join(t.TempDir(), "missing"), time.Millisecond, nil, nil); err == nil {
+		t.Error("newFolderWatcher() of a missing folder succeeded")
+	}
+
+	dir := t.TempDir()
+	added := make(chan []string, 10)
+	fw, err := newFolderWatcher(dir, 10*time.Millisecond, func(paths []string) { added <- paths }, func([]string) {})
+	if err != nil {
+		t.Fatalf("newFolderWatcher() error = %v", err)
+	}
+	fw.stop()
+	fw.stop() // safe to repeat
+
+	os.WriteFile(filepath.Join(dir, "a.mp3"), []byte("a"), 0644)
+	select {
+	case paths := <-added:
+		t.Errorf("stopped watcher reported %v", paths)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestRemoveWatched(t *testing.T) {
+	p, cleanup := newListTestPodcasterator(t)
+	defer cleanup()
+	dir := filepath.Dir(p.files[0].OriginalPath)
+	p.files[2].OriginalPath = filepath.Join(dir, "Extras", "c.mp3")
+
+	p.dirty = false
+	p.removeWatched([]string{filepath.Join(dir, "unknown.mp3")})
+	if len(p.files) != 3 || p.dirty {
+		t.Fatal("removing a path not in the list changed it")
+	}
+
+	// A folder removes what was inside it; a similarly named file doesn't match
+	p.removeWatched([]string{filepath.Join(dir, "Extras"), p.files[0].OriginalPath, filepath.Join(dir, "b")})
+	if got := displayNames(p.files); !slices.Equal(got, []string{"b.mp3"}) {
+		t.Errorf("after removal = %v; want [b.mp3]", got)
+	}
+	if state, err := readState(filepath.Join(p.configDir, "state.json")); err != nil || len(state.Files) != len(p.files) {
+		t.Errorf("removeWatched() didn't save the removal straight away (%v)", err)
+	}
+	if len(p.undoStack) != 1 {
+		t.Errorf("undo stack has %d records; want the removal undoable in one step", len(p.undoStack))
+	}
+}
+
+func TestStartWatching(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	p.startWatching()
+	if p.watcher != nil {
+		t.Error("watching with no folder set")
+	}
+
+	p.watchFolder = filepath.Join(t.TempDir(), "missing")
+	p.startWatching()
+	if p.watcher != nil {
+		t.Error("watching a missing folder")
+	}
+
+	p.watchFolder = t.TempDir()
+	p.startWatching()
+	first := p.watcher
+	if first == nil {
+		t.Fatal("startWatching() didn't watch the folder")
+	}
+	p.startWatching()
+	if p.watcher == first {
+		t.Error("startWatching() kept the old watcher")
+	}
+
+	p.tempDirUnavailable = true
+	p.startWatching()
+	if p.watcher != nil {
+		t.Error("watching while the temp dir is unavailable")
+	}
+}
+
+func TestWatchedToAdd(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(name), 0644)
+		return path
+	}
+	a, b := write("a.mp3"), write("b.mp3")
+	p.watchFolder = dir
+	p.addFile(a)
+	p.addFile(b)
+
+	// b is removed from the list, and c is saved to the folder while the
+	// app is closed
+	p.deleteFile(1)
+	if !slices.Equal(p.watchRemoved, []string{b}) {
+		t.Fatalf("watchRemoved = %v; want [%s]", p.watchRemoved, b)
+	}
+	c := write("c.mp3")
+	if got := p.watchedToAdd(); !slices.Equal(got, []string{a, c}) {
+		t.Errorf("watchedToAdd() = %v; want [%s %s] with b.mp3 kept out", got, a, c)
+	}
+
+	// Undoing the removal stops tracking it
+	if err := p.undo(); err != nil {
+		t.Fatalf("undo() error = %v", err)
+	}
+	if len(p.watchRemoved) != 0 {
+		t.Errorf("watchRemoved = %v after undo; want it empty", p.watchRemoved)
+	}
+
+	// A removed file deleted from the folder is forgotten, so one saved
+	// there again later is added
+	p.deleteFile(1)
+	os.Remove(b)
+	p.watchedToAdd()
+	if len(p.watchRemoved) != 0 {
+		t.Errorf("watchRemoved = %v with the file gone; want it forgotten", p.watchRemoved)
+	}
+
+	// The files removed are kept with the project, until another folder is
+	// watched
+	p.deleteFile(0)
+	if pr := p.currentProject(); !slices.Equal(pr.WatchRemoved, []string{a}) {
+		t.Errorf("project WatchRemoved = %v; want [%s]", pr.WatchRemoved, a)
+	}
+	p.setWatchFolder(t.TempDir())
+	defer p.stopWatching()
+	if len(p.watchRemoved) != 0 {
+		t.Error("a new watched folder kept the old one's removed files")
+	}
+}
+
+func TestStartWatchingCatchesUp(t *testing.T) {
+	test.NewTempApp(t)
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	defer p.stopWatching()
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.mp3"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+	}
+	p.watchFolder = dir
+	p.watchRemoved = []string{filepath.Join(dir, "b.mp3")}
+
+	// The import lands on p.files from another goroutine, so it's watched
+	// for through the published snapshot, as the server would see it
+	p.live = &FeedServer{tempDir: p.tempDir}
+	p.publishTo(p.live)
+	published := func() []string {
+		p.live.mu.RLock()
+		defer p.live.mu.RUnlock()
+		var names []string
+		for _, f := range p.live.files {
+			names = append(names, f.DisplayName)
+		}
+		return names
+	}
+
+	p.startWatching()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(published()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := published(); !slices.Equal(got, []string{"a.mp3"}) {
+		t.Errorf("after catching up = %v; want [a.mp3] with b.mp3 kept out", got)
+	}
+}
+
+func TestWatchFolderFollowsProject(t *testing.T) {
+	p, cleanup := newTestPodcasterator(t)
+	defer cleanup()
+	defer p.stopWatching()
+	p.projectID = "first"
+	p.watchFolder = t.TempDir()
+	p.watchRemovals = true
+	p.startWatching()
+
+	if err := p.newProject("Second"); err != nil {
+		t.Fatalf("newProject() error = %v", err)
+	}
+	if p.watcher != nil || p.watchFolder != "" || p.watchRemovals {
+		t.Error("a new project kept the previous project's watched folder")
+	}
+
+	watched := p.projects[0].WatchFolder
+	if err := p.switchProject("first"); err != nil {
+		t.Fatalf("switchProject() error = %v", err)
+	}
+	if p.watchFolder != watched || !p.watchRemovals || p.watcher == nil {
+		t.Error("switching back didn't watch the project's folder again")
+	}
+}